@@ -9,7 +9,7 @@ import (
 	"syscall"
 	"time"
 
-	"zemlyaprosto/internal/app"
+	"zemlya-prosto/internal/app"
 )
 
 // main запускает HTTP-сервер сервиса «Земля просто».
@@ -2,14 +2,21 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"zemlya-prosto/internal/assistant"
 	"zemlya-prosto/internal/catalog"
+	"zemlya-prosto/internal/crs"
 	"zemlya-prosto/internal/documents"
+	"zemlya-prosto/internal/geo"
 	"zemlya-prosto/internal/layer"
 	"zemlya-prosto/internal/plot"
+	"zemlya-prosto/internal/spatial"
+	"zemlya-prosto/internal/templates"
 	"zemlya-prosto/internal/workflow"
 )
 
@@ -22,18 +29,23 @@ type App struct {
 	assistant       assistant.Service
 	catalogService  catalog.Service
 	layerService    layer.Service
+	templates       *templates.Store
 }
 
 // NewApp создаёт приложение с базовыми (пока in-memory) реализациями сервисов.
 func NewApp(cfg Config) (*App, error) {
+	plots := plot.NewInMemoryService()
+	plotCatalog := catalog.NewInMemoryService()
+	store := templates.NewStore()
 	return &App{
 		config:          cfg,
-		plotService:     plot.NewInMemoryService(),
-		documentService: documents.NewStubService(),
+		plotService:     plots,
+		documentService: documents.NewGenerator(plots, plotCatalog, store),
 		workflowService: workflow.NewStubService(),
 		assistant:       assistant.NewScenarioAssistant(),
-		catalogService:  catalog.NewInMemoryService(),
+		catalogService:  plotCatalog,
 		layerService:    layer.NewStubService(),
+		templates:       store,
 	}, nil
 }
 
@@ -45,7 +57,13 @@ func (a *App) RegisterRoutes(mux *http.ServeMux) {
 	})
 
 	mux.HandleFunc("/api/v1/plots", a.handlePlots)
+	mux.HandleFunc("/api/v1/plots/{id}", a.handlePlot)
+	mux.HandleFunc("/api/v1/catalog/plots", a.handleCatalogPlots)
 	mux.HandleFunc("/api/v1/document-packages", a.handleDocumentPackages)
+	mux.HandleFunc("/api/v1/document-packages/{id}/files/{name}", a.handleDocumentFile)
+	mux.HandleFunc("/api/v1/templates", a.handleTemplates)
+	mux.HandleFunc("/api/v1/templates/{id}", a.handleTemplate)
+	mux.HandleFunc("/api/v1/templates/{id}/versions", a.handleTemplateVersions)
 }
 
 // Close освобождает ресурсы приложения.
@@ -55,6 +73,8 @@ func (a *App) Close() {
 	}
 }
 
+// handlePlots создаёт контур (POST) или возвращает контуры владельца ?ownerId=
+// (GET) с пространственными фильтрами ?bbox=, ?point= или ?intersects=.
 func (a *App) handlePlots(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
@@ -65,13 +85,18 @@ func (a *App) handlePlots(w http.ResponseWriter, r *http.Request) {
 		}
 		contour, err := a.plotService.CreateContour(r.Context(), req)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeContourError(w, err)
 			return
 		}
 		writeJSON(w, contour)
 	case http.MethodGet:
 		ownerID := r.URL.Query().Get("ownerId")
-		contours, err := a.plotService.ListContours(r.Context(), ownerID)
+		q, err := spatial.ParseQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		contours, err := a.plotService.SearchContours(r.Context(), ownerID, q)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -82,6 +107,66 @@ func (a *App) handlePlots(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handlePlot возвращает контур, при необходимости переводя геометрию в систему координат ?crs=.
+// С ?format=kml контур выгружается документом KML в WGS 84.
+func (a *App) handlePlot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	crsID := r.URL.Query().Get("crs")
+	format := r.URL.Query().Get("format")
+	if format == "kml" {
+		crsID = crs.WGS84ID
+	}
+	contour, err := a.plotService.ExportContour(r.Context(), r.PathValue("id"), crsID)
+	switch {
+	case errors.Is(err, plot.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		writeContourError(w, err)
+		return
+	}
+	switch format {
+	case "", "json":
+		writeJSON(w, contour)
+	case "kml":
+		w.Header().Set("Content-Type", "application/vnd.google-earth.kml+xml")
+		_, _ = w.Write(contour.KML())
+	default:
+		http.Error(w, "unsupported format "+format, http.StatusNotAcceptable)
+	}
+}
+
+// handleCatalogPlots возвращает готовые участки с фильтрами ?situation= и ?bbox=minX,minY,maxX,maxY.
+func (a *App) handleCatalogPlots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	situation := r.URL.Query().Get("situation")
+	var (
+		plots []catalog.Plot
+		err   error
+	)
+	if raw := r.URL.Query().Get("bbox"); raw != "" {
+		bbox, perr := geo.ParseBBox(raw)
+		if perr != nil {
+			http.Error(w, perr.Error(), http.StatusBadRequest)
+			return
+		}
+		plots, err = a.catalogService.PlotsInBBox(r.Context(), situation, bbox)
+	} else {
+		plots, err = a.catalogService.ListPlots(r.Context(), situation)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, plots)
+}
+
 func (a *App) handleDocumentPackages(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -94,7 +179,7 @@ func (a *App) handleDocumentPackages(w http.ResponseWriter, r *http.Request) {
 	}
 	pkg, err := a.documentService.PreparePackage(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeDocumentError(w, err)
 		return
 	}
 	if err := a.workflowService.NotifyPackageReady(r.Context(), pkg.ID); err != nil {
@@ -104,62 +189,120 @@ func (a *App) handleDocumentPackages(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, pkg)
 }
 
-func writeJSON(w http.ResponseWriter, payload any) {
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// handleDocumentFile выгружает файл сформированного комплекта.
+func (a *App) handleDocumentFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
 	}
-=======
-// Package app инициализирует зависимости и запускает HTTP-сервер сервиса.
-package app
-
-import (
-	"context"
-	"log"
-	"net/http"
-	"time"
-
-	"zemlyaprosto/internal/assistant"
-	httpapi "zemlyaprosto/internal/http"
-	"zemlyaprosto/internal/layer"
-	"zemlyaprosto/internal/service"
-	"zemlyaprosto/internal/store"
-)
-
-// Application агрегирует все компоненты и управляет жизненным циклом сервиса.
-type Application struct {
-	server *http.Server
+	file, err := a.documentService.PackageFile(r.Context(), r.PathValue("id"), r.PathValue("name"))
+	if err != nil {
+		writeDocumentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", file.MediaType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Data)))
+	_, _ = w.Write(file.Data)
 }
 
-// New создаёт приложение с инициализированными зависимостями.
-func New(addr string) *Application {
-	store := store.NewMemoryStore()
-	assistant := assistant.NewDigitalAssistant()
-	layerManager := layer.NewManager()
-	svc := service.New(store, assistant, layerManager)
+// handleTemplates возвращает последние версии шаблонов документов (GET) или
+// сохраняет новую версию шаблона (POST).
+func (a *App) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, a.templates.List())
+	case http.MethodPost:
+		var t templates.Template
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		saved, err := a.templates.Save(t)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(saved)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
 
-	mux := http.NewServeMux()
-	handler := httpapi.New(svc)
-	handler.Register(mux)
+// handleTemplate возвращает шаблон: последнюю версию или версию ?version=.
+func (a *App) handleTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	version := 0
+	if raw := r.URL.Query().Get("version"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			http.Error(w, "invalid version "+raw, http.StatusBadRequest)
+			return
+		}
+		version = v
+	}
+	t, err := a.templates.Get(r.PathValue("id"), version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, t)
+}
 
-	srv := &http.Server{
-		Addr:         addr,
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+// handleTemplateVersions возвращает все версии шаблона.
+func (a *App) handleTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
 	}
+	versions, err := a.templates.Versions(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, versions)
+}
 
-	return &Application{server: srv}
+// writeDocumentError сопоставляет ошибки формирования комплекта с
+// HTTP-статусами.
+func writeDocumentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, documents.ErrInvalidRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, documents.ErrNotFound), errors.Is(err, plot.ErrNotFound), errors.Is(err, templates.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
-// Run запускает HTTP-сервер и блокирует выполнение до получения ошибки.
-func (a *Application) Run() error {
-	log.Printf("HTTP сервер запущен на %s", a.server.Addr)
-	return a.server.ListenAndServe()
+// writeContourError сопоставляет ошибки создания контура с HTTP-статусами:
+// нарушения топологии возвращаются как 422 с перечнем вершин.
+func writeContourError(w http.ResponseWriter, err error) {
+	var verr *geo.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"error":      verr.Error(),
+			"violations": verr.Violations,
+		})
+	case errors.Is(err, plot.ErrInvalidGeometry):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
 
-// Shutdown корректно останавливает сервер.
-func (a *Application) Shutdown(ctx context.Context) error {
-	return a.server.Shutdown(ctx)
+func writeJSON(w http.ResponseWriter, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
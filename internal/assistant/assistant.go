@@ -5,7 +5,7 @@
 // эвристик, которые подсказывают пользователю следующие шаги.
 package assistant
 
-import "zemlyaprosto/internal/model"
+import "zemlya-prosto/internal/model"
 
 // Request описывает вводные данные, которые пользователь предоставляет цифровому помощнику.
 type Request struct {
@@ -15,10 +15,26 @@ type Request struct {
 	PreferredCategory model.ParcelCategory `json:"preferred_category"`
 	// HasContour показывает, существует ли уже созданный пользователем контур.
 	HasContour bool `json:"has_contour"`
+	// ContourID и PermittedUse — контур и код вида разрешённого
+	// использования для проверки по правилам землепользования и застройки.
+	ContourID    string `json:"contour_id,omitempty"`
+	PermittedUse string `json:"permitted_use,omitempty"`
 }
 
+// ZoningChecker проверяет контур и вид разрешённого использования по
+// правилам землепользования и застройки.
+type ZoningChecker func(contourID, permittedUse string) (model.ZoningReport, error)
+
 // DigitalAssistant генерирует подсказки на основании ввода пользователя и доступных данных.
-type DigitalAssistant struct{}
+type DigitalAssistant struct {
+	checkZoning ZoningChecker
+}
+
+// SetZoningChecker подключает проверку по ПЗЗ, которую помощник вызывает
+// для цели check_zoning.
+func (d *DigitalAssistant) SetZoningChecker(check ZoningChecker) {
+	d.checkZoning = check
+}
 
 // NewDigitalAssistant создаёт нового помощника.
 func NewDigitalAssistant() *DigitalAssistant {
@@ -38,7 +54,7 @@ func (d *DigitalAssistant) Suggest(req Request, parcels []model.ReadyParcel) []m
 		})
 		suggestions = append(suggestions, model.AssistantSuggestion{
 			Title:       "Загрузите координаты",
-			Description: "Если у вас есть координаты в формате CSV или JSON, загрузите их для точного построения контура.",
+			Description: "Если у вас есть ведомость координат в формате CSV, TSV, XLSX или JSON, загрузите её для точного построения контура.",
 			Action:      "upload_coordinates",
 		})
 	case "choose_parcel":
@@ -70,6 +86,8 @@ func (d *DigitalAssistant) Suggest(req Request, parcels []model.ReadyParcel) []m
 				Action:      "choose_source",
 			})
 		}
+	case "check_zoning":
+		suggestions = append(suggestions, d.zoningSuggestions(req)...)
 	default:
 		suggestions = append(suggestions, model.AssistantSuggestion{
 			Title:       "Изучите руководство",
@@ -80,3 +98,66 @@ func (d *DigitalAssistant) Suggest(req Request, parcels []model.ReadyParcel) []m
 
 	return suggestions
 }
+
+// zoningSuggestions проверяет контур по ПЗЗ и превращает найденные
+// несоответствия в подсказки.
+func (d *DigitalAssistant) zoningSuggestions(req Request) []model.AssistantSuggestion {
+	if req.ContourID == "" || d.checkZoning == nil {
+		return []model.AssistantSuggestion{{
+			Title:       "Создайте контур участка",
+			Description: "Проверка по правилам землепользования и застройки выполняется для созданного контура.",
+			Action:      "draw_contour",
+		}}
+	}
+	report, err := d.checkZoning(req.ContourID, req.PermittedUse)
+	if err != nil {
+		return []model.AssistantSuggestion{{
+			Title:       "Не удалось проверить участок",
+			Description: err.Error(),
+			Action:      "draw_contour",
+		}}
+	}
+	suggestions := make([]model.AssistantSuggestion, 0, len(report.Violations)+1)
+	for _, v := range report.Violations {
+		suggestions = append(suggestions, model.AssistantSuggestion{
+			Title:       zoningTitles[v.Code],
+			Description: v.Message,
+			Action:      zoningActions[v.Code],
+		})
+	}
+	if report.Compliant {
+		suggestions = append(suggestions, model.AssistantSuggestion{
+			Title:       "Участок соответствует ПЗЗ",
+			Description: "Границы и вид разрешённого использования не противоречат градостроительному регламенту.",
+			Action:      "generate_documents",
+		})
+	}
+	return suggestions
+}
+
+// zoningTitles и zoningActions сопоставляют несоответствиям ПЗЗ заголовок
+// подсказки и предлагаемое действие.
+var (
+	zoningTitles = map[model.ZoningViolationCode]string{
+		model.ZoningOutsideZones:     "Участок вне территориальных зон",
+		model.ZoningPartiallyOutside: "Часть участка вне территориальных зон",
+		model.ZoningCrossesZones:     "Участок пересекает границу зон",
+		model.ZoningUseNotDeclared:   "Укажите вид разрешённого использования",
+		model.ZoningUseNotPermitted:  "Вид использования не разрешён в зоне",
+		model.ZoningConditionalUse:   "Нужно разрешение на условно разрешённый вид",
+		model.ZoningAuxiliaryUse:     "Вспомогательный вид использования",
+		model.ZoningAreaBelowMinimum: "Участок меньше допустимого",
+		model.ZoningAreaAboveMaximum: "Участок больше допустимого",
+	}
+	zoningActions = map[model.ZoningViolationCode]string{
+		model.ZoningOutsideZones:     "open_help",
+		model.ZoningPartiallyOutside: "edit_contour",
+		model.ZoningCrossesZones:     "edit_contour",
+		model.ZoningUseNotDeclared:   "set_permitted_use",
+		model.ZoningUseNotPermitted:  "set_permitted_use",
+		model.ZoningConditionalUse:   "request_conditional_use_permit",
+		model.ZoningAuxiliaryUse:     "set_permitted_use",
+		model.ZoningAreaBelowMinimum: "edit_contour",
+		model.ZoningAreaAboveMaximum: "edit_contour",
+	}
+)
@@ -5,8 +5,8 @@ package business
 import (
 	"time"
 
-	"zemlyaprosto/internal/model"
-	"zemlyaprosto/internal/util"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/util"
 )
 
 // NewDefaultProcess создаёт типовой бизнес-процесс предоставления земельного участка.
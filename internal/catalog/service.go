@@ -3,6 +3,9 @@ package catalog
 import (
 	"context"
 	"sync"
+
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/geojson"
 )
 
 // Plot описывает участок из перечня готовых участков.
@@ -13,11 +16,22 @@ type Plot struct {
 	LifeSituation string   `json:"lifeSituation"`
 	Geometry      string   `json:"geometry"`
 	Tags          []string `json:"tags"`
+	// AreaSqM, PerimeterM и Centroid вычисляются функцией geo.Measure на
+	// эллипсоиде WGS 84, как и метрики контуров заявителей.
+	AreaSqM    float64   `json:"areaSqM"`
+	PerimeterM float64   `json:"perimeterM"`
+	Centroid   geo.Point `json:"centroid"`
+	// BBox — охват геометрии в порядке GeoJSON: [minX, minY, maxX, maxY].
+	BBox []float64 `json:"bbox,omitempty"`
+	// Shape — типизированная геометрия участка в WGS 84.
+	Shape geojson.Geometry `json:"-"`
 }
 
 // Service описывает операции работы с каталогом участков.
 type Service interface {
 	ListPlots(ctx context.Context, situation string) ([]Plot, error)
+	// PlotsInBBox возвращает участки, охват которых пересекает bbox (WGS 84).
+	PlotsInBBox(ctx context.Context, situation string, bbox geo.BBox) ([]Plot, error)
 }
 
 // InMemoryService хранит небольшой набор участков для демонстрации.
@@ -30,29 +44,58 @@ type InMemoryService struct {
 func NewInMemoryService() *InMemoryService {
 	return &InMemoryService{
 		plots: []Plot{
-			{ID: "tour-1", Name: "Турбаза на озере", Purpose: "туризм", LifeSituation: "tourism", Geometry: "{...}", Tags: []string{"дорога", "электричество"}},
-			{ID: "build-1", Name: "Микрорайон Северный", Purpose: "строительство", LifeSituation: "construction", Geometry: "{...}", Tags: []string{"газ", "школа"}},
+			newPlot(Plot{ID: "tour-1", Name: "Турбаза на озере", Purpose: "туризм", LifeSituation: "tourism", Tags: []string{"дорога", "электричество"}},
+				`{"type":"Polygon","coordinates":[[[33.0480,57.2010],[33.0530,57.2010],[33.0530,57.2040],[33.0480,57.2040],[33.0480,57.2010]]]}`),
+			newPlot(Plot{ID: "build-1", Name: "Микрорайон Северный", Purpose: "строительство", LifeSituation: "construction", Tags: []string{"газ", "школа"}},
+				`{"type":"Polygon","coordinates":[[[37.5790,55.8890],[37.5840,55.8890],[37.5840,55.8915],[37.5790,55.8915],[37.5790,55.8890]]]}`),
 		},
 	}
 }
 
+// newPlot разбирает демонстрационную геометрию и заполняет производные поля.
+// Ошибка разбора означает опечатку в исходных данных и приводит к панике.
+func newPlot(p Plot, geometry string) Plot {
+	obj, err := geojson.Parse([]byte(geometry))
+	if err != nil {
+		panic("catalog: invalid seed geometry for " + p.ID + ": " + err.Error())
+	}
+	p.Shape = geojson.NewGeometry(obj.Shape())
+	p.Geometry = p.Shape.String()
+	p.BBox = p.Shape.BBox().Array()
+	m := geo.Measure(p.Shape.Shape)
+	p.AreaSqM = m.AreaSqM
+	p.PerimeterM = m.PerimeterM
+	p.Centroid = m.Centroid
+	return p
+}
+
 // ListPlots возвращает участки по жизненной ситуации (или все, если не указана).
 func (s *InMemoryService) ListPlots(ctx context.Context, situation string) ([]Plot, error) {
+	return s.filter(ctx, func(plot Plot) bool {
+		return situation == "" || plot.LifeSituation == situation
+	})
+}
+
+// PlotsInBBox возвращает участки ситуации situation (или все), пересекающие bbox.
+func (s *InMemoryService) PlotsInBBox(ctx context.Context, situation string, bbox geo.BBox) ([]Plot, error) {
+	return s.filter(ctx, func(plot Plot) bool {
+		if situation != "" && plot.LifeSituation != situation {
+			return false
+		}
+		return plot.Shape.BBox().Intersects(bbox)
+	})
+}
+
+func (s *InMemoryService) filter(ctx context.Context, keep func(Plot) bool) ([]Plot, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	if situation == "" {
-		result := make([]Plot, len(s.plots))
-		copy(result, s.plots)
-		return result, nil
-	}
-
-	var result []Plot
+	result := make([]Plot, 0, len(s.plots))
 	for _, plot := range s.plots {
-		if plot.LifeSituation == situation {
+		if keep(plot) {
 			result = append(result, plot)
 		}
 	}
@@ -0,0 +1,99 @@
+// Package crs реализует преобразования между системами координат, в которых
+// заявители и кадастровые инженеры передают координаты характерных точек.
+//
+// Все контуры сервиса хранятся в WGS 84. Пакет поддерживает геодезические
+// системы СК-42, ГСК-2011 и WGS 84, зоны Гаусса–Крюгера и UTM, а также местные
+// системы координат (МСК), параметры которых регистрируются в реестре.
+// Преобразования выполняются на чистом Go: переход между датумами — по
+// семи параметрам Гельмерта, проекции — по рядам Крюгера.
+package crs
+
+import (
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// CRS описывает систему координат.
+//
+// Для геодезических систем точка задаётся как (X — долгота, Y — широта) в
+// градусах, для прямоугольных — как (X — восточная координата, Y — северная)
+// в метрах. Перестановку осей в геодезическую традицию (X — север) выполняют
+// вызывающие пакеты.
+type CRS struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Datum      Datum               `json:"-"`
+	Projection *TransverseMercator `json:"projection,omitempty"`
+}
+
+// IsGeographic сообщает, что система задана географическими координатами.
+func (c *CRS) IsGeographic() bool { return c.Projection == nil }
+
+// SRID возвращает код EPSG системы (например, 4326 для WGS 84) или 0, если
+// система не имеет кода EPSG, как зоны МСК.
+func (c *CRS) SRID() int {
+	code, ok := strings.CutPrefix(c.ID, "EPSG:")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// ToWGS84 переводит точку из системы c в WGS 84 (долгота, широта).
+func (c *CRS) ToWGS84(p geo.Point) geo.Point {
+	lat, lon := p.Y, p.X
+	if c.Projection != nil {
+		lat, lon = c.Projection.inverse(c.Datum.Ellipsoid, p.X, p.Y)
+	}
+	lat, lon = c.Datum.toWGS84(lat*deg, lon*deg)
+	return geo.Point{X: lon / deg, Y: lat / deg}
+}
+
+// FromWGS84 переводит точку WGS 84 (долгота, широта) в систему c.
+func (c *CRS) FromWGS84(p geo.Point) geo.Point {
+	lat, lon := c.Datum.fromWGS84(p.Y*deg, p.X*deg)
+	lat, lon = lat/deg, lon/deg
+	if c.Projection != nil {
+		e, n := c.Projection.forward(c.Datum.Ellipsoid, lat, lon)
+		return geo.Point{X: e, Y: n}
+	}
+	return geo.Point{X: lon, Y: lat}
+}
+
+// ToWGS84Multi переводит все вершины геометрии в WGS 84.
+func (c *CRS) ToWGS84Multi(m geo.MultiPolygon) geo.MultiPolygon {
+	return mapMulti(m, c.ToWGS84)
+}
+
+// FromWGS84Multi переводит все вершины геометрии из WGS 84 в систему c.
+func (c *CRS) FromWGS84Multi(m geo.MultiPolygon) geo.MultiPolygon {
+	return mapMulti(m, c.FromWGS84)
+}
+
+// Transform переводит точку между произвольными системами через WGS 84.
+func Transform(from, to *CRS, p geo.Point) geo.Point {
+	if from.ID == to.ID {
+		return p
+	}
+	return to.FromWGS84(from.ToWGS84(p))
+}
+
+func mapMulti(m geo.MultiPolygon, fn func(geo.Point) geo.Point) geo.MultiPolygon {
+	out := make(geo.MultiPolygon, len(m))
+	for i, poly := range m {
+		out[i] = make(geo.Polygon, len(poly))
+		for j, ring := range poly {
+			out[i][j] = make(geo.Ring, len(ring))
+			for k, p := range ring {
+				out[i][j][k] = fn(p)
+			}
+		}
+	}
+	return out
+}
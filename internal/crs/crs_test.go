@@ -0,0 +1,118 @@
+package crs
+
+import (
+	"math"
+	"testing"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// dms переводит градусы, минуты и секунды в градусы.
+func dms(d, m, s float64) float64 { return d + m/60 + s/3600 }
+
+// Контрольные точки проекции взяты из опубликованных примеров: набора тестов
+// PROJ (builtins.gie, tmerc на GRS 80) и руководства EPSG Guidance Note 7-2
+// (пример Transverse Mercator для OSGB 1936 / British National Grid).
+// Допуск равен половине последнего опубликованного знака, но не больше 5 мм.
+func TestTransverseMercatorControlPoints(t *testing.T) {
+	grs80 := Ellipsoid{Name: "GRS 80", A: 6378137, InvF: 298.257222101}
+	airy := Ellipsoid{Name: "Airy 1830", A: 6377563.396, InvF: 299.3249646}
+	bng := TransverseMercator{LatOrigin: 49, LonOrigin: -2, Scale: 0.9996012717, FalseEasting: 400000, FalseNorthing: -100000}
+	tests := []struct {
+		name      string
+		el        Ellipsoid
+		proj      TransverseMercator
+		lat, lon  float64
+		e, n, tol float64
+	}{
+		{"PROJ tmerc GRS 80", grs80, TransverseMercator{Scale: 1}, 1, 2, 222650.796795778, 110642.229411927, 1e-4},
+		{"PROJ tmerc GRS 80, юго-запад", grs80, TransverseMercator{Scale: 1}, -1, -2, -222650.796795778, -110642.229411927, 1e-4},
+		{"EPSG British National Grid", airy, bng, dms(50, 30, 0), dms(0, 30, 0), 577274.98, 69740.49, 0.005},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, n := tt.proj.forward(tt.el, tt.lat, tt.lon)
+			if math.Abs(e-tt.e) > tt.tol || math.Abs(n-tt.n) > tt.tol {
+				t.Errorf("forward = (%.4f, %.4f), want (%.4f, %.4f)", e, n, tt.e, tt.n)
+			}
+			// Опубликованные координаты округлены, поэтому обратный переход
+			// сверяется с исходными градусами с допуском 1e-7° (около 1 см),
+			// а с прямым — до микрометра.
+			lat, lon := tt.proj.inverse(tt.el, tt.e, tt.n)
+			if e2, n2 := tt.proj.forward(tt.el, lat, lon); math.Abs(e2-tt.e) > 1e-6 || math.Abs(n2-tt.n) > 1e-6 {
+				t.Errorf("inverse = (%.9f, %.9f) не возвращается в (%.4f, %.4f): (%.6f, %.6f)", lat, lon, tt.e, tt.n, e2, n2)
+			}
+			if math.Abs(lat-tt.lat) > 1e-7 || math.Abs(lon-tt.lon) > 1e-7 {
+				t.Errorf("inverse = (%.9f, %.9f), want (%.9f, %.9f)", lat, lon, tt.lat, tt.lon)
+			}
+		})
+	}
+}
+
+// Пример перехода к геоцентрическим координатам из EPSG Guidance Note 7-2.
+func TestGeocentricControlPoint(t *testing.T) {
+	lat, lon, h := dms(53, 48, 33.820), dms(2, 7, 46.380), 73.0
+	x, y, z := WGS84Ellipsoid.toGeocentric(lat*deg, lon*deg, h)
+	if math.Abs(x-3771793.968) > 0.001 || math.Abs(y-140253.342) > 0.001 || math.Abs(z-5124304.349) > 0.001 {
+		t.Errorf("toGeocentric = (%.4f, %.4f, %.4f), want (3771793.968, 140253.342, 5124304.349)", x, y, z)
+	}
+	gotLat, gotLon, gotH := WGS84Ellipsoid.fromGeocentric(x, y, z)
+	if math.Abs(gotLat/deg-lat) > 1e-9 || math.Abs(gotLon/deg-lon) > 1e-9 || math.Abs(gotH-h) > 0.001 {
+		t.Errorf("fromGeocentric = (%.9f, %.9f, %.4f), want (%.9f, %.9f, %.4f)", gotLat/deg, gotLon/deg, gotH, lat, lon, h)
+	}
+}
+
+func TestTransformRoundTrip(t *testing.T) {
+	points := []geo.Point{
+		{X: 37.6173, Y: 55.7558},
+		{X: 36.2, Y: 56.3},
+		{X: 39.4, Y: 54.9},
+	}
+	ids := []string{"EPSG:4284", "EPSG:28407", "EPSG:7683", "EPSG:20907", "EPSG:32637", "MSK-50-1", "MSK-50-2"}
+	for _, id := range ids {
+		t.Run(id, func(t *testing.T) {
+			c, err := Lookup(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range points {
+				q := c.FromWGS84(p)
+				back := c.ToWGS84(q)
+				if d := geo.GeodesicDistance(p, back); d > 0.001 {
+					t.Errorf("%v -> %v -> %v: расхождение %.4f м", p, q, back, d)
+				}
+				if c.IsGeographic() {
+					continue
+				}
+				again := c.FromWGS84(back)
+				if d := again.Dist(q); d > 0.001 {
+					t.Errorf("%v -> %v: повторный переход дал %v (%.4f м)", back, q, again, d)
+				}
+			}
+		})
+	}
+}
+
+// Переход WGS 84 → СК-42 → зона Гаусса–Крюгера должен совпадать с прямым
+// переходом WGS 84 → зона.
+func TestTransformChain(t *testing.T) {
+	sk42, err := Lookup("SK-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zone7, err := Lookup("EPSG:28407")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := geo.Point{X: 37.6173, Y: 55.7558}
+	geographic := sk42.FromWGS84(p)
+	viaSK42 := Transform(sk42, zone7, geographic)
+	direct := zone7.FromWGS84(p)
+	if d := viaSK42.Dist(direct); d > 0.001 {
+		t.Errorf("WGS 84 → СК-42 → зона 7 = %v, напрямую %v (%.4f м)", viaSK42, direct, d)
+	}
+	// Датумы различаются на десятки метров: переход не должен быть тождественным.
+	if d := geo.GeodesicDistance(p, geographic); d < 10 || d > 300 {
+		t.Errorf("сдвиг СК-42 относительно WGS 84 = %.1f м, ожидается 10–300 м", d)
+	}
+}
@@ -0,0 +1,67 @@
+package crs
+
+import "math"
+
+// Ellipsoid описывает эллипсоид вращения, на котором задана система координат.
+type Ellipsoid struct {
+	Name string
+	// A — большая полуось в метрах.
+	A float64
+	// InvF — обратное сжатие (1/f).
+	InvF float64
+}
+
+// Эллипсоиды, используемые в российских и международных системах координат.
+var (
+	// WGS84Ellipsoid — эллипсоид системы WGS 84.
+	WGS84Ellipsoid = Ellipsoid{Name: "WGS 84", A: 6378137.0, InvF: 298.257223563}
+	// Krassowsky1940 — эллипсоид Красовского (СК-42, СК-63, большинство МСК).
+	Krassowsky1940 = Ellipsoid{Name: "Krassowsky 1940", A: 6378245.0, InvF: 298.3}
+	// GSK2011Ellipsoid — эллипсоид геодезической системы координат 2011 года.
+	GSK2011Ellipsoid = Ellipsoid{Name: "GSK-2011", A: 6378136.5, InvF: 298.2564151}
+)
+
+// F возвращает сжатие эллипсоида.
+func (e Ellipsoid) F() float64 { return 1 / e.InvF }
+
+// E2 возвращает квадрат первого эксцентриситета.
+func (e Ellipsoid) E2() float64 {
+	f := e.F()
+	return f * (2 - f)
+}
+
+// toGeocentric переводит геодезические координаты (радианы, высота в метрах)
+// в прямоугольные геоцентрические.
+func (e Ellipsoid) toGeocentric(lat, lon, h float64) (x, y, z float64) {
+	e2 := e.E2()
+	sinLat, cosLat := math.Sincos(lat)
+	sinLon, cosLon := math.Sincos(lon)
+	n := e.A / math.Sqrt(1-e2*sinLat*sinLat)
+	x = (n + h) * cosLat * cosLon
+	y = (n + h) * cosLat * sinLon
+	z = (n*(1-e2) + h) * sinLat
+	return x, y, z
+}
+
+// fromGeocentric выполняет обратное преобразование итерационным методом.
+func (e Ellipsoid) fromGeocentric(x, y, z float64) (lat, lon, h float64) {
+	e2 := e.E2()
+	lon = math.Atan2(y, x)
+	p := math.Hypot(x, y)
+	lat = math.Atan2(z, p*(1-e2))
+	for i := 0; i < 10; i++ {
+		sinLat := math.Sin(lat)
+		n := e.A / math.Sqrt(1-e2*sinLat*sinLat)
+		h = p/math.Cos(lat) - n
+		next := math.Atan2(z, p*(1-e2*n/(n+h)))
+		if math.Abs(next-lat) < 1e-14 {
+			lat = next
+			break
+		}
+		lat = next
+	}
+	sinLat := math.Sin(lat)
+	n := e.A / math.Sqrt(1-e2*sinLat*sinLat)
+	h = p/math.Cos(lat) - n
+	return lat, lon, h
+}
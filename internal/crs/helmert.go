@@ -0,0 +1,97 @@
+package crs
+
+// arcSecond — угловая секунда в радианах.
+const arcSecond = 4.84813681109536e-6
+
+// Helmert — семь параметров перехода между геоцентрическими системами координат.
+//
+// Параметры задаются в соответствии с ГОСТ 32453-2017: линейные элементы в
+// метрах, углы разворота осей в угловых секундах, масштаб в миллионных долях.
+type Helmert struct {
+	DX, DY, DZ float64
+	RX, RY, RZ float64
+	// Scale — масштабный коэффициент m в ppm (1e-6).
+	Scale float64
+}
+
+// apply переводит геоцентрические координаты в целевую систему по формулам ГОСТ 32453-2017.
+func (h Helmert) apply(x, y, z float64) (float64, float64, float64) {
+	m := 1 + h.Scale*1e-6
+	wx, wy, wz := h.RX*arcSecond, h.RY*arcSecond, h.RZ*arcSecond
+	return m*(x+wz*y-wy*z) + h.DX,
+		m*(-wz*x+y+wx*z) + h.DY,
+		m*(wy*x-wx*y+z) + h.DZ
+}
+
+// applyInverse выполняет обратное преобразование, точно решая линейную систему,
+// чтобы прямой и обратный переходы согласовывались до долей миллиметра.
+func (h Helmert) applyInverse(x, y, z float64) (float64, float64, float64) {
+	m := 1 + h.Scale*1e-6
+	wx, wy, wz := h.RX*arcSecond, h.RY*arcSecond, h.RZ*arcSecond
+	u := [3]float64{(x - h.DX) / m, (y - h.DY) / m, (z - h.DZ) / m}
+	r := [3][3]float64{
+		{1, wz, -wy},
+		{-wz, 1, wx},
+		{wy, -wx, 1},
+	}
+	det := r[0][0]*(r[1][1]*r[2][2]-r[1][2]*r[2][1]) -
+		r[0][1]*(r[1][0]*r[2][2]-r[1][2]*r[2][0]) +
+		r[0][2]*(r[1][0]*r[2][1]-r[1][1]*r[2][0])
+	solve := func(col int) float64 {
+		c := r
+		for i := 0; i < 3; i++ {
+			c[i][col] = u[i]
+		}
+		return (c[0][0]*(c[1][1]*c[2][2]-c[1][2]*c[2][1]) -
+			c[0][1]*(c[1][0]*c[2][2]-c[1][2]*c[2][0]) +
+			c[0][2]*(c[1][0]*c[2][1]-c[1][1]*c[2][0])) / det
+	}
+	return solve(0), solve(1), solve(2)
+}
+
+// IsZero сообщает, что переход является тождественным.
+func (h Helmert) IsZero() bool { return h == Helmert{} }
+
+// Датумы и параметры их связи с WGS 84.
+var (
+	// SK42ToWGS84 — переход СК-42 → WGS 84 по ГОСТ Р 51794-2008 (через ПЗ-90.02).
+	SK42ToWGS84 = Helmert{DX: 23.57, DY: -140.95, DZ: -79.8, RX: 0, RY: -0.35, RZ: -0.79, Scale: -0.22}
+	// GSK2011ToWGS84 — переход ГСК-2011 → WGS 84 (через ПЗ-90.11) по ГОСТ 32453-2017.
+	GSK2011ToWGS84 = Helmert{DX: -0.013, DY: 0.120, DZ: 0.014, RX: -0.00286, RY: 0.00356, RZ: -0.00426, Scale: -0.0086}
+)
+
+// Datum связывает эллипсоид с параметрами перехода к WGS 84.
+type Datum struct {
+	Name      string
+	Ellipsoid Ellipsoid
+	ToWGS84   Helmert
+}
+
+// Датумы, поддерживаемые сервисом.
+var (
+	DatumWGS84   = Datum{Name: "WGS 84", Ellipsoid: WGS84Ellipsoid}
+	DatumSK42    = Datum{Name: "Pulkovo 1942", Ellipsoid: Krassowsky1940, ToWGS84: SK42ToWGS84}
+	DatumGSK2011 = Datum{Name: "GSK-2011", Ellipsoid: GSK2011Ellipsoid, ToWGS84: GSK2011ToWGS84}
+)
+
+// toWGS84 переводит геодезические координаты датума (радианы) в WGS 84.
+func (d Datum) toWGS84(lat, lon float64) (float64, float64) {
+	if d.ToWGS84.IsZero() && d.Ellipsoid == WGS84Ellipsoid {
+		return lat, lon
+	}
+	x, y, z := d.Ellipsoid.toGeocentric(lat, lon, 0)
+	x, y, z = d.ToWGS84.apply(x, y, z)
+	lat, lon, _ = WGS84Ellipsoid.fromGeocentric(x, y, z)
+	return lat, lon
+}
+
+// fromWGS84 переводит геодезические координаты WGS 84 (радианы) в датум.
+func (d Datum) fromWGS84(lat, lon float64) (float64, float64) {
+	if d.ToWGS84.IsZero() && d.Ellipsoid == WGS84Ellipsoid {
+		return lat, lon
+	}
+	x, y, z := WGS84Ellipsoid.toGeocentric(lat, lon, 0)
+	x, y, z = d.ToWGS84.applyInverse(x, y, z)
+	lat, lon, _ = d.Ellipsoid.fromGeocentric(x, y, z)
+	return lat, lon
+}
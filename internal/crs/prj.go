@@ -0,0 +1,310 @@
+package crs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParsePRJ определяет систему координат по описанию OGC WKT из файла .prj
+// шейп-файла (в вариантах ESRI и GDAL).
+//
+// Если описание содержит код EPSG, используется он. Иначе датум определяется
+// по эллипсоиду, а для проекции Transverse Mercator параметры сравниваются с
+// зарегистрированными системами (в том числе зонами МСК) и зональными
+// семействами EPSG. Параметры TOWGS84 из файла не используются: переход к
+// WGS 84 берётся из найденной системы.
+func ParsePRJ(text string) (*CRS, error) {
+	root, err := parseWKT(text)
+	if err != nil {
+		return nil, err
+	}
+	if code := root.authority(); code != "" {
+		if c, err := Lookup("EPSG:" + code); err == nil {
+			return c, nil
+		}
+	}
+
+	geog := root
+	if root.keyword != "GEOGCS" {
+		geog = root.child("GEOGCS")
+	}
+	if geog == nil {
+		return nil, fmt.Errorf("%w: в описании .prj нет GEOGCS", ErrUnknownCRS)
+	}
+	datum, ok := prjDatum(geog)
+	if !ok {
+		return nil, fmt.Errorf("%w: датум %s не поддерживается", ErrUnknownCRS, geog.name())
+	}
+
+	switch root.keyword {
+	case "GEOGCS":
+		for _, c := range []*CRS{WGS84, mustLookup("EPSG:4284"), mustLookup("EPSG:7683")} {
+			if c.Datum.Ellipsoid == datum.Ellipsoid {
+				return c, nil
+			}
+		}
+	case "PROJCS":
+		projection, err := prjProjection(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range projectedCandidates() {
+			if c.Datum.Ellipsoid == datum.Ellipsoid && sameProjection(*c.Projection, projection) {
+				return c, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: параметры проекции %s не совпадают ни с одной известной системой, укажите систему координат явно",
+			ErrUnknownCRS, root.name())
+	}
+	return nil, fmt.Errorf("%w: неподдерживаемое описание %s", ErrUnknownCRS, root.keyword)
+}
+
+func mustLookup(id string) *CRS {
+	c, err := Lookup(id)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// prjDatum определяет датум по названию или параметрам эллипсоида.
+func prjDatum(geog *wktNode) (Datum, bool) {
+	name := ""
+	if d := geog.child("DATUM"); d != nil {
+		name = strings.ToUpper(d.name())
+	}
+	switch {
+	case strings.Contains(name, "PULKOVO") || strings.Contains(name, "SK42") || strings.Contains(name, "SK_42"):
+		return DatumSK42, true
+	case strings.Contains(name, "GSK"):
+		return DatumGSK2011, true
+	case strings.Contains(name, "WGS") && strings.Contains(name, "84"):
+		return DatumWGS84, true
+	}
+	spheroid := geog.find("SPHEROID")
+	if spheroid == nil || len(spheroid.args) < 3 {
+		return Datum{}, false
+	}
+	a, _ := strconv.ParseFloat(spheroid.args[1].value, 64)
+	for _, d := range []Datum{DatumWGS84, DatumSK42, DatumGSK2011} {
+		if math.Abs(d.Ellipsoid.A-a) < 0.01 {
+			return d, true
+		}
+	}
+	return Datum{}, false
+}
+
+// prjProjection читает параметры поперечно-цилиндрической проекции Меркатора.
+func prjProjection(root *wktNode) (TransverseMercator, error) {
+	proj := root.child("PROJECTION")
+	if proj == nil {
+		return TransverseMercator{}, fmt.Errorf("%w: не указана проекция", ErrUnknownCRS)
+	}
+	switch strings.ToLower(proj.name()) {
+	case "transverse_mercator", "gauss_kruger", "gauss-kruger":
+	default:
+		return TransverseMercator{}, fmt.Errorf("%w: проекция %s не поддерживается", ErrUnknownCRS, proj.name())
+	}
+	if unit := root.child("UNIT"); unit != nil && len(unit.args) > 1 {
+		if factor, err := strconv.ParseFloat(unit.args[1].value, 64); err == nil && factor != 1 {
+			return TransverseMercator{}, fmt.Errorf("%w: единица измерения %s не поддерживается, ожидаются метры", ErrUnknownCRS, unit.name())
+		}
+	}
+
+	p := TransverseMercator{Scale: 1}
+	for _, param := range root.children("PARAMETER") {
+		if len(param.args) < 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(param.args[1].value, 64)
+		if err != nil {
+			return TransverseMercator{}, fmt.Errorf("%w: некорректное значение параметра %s", ErrUnknownCRS, param.name())
+		}
+		switch strings.ToLower(param.name()) {
+		case "central_meridian", "longitude_of_origin":
+			p.LonOrigin = v
+		case "latitude_of_origin":
+			p.LatOrigin = v
+		case "scale_factor":
+			p.Scale = v
+		case "false_easting":
+			p.FalseEasting = v
+		case "false_northing":
+			p.FalseNorthing = v
+		}
+	}
+	return p, nil
+}
+
+// projectedCandidates возвращает зарегистрированные прямоугольные системы и
+// зональные системы EPSG, с которыми сравниваются параметры из .prj.
+func projectedCandidates() []*CRS {
+	var out []*CRS
+	for _, c := range List() {
+		if c.Projection != nil {
+			out = append(out, c)
+		}
+	}
+	families := [][2]int{{28402, 28432}, {20904, 20932}, {32601, 32660}, {32701, 32760}}
+	for _, family := range families {
+		for code := family[0]; code <= family[1]; code++ {
+			if c, ok := lookupZoned("EPSG:" + strconv.Itoa(code)); ok {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+func sameProjection(a, b TransverseMercator) bool {
+	const angle, metre = 1e-6, 1e-3
+	return math.Abs(a.LonOrigin-b.LonOrigin) < angle &&
+		math.Abs(a.LatOrigin-b.LatOrigin) < angle &&
+		math.Abs(a.Scale-b.Scale) < 1e-9 &&
+		math.Abs(a.FalseEasting-b.FalseEasting) < metre &&
+		math.Abs(a.FalseNorthing-b.FalseNorthing) < metre
+}
+
+// wktNode — узел описания OGC WKT вида KEYWORD["имя", аргументы...].
+type wktNode struct {
+	keyword string
+	// value — значение для строкового или числового аргумента.
+	value string
+	args  []*wktNode
+}
+
+func (n *wktNode) name() string {
+	if len(n.args) == 0 {
+		return ""
+	}
+	return n.args[0].value
+}
+
+func (n *wktNode) child(keyword string) *wktNode {
+	for _, a := range n.args {
+		if a.keyword == keyword {
+			return a
+		}
+	}
+	return nil
+}
+
+func (n *wktNode) children(keyword string) []*wktNode {
+	var out []*wktNode
+	for _, a := range n.args {
+		if a.keyword == keyword {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// find ищет узел на любой глубине.
+func (n *wktNode) find(keyword string) *wktNode {
+	for _, a := range n.args {
+		if a.keyword == keyword {
+			return a
+		}
+		if found := a.find(keyword); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// authority возвращает код EPSG узла из AUTHORITY["EPSG","код"] или ID["EPSG",код].
+func (n *wktNode) authority() string {
+	for _, keyword := range []string{"AUTHORITY", "ID"} {
+		if a := n.child(keyword); a != nil && len(a.args) > 1 && strings.EqualFold(a.args[0].value, "EPSG") {
+			return a.args[1].value
+		}
+	}
+	return ""
+}
+
+// parseWKT разбирает описание OGC WKT в дерево узлов.
+func parseWKT(text string) (*wktNode, error) {
+	p := wktParser{text: strings.TrimSpace(strings.TrimPrefix(text, "\ufeff"))}
+	node, err := p.node()
+	if err != nil {
+		return nil, err
+	}
+	if node.keyword == "" {
+		return nil, fmt.Errorf("%w: описание .prj не распознано", ErrUnknownCRS)
+	}
+	return node, nil
+}
+
+type wktParser struct {
+	text string
+	pos  int
+}
+
+func (p *wktParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: ошибка в описании .prj, позиция %d: %s", ErrUnknownCRS, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.text) && strings.ContainsRune(" \t\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *wktParser) node() (*wktNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.text) {
+		return nil, p.errorf("описание закончилось")
+	}
+	if p.text[p.pos] == '"' {
+		end := strings.IndexByte(p.text[p.pos+1:], '"')
+		if end < 0 {
+			return nil, p.errorf("незакрытая строка")
+		}
+		value := p.text[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return &wktNode{value: value}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.text) && !strings.ContainsRune("[](),\" \t\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+	word := p.text[start:p.pos]
+	p.skipSpace()
+	if p.pos >= len(p.text) || (p.text[p.pos] != '[' && p.text[p.pos] != '(') {
+		if word == "" {
+			return nil, p.errorf("ожидается значение")
+		}
+		return &wktNode{value: word}, nil
+	}
+
+	open := p.text[p.pos]
+	closing := byte(']')
+	if open == '(' {
+		closing = ')'
+	}
+	p.pos++
+	node := &wktNode{keyword: strings.ToUpper(word)}
+	for {
+		arg, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		node.args = append(node.args, arg)
+		p.skipSpace()
+		if p.pos >= len(p.text) {
+			return nil, p.errorf("не закрыт узел %s", node.keyword)
+		}
+		switch p.text[p.pos] {
+		case ',':
+			p.pos++
+		case closing:
+			p.pos++
+			return node, nil
+		default:
+			return nil, p.errorf("неожиданный символ %q", p.text[p.pos])
+		}
+	}
+}
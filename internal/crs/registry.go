@@ -0,0 +1,224 @@
+package crs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownCRS возвращается, если система координат не найдена в реестре.
+var ErrUnknownCRS = errors.New("неизвестная система координат")
+
+// WGS84ID — идентификатор системы, в которой хранятся контуры.
+const WGS84ID = "EPSG:4326"
+
+// WGS84 — географическая система координат WGS 84.
+var WGS84 = &CRS{ID: WGS84ID, Name: "WGS 84", Datum: DatumWGS84}
+
+var registry = struct {
+	sync.RWMutex
+	byID    map[string]*CRS
+	aliases map[string]string
+}{
+	byID: map[string]*CRS{
+		WGS84ID:     WGS84,
+		"EPSG:4284": {ID: "EPSG:4284", Name: "Pulkovo 1942 (СК-42)", Datum: DatumSK42},
+		"EPSG:7683": {ID: "EPSG:7683", Name: "GSK-2011 (ГСК-2011)", Datum: DatumGSK2011},
+	},
+	aliases: map[string]string{
+		"WGS84":    WGS84ID,
+		"WGS-84":   WGS84ID,
+		"CRS84":    WGS84ID,
+		"SK-42":    "EPSG:4284",
+		"СК-42":    "EPSG:4284",
+		"GSK-2011": "EPSG:7683",
+		"ГСК-2011": "EPSG:7683",
+	},
+}
+
+// MSKZone описывает параметры зоны местной системы координат субъекта РФ.
+type MSKZone struct {
+	// Region — код субъекта РФ (например, "50" для Московской области).
+	Region string `json:"region"`
+	// Zone — номер зоны; 0 для однозонных МСК.
+	Zone int    `json:"zone"`
+	Name string `json:"name"`
+	// Datum — исходный датум зоны: "SK-42" (по умолчанию), "GSK-2011" или "WGS84".
+	Datum      string             `json:"datum"`
+	Projection TransverseMercator `json:"projection"`
+}
+
+// ID возвращает идентификатор зоны вида MSK-50-1 (или MSK-77 для однозонной МСК).
+func (z MSKZone) ID() string {
+	if z.Zone == 0 {
+		return "MSK-" + z.Region
+	}
+	return fmt.Sprintf("MSK-%s-%d", z.Region, z.Zone)
+}
+
+// builtinMSK — зоны МСК с опубликованными параметрами. Остальные зоны
+// загружаются функцией LoadMSK из файла, предоставленного органом власти.
+var builtinMSK = []MSKZone{
+	{Region: "50", Zone: 1, Name: "МСК-50, зона 1", Projection: TransverseMercator{
+		LonOrigin: 35.48333333333, Scale: 1, FalseEasting: 1250000, FalseNorthing: -5712900.566,
+	}},
+	{Region: "50", Zone: 2, Name: "МСК-50, зона 2", Projection: TransverseMercator{
+		LonOrigin: 38.48333333333, Scale: 1, FalseEasting: 2250000, FalseNorthing: -5712900.566,
+	}},
+}
+
+func init() {
+	for _, zone := range builtinMSK {
+		if err := RegisterMSK(zone); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register добавляет систему координат в реестр.
+func Register(c *CRS) error {
+	if c == nil || c.ID == "" {
+		return errors.New("не указан идентификатор системы координат")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.byID[strings.ToUpper(c.ID)] = c
+	return nil
+}
+
+// RegisterMSK регистрирует зону местной системы координат.
+func RegisterMSK(z MSKZone) error {
+	if z.Region == "" {
+		return errors.New("не указан код субъекта для МСК")
+	}
+	datum := DatumSK42
+	switch strings.ToUpper(z.Datum) {
+	case "", "SK-42", "СК-42":
+	case "GSK-2011", "ГСК-2011":
+		datum = DatumGSK2011
+	case "WGS84", "WGS-84":
+		datum = DatumWGS84
+	default:
+		return fmt.Errorf("неизвестный датум %q для %s", z.Datum, z.ID())
+	}
+	name := z.Name
+	if name == "" {
+		name = z.ID()
+	}
+	projection := z.Projection
+	return Register(&CRS{ID: z.ID(), Name: name, Datum: datum, Projection: &projection})
+}
+
+// LoadMSK загружает параметры зон МСК из JSON-массива объектов MSKZone.
+func LoadMSK(r io.Reader) error {
+	var zones []MSKZone
+	if err := json.NewDecoder(r).Decode(&zones); err != nil {
+		return fmt.Errorf("не удалось прочитать параметры МСК: %w", err)
+	}
+	for _, zone := range zones {
+		if err := RegisterMSK(zone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mskName распознаёт названия зон МСК, в том числе записанные по-русски.
+var mskName = regexp.MustCompile(`^(?:МСК|MSK)[\s-]*(\d+)(?:[\s,;(-]*(?:ЗОНА|ZONE)?[\s№]*(\d+)\)?)?$`)
+
+// Lookup находит систему координат по идентификатору.
+//
+// Помимо зарегистрированных систем поддерживаются семейства EPSG:
+// 28402–28432 (СК-42, зоны Гаусса–Крюгера), 20904–20932 (ГСК-2011, зоны
+// Гаусса–Крюгера), 32601–32660 и 32701–32760 (WGS 84, UTM). Зоны МСК можно
+// указывать и так, как они записаны в документах Росреестра: «МСК-50, зона 2».
+func Lookup(id string) (*CRS, error) {
+	key := strings.ToUpper(strings.TrimSpace(id))
+	if key == "" {
+		return WGS84, nil
+	}
+	// Код без префикса («28407», SRID из PostGIS) считается кодом EPSG.
+	if _, err := strconv.Atoi(key); err == nil {
+		key = "EPSG:" + key
+	}
+	// Названия МСК в документах Росреестра: «МСК-50, зона 2», «МСК 77».
+	if m := mskName.FindStringSubmatch(key); m != nil {
+		key = "MSK-" + m[1]
+		if m[2] != "" {
+			key += "-" + m[2]
+		}
+	}
+	registry.RLock()
+	if alias, ok := registry.aliases[key]; ok {
+		key = alias
+	}
+	c, ok := registry.byID[key]
+	registry.RUnlock()
+	if ok {
+		return c, nil
+	}
+	if c, ok := lookupZoned(key); ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCRS, id)
+}
+
+// lookupZoned строит зональные системы EPSG по коду.
+func lookupZoned(key string) (*CRS, bool) {
+	code, ok := strings.CutPrefix(key, "EPSG:")
+	if !ok {
+		return nil, false
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, false
+	}
+	gaussKruger := func(name string, datum Datum, zone int) (*CRS, bool) {
+		return &CRS{ID: key, Name: fmt.Sprintf("%s / Gauss-Kruger zone %d", name, zone), Datum: datum,
+			Projection: &TransverseMercator{
+				LonOrigin:    float64(6*zone - 3),
+				Scale:        1,
+				FalseEasting: float64(zone)*1e6 + 500000,
+			}}, true
+	}
+	switch {
+	case n >= 28402 && n <= 28432:
+		return gaussKruger("Pulkovo 1942", DatumSK42, n-28400)
+	case n >= 20904 && n <= 20932:
+		return gaussKruger("GSK-2011", DatumGSK2011, n-20900)
+	case n >= 32601 && n <= 32660, n >= 32701 && n <= 32760:
+		zone := n % 100
+		north := n < 32700
+		falseNorthing, hemi := 0.0, "N"
+		if !north {
+			falseNorthing, hemi = 10000000, "S"
+		}
+		return &CRS{ID: key, Name: fmt.Sprintf("WGS 84 / UTM zone %d%s", zone, hemi), Datum: DatumWGS84,
+			Projection: &TransverseMercator{
+				LonOrigin:     float64(6*zone - 183),
+				Scale:         0.9996,
+				FalseEasting:  500000,
+				FalseNorthing: falseNorthing,
+			}}, true
+	}
+	return nil, false
+}
+
+// List возвращает зарегистрированные системы координат, упорядоченные по идентификатору.
+// Зональные семейства EPSG в список не входят.
+func List() []*CRS {
+	registry.RLock()
+	defer registry.RUnlock()
+	out := make([]*CRS, 0, len(registry.byID))
+	for _, c := range registry.byID {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
@@ -0,0 +1,60 @@
+package crs
+
+import (
+	"math"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// TransverseMercator описывает поперечно-цилиндрическую проекцию Меркатора
+// (Гаусса–Крюгера). Вычисления выполняются по рядам Крюгера шестого порядка
+// (geo.Krueger), что обеспечивает миллиметровую точность в пределах ±4° от
+// осевого меридиана.
+type TransverseMercator struct {
+	// LatOrigin и LonOrigin — широта начала отсчёта и осевой меридиан в градусах.
+	LatOrigin float64 `json:"lat_0"`
+	LonOrigin float64 `json:"lon_0"`
+	// Scale — масштаб на осевом меридиане.
+	Scale float64 `json:"k"`
+	// FalseEasting и FalseNorthing — смещения начала координат в метрах.
+	FalseEasting  float64 `json:"x_0"`
+	FalseNorthing float64 `json:"y_0"`
+}
+
+// forward переводит геодезические координаты (градусы) в прямоугольные (метры).
+func (p TransverseMercator) forward(el Ellipsoid, lat, lon float64) (easting, northing float64) {
+	k := geo.NewKrueger(el.A, el.F())
+	x, y := k.Forward(lat, lon-p.LonOrigin)
+	_, y0 := k.Forward(p.LatOrigin, 0)
+	scale := p.scale()
+	return p.FalseEasting + scale*x, p.FalseNorthing + scale*(y-y0)
+}
+
+// inverse переводит прямоугольные координаты (метры) в геодезические (градусы).
+func (p TransverseMercator) inverse(el Ellipsoid, easting, northing float64) (lat, lon float64) {
+	k := geo.NewKrueger(el.A, el.F())
+	_, y0 := k.Forward(p.LatOrigin, 0)
+	scale := p.scale()
+	lat, dLon := k.Inverse((easting-p.FalseEasting)/scale, (northing-p.FalseNorthing)/scale+y0)
+	return lat, normalizeLon(p.LonOrigin + dLon)
+}
+
+func (p TransverseMercator) scale() float64 {
+	if p.Scale == 0 {
+		return 1
+	}
+	return p.Scale
+}
+
+const deg = math.Pi / 180
+
+// normalizeLon приводит долготу к диапазону (-180, 180].
+func normalizeLon(lon float64) float64 {
+	for lon > 180 {
+		lon -= 360
+	}
+	for lon <= -180 {
+		lon += 360
+	}
+	return lon
+}
@@ -2,57 +2,291 @@ package documents
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"zemlya-prosto/internal/catalog"
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/plot"
+	"zemlya-prosto/internal/templates"
 )
 
 // PackageRequest описывает данные, необходимые для формирования комплекта документов.
 type PackageRequest struct {
-	ContourID string            `json:"contourId"`
-	Template  string            `json:"template"`
-	Metadata  map[string]string `json:"metadata"`
+	ContourID string `json:"contourId"`
+	// ParcelID — участок из перечня готовых участков, если заявитель выбрал его.
+	ParcelID string `json:"parcelId,omitempty"`
+	// Template — шаблон документа; если не указан, комплект формируется по
+	// всем шаблонам из DefaultTemplates. TemplateVersion — версия шаблона,
+	// по умолчанию последняя.
+	Template        string    `json:"template"`
+	TemplateVersion int       `json:"templateVersion,omitempty"`
+	Applicant       Applicant `json:"applicant"`
+	// Metadata — дополнительные поля шаблона, доступные как metadata.<ключ>,
+	// например authority — орган власти, в который подаётся заявление.
+	Metadata map[string]string `json:"metadata"`
+}
+
+// Applicant — сведения о заявителе для заявлений и согласий.
+type Applicant struct {
+	FullName         string `json:"fullName"`
+	IdentityDocument string `json:"identityDocument,omitempty"`
+	Address          string `json:"address,omitempty"`
+	Phone            string `json:"phone,omitempty"`
+	Email            string `json:"email,omitempty"`
+	SNILS            string `json:"snils,omitempty"`
+	INN              string `json:"inn,omitempty"`
 }
 
 // Package содержит результат формирования документов.
 type Package struct {
-	ID        string    `json:"id"`
-	ContourID string    `json:"contourId"`
-	Status    string    `json:"status"`
-	Files     []string  `json:"files"`
+	ID        string `json:"id"`
+	ContourID string `json:"contourId"`
+	ParcelID  string `json:"parcelId,omitempty"`
+	Status    string `json:"status"`
+	// Files — адреса, по которым выгружаются файлы комплекта.
+	Files []string `json:"files"`
+	// Templates — шаблоны с версиями, по которым сформирован комплект,
+	// например application@2.
+	Templates []string  `json:"templates"`
 	CreatedAt time.Time `json:"createdAt"`
 }
 
+// File — файл сформированного комплекта.
+type File struct {
+	Name      string
+	MediaType string
+	Data      []byte
+}
+
 // Service описывает операции генерации комплектов документов.
 type Service interface {
 	PreparePackage(ctx context.Context, req PackageRequest) (Package, error)
+	// PackageFile возвращает файл комплекта по имени.
+	PackageFile(ctx context.Context, packageID, name string) (File, error)
 }
 
-// StubService возвращает заранее подготовленные ответы, имитируя работу генератора документов.
-type StubService struct{}
+var (
+	// ErrNotFound возвращается, если комплекта или файла нет.
+	ErrNotFound = errors.New("document package or file not found")
+	// ErrInvalidRequest возвращается при неполном или противоречивом запросе.
+	ErrInvalidRequest = errors.New("invalid document package request")
+)
+
+// DefaultTemplates — шаблоны комплекта, если запрос не указывает шаблон.
+var DefaultTemplates = []string{"application", "consent"}
+
+const (
+	mediaTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	mediaTypePDF  = "application/pdf"
+)
+
+// Generator формирует комплекты по шаблонам из хранилища шаблонов, связывая
+// их поля с контуром заявителя, его атрибутами (карточкой участка),
+// участком из перечня и сведениями о заявителе. Файлы хранятся в памяти.
+type Generator struct {
+	plots     plot.Service
+	catalog   catalog.Service
+	templates *templates.Store
 
-// NewStubService создаёт заглушку.
-func NewStubService() *StubService { return &StubService{} }
+	mu    sync.RWMutex
+	files map[string]map[string]File
+}
 
-// PreparePackage формирует фиктивный набор документов.
-func (s *StubService) PreparePackage(ctx context.Context, req PackageRequest) (Package, error) {
+// NewGenerator создаёт генератор комплектов.
+func NewGenerator(plots plot.Service, catalog catalog.Service, store *templates.Store) *Generator {
+	return &Generator{
+		plots:     plots,
+		catalog:   catalog,
+		templates: store,
+		files:     make(map[string]map[string]File),
+	}
+}
+
+// PreparePackage формирует документы комплекта в DOCX и PDF.
+func (g *Generator) PreparePackage(ctx context.Context, req PackageRequest) (Package, error) {
 	if req.ContourID == "" {
-		return Package{}, fmt.Errorf("contourId is required")
+		return Package{}, fmt.Errorf("%w: contourId is required", ErrInvalidRequest)
+	}
+	if req.TemplateVersion != 0 && req.Template == "" {
+		return Package{}, fmt.Errorf("%w: templateVersion requires template", ErrInvalidRequest)
 	}
 	if err := ctx.Err(); err != nil {
 		return Package{}, err
 	}
+	selected := make([]templates.Template, 0, len(DefaultTemplates))
+	if req.Template != "" {
+		t, err := g.templates.Get(req.Template, req.TemplateVersion)
+		if err != nil {
+			return Package{}, err
+		}
+		selected = append(selected, t)
+	} else {
+		for _, id := range DefaultTemplates {
+			t, err := g.templates.Get(id, 0)
+			if err != nil {
+				return Package{}, err
+			}
+			selected = append(selected, t)
+		}
+	}
+	data, err := g.templateData(ctx, req)
+	if err != nil {
+		return Package{}, err
+	}
+
 	createdAt := time.Now().UTC()
 	pkg := Package{
 		ID:        fmt.Sprintf("pkg-%d", createdAt.UnixNano()),
 		ContourID: req.ContourID,
+		ParcelID:  req.ParcelID,
 		Status:    "READY",
-		Files: []string{
-			fmt.Sprintf("%s/statement.pdf", req.ContourID),
-			fmt.Sprintf("%s/plot-plan.pdf", req.ContourID),
-		},
+		Files:     make([]string, 0, 2*len(selected)),
+		Templates: make([]string, 0, len(selected)),
 		CreatedAt: createdAt,
 	}
+	files := make(map[string]File, 2*len(selected))
+	for _, t := range selected {
+		doc := t.Render(data)
+		docx, err := doc.DOCX()
+		if err != nil {
+			return Package{}, fmt.Errorf("render %s: %w", t.ID, err)
+		}
+		base := fmt.Sprintf("%s-v%d", t.ID, t.Version)
+		for _, f := range []File{
+			{Name: base + ".docx", MediaType: mediaTypeDOCX, Data: docx},
+			{Name: base + ".pdf", MediaType: mediaTypePDF, Data: doc.PDF()},
+		} {
+			files[f.Name] = f
+			pkg.Files = append(pkg.Files, "/api/v1/document-packages/"+pkg.ID+"/files/"+f.Name)
+		}
+		pkg.Templates = append(pkg.Templates, t.ID+"@"+strconv.Itoa(t.Version))
+	}
+
+	g.mu.Lock()
+	g.files[pkg.ID] = files
+	g.mu.Unlock()
 	return pkg, nil
 }
 
-var _ Service = (*StubService)(nil)
+// PackageFile возвращает файл комплекта.
+func (g *Generator) PackageFile(ctx context.Context, packageID, name string) (File, error) {
+	if err := ctx.Err(); err != nil {
+		return File{}, err
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	f, ok := g.files[packageID][name]
+	if !ok {
+		return File{}, ErrNotFound
+	}
+	return f, nil
+}
+
+// templateData собирает данные, доступные шаблонам: applicant, contour,
+// card, parcel, metadata и date (см. описание встроенных шаблонов).
+func (g *Generator) templateData(ctx context.Context, req PackageRequest) (map[string]any, error) {
+	contour, err := g.plots.ExportContour(ctx, req.ContourID, "")
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]any{
+		"date": time.Now().Format("02.01.2006"),
+		"applicant": map[string]any{
+			"full_name":         req.Applicant.FullName,
+			"identity_document": req.Applicant.IdentityDocument,
+			"address":           req.Applicant.Address,
+			"phone":             req.Applicant.Phone,
+			"email":             req.Applicant.Email,
+			"snils":             req.Applicant.SNILS,
+			"inn":               req.Applicant.INN,
+		},
+		"contour": contourData(contour),
+		"card":    cardData(contour.Attributes),
+	}
+	metadata := make(map[string]any, len(req.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	data["metadata"] = metadata
+
+	if req.ParcelID != "" {
+		plots, err := g.catalog.ListPlots(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		var found bool
+		for _, p := range plots {
+			if p.ID == req.ParcelID {
+				data["parcel"] = map[string]any{"id": p.ID, "name": p.Name, "purpose": p.Purpose}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: catalog plot %s", ErrNotFound, req.ParcelID)
+		}
+	}
+	return data, nil
+}
+
+// contourData описывает контур для шаблонов. Координаты характерных точек
+// приводятся только для прямоугольных систем координат.
+func contourData(contour plot.Contour) map[string]any {
+	data := map[string]any{
+		"id":          contour.ID,
+		"description": contour.Attributes["description"],
+		"area_sq_m":   math.Round(contour.AreaSqM),
+		"crs":         contour.CRS,
+	}
+	system, err := crs.Lookup(contour.CRS)
+	if err != nil || system.IsGeographic() {
+		return data
+	}
+	data["crs_name"] = system.Name
+	var points []any
+	perimeter := 0.0
+	for _, poly := range system.FromWGS84Multi(contour.Shape.Shape) {
+		for _, ring := range poly {
+			open := ring.Open()
+			for i, p := range open {
+				perimeter += p.Dist(open[(i+1)%len(open)])
+				points = append(points, map[string]any{
+					"number": "н" + strconv.Itoa(len(points)+1),
+					"x":      decimal(p.Y),
+					"y":      decimal(p.X),
+				})
+			}
+		}
+	}
+	data["points"] = points
+	data["perimeter_m"] = math.Round(perimeter*100) / 100
+	return data
+}
+
+// decimal записывает координату с точностью 0,01 м и запятой.
+func decimal(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', 2, 64), ".", ",", 1)
+}
+
+// cardData переносит атрибуты контура в карточку участка.
+func cardData(attributes map[string]string) map[string]any {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	items := make([]any, 0, len(keys))
+	for _, k := range keys {
+		items = append(items, map[string]any{"name": k, "value": attributes[k]})
+	}
+	return map[string]any{"attributes": items}
+}
+
+var _ Service = (*Generator)(nil)
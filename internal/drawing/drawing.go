@@ -0,0 +1,296 @@
+// Package drawing строит векторные чертежи — схемы, планы, страницы
+// документов — и выводит их в PNG и PDF без внешних зависимостей.
+//
+// Чертёж — список примитивов (заливка многоугольников, линии, текст) в
+// миллиметрах на листе заданного размера; начало координат в левом нижнем
+// углу листа, ось Y направлена вверх. Текст выводится встроенным штриховым
+// шрифтом, поэтому PNG и PDF выглядят одинаково и не требуют файлов шрифтов.
+// Цвета задаются без предварительного умножения на непрозрачность A.
+package drawing
+
+import (
+	"image/color"
+	"math"
+)
+
+// Point — точка на листе, мм.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Rect — прямоугольник на листе, мм.
+type Rect struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// Contains сообщает, что точка лежит внутри прямоугольника или на его границе.
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.MinX && p.X <= r.MaxX && p.Y >= r.MinY && p.Y <= r.MaxY
+}
+
+// Width и Height возвращают размеры прямоугольника.
+func (r Rect) Width() float64  { return r.MaxX - r.MinX }
+func (r Rect) Height() float64 { return r.MaxY - r.MinY }
+
+// LineStyle задаёт толщину, цвет и штрих линии. Dash — длины штрихов и
+// промежутков по очереди, мм; пустой Dash — сплошная линия.
+type LineStyle struct {
+	Width float64
+	Color color.RGBA
+	Dash  []float64
+}
+
+// Align — выравнивание текста относительно точки привязки.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+type opKind int
+
+const (
+	opFill opKind = iota
+	opStroke
+)
+
+// op — примитив чертежа. Для заливки rings — кольца многоугольника
+// (заливка по правилу чётности), для линии — ломаные.
+type op struct {
+	kind  opKind
+	rings [][]Point
+	color color.RGBA
+	width float64
+}
+
+// Drawing — один лист чертежа.
+type Drawing struct {
+	Width, Height float64
+	ops           []op
+	clip          *Rect
+}
+
+// New создаёт пустой лист размером width×height мм.
+func New(width, height float64) *Drawing {
+	return &Drawing{Width: width, Height: height}
+}
+
+// SetClip ограничивает вывод последующих примитивов прямоугольником r.
+func (d *Drawing) SetClip(r Rect) { d.clip = &r }
+
+// ResetClip снимает ограничение, заданное SetClip.
+func (d *Drawing) ResetClip() { d.clip = nil }
+
+// Fill заливает многоугольник с внутренними границами по правилу чётности.
+func (d *Drawing) Fill(rings [][]Point, c color.RGBA) {
+	out := make([][]Point, 0, len(rings))
+	for _, ring := range rings {
+		if d.clip != nil {
+			ring = clipPolygon(ring, *d.clip)
+		}
+		if len(ring) >= 3 {
+			out = append(out, ring)
+		}
+	}
+	if len(out) > 0 {
+		d.ops = append(d.ops, op{kind: opFill, rings: out, color: c})
+	}
+}
+
+// FillRect заливает прямоугольник.
+func (d *Drawing) FillRect(r Rect, c color.RGBA) {
+	d.Fill([][]Point{{{r.MinX, r.MinY}, {r.MaxX, r.MinY}, {r.MaxX, r.MaxY}, {r.MinX, r.MaxY}}}, c)
+}
+
+// Line проводит ломаную; при closed ломаная замыкается.
+func (d *Drawing) Line(pts []Point, closed bool, style LineStyle) {
+	if len(pts) < 2 || style.Width <= 0 {
+		return
+	}
+	if closed && pts[0] != pts[len(pts)-1] {
+		pts = append(append([]Point(nil), pts...), pts[0])
+	}
+	lines := [][]Point{pts}
+	if len(style.Dash) > 0 {
+		lines = dash(pts, style.Dash)
+	}
+	d.lines(lines, style.Width, style.Color)
+}
+
+// lines добавляет ломаные одной толщины и цвета, отсекая их прямоугольником
+// SetClip.
+func (d *Drawing) lines(lines [][]Point, width float64, c color.RGBA) {
+	if d.clip != nil {
+		var clipped [][]Point
+		for _, l := range lines {
+			clipped = append(clipped, clipLine(l, *d.clip)...)
+		}
+		lines = clipped
+	}
+	if len(lines) > 0 {
+		d.ops = append(d.ops, op{kind: opStroke, rings: lines, color: c, width: width})
+	}
+}
+
+// Frame обводит прямоугольник линией.
+func (d *Drawing) Frame(r Rect, style LineStyle) {
+	d.Line([]Point{{r.MinX, r.MinY}, {r.MaxX, r.MinY}, {r.MaxX, r.MaxY}, {r.MinX, r.MaxY}}, true, style)
+}
+
+// Circle заливает круг радиусом r.
+func (d *Drawing) Circle(center Point, r float64, c color.RGBA) {
+	const n = 24
+	ring := make([]Point, n)
+	for i := range ring {
+		a := 2 * math.Pi * float64(i) / n
+		ring[i] = Point{center.X + r*math.Cos(a), center.Y + r*math.Sin(a)}
+	}
+	d.Fill([][]Point{ring}, c)
+}
+
+// dash разбивает ломаную на штрихи по образцу pattern.
+func dash(pts []Point, pattern []float64) [][]Point {
+	total := 0.0
+	for _, v := range pattern {
+		total += v
+	}
+	if total <= 0 {
+		return [][]Point{pts}
+	}
+	var out [][]Point
+	cur := []Point{pts[0]}
+	k, left, on := 0, pattern[0], true
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		pos := 0.0
+		for length-pos > left {
+			pos += left
+			t := pos / length
+			p := Point{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t}
+			if on {
+				out = append(out, append(cur, p))
+				cur = nil
+			} else {
+				cur = []Point{p}
+			}
+			on = !on
+			k = (k + 1) % len(pattern)
+			left = pattern[k]
+		}
+		left -= length - pos
+		if on {
+			cur = append(cur, b)
+		}
+	}
+	if on && len(cur) >= 2 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// clipPolygon отсекает многоугольник прямоугольником (алгоритм
+// Сазерленда — Ходжмана).
+func clipPolygon(ring []Point, r Rect) []Point {
+	edges := []struct {
+		inside func(Point) bool
+		cross  func(a, b Point) Point
+	}{
+		{func(p Point) bool { return p.X >= r.MinX }, func(a, b Point) Point { return atX(a, b, r.MinX) }},
+		{func(p Point) bool { return p.X <= r.MaxX }, func(a, b Point) Point { return atX(a, b, r.MaxX) }},
+		{func(p Point) bool { return p.Y >= r.MinY }, func(a, b Point) Point { return atY(a, b, r.MinY) }},
+		{func(p Point) bool { return p.Y <= r.MaxY }, func(a, b Point) Point { return atY(a, b, r.MaxY) }},
+	}
+	out := ring
+	for _, e := range edges {
+		if len(out) == 0 {
+			break
+		}
+		in := out
+		out = make([]Point, 0, len(in)+4)
+		prev := in[len(in)-1]
+		for _, p := range in {
+			switch {
+			case e.inside(p) && e.inside(prev):
+				out = append(out, p)
+			case e.inside(p):
+				out = append(out, e.cross(prev, p), p)
+			case e.inside(prev):
+				out = append(out, e.cross(prev, p))
+			}
+			prev = p
+		}
+	}
+	return out
+}
+
+func atX(a, b Point, x float64) Point {
+	t := (x - a.X) / (b.X - a.X)
+	return Point{x, a.Y + (b.Y-a.Y)*t}
+}
+
+func atY(a, b Point, y float64) Point {
+	t := (y - a.Y) / (b.Y - a.Y)
+	return Point{a.X + (b.X-a.X)*t, y}
+}
+
+// clipLine отсекает ломаную прямоугольником и возвращает видимые части
+// (алгоритм Лианга — Барски для каждого отрезка).
+func clipLine(pts []Point, r Rect) [][]Point {
+	var out [][]Point
+	var cur []Point
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		t0, t1, ok := clipSegment(a, b, r)
+		if !ok {
+			if len(cur) >= 2 {
+				out = append(out, cur)
+			}
+			cur = nil
+			continue
+		}
+		pa := Point{a.X + (b.X-a.X)*t0, a.Y + (b.Y-a.Y)*t0}
+		pb := Point{a.X + (b.X-a.X)*t1, a.Y + (b.Y-a.Y)*t1}
+		if len(cur) == 0 || cur[len(cur)-1] != pa {
+			if len(cur) >= 2 {
+				out = append(out, cur)
+			}
+			cur = []Point{pa}
+		}
+		cur = append(cur, pb)
+		if t1 < 1 {
+			out = append(out, cur)
+			cur = nil
+		}
+	}
+	if len(cur) >= 2 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+func clipSegment(a, b Point, r Rect) (t0, t1 float64, ok bool) {
+	t0, t1 = 0, 1
+	dx, dy := b.X-a.X, b.Y-a.Y
+	for _, c := range [4][2]float64{
+		{-dx, a.X - r.MinX}, {dx, r.MaxX - a.X}, {-dy, a.Y - r.MinY}, {dy, r.MaxY - a.Y},
+	} {
+		p, q := c[0], c[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+	}
+	return t0, t1, t0 < t1
+}
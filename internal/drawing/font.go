@@ -0,0 +1,221 @@
+package drawing
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Штриховой шрифт: каждый знак — набор ломаных в сетке, где прописные буквы
+// и цифры имеют высоту 6 единиц, а подстрочные элементы опускаются до -1.
+// Строчные буквы выводятся прописными уменьшенного размера (капителью).
+// Ломаные разделяются точкой с запятой, точки ломаной — пробелами.
+var glyphSource = map[rune]string{
+	'0': "1,0 0,1 0,5 1,6 3,6 4,5 4,1 3,0 1,0",
+	'1': "1,5 2,6 2,0; 1,0 3,0",
+	'2': "0,5 1,6 3,6 4,5 4,4 0,0 4,0",
+	'3': "0,5 1,6 3,6 4,5 4,4 3,3 1,3; 3,3 4,2 4,1 3,0 1,0 0,1",
+	'4': "3,0 3,6 0,2 4,2",
+	'5': "4,6 0,6 0,3 3,3 4,2 4,1 3,0 0,0",
+	'6': "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1 4,2 3,3 0,3",
+	'7': "0,6 4,6 1,0",
+	'8': "1,3 0,4 0,5 1,6 3,6 4,5 4,4 3,3 1,3 0,2 0,1 1,0 3,0 4,1 4,2 3,3",
+	'9': "4,3 1,3 0,4 0,5 1,6 3,6 4,5 4,1 3,0 1,0 0,1",
+
+	'A': "0,0 0,4 2,6 4,4 4,0; 0,3 4,3",
+	'B': "0,0 0,6 3,6 4,5 4,4 3,3 0,3; 3,3 4,2 4,1 3,0 0,0",
+	'C': "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1",
+	'D': "0,0 0,6 2,6 4,4 4,2 2,0 0,0",
+	'E': "4,6 0,6 0,0 4,0; 0,3 3,3",
+	'F': "4,6 0,6 0,0; 0,3 3,3",
+	'G': "4,5 3,6 1,6 0,5 0,1 1,0 3,0 4,1 4,3 2,3",
+	'H': "0,0 0,6; 4,0 4,6; 0,3 4,3",
+	'I': "0,6 2,6; 1,6 1,0; 0,0 2,0",
+	'J': "4,6 4,1 3,0 1,0 0,1",
+	'K': "0,0 0,6; 4,6 0,2; 1,3 4,0",
+	'L': "0,6 0,0 4,0",
+	'M': "0,0 0,6 2,3 4,6 4,0",
+	'N': "0,0 0,6 4,0 4,6",
+	'O': "1,0 0,1 0,5 1,6 3,6 4,5 4,1 3,0 1,0",
+	'P': "0,0 0,6 3,6 4,5 4,4 3,3 0,3",
+	'Q': "1,0 0,1 0,5 1,6 3,6 4,5 4,1 3,0 1,0; 2,2 4,0",
+	'R': "0,0 0,6 3,6 4,5 4,4 3,3 0,3; 2,3 4,0",
+	'S': "4,5 3,6 1,6 0,5 0,4 1,3 3,3 4,2 4,1 3,0 1,0 0,1",
+	'T': "0,6 4,6; 2,6 2,0",
+	'U': "0,6 0,1 1,0 3,0 4,1 4,6",
+	'V': "0,6 2,0 4,6",
+	'W': "0,6 1,0 2,4 3,0 4,6",
+	'X': "0,0 4,6; 0,6 4,0",
+	'Y': "0,6 2,3 4,6; 2,3 2,0",
+	'Z': "0,6 4,6 0,0 4,0",
+
+	'Б': "4,6 0,6 0,0 3,0 4,1 4,2 3,3 0,3",
+	'Г': "4,6 0,6 0,0",
+	'Д': "0,-1 0,0 4,0 4,-1; 0.5,0 1,1 1.5,6 3.5,6 3.5,0",
+	'Ж': "0,6 2,3 0,0; 4,6 2,3 4,0; 2,0 2,6",
+	'З': "0,5 1,6 3,6 4,5 4,4 3,3 1,3; 3,3 4,2 4,1 3,0 1,0 0,1",
+	'И': "0,6 0,0 4,6 4,0",
+	'Й': "0,6 0,0 4,6 4,0; 1,7 3,7",
+	'Л': "0,0 1,1 1.5,6 4,6 4,0",
+	'П': "0,0 0,6 4,6 4,0",
+	'У': "0,6 2,3; 4,6 2,1 1,0 0,0",
+	'Ф': "1,5 3,5 4,4 4,2 3,1 1,1 0,2 0,4 1,5; 2,0 2,6",
+	'Ц': "0,6 0,0 4,0 4,6; 4,0 4.5,0 4.5,-1",
+	'Ч': "0,6 0,4 1,3 4,3; 4,6 4,0",
+	'Ш': "0,6 0,0 4,0 4,6; 2,0 2,6",
+	'Щ': "0,6 0,0 4,0 4,6; 2,0 2,6; 4,0 4.5,0 4.5,-1",
+	'Ъ': "0,6 1,6 1,0 3,0 4,1 4,2 3,3 1,3",
+	'Ы': "0,6 0,0 2,0 3,1 3,2 2,3 0,3; 4,6 4,0",
+	'Ь': "0,6 0,0 3,0 4,1 4,2 3,3 0,3",
+	'Э': "0,5 1,6 3,6 4,5 4,1 3,0 1,0 0,1; 1,3 4,3",
+	'Ю': "0,0 0,6; 0,3 1,3; 2,6 3,6 4,5 4,1 3,0 2,0 1,1 1,5 2,6",
+	'Я': "4,0 4,6 1,6 0,5 0,4 1,3 4,3; 1,3 0,0",
+	'Ё': "4,6 0,6 0,0 4,0; 0,3 3,3; 1,7 1,7.3; 3,7 3,7.3",
+
+	'.':  "0,0 0,0.3",
+	',':  "0.3,0.3 0.3,0 0,-1",
+	':':  "0,0 0,0.3; 0,3.5 0,3.8",
+	';':  "0.3,0.3 0.3,0 0,-1; 0.3,3.5 0.3,3.8",
+	'!':  "0,6 0,2; 0,0 0,0.3",
+	'?':  "0,5 1,6 3,6 4,5 4,4 2,3 2,2; 2,0 2,0.3",
+	'-':  "0,3 2.5,3",
+	'–':  "0,3 4,3",
+	'—':  "0,3 6,3",
+	'+':  "0,3 4,3; 2,1 2,5",
+	'±':  "0,3.5 4,3.5; 2,1.5 2,5.5; 0,0.5 4,0.5",
+	'=':  "0,2 4,2; 0,4 4,4",
+	'×':  "0.5,1 3.5,5; 0.5,5 3.5,1",
+	'/':  "0,0 3,6",
+	'\\': "0,6 3,0",
+	'(':  "1.5,6.5 0.5,5 0.5,1 1.5,-0.5",
+	')':  "0,6.5 1,5 1,1 0,-0.5",
+	'[':  "1.5,6.5 0,6.5 0,-0.5 1.5,-0.5",
+	']':  "0,6.5 1.5,6.5 1.5,-0.5 0,-0.5",
+	'\'': "0,6 0,4.5",
+	'"':  "0,6 0,4.5; 1,6 1,4.5",
+	'°':  "0.5,6 1.5,6 2,5.5 2,4.5 1.5,4 0.5,4 0,4.5 0,5.5 0.5,6",
+	'²':  "0,5.5 0.5,6 1.5,6 2,5.5 2,5 0,3.5 2,3.5",
+	'«':  "2,5 0,3 2,1; 4,5 2,3 4,1",
+	'»':  "0,5 2,3 0,1; 2,5 4,3 2,1",
+	'_':  "0,-1 4,-1",
+	'%':  "0,0 4,6; 0.5,6 1,5.5 0.5,5 0,5.5 0.5,6; 3.5,1 4,0.5 3.5,0 3,0.5 3.5,1",
+	'*':  "2,1 2,5; 0.5,2 3.5,4; 0.5,4 3.5,2",
+	'#':  "1,0 1.5,6; 2.5,0 3,6; 0,2 4,2; 0,4 4,4",
+	'№':  "0,0 0,6 3,0 3,6; 4,1 5.5,1; 4.75,2.5 4.25,3 4.25,4.5 4.75,5 5.25,4.5 5.25,3 4.75,2.5",
+}
+
+// latinLike — кириллические буквы, совпадающие по начертанию с латинскими.
+var latinLike = map[rune]rune{
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H', 'О': 'O',
+	'Р': 'P', 'С': 'C', 'Т': 'T', 'Х': 'X',
+}
+
+const (
+	// glyphHeight — высота прописной буквы в единицах сетки шрифта.
+	glyphHeight = 6.0
+	// glyphSpacing — промежуток между знаками, единиц сетки.
+	glyphSpacing = 1.5
+	// spaceWidth — ширина пробела, единиц сетки.
+	spaceWidth = 3.5
+	// smallCaps — размер строчных букв относительно прописных.
+	smallCaps = 0.75
+	// strokeRatio — толщина штриха относительно высоты прописной буквы.
+	strokeRatio = 0.1
+)
+
+type glyph struct {
+	strokes [][]Point
+	width   float64
+}
+
+var glyphs = parseGlyphs()
+
+func parseGlyphs() map[rune]glyph {
+	out := make(map[rune]glyph, len(glyphSource))
+	for r, src := range glyphSource {
+		var g glyph
+		for _, stroke := range strings.Split(src, ";") {
+			var line []Point
+			for _, pair := range strings.Fields(stroke) {
+				x, y, _ := strings.Cut(pair, ",")
+				px, err1 := strconv.ParseFloat(x, 64)
+				py, err2 := strconv.ParseFloat(y, 64)
+				if err1 != nil || err2 != nil {
+					panic("drawing: неверное описание знака " + string(r))
+				}
+				line = append(line, Point{px, py})
+				g.width = max(g.width, px)
+			}
+			g.strokes = append(g.strokes, line)
+		}
+		out[r] = g
+	}
+	return out
+}
+
+// lookupGlyph возвращает знак шрифта и масштаб, в котором он выводится.
+func lookupGlyph(r rune) (glyph, float64) {
+	scale := 1.0
+	if unicode.IsLower(r) {
+		r, scale = unicode.ToUpper(r), smallCaps
+	}
+	if l, ok := latinLike[r]; ok {
+		r = l
+	}
+	g, ok := glyphs[r]
+	if !ok {
+		g = glyphs['?']
+	}
+	return g, scale
+}
+
+// TextWidth возвращает ширину строки, выведенной с высотой прописных букв
+// size мм.
+func TextWidth(s string, size float64) float64 {
+	unit := size / glyphHeight
+	w := 0.0
+	for _, r := range s {
+		if r == ' ' {
+			w += spaceWidth * unit
+			continue
+		}
+		g, scale := lookupGlyph(r)
+		w += (g.width*scale + glyphSpacing) * unit
+	}
+	if w > 0 {
+		w -= glyphSpacing * unit
+	}
+	return w
+}
+
+// Text выводит строку штриховым шрифтом. size — высота прописных букв, мм;
+// at — точка привязки на базовой линии, положение которой относительно
+// строки задаёт align.
+func (d *Drawing) Text(s string, at Point, size float64, align Align, c color.RGBA) {
+	unit := size / glyphHeight
+	x := at.X
+	switch align {
+	case AlignCenter:
+		x -= TextWidth(s, size) / 2
+	case AlignRight:
+		x -= TextWidth(s, size)
+	}
+	var lines [][]Point
+	for _, r := range s {
+		if r == ' ' {
+			x += spaceWidth * unit
+			continue
+		}
+		g, scale := lookupGlyph(r)
+		for _, stroke := range g.strokes {
+			line := make([]Point, len(stroke))
+			for i, p := range stroke {
+				line[i] = Point{x + p.X*scale*unit, at.Y + p.Y*scale*unit}
+			}
+			lines = append(lines, line)
+		}
+		x += (g.width*scale + glyphSpacing) * unit
+	}
+	d.lines(lines, size*strokeRatio, c)
+}
@@ -0,0 +1,144 @@
+package drawing
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// mmToPt — число пунктов PDF в миллиметре.
+const mmToPt = 72 / 25.4
+
+// PDF выводит листы в документ PDF 1.4, по листу на страницу. Чертёж
+// записывается векторными командами; title сохраняется в свойствах
+// документа.
+func PDF(title string, pages ...*Drawing) []byte {
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Объекты 1 и 2 — каталог и дерево страниц, 3 — свойства документа,
+	// далее по два объекта на страницу: страница и её содержимое.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	w.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	w.object(3, fmt.Sprintf("<< /Title %s /Producer (zemlya-prosto) >>", pdfText(title)))
+	for i, page := range pages {
+		content, states := page.pdfContent()
+		var resources strings.Builder
+		resources.WriteString("<< /ExtGState <<")
+		for _, alpha := range states {
+			fmt.Fprintf(&resources, " /A%d << /ca %s /CA %s >>", alpha, pdfNumber(float64(alpha)/255), pdfNumber(float64(alpha)/255))
+		}
+		resources.WriteString(" >> >>")
+		w.object(4+2*i, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources %s /Contents %d 0 R >>",
+			pdfNumber(page.Width*mmToPt), pdfNumber(page.Height*mmToPt), resources.String(), 5+2*i))
+
+		var packed bytes.Buffer
+		zw := zlib.NewWriter(&packed)
+		zw.Write(content)
+		zw.Close()
+		w.stream(5+2*i, packed.Bytes())
+	}
+
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, xref)
+	return w.buf.Bytes()
+}
+
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+// object записывает объект с номером n; объекты записываются по порядку.
+func (w *pdfWriter) object(n int, body string) {
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", n, body)
+}
+
+func (w *pdfWriter) stream(n int, data []byte) {
+	w.offsets = append(w.offsets, w.buf.Len())
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", n, len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// pdfContent формирует поток команд страницы и возвращает его вместе с
+// использованными уровнями прозрачности.
+func (d *Drawing) pdfContent() ([]byte, []uint8) {
+	var b bytes.Buffer
+	var states []uint8
+	seen := make(map[uint8]bool)
+	// Координаты чертежа — миллиметры, поэтому вся страница масштабируется.
+	fmt.Fprintf(&b, "%.7f 0 0 %.7f 0 0 cm 1 J 1 j\n", mmToPt, mmToPt)
+	path := func(rings [][]Point, closed bool) {
+		for _, ring := range rings {
+			for i, p := range ring {
+				op := "l"
+				if i == 0 {
+					op = "m"
+				}
+				fmt.Fprintf(&b, "%s %s %s\n", pdfNumber(p.X), pdfNumber(p.Y), op)
+			}
+			if closed {
+				b.WriteString("h\n")
+			}
+		}
+	}
+	for _, o := range d.ops {
+		c := o.color
+		b.WriteString("q\n")
+		if c.A < 0xff {
+			if !seen[c.A] {
+				seen[c.A] = true
+				states = append(states, c.A)
+			}
+			fmt.Fprintf(&b, "/A%d gs\n", c.A)
+		}
+		rgb := fmt.Sprintf("%s %s %s", pdfNumber(float64(c.R)/255), pdfNumber(float64(c.G)/255), pdfNumber(float64(c.B)/255))
+		switch o.kind {
+		case opFill:
+			fmt.Fprintf(&b, "%s rg\n", rgb)
+			path(o.rings, true)
+			b.WriteString("f*\n")
+		case opStroke:
+			fmt.Fprintf(&b, "%s RG %s w\n", rgb, pdfNumber(o.width))
+			path(o.rings, false)
+			b.WriteString("S\n")
+		}
+		b.WriteString("Q\n")
+	}
+	return b.Bytes(), states
+}
+
+// pdfNumber записывает число с точностью до тысячных без лишних нулей.
+func pdfNumber(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 3, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// pdfText записывает строку в кодировке UTF-16BE с меткой порядка байтов,
+// как требуется для текстовых строк PDF вне латиницы.
+func pdfText(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
@@ -0,0 +1,207 @@
+package drawing
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"sort"
+)
+
+// subScanlines — число строк выборки на пиксель по вертикали при
+// сглаживании; по горизонтали покрытие вычисляется точно.
+const subScanlines = 4
+
+// PNG выводит лист в PNG с разрешением dpi точек на дюйм на белом фоне.
+func (d *Drawing) PNG(dpi float64) ([]byte, error) {
+	scale := dpi / 25.4
+	w, h := int(math.Ceil(d.Width*scale)), int(math.Ceil(d.Height*scale))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	toPixels := func(rings [][]Point) [][]Point {
+		out := make([][]Point, len(rings))
+		for i, ring := range rings {
+			out[i] = make([]Point, len(ring))
+			for j, p := range ring {
+				out[i][j] = Point{p.X * scale, (d.Height - p.Y) * scale}
+			}
+		}
+		return out
+	}
+	for _, o := range d.ops {
+		switch o.kind {
+		case opFill:
+			fillPolygons(img, toPixels(o.rings), o.color, false)
+		case opStroke:
+			// Тонкие линии выводятся шириной не меньше пикселя, чтобы не
+			// пропадать при низком разрешении.
+			fillPolygons(img, strokePolygons(toPixels(o.rings), math.Max(o.width*scale, 1)), o.color, true)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// strokePolygons заменяет ломаные многоугольниками той же толщины:
+// прямоугольником на каждый отрезок и кругом на каждую вершину (скруглённые
+// соединения и концы). Все многоугольники обходятся в одном направлении,
+// поэтому их объединение заливается по правилу ненулевого индекса.
+func strokePolygons(lines [][]Point, width float64) [][]Point {
+	r := width / 2
+	const joinSegments = 8
+	var out [][]Point
+	disc := func(c Point) {
+		ring := make([]Point, joinSegments)
+		for i := range ring {
+			a := 2 * math.Pi * float64(i) / joinSegments
+			ring[i] = Point{c.X + r*math.Cos(a), c.Y + r*math.Sin(a)}
+		}
+		out = append(out, ring)
+	}
+	for _, line := range lines {
+		for i, b := range line {
+			disc(b)
+			if i == 0 {
+				continue
+			}
+			a := line[i-1]
+			length := math.Hypot(b.X-a.X, b.Y-a.Y)
+			if length == 0 {
+				continue
+			}
+			nx, ny := -(b.Y-a.Y)/length*r, (b.X-a.X)/length*r
+			out = append(out, []Point{
+				{a.X - nx, a.Y - ny}, {b.X - nx, b.Y - ny}, {b.X + nx, b.Y + ny}, {a.X + nx, a.Y + ny},
+			})
+		}
+	}
+	return out
+}
+
+type edge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// fillPolygons заливает многоугольники цветом c со сглаживанием: правило
+// ненулевого индекса при nonZero, иначе правило чётности.
+func fillPolygons(img *image.RGBA, polys [][]Point, c color.RGBA, nonZero bool) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	var edges []edge
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, ring := range polys {
+		for i, a := range ring {
+			b := ring[(i+1)%len(ring)]
+			minX, maxX = math.Min(minX, a.X), math.Max(maxX, a.X)
+			minY, maxY = math.Min(minY, a.Y), math.Max(maxY, a.Y)
+			switch {
+			case a.Y < b.Y:
+				edges = append(edges, edge{a.X, a.Y, b.X, b.Y, 1})
+			case a.Y > b.Y:
+				edges = append(edges, edge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+	if len(edges) == 0 || maxX < 0 || minX >= float64(w) || maxY < 0 || minY >= float64(h) {
+		return
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	x0, x1 := max(int(math.Floor(minX)), 0), min(int(math.Ceil(maxX)), w-1)
+	cover := make([]float64, w+1)
+	var active []edge
+	var crossings []crossing
+	next := 0
+	for py := max(int(math.Floor(minY)), 0); py <= min(int(math.Ceil(maxY)), h-1); py++ {
+		clear(cover[x0 : x1+2])
+		for s := 0; s < subScanlines; s++ {
+			y := float64(py) + (float64(s)+0.5)/subScanlines
+			for next < len(edges) && edges[next].y0 <= y {
+				active = append(active, edges[next])
+				next++
+			}
+			crossings = crossings[:0]
+			kept := active[:0]
+			for _, e := range active {
+				if e.y1 <= y {
+					continue
+				}
+				kept = append(kept, e)
+				if e.y0 <= y {
+					crossings = append(crossings, crossing{e.x0 + (y-e.y0)*(e.x1-e.x0)/(e.y1-e.y0), e.dir})
+				}
+			}
+			active = kept
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+			winding, start := 0, 0.0
+			for _, cr := range crossings {
+				wasInside := inside(winding, nonZero)
+				if nonZero {
+					winding += cr.dir
+				} else {
+					winding++
+				}
+				switch isInside := inside(winding, nonZero); {
+				case !wasInside && isInside:
+					start = cr.x
+				case wasInside && !isInside:
+					addSpan(cover, start, cr.x, w)
+				}
+			}
+		}
+		row := img.Pix[py*img.Stride:]
+		for px := x0; px <= x1; px++ {
+			a := math.Min(cover[px], 1) * float64(c.A) / 255
+			if a <= 0 {
+				continue
+			}
+			p := row[px*4 : px*4+4]
+			p[0] = blend(p[0], c.R, a)
+			p[1] = blend(p[1], c.G, a)
+			p[2] = blend(p[2], c.B, a)
+			p[3] = 0xff
+		}
+	}
+}
+
+func inside(winding int, nonZero bool) bool {
+	if nonZero {
+		return winding != 0
+	}
+	return winding%2 == 1
+}
+
+// addSpan добавляет в строку покрытия отрезок [xa, xb) одной строки выборки.
+func addSpan(cover []float64, xa, xb float64, w int) {
+	xa, xb = math.Max(xa, 0), math.Min(xb, float64(w))
+	if xa >= xb {
+		return
+	}
+	const weight = 1.0 / subScanlines
+	ia, ib := int(xa), int(xb)
+	if ia == ib {
+		cover[ia] += (xb - xa) * weight
+		return
+	}
+	cover[ia] += (float64(ia+1) - xa) * weight
+	for i := ia + 1; i < ib; i++ {
+		cover[i] += weight
+	}
+	cover[ib] += (xb - float64(ib)) * weight
+}
+
+func blend(dst, src uint8, a float64) uint8 {
+	return uint8(math.Round(float64(dst)*(1-a) + float64(src)*a))
+}
@@ -0,0 +1,75 @@
+// Package cp1251 преобразует текст в кодировке Windows-1251 в UTF-8 и обратно.
+//
+// Кодировка по-прежнему встречается в файлах, выгруженных из российских ГИС
+// и табличных редакторов: атрибутах DBF, CSV-ведомостях координат, DXF.
+package cp1251
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// high содержит символы Unicode для байтов 0x80–0xBF. Байты 0xC0–0xFF
+// соответствуют буквам А–я (U+0410–U+044F).
+var high = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', utf8.RuneError, '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	'\u00a0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', '\u00ad', '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+// Decode переводит байты Windows-1251 в строку UTF-8.
+func Decode(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xC0:
+			b.WriteRune(high[c-0x80])
+		default:
+			b.WriteRune(rune(c-0xC0) + 0x0410)
+		}
+	}
+	return b.String()
+}
+
+// Encode переводит строку UTF-8 в Windows-1251. Символы, отсутствующие в
+// кодировке, заменяются знаком вопроса.
+func Encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case r >= 0x0410 && r <= 0x044F:
+			out = append(out, byte(r-0x0410)+0xC0)
+		default:
+			out = append(out, encodeHigh(r))
+		}
+	}
+	return out
+}
+
+func encodeHigh(r rune) byte {
+	for i, h := range high {
+		if h == r && h != utf8.RuneError {
+			return byte(0x80 + i)
+		}
+	}
+	return '?'
+}
+
+// DecodeAuto возвращает текст как есть, если он является корректным UTF-8
+// (метка порядка байтов отбрасывается), и декодирует его из Windows-1251 в
+// противном случае.
+func DecodeAuto(data []byte) string {
+	if len(data) >= 3 && data[0] == 0xEF && data[1] == 0xBB && data[2] == 0xBF {
+		return string(data[3:])
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return Decode(data)
+}
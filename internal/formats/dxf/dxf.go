@@ -0,0 +1,437 @@
+// Package dxf читает границы участков из чертежей AutoCAD в текстовом
+// формате DXF, которые присылают кадастровые инженеры.
+//
+// Из раздела ENTITIES извлекаются полилинии LWPOLYLINE и POLYLINE вместе с
+// их слоями и дескрипторами (handle). Дуговые сегменты (bulge) заменяются
+// ломаной с шагом не более 5°, координата Z отбрасывается. Система координат
+// в DXF не хранится, а единицы чертежа задаются переменной $INSUNITS
+// заголовка; пересчёт в метры и в WGS 84 выполняет вызывающий код.
+package dxf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/formats/cp1251"
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для некорректного или неподдерживаемого чертежа.
+var ErrInvalid = errors.New("некорректный DXF")
+
+// Unit — единицы чертежа по коду переменной $INSUNITS.
+type Unit int
+
+const (
+	// UnitNone — единицы не заданы.
+	UnitNone       Unit = 0
+	UnitInch       Unit = 1
+	UnitFoot       Unit = 2
+	UnitMillimetre Unit = 4
+	UnitCentimetre Unit = 5
+	UnitMetre      Unit = 6
+	UnitKilometre  Unit = 7
+)
+
+// unitScales — длина единицы чертежа в метрах.
+var unitScales = map[Unit]float64{
+	UnitInch:       0.0254,
+	UnitFoot:       0.3048,
+	UnitMillimetre: 0.001,
+	UnitCentimetre: 0.01,
+	UnitMetre:      1,
+	UnitKilometre:  1000,
+}
+
+// unitNames сопоставляет обозначения единиц их кодам.
+var unitNames = map[string]Unit{
+	"in": UnitInch, "ft": UnitFoot,
+	"mm": UnitMillimetre, "мм": UnitMillimetre,
+	"cm": UnitCentimetre, "см": UnitCentimetre,
+	"m": UnitMetre, "м": UnitMetre,
+	"km": UnitKilometre, "км": UnitKilometre,
+}
+
+// ParseUnit разбирает обозначение единиц: mm, cm, m, km, in, ft или их
+// русские варианты мм, см, м, км.
+func ParseUnit(name string) (Unit, error) {
+	u, ok := unitNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return UnitNone, fmt.Errorf("неизвестные единицы чертежа %q, ожидаются mm, cm, m, km, in или ft", name)
+	}
+	return u, nil
+}
+
+// Metres возвращает длину единицы в метрах или 0, если единицы не заданы или
+// не являются линейными мерами, поддерживаемыми пакетом.
+func (u Unit) Metres() float64 { return unitScales[u] }
+
+// Polyline — полилиния чертежа.
+type Polyline struct {
+	// Handle — дескриптор объекта чертежа (код 5), по которому его можно найти в САПР.
+	Handle string
+	Layer  string
+	// Closed сообщает, что полилиния замкнута флагом или совпадением первой и последней вершин.
+	Closed bool
+	// Ring — вершины полилинии без повтора первой точки; дуги заменены ломаной.
+	Ring geo.Ring
+}
+
+// Drawing — полилинии чертежа и его единицы.
+type Drawing struct {
+	Units     Unit
+	Polylines []Polyline
+}
+
+// Layers возвращает имена слоёв, на которых есть полилинии, по алфавиту.
+func (d *Drawing) Layers() []string {
+	seen := make(map[string]struct{})
+	var layers []string
+	for _, p := range d.Polylines {
+		if _, ok := seen[p.Layer]; !ok {
+			seen[p.Layer] = struct{}{}
+			layers = append(layers, p.Layer)
+		}
+	}
+	sort.Strings(layers)
+	return layers
+}
+
+// Contours возвращает замкнутые полилинии не менее чем из трёх вершин на
+// указанных слоях в порядке следования в чертеже. Имена слоёв сравниваются
+// без учёта регистра; без слоёв учитываются все.
+func (d *Drawing) Contours(layers ...string) []Polyline {
+	var out []Polyline
+	for _, p := range d.Polylines {
+		if !p.Closed || len(p.Ring) < 3 {
+			continue
+		}
+		if len(layers) > 0 && !containsFold(layers, p.Layer) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Флаги полилиний (код 70).
+const (
+	flagClosed      = 1
+	flagMesh        = 16
+	flagPolyface    = 64
+	flagSplineFrame = 16 // флаг вершины: управляющая точка сплайна
+	flagFaceRecord  = 128
+)
+
+// pair — пара «код группы — значение».
+type pair struct {
+	code  int
+	value string
+}
+
+// entity — объект раздела ENTITIES с его парами.
+type entity struct {
+	kind  string
+	pairs []pair
+}
+
+// Parse разбирает текстовый DXF любой версии. Двоичный DXF не поддерживается.
+func Parse(data []byte) (*Drawing, error) {
+	if bytes.HasPrefix(data, []byte("AutoCAD Binary DXF")) {
+		return nil, fmt.Errorf("%w: двоичный DXF не поддерживается, сохраните чертёж в текстовом формате", ErrInvalid)
+	}
+	pairs, err := readPairs(data)
+	if err != nil {
+		return nil, err
+	}
+
+	drawing := &Drawing{}
+	var (
+		entities []entity
+		section  string
+		variable string
+		sawEOF   bool
+	)
+	for i := 0; i < len(pairs); i++ {
+		p := pairs[i]
+		if p.code == 0 {
+			switch p.value {
+			case "SECTION":
+				if i+1 < len(pairs) && pairs[i+1].code == 2 {
+					section = pairs[i+1].value
+					i++
+				}
+				continue
+			case "ENDSEC":
+				section = ""
+				continue
+			case "EOF":
+				sawEOF = true
+			}
+			if section == "ENTITIES" {
+				entities = append(entities, entity{kind: p.value})
+			}
+			continue
+		}
+		switch section {
+		case "HEADER":
+			if p.code == 9 {
+				variable = p.value
+			} else if variable == "$INSUNITS" && p.code == 70 {
+				n, err := strconv.Atoi(p.value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: некорректное значение $INSUNITS %q", ErrInvalid, p.value)
+				}
+				drawing.Units = Unit(n)
+			}
+		case "ENTITIES":
+			if len(entities) > 0 {
+				last := &entities[len(entities)-1]
+				last.pairs = append(last.pairs, p)
+			}
+		}
+	}
+	if !sawEOF && len(entities) == 0 {
+		return nil, fmt.Errorf("%w: файл не похож на чертёж DXF", ErrInvalid)
+	}
+
+	for i := 0; i < len(entities); i++ {
+		e := entities[i]
+		switch e.kind {
+		case "LWPOLYLINE":
+			pl, err := lwPolyline(e)
+			if err != nil {
+				return nil, err
+			}
+			drawing.Polylines = append(drawing.Polylines, pl)
+		case "POLYLINE":
+			var vertices []entity
+			for i+1 < len(entities) && entities[i+1].kind == "VERTEX" {
+				vertices = append(vertices, entities[i+1])
+				i++
+			}
+			pl, ok, err := polyline(e, vertices)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				drawing.Polylines = append(drawing.Polylines, pl)
+			}
+		}
+	}
+	if len(drawing.Polylines) == 0 {
+		return nil, fmt.Errorf("%w: чертёж не содержит полилиний", ErrInvalid)
+	}
+	return drawing, nil
+}
+
+// readPairs делит файл на пары строк «код группы — значение».
+func readPairs(data []byte) ([]pair, error) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	var (
+		pairs []pair
+		line  int
+	)
+	for sc.Scan() {
+		line++
+		codeText := strings.TrimSpace(strings.TrimPrefix(sc.Text(), "\ufeff"))
+		if codeText == "" {
+			continue
+		}
+		code, err := strconv.Atoi(codeText)
+		if err != nil {
+			return nil, fmt.Errorf("%w: строка %d: ожидается код группы, получено %q", ErrInvalid, line, codeText)
+		}
+		if !sc.Scan() {
+			return nil, fmt.Errorf("%w: строка %d: нет значения для кода группы %d", ErrInvalid, line, code)
+		}
+		line++
+		value := strings.TrimRight(sc.Text(), "\r")
+		if code != 1 && code != 3 {
+			value = strings.TrimSpace(value)
+		}
+		pairs = append(pairs, pair{code: code, value: value})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return pairs, nil
+}
+
+// vertex — вершина полилинии с выпуклостью следующего сегмента.
+type vertex struct {
+	point geo.Point
+	bulge float64
+}
+
+func lwPolyline(e entity) (Polyline, error) {
+	pl := Polyline{}
+	var (
+		vertices []vertex
+		flags    int
+	)
+	for _, p := range e.pairs {
+		var err error
+		switch p.code {
+		case 5:
+			pl.Handle = p.value
+		case 8:
+			pl.Layer = layerName(p.value)
+		case 70:
+			flags, err = strconv.Atoi(p.value)
+		case 10:
+			var x float64
+			x, err = strconv.ParseFloat(p.value, 64)
+			vertices = append(vertices, vertex{point: geo.Point{X: x}})
+		case 20:
+			if len(vertices) > 0 {
+				vertices[len(vertices)-1].point.Y, err = strconv.ParseFloat(p.value, 64)
+			}
+		case 42:
+			if len(vertices) > 0 {
+				vertices[len(vertices)-1].bulge, err = strconv.ParseFloat(p.value, 64)
+			}
+		}
+		if err != nil {
+			return Polyline{}, fmt.Errorf("%w: полилиния %s: некорректное значение %q кода %d", ErrInvalid, pl.Handle, p.value, p.code)
+		}
+	}
+	pl.Closed, pl.Ring = ring(vertices, flags&flagClosed != 0)
+	return pl, nil
+}
+
+// polyline собирает POLYLINE из последующих вершин VERTEX. Сети и
+// многогранные сетки пропускаются.
+func polyline(e entity, vertexEntities []entity) (Polyline, bool, error) {
+	pl := Polyline{}
+	flags := 0
+	for _, p := range e.pairs {
+		switch p.code {
+		case 5:
+			pl.Handle = p.value
+		case 8:
+			pl.Layer = layerName(p.value)
+		case 70:
+			n, err := strconv.Atoi(p.value)
+			if err != nil {
+				return Polyline{}, false, fmt.Errorf("%w: полилиния %s: некорректные флаги %q", ErrInvalid, pl.Handle, p.value)
+			}
+			flags = n
+		}
+	}
+	if flags&(flagMesh|flagPolyface) != 0 {
+		return Polyline{}, false, nil
+	}
+	vertices := make([]vertex, 0, len(vertexEntities))
+	for _, ve := range vertexEntities {
+		var (
+			v     vertex
+			vflag int
+			err   error
+		)
+		for _, p := range ve.pairs {
+			switch p.code {
+			case 10:
+				v.point.X, err = strconv.ParseFloat(p.value, 64)
+			case 20:
+				v.point.Y, err = strconv.ParseFloat(p.value, 64)
+			case 42:
+				v.bulge, err = strconv.ParseFloat(p.value, 64)
+			case 70:
+				vflag, err = strconv.Atoi(p.value)
+			}
+			if err != nil {
+				return Polyline{}, false, fmt.Errorf("%w: полилиния %s: некорректное значение %q кода %d", ErrInvalid, pl.Handle, p.value, p.code)
+			}
+		}
+		if vflag&(flagSplineFrame|flagFaceRecord) != 0 {
+			continue
+		}
+		vertices = append(vertices, v)
+	}
+	pl.Closed, pl.Ring = ring(vertices, flags&flagClosed != 0)
+	return pl, true, nil
+}
+
+// ring строит кольцо из вершин, заменяя дуговые сегменты ломаной. Полилиния
+// без флага замыкания считается замкнутой, если её первая и последняя
+// вершины совпадают.
+func ring(vertices []vertex, closed bool) (bool, geo.Ring) {
+	n := len(vertices)
+	if n > 1 && !closed && vertices[0].point == vertices[n-1].point {
+		closed = true
+		vertices = vertices[:n-1]
+		n--
+	}
+	var r geo.Ring
+	for i, v := range vertices {
+		r = append(r, v.point)
+		if v.bulge == 0 || (i == n-1 && !closed) {
+			continue
+		}
+		r = append(r, arc(v.point, vertices[(i+1)%n].point, v.bulge)...)
+	}
+	return closed, r.Open()
+}
+
+// maxArcStep — наибольший угол дуги, заменяемый одним отрезком.
+const maxArcStep = math.Pi / 36
+
+// arc возвращает промежуточные точки дуги от a до b с выпуклостью bulge
+// (тангенс четверти центрального угла, положительный — против часовой стрелки).
+func arc(a, b geo.Point, bulge float64) []geo.Point {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	chord := math.Hypot(dx, dy)
+	if chord == 0 {
+		return nil
+	}
+	theta := 4 * math.Atan(bulge)
+	// Центр лежит на серединном перпендикуляре к хорде, слева от направления a→b.
+	offset := chord * (1 - bulge*bulge) / (4 * bulge)
+	cx := (a.X+b.X)/2 - dy/chord*offset
+	cy := (a.Y+b.Y)/2 + dx/chord*offset
+	radius := math.Hypot(a.X-cx, a.Y-cy)
+	start := math.Atan2(a.Y-cy, a.X-cx)
+
+	steps := int(math.Ceil(math.Abs(theta) / maxArcStep))
+	points := make([]geo.Point, 0, steps-1)
+	for i := 1; i < steps; i++ {
+		angle := start + theta*float64(i)/float64(steps)
+		points = append(points, geo.Point{X: cx + radius*math.Cos(angle), Y: cy + radius*math.Sin(angle)})
+	}
+	return points
+}
+
+// unicodeEscape — экранированный символ \U+XXXX в строках DXF.
+var unicodeEscape = regexp.MustCompile(`\\U\+([0-9A-Fa-f]{4})`)
+
+// layerName декодирует имя слоя: в чертежах до AutoCAD 2007 оно записано в
+// кодовой странице чертежа (для русских чертежей — Windows-1251), а символы
+// вне неё экранируются как \U+XXXX.
+func layerName(raw string) string {
+	name := cp1251.DecodeAuto([]byte(raw))
+	return unicodeEscape.ReplaceAllStringFunc(name, func(m string) string {
+		code, err := strconv.ParseUint(m[3:], 16, 32)
+		if err != nil {
+			return m
+		}
+		return string(rune(code))
+	})
+}
@@ -0,0 +1,98 @@
+// Package gpx читает границы участков из файлов GPX 1.0/1.1, записанных
+// GPS-приёмником при обходе участка.
+//
+// Трек (trk) превращается в кольцо из точек всех его сегментов, маршрут (rte)
+// — из точек маршрута, а путевые точки (wpt) файла образуют одно кольцо в
+// порядке записи. Кольцо замыкается: если последняя точка совпадает с первой,
+// она отбрасывается.
+package gpx
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для некорректного файла GPX.
+var ErrInvalid = errors.New("некорректный GPX")
+
+// Kind обозначает элемент GPX, из которого получена граница.
+type Kind string
+
+const (
+	// KindTrack — граница получена из трека.
+	KindTrack Kind = "trk"
+	// KindRoute — граница получена из маршрута.
+	KindRoute Kind = "rte"
+	// KindWaypoints — граница составлена из путевых точек файла.
+	KindWaypoints Kind = "wpt"
+)
+
+// Boundary — граница участка, полученная из трека, маршрута или путевых точек.
+type Boundary struct {
+	Kind Kind
+	// Name — название трека или маршрута; для путевых точек пусто.
+	Name string
+	Ring geo.Ring
+}
+
+type xmlPoint struct {
+	Lat float64 `xml:"lat,attr"`
+	Lon float64 `xml:"lon,attr"`
+}
+
+type xmlFile struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Waypoints []xmlPoint `xml:"wpt"`
+	Routes    []struct {
+		Name   string     `xml:"name"`
+		Points []xmlPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Tracks []struct {
+		Name     string `xml:"name"`
+		Segments []struct {
+			Points []xmlPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// Unmarshal разбирает файл GPX и возвращает границы: сначала треки, затем
+// маршруты, затем кольцо путевых точек. Элементы меньше чем из трёх точек
+// пропускаются.
+func Unmarshal(data []byte) ([]Boundary, error) {
+	var f xmlFile
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	var out []Boundary
+	add := func(kind Kind, name string, points []xmlPoint) {
+		ring := make(geo.Ring, 0, len(points))
+		for _, p := range points {
+			ring = append(ring, geo.Point{X: p.Lon, Y: p.Lat})
+		}
+		ring = ring.Open()
+		if len(ring) >= 3 {
+			out = append(out, Boundary{Kind: kind, Name: strings.TrimSpace(name), Ring: ring})
+		}
+	}
+	for _, trk := range f.Tracks {
+		var points []xmlPoint
+		for _, seg := range trk.Segments {
+			points = append(points, seg.Points...)
+		}
+		add(KindTrack, trk.Name, points)
+	}
+	for _, rte := range f.Routes {
+		add(KindRoute, rte.Name, rte.Points)
+	}
+	add(KindWaypoints, "", f.Waypoints)
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: файл не содержит треков, маршрутов или путевых точек, образующих контур", ErrInvalid)
+	}
+	return out, nil
+}
@@ -0,0 +1,288 @@
+// Package kml читает и записывает полигоны в формате KML 2.2 (Google Earth)
+// и его сжатом варианте KMZ.
+//
+// При чтении из документа извлекаются метки (Placemark) с геометрией
+// Polygon и MultiGeometry на любой глубине вложенности папок; метки с точками
+// и линиями пропускаются. Координаты KML всегда задаются в WGS 84 как
+// «долгота,широта[,высота]», высота отбрасывается.
+package kml
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для некорректного документа KML или архива KMZ.
+var ErrInvalid = errors.New("некорректный KML")
+
+// Data — именованное значение из ExtendedData метки.
+type Data struct {
+	Name  string
+	Value string
+}
+
+// Placemark — метка документа с полигональной геометрией.
+type Placemark struct {
+	Name        string
+	Description string
+	Data        []Data
+	Shape       geo.MultiPolygon
+}
+
+// Document — документ KML для выгрузки.
+type Document struct {
+	Name       string
+	Placemarks []Placemark
+}
+
+// maxKMLSize ограничивает распакованный размер документа из архива KMZ.
+const maxKMLSize = 64 << 20
+
+// Read разбирает документ KML или архив KMZ (определяется по сигнатуре zip)
+// и возвращает метки с полигонами в порядке следования в документе.
+func Read(data []byte) ([]Placemark, error) {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		doc, err := kmzDocument(data)
+		if err != nil {
+			return nil, err
+		}
+		data = doc
+	}
+	return Unmarshal(data)
+}
+
+// kmzDocument извлекает основной документ из архива KMZ: doc.kml, а при его
+// отсутствии — первый файл .kml в корне архива.
+func kmzDocument(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: архив KMZ не прочитан: %v", ErrInvalid, err)
+	}
+	var doc *zip.File
+	for _, f := range zr.File {
+		if !strings.EqualFold(path.Ext(f.Name), ".kml") || strings.Contains(f.Name, "/") {
+			continue
+		}
+		if strings.EqualFold(f.Name, "doc.kml") {
+			doc = f
+			break
+		}
+		if doc == nil {
+			doc = f
+		}
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("%w: в архиве KMZ нет документа .kml", ErrInvalid)
+	}
+	rc, err := doc.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, doc.Name, err)
+	}
+	defer rc.Close()
+	out, err := io.ReadAll(io.LimitReader(rc, maxKMLSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, doc.Name, err)
+	}
+	if len(out) > maxKMLSize {
+		return nil, fmt.Errorf("%w: документ %s слишком велик", ErrInvalid, doc.Name)
+	}
+	return out, nil
+}
+
+type xmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+type xmlMultiGeometry struct {
+	Polygons []xmlPolygon       `xml:"Polygon"`
+	Multi    []xmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+type xmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Data        []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"ExtendedData>Data"`
+	SimpleData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"ExtendedData>SchemaData>SimpleData"`
+	xmlMultiGeometry
+}
+
+// Unmarshal разбирает документ KML. Ошибка возвращается, если документ не
+// является корректным XML или не содержит ни одного полигона.
+func Unmarshal(data []byte) ([]Placemark, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var placemarks []Placemark
+	seen := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+		seen++
+		var raw xmlPlacemark
+		if err := dec.DecodeElement(&raw, &start); err != nil {
+			return nil, fmt.Errorf("%w: метка %d: %v", ErrInvalid, seen, err)
+		}
+		shape, err := raw.shape()
+		if err != nil {
+			return nil, fmt.Errorf("метка %d: %w", seen, err)
+		}
+		if len(shape) == 0 {
+			continue
+		}
+		pm := Placemark{
+			Name:        strings.TrimSpace(raw.Name),
+			Description: strings.TrimSpace(raw.Description),
+			Shape:       shape,
+		}
+		for _, d := range raw.Data {
+			pm.Data = append(pm.Data, Data{Name: d.Name, Value: strings.TrimSpace(d.Value)})
+		}
+		for _, d := range raw.SimpleData {
+			pm.Data = append(pm.Data, Data{Name: d.Name, Value: strings.TrimSpace(d.Value)})
+		}
+		placemarks = append(placemarks, pm)
+	}
+	if len(placemarks) == 0 {
+		return nil, fmt.Errorf("%w: документ не содержит полигонов", ErrInvalid)
+	}
+	return placemarks, nil
+}
+
+func (g xmlMultiGeometry) shape() (geo.MultiPolygon, error) {
+	var m geo.MultiPolygon
+	for _, p := range g.Polygons {
+		outer, err := parseCoordinates(p.Outer)
+		if err != nil {
+			return nil, err
+		}
+		poly := geo.Polygon{outer}
+		for _, inner := range p.Inner {
+			hole, err := parseCoordinates(inner)
+			if err != nil {
+				return nil, err
+			}
+			poly = append(poly, hole)
+		}
+		m = append(m, poly)
+	}
+	for _, sub := range g.Multi {
+		parts, err := sub.shape()
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, parts...)
+	}
+	return m, nil
+}
+
+// parseCoordinates разбирает содержимое элемента coordinates: кортежи
+// «долгота,широта[,высота]», разделённые пробельными символами.
+func parseCoordinates(text string) (geo.Ring, error) {
+	var ring geo.Ring
+	for _, tuple := range strings.Fields(text) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("%w: некорректный кортеж координат %q", ErrInvalid, tuple)
+		}
+		lon, err1 := strconv.ParseFloat(parts[0], 64)
+		lat, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%w: некорректный кортеж координат %q", ErrInvalid, tuple)
+		}
+		ring = append(ring, geo.Point{X: lon, Y: lat})
+	}
+	return ring.Open(), nil
+}
+
+// Marshal записывает документ KML 2.2. Метка с одной частью записывается
+// как Polygon, многоконтурная — как MultiGeometry; кольца замыкаются.
+func Marshal(doc Document) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<kml xmlns="http://www.opengis.net/kml/2.2">` + "\n<Document>\n")
+	if doc.Name != "" {
+		writeElement(&b, "name", doc.Name)
+	}
+	for _, pm := range doc.Placemarks {
+		b.WriteString("<Placemark>\n")
+		if pm.Name != "" {
+			writeElement(&b, "name", pm.Name)
+		}
+		if pm.Description != "" {
+			writeElement(&b, "description", pm.Description)
+		}
+		if len(pm.Data) > 0 {
+			b.WriteString("<ExtendedData>\n")
+			for _, d := range pm.Data {
+				b.WriteString(`<Data name="`)
+				_ = xml.EscapeText(&b, []byte(d.Name))
+				b.WriteString(`">`)
+				writeElement(&b, "value", d.Value)
+				b.WriteString("</Data>\n")
+			}
+			b.WriteString("</ExtendedData>\n")
+		}
+		if len(pm.Shape) > 1 {
+			b.WriteString("<MultiGeometry>\n")
+		}
+		for _, poly := range pm.Shape {
+			writePolygon(&b, poly)
+		}
+		if len(pm.Shape) > 1 {
+			b.WriteString("</MultiGeometry>\n")
+		}
+		b.WriteString("</Placemark>\n")
+	}
+	b.WriteString("</Document>\n</kml>\n")
+	return b.Bytes()
+}
+
+func writeElement(b *bytes.Buffer, name, text string) {
+	b.WriteString("<" + name + ">")
+	_ = xml.EscapeText(b, []byte(text))
+	b.WriteString("</" + name + ">\n")
+}
+
+func writePolygon(b *bytes.Buffer, poly geo.Polygon) {
+	b.WriteString("<Polygon>\n")
+	for i, ring := range poly {
+		boundary := "innerBoundaryIs"
+		if i == 0 {
+			boundary = "outerBoundaryIs"
+		}
+		b.WriteString("<" + boundary + "><LinearRing><coordinates>")
+		for j, p := range ring.Closed() {
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strconv.FormatFloat(p.X, 'f', -1, 64))
+			b.WriteByte(',')
+			b.WriteString(strconv.FormatFloat(p.Y, 'f', -1, 64))
+		}
+		b.WriteString("</coordinates></LinearRing></" + boundary + ">\n")
+	}
+	b.WriteString("</Polygon>\n")
+}
@@ -0,0 +1,286 @@
+// Package rosreestr читает сведения о земельных участках из XML-документов
+// Росреестра действующих схем: кадастрового плана территории
+// (extract_cadastral_plan_territory) и выписки ЕГРН об объекте
+// недвижимости — земельном участке (extract_about_property_land).
+//
+// Из документа извлекаются кадастровый номер, категория земель, вид
+// разрешённого использования, площадь, адрес, ограничения и обременения, а
+// также контуры границ. Координаты в документах Росреестра записываются в
+// геодезической традиции (x — север, y — восток); пакет возвращает их как
+// geo.Point с X — восточной и Y — северной координатой.
+//
+// Пакет также формирует XML-документ схемы расположения земельного участка
+// на кадастровом плане территории (Scheme) со сведениями о заявителе, об
+// образуемом участке и описанием его границ и проверяет его по правилам
+// XML-схемы (ValidateScheme). Элементы документа именуются так же, как в
+// выписках, которые читает Parse.
+package rosreestr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"zemlya-prosto/internal/formats/cp1251"
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для некорректного или неподдерживаемого документа.
+var ErrInvalid = errors.New("некорректный XML-документ Росреестра")
+
+// Kind обозначает вид документа Росреестра.
+type Kind string
+
+const (
+	// KindKPT — кадастровый план территории.
+	KindKPT Kind = "КПТ"
+	// KindEGRN — выписка ЕГРН об объекте недвижимости.
+	KindEGRN Kind = "Выписка ЕГРН"
+)
+
+// Document — сведения, извлечённые из документа Росреестра.
+type Document struct {
+	Kind Kind
+	// Date — дата формирования документа в виде ГГГГ-ММ-ДД.
+	Date    string
+	Parcels []Parcel
+}
+
+// Parcel — земельный участок из документа.
+type Parcel struct {
+	CadastralNumber string
+	// CadastralBlock — номер кадастрового квартала.
+	CadastralBlock string
+	CategoryCode   string
+	Category       string
+	// PermittedUse — вид разрешённого использования по документу,
+	// PermittedUseCode — код по классификатору видов разрешённого использования.
+	PermittedUse     string
+	PermittedUseCode string
+	// Area и AreaInaccuracy — площадь по сведениям ЕГРН и её погрешность, м².
+	Area           float64
+	AreaInaccuracy float64
+	Address        string
+	Encumbrances   []Encumbrance
+	// CoordinateSystem — система координат контуров (sk_id), например «МСК-50, зона 2».
+	CoordinateSystem string
+	// Shape — контуры участка: каждый контур — часть, первое кольцо — внешняя граница.
+	Shape geo.MultiPolygon
+}
+
+// Encumbrance — ограничение прав или обременение участка.
+type Encumbrance struct {
+	Number   string
+	TypeCode string
+	Type     string
+	// RegistrationDate — дата государственной регистрации в виде ГГГГ-ММ-ДД.
+	RegistrationDate string
+}
+
+// Корневые элементы поддерживаемых схем.
+const (
+	rootKPT  = "extract_cadastral_plan_territory"
+	rootEGRN = "extract_about_property_land"
+)
+
+// Parse разбирает кадастровый план территории или выписку ЕГРН о земельном
+// участке. Поддерживаются кодировки UTF-8 и windows-1251.
+func Parse(data []byte) (*Document, error) {
+	dec := newDecoder(data)
+	root, err := rootElement(dec)
+	if err != nil {
+		return nil, err
+	}
+	switch root.Name.Local {
+	case rootKPT:
+		var raw xmlKPT
+		if err := dec.DecodeElement(&raw, &root); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		doc := &Document{Kind: KindKPT, Date: raw.Date}
+		for _, block := range raw.Blocks {
+			for _, rec := range block.LandRecords {
+				parcel, err := rec.parcel()
+				if err != nil {
+					return nil, err
+				}
+				parcel.CadastralBlock = block.CadastralNumber
+				doc.Parcels = append(doc.Parcels, parcel)
+			}
+		}
+		return doc, nil
+	case rootEGRN:
+		var raw xmlEGRN
+		if err := dec.DecodeElement(&raw, &root); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		parcel, err := raw.LandRecord.parcel()
+		if err != nil {
+			return nil, err
+		}
+		parcel.CadastralBlock = blockOf(parcel.CadastralNumber)
+		for _, r := range raw.RestrictRecords {
+			parcel.Encumbrances = append(parcel.Encumbrances, r.encumbrance())
+		}
+		return &Document{Kind: KindEGRN, Date: raw.Date, Parcels: []Parcel{parcel}}, nil
+	default:
+		return nil, fmt.Errorf("%w: схема %s не поддерживается, ожидается КПТ (%s) или выписка ЕГРН о земельном участке (%s)",
+			ErrInvalid, root.Name.Local, rootKPT, rootEGRN)
+	}
+}
+
+func newDecoder(data []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		switch strings.ToLower(charset) {
+		case "windows-1251", "cp1251":
+			raw, err := io.ReadAll(input)
+			if err != nil {
+				return nil, err
+			}
+			return strings.NewReader(cp1251.Decode(raw)), nil
+		}
+		return nil, fmt.Errorf("кодировка %s не поддерживается", charset)
+	}
+	return dec
+}
+
+func rootElement(dec *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return xml.StartElement{}, fmt.Errorf("%w: документ пуст", ErrInvalid)
+		}
+		if err != nil {
+			return xml.StartElement{}, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// blockOf возвращает номер кадастрового квартала из кадастрового номера участка.
+func blockOf(cadNumber string) string {
+	if i := strings.LastIndexByte(cadNumber, ':'); i > 0 {
+		return cadNumber[:i]
+	}
+	return ""
+}
+
+type xmlKPT struct {
+	Date   string `xml:"details_statement>group_top_requisites>date_formation"`
+	Blocks []struct {
+		CadastralNumber string          `xml:"cadastral_number"`
+		LandRecords     []xmlLandRecord `xml:"record_data>base_data>land_records>land_record"`
+	} `xml:"cadastral_blocks>cadastral_block"`
+}
+
+type xmlEGRN struct {
+	Date            string              `xml:"details_statement>group_top_requisites>date_formation"`
+	LandRecord      xmlLandRecord       `xml:"land_record"`
+	RestrictRecords []xmlRestrictRecord `xml:"restrict_records>restrict_record"`
+}
+
+// xmlDict — значение справочника Росреестра: код и расшифровка.
+type xmlDict struct {
+	Code  string `xml:"code"`
+	Value string `xml:"value"`
+}
+
+type xmlLandRecord struct {
+	CadNumber string `xml:"object>common_data>cad_number"`
+	Params    struct {
+		Category     xmlDict `xml:"category>type"`
+		PermittedUse struct {
+			ByDocument string  `xml:"by_document"`
+			LandUse    xmlDict `xml:"land_use"`
+		} `xml:"permitted_use>permitted_use_established"`
+		Area struct {
+			Value      float64 `xml:"value"`
+			Inaccuracy float64 `xml:"inaccuracy"`
+		} `xml:"area"`
+	} `xml:"params"`
+	Address  string       `xml:"address_location>address>readable_address"`
+	Contours []xmlContour `xml:"contours_location>contours>contour"`
+}
+
+type xmlContour struct {
+	EntitySpatial struct {
+		SkID     string `xml:"sk_id"`
+		Elements []struct {
+			Ordinates []struct {
+				X float64 `xml:"x"`
+				Y float64 `xml:"y"`
+			} `xml:"ordinates>ordinate"`
+		} `xml:"spatials_elements>spatial_element"`
+	} `xml:"entity_spatial"`
+}
+
+type xmlRestrictRecord struct {
+	RegistrationDate string `xml:"record_info>registration_date"`
+	Data             struct {
+		Number string  `xml:"restriction_encumbrance_number"`
+		Type   xmlDict `xml:"restriction_encumbrance_type"`
+	} `xml:"restrictions_encumbrances_data"`
+}
+
+func (r xmlLandRecord) parcel() (Parcel, error) {
+	p := Parcel{
+		CadastralNumber:  strings.TrimSpace(r.CadNumber),
+		CategoryCode:     strings.TrimSpace(r.Params.Category.Code),
+		Category:         strings.TrimSpace(r.Params.Category.Value),
+		PermittedUse:     strings.TrimSpace(r.Params.PermittedUse.ByDocument),
+		PermittedUseCode: strings.TrimSpace(r.Params.PermittedUse.LandUse.Code),
+		Area:             r.Params.Area.Value,
+		AreaInaccuracy:   r.Params.Area.Inaccuracy,
+		Address:          strings.TrimSpace(r.Address),
+	}
+	if p.CadastralNumber == "" {
+		return Parcel{}, fmt.Errorf("%w: у земельного участка не указан кадастровый номер", ErrInvalid)
+	}
+	if p.PermittedUse == "" {
+		p.PermittedUse = strings.TrimSpace(r.Params.PermittedUse.LandUse.Value)
+	}
+
+	for i, c := range r.Contours {
+		skID := strings.TrimSpace(c.EntitySpatial.SkID)
+		if p.CoordinateSystem == "" {
+			p.CoordinateSystem = skID
+		} else if skID != "" && skID != p.CoordinateSystem {
+			return Parcel{}, fmt.Errorf("%w: %s: контуры заданы в разных системах координат: %s и %s",
+				ErrInvalid, p.CadastralNumber, p.CoordinateSystem, skID)
+		}
+		var poly geo.Polygon
+		for _, el := range c.EntitySpatial.Elements {
+			ring := make(geo.Ring, 0, len(el.Ordinates))
+			for _, o := range el.Ordinates {
+				ring = append(ring, geo.Point{X: o.Y, Y: o.X})
+			}
+			if ring = ring.Open(); len(ring) > 0 {
+				poly = append(poly, ring)
+			}
+		}
+		if len(poly) == 0 {
+			return Parcel{}, fmt.Errorf("%w: %s: контур %d не содержит координат", ErrInvalid, p.CadastralNumber, i+1)
+		}
+		p.Shape = append(p.Shape, poly)
+	}
+	return p, nil
+}
+
+func (r xmlRestrictRecord) encumbrance() Encumbrance {
+	date := strings.TrimSpace(r.RegistrationDate)
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	return Encumbrance{
+		Number:           strings.TrimSpace(r.Data.Number),
+		TypeCode:         strings.TrimSpace(r.Data.Type.Code),
+		Type:             strings.TrimSpace(r.Data.Type.Value),
+		RegistrationDate: date,
+	}
+}
@@ -0,0 +1,299 @@
+package rosreestr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Violation — нарушение правил XML-схемы: путь к элементу и описание.
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string { return v.Path + ": " + v.Message }
+
+// SchemaError возвращается, если документ не соответствует XML-схеме.
+type SchemaError struct {
+	Violations []Violation
+}
+
+func (e *SchemaError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return "документ не соответствует XML-схеме: " + strings.Join(parts, "; ")
+}
+
+// simpleType — ограничения значения простого элемента или атрибута:
+// перечисление допустимых значений или шаблон.
+type simpleType struct {
+	enum    []string
+	pattern *regexp.Regexp
+	// describe поясняет шаблон в сообщении об ошибке.
+	describe string
+}
+
+// element — объявление элемента: число вхождений, тип значения для простых
+// элементов и последовательность дочерних элементов для составных.
+type element struct {
+	name     string
+	min, max int // max 0 — без ограничения
+	value    *simpleType
+	attrs    []attribute
+	children []element
+}
+
+type attribute struct {
+	name     string
+	required bool
+	value    *simpleType
+}
+
+func required(name string, value *simpleType, children ...element) element {
+	return element{name: name, min: 1, max: 1, value: value, children: children}
+}
+
+func optional(name string, value *simpleType, children ...element) element {
+	return element{name: name, min: 0, max: 1, value: value, children: children}
+}
+
+func repeated(name string, min int, children ...element) element {
+	return element{name: name, min: min, children: children}
+}
+
+// Простые типы XML-схемы.
+var (
+	typeString = &simpleType{pattern: regexp.MustCompile(`\S`), describe: "непустая строка"}
+	typeDate   = &simpleType{pattern: regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`), describe: "дата ГГГГ-ММ-ДД"}
+	typeGUID   = &simpleType{
+		pattern:  regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+		describe: "GUID",
+	}
+	typeInteger = &simpleType{pattern: regexp.MustCompile(`^[1-9]\d{0,9}$`), describe: "целое положительное число"}
+	// typeCoordinate — координата: не более 10 цифр, из них 2 после точки.
+	typeCoordinate = &simpleType{
+		pattern:  regexp.MustCompile(`^-?\d{1,8}\.\d{2}$`),
+		describe: "число с двумя знаками после точки, не более 10 цифр",
+	}
+	typeDecimal2 = &simpleType{
+		pattern:  regexp.MustCompile(`^\d{1,8}\.\d{2}$`),
+		describe: "неотрицательное число с двумя знаками после точки",
+	}
+	typeSNILS          = &simpleType{pattern: regexp.MustCompile(`^\d{3}-\d{3}-\d{3} \d{2}$`), describe: "СНИЛС вида 000-000-000 00"}
+	typeCadastralBlock = &simpleType{pattern: regexp.MustCompile(`^\d{2}:\d{2}:\d{6,7}$`), describe: "номер кадастрового квартала"}
+	typeDefinition     = &simpleType{pattern: regexp.MustCompile(`^:ЗУ\d+$`), describe: "обозначение вида :ЗУ1"}
+	typeAngle          = &simpleType{pattern: regexp.MustCompile(`^\d{1,3}°[0-5]\d'[0-5]\d"$`), describe: "дирекционный угол вида 123°45'06\""}
+	// typeCategory — справочник категорий земель dCategories.
+	typeCategory = &simpleType{enum: []string{
+		"003001000000", "003002000000", "003003000000", "003004000000",
+		"003005000000", "003006000000", "003007000000", "003008000000",
+	}}
+	// typeGeopointOpred — справочник методов определения координат dGeopointOpred.
+	typeGeopointOpred = &simpleType{enum: []string{
+		"692001000000", "692002000000", "692003000000", "692004000000", "692005000000",
+	}}
+	typeUnit    = &simpleType{enum: []string{UnitSquareMetre}}
+	typeVersion = &simpleType{enum: []string{SchemeVersion}}
+)
+
+// schemeSchema — правила XML-схемы документа схемы расположения.
+var schemeSchema = element{
+	name: "scheme_parcels", min: 1, max: 1,
+	attrs: []attribute{
+		{name: "guid", required: true, value: typeGUID},
+		{name: "version", required: true, value: typeVersion},
+	},
+	children: []element{
+		required("details_statement", nil,
+			required("group_top_requisites", nil,
+				required("date_formation", typeDate))),
+		required("applicant", nil,
+			required("person", nil,
+				required("surname", typeString),
+				required("name", typeString),
+				optional("patronymic", typeString),
+				optional("snils", typeSNILS),
+				optional("identity_document", typeString),
+				optional("address", typeString),
+				optional("contacts", nil,
+					optional("phone", typeString),
+					optional("email", typeString)))),
+		required("land_plot", nil,
+			required("definition", typeDefinition),
+			required("cadastral_block", typeCadastralBlock),
+			optional("category", nil, required("code", typeCategory)),
+			optional("permitted_use", nil, required("by_document", typeString)),
+			optional("address", typeString),
+			required("area", nil,
+				required("value", typeInteger),
+				required("inaccuracy", typeDecimal2),
+				required("unit", typeUnit)),
+			required("contours_location", nil,
+				required("contours", nil,
+					repeated("contour", 1,
+						required("number_pp", typeInteger),
+						required("entity_spatial", nil,
+							required("sk_id", typeString),
+							required("spatials_elements", nil,
+								repeated("spatial_element", 1,
+									required("ordinates", nil,
+										repeated("ordinate", 4,
+											required("ord_nmb", typeInteger),
+											required("num_geopoint", typeString),
+											required("x", typeCoordinate),
+											required("y", typeCoordinate),
+											required("delta_geopoint", typeDecimal2),
+											required("geopoint_opred", nil, required("code", typeGeopointOpred))))))),
+						optional("borders", nil,
+							repeated("border", 3,
+								required("spatial", typeInteger),
+								required("point1", typeInteger),
+								required("point2", typeInteger),
+								required("edge", nil,
+									required("length", typeDecimal2),
+									required("direction_angle", typeAngle)))))))),
+	},
+}
+
+// ValidateScheme проверяет XML-документ схемы расположения по правилам
+// XML-схемы: наличие и порядок обязательных элементов, число их вхождений,
+// значения из справочников и точность координат, длин и погрешностей.
+func ValidateScheme(data []byte) []Violation {
+	root, err := readTree(data)
+	if err != nil {
+		return []Violation{{Path: "/", Message: err.Error()}}
+	}
+	if root.name != schemeSchema.name {
+		return []Violation{{Path: "/" + root.name, Message: "ожидается корневой элемент " + schemeSchema.name}}
+	}
+	var out []Violation
+	validate(root, schemeSchema, "/"+root.name, &out)
+	return out
+}
+
+// node — элемент XML-документа.
+type node struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*node
+}
+
+func readTree(data []byte) (*node, error) {
+	dec := newDecoder(data)
+	var stack []*node
+	var root *node
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, a := range t.Attr {
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(bytes.TrimSpace(t))
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("документ пуст")
+	}
+	return root, nil
+}
+
+func validate(n *node, decl element, path string, out *[]Violation) {
+	for _, a := range decl.attrs {
+		v, ok := n.attrs[a.name]
+		if !ok {
+			if a.required {
+				*out = append(*out, Violation{Path: path + "/@" + a.name, Message: "отсутствует обязательный атрибут"})
+			}
+			continue
+		}
+		if msg := a.value.check(v); msg != "" {
+			*out = append(*out, Violation{Path: path + "/@" + a.name, Message: msg})
+		}
+	}
+	if decl.value != nil {
+		if len(n.children) > 0 {
+			*out = append(*out, Violation{Path: path, Message: "простой элемент не может содержать вложенные элементы"})
+			return
+		}
+		if msg := decl.value.check(n.text); msg != "" {
+			*out = append(*out, Violation{Path: path, Message: msg})
+		}
+		return
+	}
+
+	// Дочерние элементы проверяются как последовательность xs:sequence.
+	i := 0
+	for _, child := range decl.children {
+		count := 0
+		for i < len(n.children) && n.children[i].name == child.name {
+			count++
+			childPath := path + "/" + child.name
+			if child.max != 1 {
+				childPath += fmt.Sprintf("[%d]", count)
+			}
+			if child.max == 0 || count <= child.max {
+				validate(n.children[i], child, childPath, out)
+			}
+			i++
+		}
+		switch {
+		case count < child.min && count == 0:
+			*out = append(*out, Violation{Path: path + "/" + child.name, Message: "отсутствует обязательный элемент"})
+		case count < child.min:
+			*out = append(*out, Violation{Path: path + "/" + child.name,
+				Message: fmt.Sprintf("элемент должен встречаться не менее %d раз, найдено %d", child.min, count)})
+		case child.max > 0 && count > child.max:
+			*out = append(*out, Violation{Path: path + "/" + child.name,
+				Message: fmt.Sprintf("элемент может встречаться не более %d раз, найдено %d", child.max, count)})
+		}
+	}
+	for ; i < len(n.children); i++ {
+		*out = append(*out, Violation{Path: path + "/" + n.children[i].name,
+			Message: "элемент не предусмотрен схемой в этом месте"})
+	}
+}
+
+// check возвращает описание ошибки или пустую строку, если значение допустимо.
+func (t *simpleType) check(v string) string {
+	if t == nil {
+		return ""
+	}
+	if len(t.enum) > 0 && !slices.Contains(t.enum, v) {
+		return fmt.Sprintf("значение %q не входит в перечисление (%s)", v, strings.Join(t.enum, ", "))
+	}
+	if t.pattern != nil && !t.pattern.MatchString(v) {
+		if v == "" {
+			return "пустое значение, ожидается " + t.describe
+		}
+		return fmt.Sprintf("значение %q не соответствует типу: ожидается %s", v, t.describe)
+	}
+	return ""
+}
@@ -0,0 +1,256 @@
+package rosreestr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+)
+
+// Коды справочников Росреестра, используемые в схеме расположения.
+const (
+	// UnitSquareMetre — код квадратного метра по ОКЕИ.
+	UnitSquareMetre = "055"
+	// MethodCartometric — картометрический метод определения координат
+	// характерных точек (справочник dGeopointOpred).
+	MethodCartometric = "692004000000"
+)
+
+// SchemeVersion — версия формата XML-документа схемы расположения.
+const SchemeVersion = "01"
+
+// Scheme — сведения для XML-документа схемы расположения земельного участка
+// на кадастровом плане территории.
+type Scheme struct {
+	// GUID — уникальный идентификатор документа.
+	GUID string
+	// Date — дата подготовки схемы в виде ГГГГ-ММ-ДД.
+	Date      string
+	Applicant Person
+	Parcel    SchemeParcel
+}
+
+// Person — заявитель — физическое лицо.
+type Person struct {
+	Surname    string
+	Name       string
+	Patronymic string
+	SNILS      string
+	// IdentityDocument — реквизиты документа, удостоверяющего личность.
+	IdentityDocument string
+	Address          string
+	Phone            string
+	Email            string
+}
+
+// SchemeParcel — образуемый земельный участок.
+type SchemeParcel struct {
+	// Definition — обозначение участка, например «:ЗУ1».
+	Definition     string
+	CadastralBlock string
+	// CategoryCode — код категории земель (справочник dCategories).
+	CategoryCode string
+	PermittedUse string
+	Address      string
+	// Area и AreaInaccuracy — площадь участка и её погрешность, м².
+	Area           float64
+	AreaInaccuracy float64
+	// CoordinateSystem — система координат контуров (sk_id), например «МСК-50, зона 2».
+	CoordinateSystem string
+	Contours         []SchemeContour
+}
+
+// SchemeContour — контур участка: границы, каждая из которых начинается и
+// заканчивается одной и той же характерной точкой, первая — внешняя.
+type SchemeContour struct {
+	Borders [][]SchemePoint
+}
+
+// SchemePoint — характерная точка границы и часть границы до следующей точки.
+// Координаты записываются в геодезической традиции: X — север, Y — восток.
+type SchemePoint struct {
+	Number string
+	X      float64
+	Y      float64
+	// MeanSquareError — средняя квадратическая погрешность положения точки, м.
+	MeanSquareError float64
+	// Method — код метода определения координат; по умолчанию MethodCartometric.
+	Method string
+	// Length и DirectionalAngle — длина части границы до следующей точки, м,
+	// и её дирекционный угол в виде «ГГГ°ММ'СС"»; у замыкающей точки не
+	// заполняются.
+	Length           float64
+	DirectionalAngle string
+}
+
+// XML формирует XML-документ схемы расположения и проверяет его по правилам
+// XML-схемы (см. ValidateScheme). Если документ не соответствует схеме,
+// возвращается *SchemaError.
+func (s Scheme) XML() ([]byte, error) {
+	doc := xmlScheme{
+		GUID:    s.GUID,
+		Version: SchemeVersion,
+		Date:    s.Date,
+		Applicant: xmlPerson{
+			Surname:          s.Applicant.Surname,
+			Name:             s.Applicant.Name,
+			Patronymic:       s.Applicant.Patronymic,
+			SNILS:            s.Applicant.SNILS,
+			IdentityDocument: s.Applicant.IdentityDocument,
+			Address:          s.Applicant.Address,
+			Phone:            s.Applicant.Phone,
+			Email:            s.Applicant.Email,
+		},
+	}
+	p := s.Parcel
+	doc.LandPlot.Definition = p.Definition
+	doc.LandPlot.CadastralBlock = p.CadastralBlock
+	if p.CategoryCode != "" {
+		doc.LandPlot.Category = &xmlCode{Code: p.CategoryCode}
+	}
+	if p.PermittedUse != "" {
+		doc.LandPlot.PermittedUse = &xmlPermittedUse{ByDocument: p.PermittedUse}
+	}
+	doc.LandPlot.Address = p.Address
+	doc.LandPlot.Area = xmlArea{
+		Value:      strconv.FormatFloat(p.Area, 'f', 0, 64),
+		Inaccuracy: decimal(p.AreaInaccuracy),
+		Unit:       UnitSquareMetre,
+	}
+
+	number := 0
+	for i, c := range p.Contours {
+		contour := xmlSchemeContour{NumberPP: i + 1}
+		contour.EntitySpatial.SkID = p.CoordinateSystem
+		for k, border := range c.Borders {
+			var el xmlSpatialElement
+			first := number + 1
+			for j, pt := range border {
+				method := pt.Method
+				if method == "" {
+					method = MethodCartometric
+				}
+				// Замыкающая точка повторяет номер первой.
+				ord := first + j
+				if j == len(border)-1 && len(border) > 1 {
+					ord = first
+				} else {
+					number = ord
+				}
+				el.Ordinates = append(el.Ordinates, xmlOrdinate{
+					OrdNmb:      ord,
+					NumGeopoint: pt.Number,
+					X:           decimal(pt.X),
+					Y:           decimal(pt.Y),
+					Delta:       decimal(pt.MeanSquareError),
+					Method:      xmlCode{Code: method},
+				})
+				if pt.DirectionalAngle != "" && j < len(border)-1 {
+					next := ord + 1
+					if j == len(border)-2 {
+						next = first
+					}
+					contour.Borders = append(contour.Borders, xmlBorder{
+						Spatial:          k + 1,
+						Point1:           ord,
+						Point2:           next,
+						Length:           decimal(pt.Length),
+						DirectionalAngle: pt.DirectionalAngle,
+					})
+				}
+			}
+			contour.EntitySpatial.Elements = append(contour.EntitySpatial.Elements, el)
+		}
+		doc.LandPlot.Contours = append(doc.LandPlot.Contours, contour)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	if violations := ValidateScheme(buf.Bytes()); len(violations) > 0 {
+		return nil, &SchemaError{Violations: violations}
+	}
+	return buf.Bytes(), nil
+}
+
+// decimal записывает число с двумя знаками после точки, как того требуют
+// типы координат, длин и погрешностей XML-схемы.
+func decimal(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+type xmlScheme struct {
+	XMLName   xml.Name  `xml:"scheme_parcels"`
+	GUID      string    `xml:"guid,attr"`
+	Version   string    `xml:"version,attr"`
+	Date      string    `xml:"details_statement>group_top_requisites>date_formation"`
+	Applicant xmlPerson `xml:"applicant>person"`
+	LandPlot  struct {
+		Definition     string             `xml:"definition"`
+		CadastralBlock string             `xml:"cadastral_block"`
+		Category       *xmlCode           `xml:"category,omitempty"`
+		PermittedUse   *xmlPermittedUse   `xml:"permitted_use,omitempty"`
+		Address        string             `xml:"address,omitempty"`
+		Area           xmlArea            `xml:"area"`
+		Contours       []xmlSchemeContour `xml:"contours_location>contours>contour"`
+	} `xml:"land_plot"`
+}
+
+type xmlPerson struct {
+	Surname          string `xml:"surname"`
+	Name             string `xml:"name"`
+	Patronymic       string `xml:"patronymic,omitempty"`
+	SNILS            string `xml:"snils,omitempty"`
+	IdentityDocument string `xml:"identity_document,omitempty"`
+	Address          string `xml:"address,omitempty"`
+	Phone            string `xml:"contacts>phone,omitempty"`
+	Email            string `xml:"contacts>email,omitempty"`
+}
+
+type xmlCode struct {
+	Code string `xml:"code"`
+}
+
+type xmlPermittedUse struct {
+	ByDocument string `xml:"by_document"`
+}
+
+type xmlArea struct {
+	Value      string `xml:"value"`
+	Inaccuracy string `xml:"inaccuracy"`
+	Unit       string `xml:"unit"`
+}
+
+type xmlSchemeContour struct {
+	NumberPP      int `xml:"number_pp"`
+	EntitySpatial struct {
+		SkID     string              `xml:"sk_id"`
+		Elements []xmlSpatialElement `xml:"spatials_elements>spatial_element"`
+	} `xml:"entity_spatial"`
+	Borders []xmlBorder `xml:"borders>border"`
+}
+
+type xmlSpatialElement struct {
+	Ordinates []xmlOrdinate `xml:"ordinates>ordinate"`
+}
+
+type xmlOrdinate struct {
+	OrdNmb      int     `xml:"ord_nmb"`
+	NumGeopoint string  `xml:"num_geopoint"`
+	X           string  `xml:"x"`
+	Y           string  `xml:"y"`
+	Delta       string  `xml:"delta_geopoint"`
+	Method      xmlCode `xml:"geopoint_opred"`
+}
+
+type xmlBorder struct {
+	Spatial          int    `xml:"spatial"`
+	Point1           int    `xml:"point1"`
+	Point2           int    `xml:"point2"`
+	Length           string `xml:"edge>length"`
+	DirectionalAngle string `xml:"edge>direction_angle"`
+}
@@ -0,0 +1,137 @@
+package shapefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"zemlya-prosto/internal/formats/cp1251"
+)
+
+// Field описывает поле таблицы атрибутов .dbf.
+type Field struct {
+	Name string
+	// Type — тип поля dBASE: C (строка), N и F (число), D (дата), L (логическое).
+	Type     byte
+	Length   int
+	Decimals int
+}
+
+type dbfTable struct {
+	fields  []Field
+	records [][]string
+	deleted []bool
+}
+
+// ldidCP1251 — байт языкового драйвера dBASE для кодировки Windows-1251.
+const ldidCP1251 = 0xC9
+
+// readDBF читает таблицу атрибутов. Значения возвращаются строками: числа —
+// как записаны в файле, даты — в виде ГГГГ-ММ-ДД, логические — true/false.
+func readDBF(data []byte, cpg string) (*dbfTable, error) {
+	if len(data) < 32 {
+		return nil, fmt.Errorf("%w: заголовок таблицы обрезан", ErrInvalid)
+	}
+	le := binary.LittleEndian
+	numRecords := int(le.Uint32(data[4:8]))
+	headerLen := int(le.Uint16(data[8:10]))
+	recordLen := int(le.Uint16(data[10:12]))
+	decode := decoder(cpg, data[29])
+
+	if headerLen > len(data) || headerLen < 33 {
+		return nil, fmt.Errorf("%w: некорректная длина заголовка таблицы", ErrInvalid)
+	}
+	var fields []Field
+	width := 1 // флаг удаления записи
+	for pos := 32; pos+32 <= headerLen && data[pos] != 0x0D; pos += 32 {
+		desc := data[pos : pos+32]
+		name := desc[:11]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		field := Field{
+			Name:     strings.TrimSpace(decode(name)),
+			Type:     desc[11],
+			Length:   int(desc[16]),
+			Decimals: int(desc[17]),
+		}
+		fields = append(fields, field)
+		width += field.Length
+	}
+	if width > recordLen {
+		return nil, fmt.Errorf("%w: поля таблицы не помещаются в запись", ErrInvalid)
+	}
+	if headerLen+numRecords*recordLen > len(data) {
+		return nil, fmt.Errorf("%w: таблица содержит %d записей, но файл обрезан", ErrInvalid, numRecords)
+	}
+
+	table := &dbfTable{
+		fields:  fields,
+		records: make([][]string, numRecords),
+		deleted: make([]bool, numRecords),
+	}
+	for i := range numRecords {
+		rec := data[headerLen+i*recordLen : headerLen+(i+1)*recordLen]
+		table.deleted[i] = rec[0] == '*'
+		values := make([]string, len(fields))
+		pos := 1
+		for j, f := range fields {
+			values[j] = fieldValue(f, rec[pos:pos+f.Length], decode)
+			pos += f.Length
+		}
+		table.records[i] = values
+	}
+	return table, nil
+}
+
+func fieldValue(f Field, raw []byte, decode func([]byte) string) string {
+	switch f.Type {
+	case 'N', 'F':
+		v := strings.TrimSpace(string(raw))
+		if strings.Trim(v, "*") == "" {
+			return ""
+		}
+		return v
+	case 'D':
+		v := strings.TrimSpace(string(raw))
+		if len(v) == 8 {
+			return v[0:4] + "-" + v[4:6] + "-" + v[6:8]
+		}
+		return v
+	case 'L':
+		switch strings.ToUpper(strings.TrimSpace(string(raw))) {
+		case "T", "Y":
+			return "true"
+		case "F", "N":
+			return "false"
+		}
+		return ""
+	default:
+		return strings.TrimSpace(decode(bytes.TrimRight(raw, "\x00")))
+	}
+}
+
+// decoder выбирает кодировку текста по файлу .cpg, а без него — по байту
+// языкового драйвера.
+func decoder(cpg string, ldid byte) func([]byte) string {
+	page := strings.ToUpper(strings.TrimSpace(cpg))
+	page = strings.TrimPrefix(strings.TrimPrefix(page, "ANSI "), "WINDOWS-")
+	page = strings.TrimPrefix(page, "CP")
+	switch page {
+	case "1251":
+		return cp1251.Decode
+	case "UTF-8", "UTF8", "65001":
+		return func(b []byte) string {
+			if utf8.Valid(b) {
+				return string(b)
+			}
+			return cp1251.Decode(b)
+		}
+	}
+	if cpg == "" && ldid == ldidCP1251 {
+		return cp1251.Decode
+	}
+	return cp1251.DecodeAuto
+}
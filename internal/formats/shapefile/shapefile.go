@@ -0,0 +1,309 @@
+// Package shapefile читает полигональные слои ESRI Shapefile, переданные
+// zip-архивом из файлов .shp, .shx, .dbf, .prj и .cpg.
+//
+// Геометрия читается из .shp последовательно, поэтому индекс .shx не
+// обязателен. Атрибуты читаются из .dbf в кодировке, указанной в .cpg или
+// байте языкового драйвера; по умолчанию текст считается UTF-8, а при
+// некорректных последовательностях — Windows-1251. Содержимое .prj
+// возвращается как есть: систему координат по нему определяет пакет crs.
+package shapefile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для некорректного архива или шейп-файла.
+var ErrInvalid = errors.New("некорректный шейп-файл")
+
+// Типы фигур ESRI Shapefile.
+const (
+	shapeNull     = 0
+	shapePolygon  = 5
+	shapePolygonZ = 15
+	shapePolygonM = 25
+)
+
+// Layer — слой шейп-файла: набор записей с геометрией и атрибутами.
+type Layer struct {
+	// Name — имя файла .shp без расширения.
+	Name string
+	// PRJ — описание системы координат из .prj или пустая строка.
+	PRJ     string
+	Fields  []Field
+	Records []Record
+}
+
+// Record — запись слоя. Values соответствуют полям слоя по порядку.
+type Record struct {
+	// Number — номер записи в файле, с единицы.
+	Number int
+	// Shape — геометрия записи; пуста для фигур типа Null.
+	Shape  geo.MultiPolygon
+	Values []string
+}
+
+// maxFileSize ограничивает распакованный размер файла из архива.
+const maxFileSize = 256 << 20
+
+// ReadZip читает все слои из zip-архива. Файлы одного слоя сопоставляются
+// по имени без учёта регистра расширения; служебные каталоги __MACOSX
+// пропускаются.
+func ReadZip(data []byte) ([]Layer, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: архив не прочитан: %v", ErrInvalid, err)
+	}
+	files := make(map[string]*zip.File)
+	var names []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(f.Name))
+		base := strings.TrimSuffix(f.Name, path.Ext(f.Name))
+		files[base+ext] = f
+		if ext == ".shp" {
+			names = append(names, base)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: в архиве нет файла .shp", ErrInvalid)
+	}
+	sort.Strings(names)
+
+	layers := make([]Layer, 0, len(names))
+	for _, base := range names {
+		read := func(ext string) ([]byte, error) {
+			f, ok := files[base+ext]
+			if !ok {
+				return nil, nil
+			}
+			return readZipFile(f)
+		}
+		shp, err := read(".shp")
+		if err != nil {
+			return nil, err
+		}
+		dbf, err := read(".dbf")
+		if err != nil {
+			return nil, err
+		}
+		prj, err := read(".prj")
+		if err != nil {
+			return nil, err
+		}
+		cpg, err := read(".cpg")
+		if err != nil {
+			return nil, err
+		}
+		layer, err := Read(path.Base(base), shp, dbf, string(cpg))
+		if err != nil {
+			return nil, err
+		}
+		layer.PRJ = strings.TrimSpace(string(prj))
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+	}
+	if len(data) > maxFileSize {
+		return nil, fmt.Errorf("%w: файл %s слишком велик", ErrInvalid, f.Name)
+	}
+	return data, nil
+}
+
+// Read читает слой из содержимого .shp и .dbf. Файл .dbf может отсутствовать
+// (nil), тогда записи не содержат атрибутов. cpg — содержимое файла .cpg.
+// Записи, помеченные в .dbf как удалённые, пропускаются.
+func Read(name string, shp, dbf []byte, cpg string) (Layer, error) {
+	layer := Layer{Name: name}
+	shapes, err := readShapes(shp)
+	if err != nil {
+		return Layer{}, fmt.Errorf("%s.shp: %w", name, err)
+	}
+	var table *dbfTable
+	if dbf != nil {
+		if table, err = readDBF(dbf, cpg); err != nil {
+			return Layer{}, fmt.Errorf("%s.dbf: %w", name, err)
+		}
+		if len(table.records) != len(shapes) {
+			return Layer{}, fmt.Errorf("%w: %s: число записей в .shp (%d) и .dbf (%d) не совпадает",
+				ErrInvalid, name, len(shapes), len(table.records))
+		}
+		layer.Fields = table.fields
+	}
+
+	for i, shape := range shapes {
+		record := Record{Number: i + 1, Shape: shape}
+		if table != nil {
+			if table.deleted[i] {
+				continue
+			}
+			record.Values = table.records[i]
+		}
+		layer.Records = append(layer.Records, record)
+	}
+	return layer, nil
+}
+
+// readShapes читает геометрию всех записей файла .shp.
+func readShapes(data []byte) ([]geo.MultiPolygon, error) {
+	if len(data) < 100 || binary.BigEndian.Uint32(data[0:4]) != 9994 {
+		return nil, fmt.Errorf("%w: неверный заголовок файла", ErrInvalid)
+	}
+	switch typ := binary.LittleEndian.Uint32(data[32:36]); typ {
+	case shapeNull, shapePolygon, shapePolygonZ, shapePolygonM:
+	default:
+		return nil, fmt.Errorf("%w: тип фигур %d не поддерживается, ожидаются полигоны", ErrInvalid, typ)
+	}
+
+	var shapes []geo.MultiPolygon
+	for pos := 100; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, fmt.Errorf("%w: запись %d обрезана", ErrInvalid, len(shapes)+1)
+		}
+		length := int(binary.BigEndian.Uint32(data[pos+4:pos+8])) * 2
+		start := pos + 8
+		if length < 4 || start+length > len(data) {
+			return nil, fmt.Errorf("%w: запись %d обрезана", ErrInvalid, len(shapes)+1)
+		}
+		shape, err := readPolygon(data[start : start+length])
+		if err != nil {
+			return nil, fmt.Errorf("запись %d: %w", len(shapes)+1, err)
+		}
+		shapes = append(shapes, shape)
+		pos = start + length
+	}
+	return shapes, nil
+}
+
+// readPolygon читает фигуру Polygon, PolygonZ или PolygonM. Координаты Z и M
+// отбрасываются.
+func readPolygon(content []byte) (geo.MultiPolygon, error) {
+	le := binary.LittleEndian
+	switch typ := le.Uint32(content[0:4]); typ {
+	case shapeNull:
+		return nil, nil
+	case shapePolygon, shapePolygonZ, shapePolygonM:
+	default:
+		return nil, fmt.Errorf("%w: тип фигуры %d не поддерживается, ожидается полигон", ErrInvalid, typ)
+	}
+	if len(content) < 44 {
+		return nil, fmt.Errorf("%w: заголовок полигона обрезан", ErrInvalid)
+	}
+	numParts := int(le.Uint32(content[36:40]))
+	numPoints := int(le.Uint32(content[40:44]))
+	partsEnd := 44 + 4*numParts
+	pointsEnd := partsEnd + 16*numPoints
+	if numParts <= 0 || numPoints <= 0 || partsEnd > len(content) || pointsEnd > len(content) || pointsEnd < partsEnd {
+		return nil, fmt.Errorf("%w: некорректное число частей (%d) или точек (%d)", ErrInvalid, numParts, numPoints)
+	}
+
+	rings := make([]geo.Ring, 0, numParts)
+	for i := 0; i < numParts; i++ {
+		from := int(le.Uint32(content[44+4*i:]))
+		to := numPoints
+		if i+1 < numParts {
+			to = int(le.Uint32(content[44+4*(i+1):]))
+		}
+		if from < 0 || from > to || to > numPoints {
+			return nil, fmt.Errorf("%w: некорректные границы кольца %d", ErrInvalid, i+1)
+		}
+		ring := make(geo.Ring, 0, to-from)
+		for j := from; j < to; j++ {
+			off := partsEnd + 16*j
+			ring = append(ring, geo.Point{
+				X: math.Float64frombits(le.Uint64(content[off:])),
+				Y: math.Float64frombits(le.Uint64(content[off+8:])),
+			})
+		}
+		rings = append(rings, ring.Open())
+	}
+	return assemble(rings), nil
+}
+
+// assemble группирует кольца в полигоны. Формат требует обхода внешних
+// границ по часовой стрелке, а дыр — против, но многие программы это
+// нарушают, поэтому роль кольца определяется вложенностью: кольцо внутри
+// чётного числа других колец — внешняя граница, внутри нечётного — дыра
+// наименьшей из содержащих его внешних границ.
+func assemble(rings []geo.Ring) geo.MultiPolygon {
+	contains := func(outer, inner geo.Ring) bool {
+		if len(outer) < 3 || len(inner) == 0 || !outer.Bounds().Intersects(inner.Bounds()) {
+			return false
+		}
+		// Вершины, общие с внешним кольцом (дыра касается границы), не
+		// учитываются: для них проверка попадания неустойчива.
+		vertices := make(map[geo.Point]bool, len(outer))
+		for _, p := range outer {
+			vertices[p] = true
+		}
+		checked := 0
+		for _, p := range inner {
+			if vertices[p] {
+				continue
+			}
+			if !outer.ContainsPoint(p) {
+				return false
+			}
+			checked++
+		}
+		return checked > 0
+	}
+
+	depth := make([]int, len(rings))
+	for i := range rings {
+		for j := range rings {
+			if i != j && contains(rings[j], rings[i]) {
+				depth[i]++
+			}
+		}
+	}
+
+	var m geo.MultiPolygon
+	shellIndex := make(map[int]int)
+	for i, ring := range rings {
+		if depth[i]%2 == 0 {
+			shellIndex[i] = len(m)
+			m = append(m, geo.Polygon{ring})
+		}
+	}
+	for i, ring := range rings {
+		if depth[i]%2 == 0 {
+			continue
+		}
+		best := -1
+		for j := range rings {
+			if depth[j] == depth[i]-1 && contains(rings[j], ring) &&
+				(best < 0 || math.Abs(rings[j].SignedArea()) < math.Abs(rings[best].SignedArea())) {
+				best = j
+			}
+		}
+		if best >= 0 {
+			idx := shellIndex[best]
+			m[idx] = append(m[idx], ring)
+		}
+	}
+	return m
+}
@@ -0,0 +1,89 @@
+package shapefile
+
+import (
+	"reflect"
+	"testing"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// box возвращает незамкнутое кольцо прямоугольника: по часовой стрелке, как
+// требует формат для внешних границ, или против неё.
+func box(minX, minY, maxX, maxY float64, clockwise bool) geo.Ring {
+	r := geo.Ring{{X: minX, Y: minY}, {X: maxX, Y: minY}, {X: maxX, Y: maxY}, {X: minX, Y: maxY}}
+	if clockwise {
+		return r.Reverse()
+	}
+	return r
+}
+
+func TestAssemble(t *testing.T) {
+	outer := box(0, 0, 10, 10, true)
+	hole := box(2, 2, 4, 4, false)
+	holeCW := box(2, 2, 4, 4, true)
+	second := box(20, 0, 30, 10, true)
+	secondHole := box(22, 2, 24, 4, false)
+	island := box(2.5, 2.5, 3.5, 3.5, true)
+	islandHole := box(2.8, 2.8, 3.2, 3.2, false)
+	// Дыра, касающаяся внешней границы вершиной (0, 0).
+	touching := geo.Ring{{X: 0, Y: 0}, {X: 3, Y: 1}, {X: 1, Y: 3}}
+
+	tests := []struct {
+		name  string
+		rings []geo.Ring
+		want  geo.MultiPolygon
+	}{
+		{
+			name:  "внешняя граница против часовой стрелки",
+			rings: []geo.Ring{outer.Reverse()},
+			want:  geo.MultiPolygon{{outer.Reverse()}},
+		},
+		{
+			name:  "правильный обход",
+			rings: []geo.Ring{outer, hole},
+			want:  geo.MultiPolygon{{outer, hole}},
+		},
+		{
+			name:  "дыра по часовой стрелке",
+			rings: []geo.Ring{outer, holeCW},
+			want:  geo.MultiPolygon{{outer, holeCW}},
+		},
+		{
+			name:  "обход всех колец перепутан",
+			rings: []geo.Ring{outer.Reverse(), holeCW},
+			want:  geo.MultiPolygon{{outer.Reverse(), holeCW}},
+		},
+		{
+			name:  "дыра записана раньше внешней границы",
+			rings: []geo.Ring{holeCW, outer},
+			want:  geo.MultiPolygon{{outer, holeCW}},
+		},
+		{
+			name:  "дыры двух частей записаны вперемешку",
+			rings: []geo.Ring{secondHole.Reverse(), outer, hole.Reverse(), second},
+			want:  geo.MultiPolygon{{outer, hole.Reverse()}, {second, secondHole.Reverse()}},
+		},
+		{
+			name:  "остров в дыре с собственной дырой",
+			rings: []geo.Ring{islandHole.Reverse(), outer, island.Reverse(), hole.Reverse()},
+			want:  geo.MultiPolygon{{outer, hole.Reverse()}, {island.Reverse(), islandHole.Reverse()}},
+		},
+		{
+			name:  "дыра касается внешней границы",
+			rings: []geo.Ring{outer, touching.Reverse()},
+			want:  geo.MultiPolygon{{outer, touching.Reverse()}},
+		},
+		{
+			name:  "две части без дыр",
+			rings: []geo.Ring{outer.Reverse(), second},
+			want:  geo.MultiPolygon{{outer.Reverse()}, {second}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assemble(tt.rings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assemble() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
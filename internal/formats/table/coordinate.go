@@ -0,0 +1,134 @@
+package table
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseCoordinate разбирает значение координаты ведомости.
+//
+// Поддерживаются десятичная запятая («468914,87»), пробелы между разрядами
+// («468 914,87») и запись в градусах, минутах и секундах: «55°45'20.5"»,
+// «55 45 20,5», «55°45.342' N». Буквы S, W, Ю и З означают отрицательное
+// значение.
+func ParseCoordinate(s string) (float64, error) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, " ", " "))
+	if s == "" {
+		return 0, errors.New("значение не указано")
+	}
+	if isDMS(s) {
+		return parseDMS(s)
+	}
+	v, err := strconv.ParseFloat(normalizeNumber(s), 64)
+	if err != nil {
+		return 0, errors.New("ожидается число")
+	}
+	return v, nil
+}
+
+// normalizeNumber убирает разделители разрядов и приводит десятичный
+// разделитель к точке.
+func normalizeNumber(s string) string {
+	s = strings.ReplaceAll(s, " ", "")
+	if strings.Contains(s, ",") {
+		if strings.Contains(s, ".") {
+			// «1,234.56» — запятая разделяет разряды.
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.ReplaceAll(s, ",", ".")
+		}
+	}
+	return s
+}
+
+// isDMS сообщает, что значение записано в градусах, минутах и секундах:
+// содержит знаки градуса, минуты или секунды, букву полушария либо два-три
+// числа через пробел, из которых последующие меньше 60.
+func isDMS(s string) bool {
+	if strings.ContainsAny(s, "°º′″'\"") || hemisphereSign(s) != 0 {
+		return true
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 2 && len(fields) != 3 {
+		return false
+	}
+	for _, f := range fields[1:] {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(f, ",", "."), 64)
+		if err != nil || v >= 60 {
+			return false
+		}
+		// «1 005 000» — разряды числа, а не минуты: группа из трёх цифр.
+		if len(strings.SplitN(f, ",", 2)[0]) == 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// hemisphereSign возвращает -1 или 1, если значение начинается или
+// заканчивается обозначением полушария («N», «W», «с.ш.», «з.д.»), и 0 в
+// остальных случаях. Буква внутри значения не учитывается, чтобы не спутать
+// её с экспонентой («4.7E5»).
+func hemisphereSign(s string) int {
+	u := strings.TrimRight(strings.ToUpper(strings.TrimSpace(s)), ".")
+	for suffix, sign := range map[string]int{"С.Ш": 1, "В.Д": 1, "Ю.Ш": -1, "З.Д": -1} {
+		if strings.HasSuffix(u, suffix) {
+			return sign
+		}
+	}
+	runes := []rune(u)
+	if len(runes) == 0 {
+		return 0
+	}
+	for _, r := range []rune{runes[0], runes[len(runes)-1]} {
+		switch r {
+		case 'S', 'W', 'Ю', 'З':
+			return -1
+		case 'N', 'E', 'С', 'В':
+			return 1
+		}
+	}
+	return 0
+}
+
+func parseDMS(s string) (float64, error) {
+	sign := 1.0
+	if hemisphereSign(s) < 0 {
+		sign = -1
+	}
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "-") {
+		sign = -1
+	}
+
+	var parts []float64
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool {
+		return !(unicode.IsDigit(r) || r == '.' || r == ',')
+	}) {
+		if strings.Trim(f, ".,") == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.ReplaceAll(f, ",", "."), 64)
+		if err != nil {
+			return 0, fmt.Errorf("не удалось разобрать %q в записи градусов", f)
+		}
+		parts = append(parts, v)
+	}
+	if len(parts) == 0 || len(parts) > 3 {
+		return 0, errors.New("ожидаются градусы, минуты и секунды")
+	}
+	value := parts[0]
+	for i, div := range []float64{60, 3600} {
+		if i+1 >= len(parts) {
+			break
+		}
+		if parts[i+1] >= 60 {
+			return 0, errors.New("минуты и секунды должны быть меньше 60")
+		}
+		value += parts[i+1] / div
+	}
+	return sign * value, nil
+}
@@ -0,0 +1,60 @@
+package table
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want float64
+	}{
+		{"десятичная точка", "468914.87", 468914.87},
+		{"десятичная запятая", "468914,87", 468914.87},
+		{"пробел между разрядами", "468 914,87", 468914.87},
+		{"неразрывный пробел между разрядами", "468\u00a0914,87", 468914.87},
+		{"запятая между разрядами", "1,234.56", 1234.56},
+		{"три группы разрядов", "1 005 000", 1005000},
+		{"отрицательное число", "-12,5", -12.5},
+		{"экспонента", "4.7E5", 470000},
+		{"градусы, минуты, секунды", `55°45'20.5"`, 55 + 45.0/60 + 20.5/3600},
+		{"градусы через пробел", "55 45 20,5", 55 + 45.0/60 + 20.5/3600},
+		{"типографские штрихи", "60°30′15″", 60 + 30.0/60 + 15.0/3600},
+		{"минуты с долями и N", "55°45.342' N", 55 + 45.342/60},
+		{"W в конце", `37°37'00" W`, -(37 + 37.0/60)},
+		{"S в начале", "S 33°52'", -(33 + 52.0/60)},
+		{"ю.ш.", "33°52' ю.ш.", -(33 + 52.0/60)},
+		{"з.д.", "70°15' з.д.", -(70 + 15.0/60)},
+		{"с.ш.", "55°45' с.ш.", 55 + 45.0/60},
+		{"минус перед градусами", "-37°30'", -37.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCoordinate(tt.in)
+			if err != nil {
+				t.Fatalf("ParseCoordinate(%q): %v", tt.in, err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ParseCoordinate(%q) = %.10f, want %.10f", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCoordinateErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"   ",
+		"abc",
+		"12,34,56.7.8",
+		"55°61'",
+		`55°45'75"`,
+		`55°45'20"10'5`,
+	} {
+		if v, err := ParseCoordinate(in); err == nil {
+			t.Errorf("ParseCoordinate(%q) = %v, want error", in, v)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+// Package table разбирает ведомости координат характерных точек, загруженные
+// в виде таблиц CSV, TSV или XLSX.
+//
+// Ведомость — это строки с номером точки и координатами X (север) и Y (восток)
+// в системе координат заявителя. Дополнительно могут присутствовать столбец
+// системы координат и столбец номера контура для многоконтурных участков.
+// Ошибки разбора отдельных строк не прерывают обработку файла: строка
+// пропускается, а ошибка возвращается вместе с результатом.
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/formats/cp1251"
+	"zemlya-prosto/internal/formats/xlsx"
+)
+
+// Format — формат загруженной таблицы.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+	FormatXLSX Format = "xlsx"
+)
+
+// HeaderMode определяет, содержит ли первая строка заголовки столбцов.
+type HeaderMode int
+
+const (
+	// HeaderAuto — заголовок определяется по известным названиям столбцов.
+	HeaderAuto HeaderMode = iota
+	// HeaderPresent — первая строка всегда считается заголовком.
+	HeaderPresent
+	// HeaderAbsent — данные начинаются с первой строки.
+	HeaderAbsent
+)
+
+// Columns задаёт столбцы ведомости: название из заголовка (без учёта
+// регистра) или номер столбца, начиная с единицы. Пустое значение означает
+// поиск по известным названиям, а для X и Y без заголовка — положение по
+// умолчанию (№, X, Y).
+type Columns struct {
+	Number  string
+	X       string
+	Y       string
+	CRS     string
+	Contour string
+}
+
+// Options управляет разбором таблицы.
+type Options struct {
+	// Format — формат файла; если не указан, определяется по имени и содержимому.
+	Format Format
+	// Delimiter — разделитель CSV; если не указан, определяется по первой строке.
+	Delimiter rune
+	Header    HeaderMode
+	Columns   Columns
+	// Sheet — имя листа XLSX; по умолчанию используется первый лист.
+	Sheet string
+}
+
+// Point — характерная точка ведомости. X — северная координата (или широта),
+// Y — восточная (или долгота).
+type Point struct {
+	Number string
+	X, Y   float64
+	// Row — номер строки файла, из которой прочитана точка.
+	Row int
+}
+
+// Contour — точки одного контура в порядке следования в файле.
+type Contour struct {
+	Number string
+	// CRS — система координат, указанная в строках контура, если столбец задан.
+	CRS    string
+	Points []Point
+}
+
+// RowError описывает ошибку разбора строки файла.
+type RowError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error реализует интерфейс error.
+func (e RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("строка %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("строка %d, столбец %s: %s", e.Row, e.Column, e.Message)
+}
+
+// Result — результат разбора ведомости.
+type Result struct {
+	Contours []Contour
+	Errors   []RowError
+}
+
+// ErrNoData возвращается, если файл не содержит ни одной строки с данными.
+var ErrNoData = errors.New("файл не содержит строк с координатами")
+
+// DetectFormat определяет формат по расширению имени файла, а при его
+// отсутствии — по содержимому (архив ZIP считается книгой XLSX).
+func DetectFormat(filename string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".xlsx":
+		return FormatXLSX
+	case ".tsv", ".tab":
+		return FormatTSV
+	case ".csv", ".txt":
+		return FormatCSV
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return FormatXLSX
+	}
+	return FormatCSV
+}
+
+// Parse разбирает ведомость. Ошибка возвращается, только если файл нельзя
+// прочитать целиком или не найдены обязательные столбцы; ошибки отдельных
+// строк содержатся в Result.Errors.
+func Parse(data []byte, opts Options) (*Result, error) {
+	rows, err := readRows(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoData
+	}
+
+	var names []string
+	switch opts.Header {
+	case HeaderPresent:
+		names, rows = rows[0].cells, rows[1:]
+	case HeaderAuto:
+		// Над заголовком ведомости часто стоит название документа, поэтому
+		// заголовок ищется среди первых строк.
+		for i := 0; i < len(rows) && i < headerSearchRows; i++ {
+			if looksLikeHeader(rows[i].cells) {
+				names, rows = rows[i].cells, rows[i+1:]
+				break
+			}
+		}
+	}
+	layout, err := resolveColumns(opts.Columns, names, width(rows))
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoData
+	}
+	return layout.parse(rows), nil
+}
+
+// headerSearchRows — число первых строк, среди которых ищется заголовок.
+const headerSearchRows = 10
+
+// row — строка таблицы с номером в исходном файле.
+type row struct {
+	number int
+	cells  []string
+}
+
+func readRows(data []byte, opts Options) ([]row, error) {
+	format := opts.Format
+	if format == "" {
+		format = DetectFormat("", data)
+	}
+	switch format {
+	case FormatXLSX:
+		sheet, err := xlsx.ReadSheet(data, opts.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]row, len(sheet))
+		for i, r := range sheet {
+			rows[i] = row{number: r.Number, cells: r.Cells}
+		}
+		return rows, nil
+	case FormatCSV, FormatTSV:
+		text := cp1251.DecodeAuto(data)
+		delim := opts.Delimiter
+		if delim == 0 {
+			if format == FormatTSV {
+				delim = '\t'
+			} else {
+				delim = sniffDelimiter(text)
+			}
+		}
+		return readCSV(text, delim)
+	default:
+		return nil, fmt.Errorf("формат %q не поддерживается", format)
+	}
+}
+
+func readCSV(text string, delim rune) ([]row, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	var rows []row
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать CSV: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		if !blank(record) {
+			rows = append(rows, row{number: line, cells: record})
+		}
+	}
+}
+
+// sniffDelimiter выбирает разделитель по первой строке, в которой он
+// встречается. Точка с запятой предпочтительнее запятой при равном числе
+// вхождений, поскольку в русской локали запятая — десятичный разделитель.
+func sniffDelimiter(text string) rune {
+	for _, line := range strings.Split(text, "\n") {
+		best, bestCount := ',', 0
+		for _, d := range []rune{';', '\t', ','} {
+			if n := strings.Count(line, string(d)); n > bestCount {
+				best, bestCount = d, n
+			}
+		}
+		if bestCount > 0 {
+			return best
+		}
+	}
+	return ','
+}
+
+func width(rows []row) int {
+	w := 0
+	for _, r := range rows {
+		w = max(w, len(r.cells))
+	}
+	return w
+}
+
+func blank(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Известные названия столбцов ведомости (в нормализованном виде).
+var aliases = map[string][]string{
+	"number":  {"№", "n", "no", "номер", "номер точки", "№ точки", "точка", "имя точки", "обозначение", "обозначение точки", "обозначение характерной точки", "point", "name", "id"},
+	"x":       {"x", "х", "northing", "north", "lat", "latitude", "широта", "b"},
+	"y":       {"y", "у", "easting", "east", "lon", "lng", "longitude", "долгота", "l"},
+	"crs":     {"crs", "ск", "система координат", "srid", "epsg"},
+	"contour": {"контур", "номер контура", "№ контура", "contour", "part", "часть"},
+}
+
+// normalizeName приводит заголовок к виду для сравнения: нижний регистр, без
+// единиц измерения в скобках и после запятой («X, м» → «x»).
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexAny(name, ",("); i > 0 {
+		name = name[:i]
+	}
+	return strings.Join(strings.Fields(strings.Trim(name, ".:")), " ")
+}
+
+func aliasOf(name string) string {
+	n := normalizeName(name)
+	for key, list := range aliases {
+		for _, a := range list {
+			if n == a {
+				return key
+			}
+		}
+	}
+	return ""
+}
+
+// looksLikeHeader считает первую строку заголовком, если в ней есть известное
+// название столбца X или Y.
+func looksLikeHeader(cells []string) bool {
+	for _, c := range cells {
+		if a := aliasOf(c); a == "x" || a == "y" {
+			return true
+		}
+	}
+	return false
+}
+
+// layout — индексы столбцов (-1, если столбец отсутствует) и их названия для сообщений.
+type layout struct {
+	number, x, y, crs, contour int
+	names                      []string
+}
+
+func resolveColumns(cols Columns, names []string, width int) (layout, error) {
+	l := layout{number: -1, x: -1, y: -1, crs: -1, contour: -1, names: names}
+	specs := []struct {
+		key  string
+		spec string
+		dst  *int
+	}{
+		{"number", cols.Number, &l.number},
+		{"x", cols.X, &l.x},
+		{"y", cols.Y, &l.y},
+		{"crs", cols.CRS, &l.crs},
+		{"contour", cols.Contour, &l.contour},
+	}
+	for _, s := range specs {
+		idx, err := findColumn(s.key, s.spec, names, width)
+		if err != nil {
+			return layout{}, err
+		}
+		*s.dst = idx
+	}
+
+	// Без заголовка и явных настроек используется порядок «№, X, Y» или «X, Y».
+	if names == nil && cols.X == "" && cols.Y == "" {
+		switch {
+		case width >= 3:
+			l.x, l.y = 1, 2
+			if cols.Number == "" {
+				l.number = 0
+			}
+		case width == 2:
+			l.x, l.y = 0, 1
+		}
+	}
+	if l.x < 0 || l.y < 0 {
+		return layout{}, errors.New("не найдены столбцы координат X и Y; укажите их явно")
+	}
+	if l.x == l.y {
+		return layout{}, errors.New("столбцы X и Y совпадают")
+	}
+	return l, nil
+}
+
+func findColumn(key, spec string, names []string, width int) (int, error) {
+	if spec == "" {
+		for i, name := range names {
+			if aliasOf(name) == key {
+				return i, nil
+			}
+		}
+		return -1, nil
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(spec)); err == nil {
+		if n < 1 || (width > 0 && n > width) {
+			return -1, fmt.Errorf("столбец %d отсутствует в таблице", n)
+		}
+		return n - 1, nil
+	}
+	want := normalizeName(spec)
+	for i, name := range names {
+		if normalizeName(name) == want {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("столбец %q не найден в заголовке", spec)
+}
+
+// columnName возвращает название столбца для сообщения об ошибке.
+func (l layout) columnName(idx int) string {
+	if idx < len(l.names) && strings.TrimSpace(l.names[idx]) != "" {
+		return strings.TrimSpace(l.names[idx])
+	}
+	return strconv.Itoa(idx + 1)
+}
+
+func cell(cells []string, idx int) string {
+	if idx < 0 || idx >= len(cells) {
+		return ""
+	}
+	return strings.TrimSpace(cells[idx])
+}
+
+func (l layout) parse(rows []row) *Result {
+	res := &Result{}
+	index := make(map[string]int)
+	current := ""
+	for _, r := range rows {
+		// Пустой номер контура означает продолжение предыдущего контура
+		// (в ведомостях номер часто указывают только в первой строке).
+		if v := cell(r.cells, l.contour); v != "" {
+			current = v
+		}
+
+		x, errX := l.coordinate(r, l.x)
+		y, errY := l.coordinate(r, l.y)
+		if errX != nil || errY != nil {
+			for _, e := range []error{errX, errY} {
+				var re RowError
+				if errors.As(e, &re) {
+					res.Errors = append(res.Errors, re)
+				}
+			}
+			continue
+		}
+
+		i, ok := index[current]
+		if !ok {
+			i = len(res.Contours)
+			index[current] = i
+			res.Contours = append(res.Contours, Contour{Number: current})
+		}
+		c := &res.Contours[i]
+		if system := cell(r.cells, l.crs); system != "" {
+			if c.CRS != "" && !strings.EqualFold(c.CRS, system) {
+				res.Errors = append(res.Errors, RowError{
+					Row: r.number, Column: l.columnName(l.crs), Value: system,
+					Message: fmt.Sprintf("система координат отличается от указанной ранее (%s)", c.CRS),
+				})
+				continue
+			}
+			c.CRS = system
+		}
+		c.Points = append(c.Points, Point{Number: cell(r.cells, l.number), X: x, Y: y, Row: r.number})
+	}
+	return res
+}
+
+func (l layout) coordinate(r row, idx int) (float64, error) {
+	raw := cell(r.cells, idx)
+	if raw == "" {
+		return 0, RowError{Row: r.number, Column: l.columnName(idx), Message: "значение не указано"}
+	}
+	v, err := ParseCoordinate(raw)
+	if err != nil {
+		return 0, RowError{Row: r.number, Column: l.columnName(idx), Value: raw, Message: err.Error()}
+	}
+	return v, nil
+}
@@ -0,0 +1,277 @@
+// Package wkb читает и записывает полигональную геометрию в двоичном формате
+// OGC Well-Known Binary и в расширенном формате PostGIS EWKB.
+//
+// При чтении поддерживаются оба порядка байтов, размерности Z/M в нотации ISO
+// (коды 1003, 2003, 3003) и флагах EWKB, а также шестнадцатеричная запись,
+// в которой PostGIS выводит геометрию по умолчанию. Координаты Z и M
+// отбрасываются.
+package wkb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для некорректных данных WKB.
+var ErrInvalid = errors.New("некорректный WKB")
+
+// Коды типов геометрии OGC.
+const (
+	typePolygon      = 3
+	typeMultiPolygon = 6
+)
+
+// Флаги EWKB, записываемые в старших битах кода типа.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// Unmarshal разбирает WKB или EWKB в двоичном либо шестнадцатеричном виде.
+// Для EWKB с кодом системы координат возвращается SRID, иначе 0.
+func Unmarshal(data []byte) (geo.MultiPolygon, int, error) {
+	if text := bytes.TrimSpace(data); isHex(text) {
+		decoded, err := hex.DecodeString(string(text))
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		data = decoded
+	}
+	r := &reader{data: data}
+	m, srid, err := r.geometry(true)
+	if err != nil {
+		return nil, 0, err
+	}
+	if r.pos != len(r.data) {
+		return nil, 0, fmt.Errorf("%w: лишние %d байт после геометрии", ErrInvalid, len(r.data)-r.pos)
+	}
+	return m, srid, nil
+}
+
+// isHex распознаёт шестнадцатеричную запись: чётное число символов 0-9, A-F,
+// начинающееся с маркера порядка байтов 00 или 01.
+func isHex(text []byte) bool {
+	if len(text) < 2 || len(text)%2 != 0 || text[0] != '0' || (text[1] != '0' && text[1] != '1') {
+		return false
+	}
+	for _, c := range text {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Marshal записывает геометрию в порядке байтов little-endian как Polygon
+// (одна часть) или MultiPolygon. При srid > 0 записывается EWKB с кодом
+// системы координат. Кольца записываются замкнутыми.
+func Marshal(m geo.MultiPolygon, srid int) []byte {
+	var buf bytes.Buffer
+	if len(m) == 1 {
+		writePolygon(&buf, m[0], srid)
+		return buf.Bytes()
+	}
+	writeHeader(&buf, typeMultiPolygon, srid)
+	writeUint32(&buf, uint32(len(m)))
+	for _, poly := range m {
+		writePolygon(&buf, poly, 0)
+	}
+	return buf.Bytes()
+}
+
+// MarshalHex возвращает результат Marshal в шестнадцатеричной записи (верхний регистр, как в PostGIS).
+func MarshalHex(m geo.MultiPolygon, srid int) string {
+	return strings.ToUpper(hex.EncodeToString(Marshal(m, srid)))
+}
+
+func writeHeader(buf *bytes.Buffer, typ uint32, srid int) {
+	buf.WriteByte(1) // little-endian
+	if srid > 0 {
+		writeUint32(buf, typ|ewkbSRID)
+		writeUint32(buf, uint32(srid))
+		return
+	}
+	writeUint32(buf, typ)
+}
+
+func writePolygon(buf *bytes.Buffer, poly geo.Polygon, srid int) {
+	writeHeader(buf, typePolygon, srid)
+	writeUint32(buf, uint32(len(poly)))
+	for _, ring := range poly {
+		closed := ring.Closed()
+		writeUint32(buf, uint32(len(closed)))
+		for _, p := range closed {
+			writeFloat(buf, p.X)
+			writeFloat(buf, p.Y)
+		}
+	}
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeFloat(buf *bytes.Buffer, v float64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	buf.Write(b[:])
+}
+
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: смещение %d: %s", ErrInvalid, r.pos, fmt.Sprintf(format, args...))
+}
+
+func (r *reader) take(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, r.errorf("данные закончились")
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) uint32(order binary.ByteOrder) (uint32, error) {
+	b, err := r.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return order.Uint32(b), nil
+}
+
+// count читает число элементов и проверяет, что для них хватает данных,
+// чтобы повреждённый заголовок не приводил к огромным выделениям памяти.
+func (r *reader) count(order binary.ByteOrder, minSize int) (int, error) {
+	n, err := r.uint32(order)
+	if err != nil {
+		return 0, err
+	}
+	if int64(n)*int64(minSize) > int64(len(r.data)-r.pos) {
+		return 0, r.errorf("число элементов %d превышает размер данных", n)
+	}
+	return int(n), nil
+}
+
+// header читает порядок байтов, тип, размерность и SRID геометрии.
+func (r *reader) header() (order binary.ByteOrder, typ uint32, dims int, srid int, err error) {
+	b, err := r.take(1)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	switch b[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return nil, 0, 0, 0, r.errorf("неизвестный порядок байтов %d", b[0])
+	}
+	raw, err := r.uint32(order)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+
+	dims = 2
+	if raw&ewkbZ != 0 {
+		dims++
+	}
+	if raw&ewkbM != 0 {
+		dims++
+	}
+	if raw&ewkbSRID != 0 {
+		code, err := r.uint32(order)
+		if err != nil {
+			return nil, 0, 0, 0, err
+		}
+		srid = int(code)
+	}
+	typ = raw & 0x0FFFFFFF
+	switch typ / 1000 {
+	case 0:
+	case 1, 2:
+		dims = 3
+	case 3:
+		dims = 4
+	default:
+		return nil, 0, 0, 0, r.errorf("неизвестный код типа %d", typ)
+	}
+	return order, typ % 1000, dims, srid, nil
+}
+
+func (r *reader) geometry(top bool) (geo.MultiPolygon, int, error) {
+	start := r.pos
+	order, typ, dims, srid, err := r.header()
+	if err != nil {
+		return nil, 0, err
+	}
+	switch typ {
+	case typePolygon:
+		poly, err := r.polygon(order, dims)
+		if err != nil {
+			return nil, 0, err
+		}
+		return geo.MultiPolygon{poly}, srid, nil
+	case typeMultiPolygon:
+		if !top {
+			r.pos = start
+			return nil, 0, r.errorf("вложенный MultiPolygon не допускается")
+		}
+		n, err := r.count(order, 9)
+		if err != nil {
+			return nil, 0, err
+		}
+		m := make(geo.MultiPolygon, 0, n)
+		for i := 0; i < n; i++ {
+			part, _, err := r.geometry(false)
+			if err != nil {
+				return nil, 0, err
+			}
+			m = append(m, part...)
+		}
+		return m, srid, nil
+	default:
+		r.pos = start
+		return nil, 0, r.errorf("тип геометрии %d не поддерживается, ожидается Polygon (3) или MultiPolygon (6)", typ)
+	}
+}
+
+func (r *reader) polygon(order binary.ByteOrder, dims int) (geo.Polygon, error) {
+	rings, err := r.count(order, 4)
+	if err != nil {
+		return nil, err
+	}
+	poly := make(geo.Polygon, 0, rings)
+	for i := 0; i < rings; i++ {
+		n, err := r.count(order, 8*dims)
+		if err != nil {
+			return nil, err
+		}
+		ring := make(geo.Ring, n)
+		for j := range ring {
+			b, err := r.take(8 * dims)
+			if err != nil {
+				return nil, err
+			}
+			ring[j] = geo.Point{
+				X: math.Float64frombits(order.Uint64(b[0:8])),
+				Y: math.Float64frombits(order.Uint64(b[8:16])),
+			}
+		}
+		poly = append(poly, ring)
+	}
+	return poly, nil
+}
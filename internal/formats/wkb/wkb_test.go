@@ -0,0 +1,157 @@
+package wkb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"zemlya-prosto/internal/formats/wkt"
+	"zemlya-prosto/internal/geo"
+)
+
+func TestRoundTripWKT(t *testing.T) {
+	for _, text := range []string{
+		"POLYGON((0 0,10 0,10 10,0 10,0 0))",
+		"POLYGON((0 0,10 0,10 10,0 10,0 0),(2 2,2 4,4 4,2 2))",
+		"SRID=4326;POLYGON((37.61 55.75,37.62 55.75,37.62 55.76,37.61 55.75))",
+		"SRID=4326;MULTIPOLYGON(((37.61 55.75,37.62 55.75,37.62 55.76,37.61 55.75)),((1 1,2 1,2 2,1 1)))",
+		"MULTIPOLYGON(((468914.87 2195761.88,469014.87 2195761.88,469014.87 2195861.88,468914.87 2195761.88)),((0.1 0.2,0.3 0.2,0.3 0.4,0.1 0.2)))",
+	} {
+		t.Run(text, func(t *testing.T) {
+			m, srid, err := wkt.Unmarshal(text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, data := range [][]byte{Marshal(m, srid), []byte(MarshalHex(m, srid))} {
+				back, backSRID, err := Unmarshal(data)
+				if err != nil {
+					t.Fatalf("Unmarshal: %v", err)
+				}
+				if backSRID != srid {
+					t.Errorf("SRID = %d, want %d", backSRID, srid)
+				}
+				if !reflect.DeepEqual(back, m) {
+					t.Errorf("геометрия = %v, want %v", back, m)
+				}
+				if got := wkt.Marshal(back, backSRID); got != text {
+					t.Errorf("WKT = %s, want %s", got, text)
+				}
+			}
+		})
+	}
+}
+
+func TestMarshalHexPostGIS(t *testing.T) {
+	square := geo.MultiPolygon{{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 0}}}}
+	// PostGIS выводит полигон с SRID 4326 как 01 03000020 E6100000 ...
+	if got := MarshalHex(square, 4326); !strings.HasPrefix(got, "0103000020E610000001000000") {
+		t.Errorf("MarshalHex = %s", got)
+	}
+	if got := MarshalHex(square, 0); !strings.HasPrefix(got, "01030000000100000004000000") {
+		t.Errorf("MarshalHex = %s", got)
+	}
+}
+
+// polygonWKB записывает полигон с заданными кодом типа и размерностью.
+func polygonWKB(order binary.ByteOrder, typ uint32, dims int, rings ...[]float64) []byte {
+	var buf bytes.Buffer
+	if order == binary.BigEndian {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte(1)
+	}
+	binary.Write(&buf, order, typ)
+	binary.Write(&buf, order, uint32(len(rings)))
+	for _, coords := range rings {
+		binary.Write(&buf, order, uint32(len(coords)/dims))
+		for _, c := range coords {
+			binary.Write(&buf, order, math.Float64bits(c))
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestUnmarshalDimensions(t *testing.T) {
+	want := geo.MultiPolygon{{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 0}}}}
+	tests := []struct {
+		name  string
+		order binary.ByteOrder
+		typ   uint32
+		dims  int
+	}{
+		{"XY big-endian", binary.BigEndian, typePolygon, 2},
+		{"ISO Z", binary.LittleEndian, 1000 + typePolygon, 3},
+		{"ISO M big-endian", binary.BigEndian, 2000 + typePolygon, 3},
+		{"ISO ZM", binary.LittleEndian, 3000 + typePolygon, 4},
+		{"EWKB Z", binary.LittleEndian, ewkbZ | typePolygon, 3},
+		{"EWKB ZM", binary.BigEndian, ewkbZ | ewkbM | typePolygon, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var coords []float64
+			for _, p := range want[0][0] {
+				coords = append(coords, p.X, p.Y)
+				for d := 2; d < tt.dims; d++ {
+					coords = append(coords, 100*float64(d))
+				}
+			}
+			got, _, err := Unmarshal(polygonWKB(tt.order, tt.typ, tt.dims, coords))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("геометрия = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	square := []float64{0, 0, 1, 0, 1, 1, 0, 0}
+	valid := polygonWKB(binary.LittleEndian, typePolygon, 2, square)
+
+	// Число точек кольца больше, чем записано координат.
+	shortRing := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(shortRing[9:], 5)
+	// Число колец не помещается в данные.
+	hugeRings := bytes.Clone(valid)
+	binary.LittleEndian.PutUint32(hugeRings[5:], math.MaxUint32)
+	// Полигон Z, в котором точки записаны без третьей координаты.
+	missingZ := polygonWKB(binary.LittleEndian, 1000+typePolygon, 2, square)
+
+	var nested bytes.Buffer
+	nested.WriteByte(1)
+	binary.Write(&nested, binary.LittleEndian, uint32(typeMultiPolygon))
+	binary.Write(&nested, binary.LittleEndian, uint32(1))
+	nested.WriteByte(1)
+	binary.Write(&nested, binary.LittleEndian, uint32(typeMultiPolygon))
+	binary.Write(&nested, binary.LittleEndian, uint32(0))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"пустые данные", nil},
+		{"неизвестный порядок байтов", append([]byte{2}, valid[1:]...)},
+		{"точка вместо полигона", []byte{1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{"неизвестный код типа", polygonWKB(binary.LittleEndian, 4000+typePolygon, 2, square)},
+		{"обрезанные данные", valid[:len(valid)-3]},
+		{"лишние байты", append(bytes.Clone(valid), 0)},
+		{"число точек больше данных", shortRing},
+		{"число колец больше данных", hugeRings},
+		{"не хватает координаты Z", missingZ},
+		{"вложенный MultiPolygon", nested.Bytes()},
+		{"текст вместо WKB", []byte("POLYGON((0 0,1 0,1 1,0 0))")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Unmarshal(tt.data); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Unmarshal error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+// Package wkt читает и записывает полигональную геометрию в текстовом формате
+// OGC Well-Known Text, а также в расширенном формате PostGIS EWKT с префиксом
+// SRID=<код>;.
+//
+// Поддерживаются типы POLYGON и MULTIPOLYGON с размерностями XY, XYZ, XYM и
+// XYZM; координаты Z и M при чтении отбрасываются.
+package wkt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для некорректного текста WKT.
+var ErrInvalid = errors.New("некорректный WKT")
+
+// Unmarshal разбирает WKT или EWKT. Для EWKT возвращается SRID, для WKT — 0.
+//
+// Кольца возвращаются в исходном виде, с замыкающими точками, чтобы индексы
+// нарушений при проверке совпадали с позициями во входном тексте.
+func Unmarshal(text string) (geo.MultiPolygon, int, error) {
+	srid := 0
+	text = strings.TrimSpace(text)
+	if head, rest, ok := strings.Cut(text, ";"); ok && strings.HasPrefix(strings.ToUpper(strings.TrimSpace(head)), "SRID=") {
+		code := strings.TrimSpace(head)[len("SRID="):]
+		n, err := strconv.Atoi(strings.TrimSpace(code))
+		if err != nil || n < 0 {
+			return nil, 0, fmt.Errorf("%w: некорректный SRID %q", ErrInvalid, code)
+		}
+		srid, text = n, rest
+	}
+
+	p := &parser{tokens: tokenize(text)}
+	m, err := p.geometry()
+	if err != nil {
+		return nil, 0, err
+	}
+	if tok := p.peek(); tok != "" {
+		return nil, 0, p.errorf("лишний текст после геометрии: %q", tok)
+	}
+	return m, srid, nil
+}
+
+// Marshal записывает геометрию как POLYGON (одна часть) или MULTIPOLYGON.
+// Кольца записываются замкнутыми. При srid > 0 добавляется префикс EWKT.
+func Marshal(m geo.MultiPolygon, srid int) string {
+	var b strings.Builder
+	if srid > 0 {
+		fmt.Fprintf(&b, "SRID=%d;", srid)
+	}
+	switch len(m) {
+	case 0:
+		b.WriteString("POLYGON EMPTY")
+	case 1:
+		b.WriteString("POLYGON")
+		writePolygon(&b, m[0])
+	default:
+		b.WriteString("MULTIPOLYGON(")
+		for i, poly := range m {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writePolygon(&b, poly)
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+func writePolygon(b *strings.Builder, poly geo.Polygon) {
+	b.WriteByte('(')
+	for i, ring := range poly {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		for j, p := range ring.Closed() {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.FormatFloat(p.X, 'f', -1, 64))
+			b.WriteByte(' ')
+			b.WriteString(strconv.FormatFloat(p.Y, 'f', -1, 64))
+		}
+		b.WriteByte(')')
+	}
+	b.WriteByte(')')
+}
+
+// tokenize разбивает текст на слова, числа и знаки «(», «)», «,».
+func tokenize(text string) []string {
+	var tokens []string
+	start := -1
+	flush := func(i int) {
+		if start >= 0 {
+			tokens = append(tokens, text[start:i])
+			start = -1
+		}
+	}
+	for i, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == ',':
+			flush(i)
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush(i)
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	flush(len(text))
+	return tokens
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+	// dims — число координат в позиции, если размерность указана явно (Z, M, ZM).
+	dims int
+}
+
+func (p *parser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() string {
+	tok := p.peek()
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: лексема %d: %s", ErrInvalid, p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			return p.errorf("ожидается %q, текст закончился", tok)
+		}
+		return p.errorf("ожидается %q, получено %q", tok, got)
+	}
+	return nil
+}
+
+func (p *parser) geometry() (geo.MultiPolygon, error) {
+	typ := strings.ToUpper(p.next())
+	// Размерность может быть записана слитно с типом: POLYGONZ, MULTIPOLYGONZM.
+	for _, suffix := range []string{"ZM", "Z", "M"} {
+		if base, ok := strings.CutSuffix(typ, suffix); ok && (base == "POLYGON" || base == "MULTIPOLYGON") {
+			typ = base
+			p.setDims(suffix)
+			break
+		}
+	}
+	switch tag := strings.ToUpper(p.peek()); tag {
+	case "Z", "M", "ZM":
+		p.next()
+		p.setDims(tag)
+	}
+	if strings.EqualFold(p.peek(), "EMPTY") {
+		p.next()
+		switch typ {
+		case "POLYGON", "MULTIPOLYGON":
+			return geo.MultiPolygon{}, nil
+		}
+	}
+
+	switch typ {
+	case "POLYGON":
+		poly, err := p.polygon()
+		if err != nil {
+			return nil, err
+		}
+		return geo.MultiPolygon{poly}, nil
+	case "MULTIPOLYGON":
+		return p.multiPolygon()
+	case "":
+		return nil, fmt.Errorf("%w: пустой текст", ErrInvalid)
+	default:
+		return nil, fmt.Errorf("%w: тип геометрии %s не поддерживается, ожидается POLYGON или MULTIPOLYGON", ErrInvalid, typ)
+	}
+}
+
+func (p *parser) setDims(tag string) {
+	p.dims = 2 + len(tag)
+}
+
+func (p *parser) multiPolygon() (geo.MultiPolygon, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var m geo.MultiPolygon
+	for {
+		poly, err := p.polygon()
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, poly)
+		if p.peek() != "," {
+			break
+		}
+		p.next()
+	}
+	return m, p.expect(")")
+}
+
+func (p *parser) polygon() (geo.Polygon, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var poly geo.Polygon
+	for {
+		ring, err := p.ring()
+		if err != nil {
+			return nil, err
+		}
+		poly = append(poly, ring)
+		if p.peek() != "," {
+			break
+		}
+		p.next()
+	}
+	return poly, p.expect(")")
+}
+
+func (p *parser) ring() (geo.Ring, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var ring geo.Ring
+	for {
+		pt, err := p.position()
+		if err != nil {
+			return nil, err
+		}
+		ring = append(ring, pt)
+		if p.peek() != "," {
+			break
+		}
+		p.next()
+	}
+	return ring, p.expect(")")
+}
+
+// position читает позицию из 2–4 чисел; X и Y возвращаются, Z и M отбрасываются.
+func (p *parser) position() (geo.Point, error) {
+	var coords []float64
+	for {
+		tok := p.peek()
+		if tok == "" || tok == "," || tok == ")" || tok == "(" {
+			break
+		}
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return geo.Point{}, p.errorf("ожидается число, получено %q", tok)
+		}
+		p.next()
+		coords = append(coords, v)
+	}
+	switch {
+	case p.dims > 0 && len(coords) != p.dims:
+		return geo.Point{}, p.errorf("позиция должна содержать %d координаты, получено %d", p.dims, len(coords))
+	case len(coords) < 2 || len(coords) > 4:
+		return geo.Point{}, p.errorf("позиция должна содержать от 2 до 4 координат, получено %d", len(coords))
+	}
+	return geo.Point{X: coords[0], Y: coords[1]}, nil
+}
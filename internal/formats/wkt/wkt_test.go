@@ -0,0 +1,106 @@
+package wkt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnmarshalMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		srid int
+		want string
+	}{
+		{
+			name: "полигон",
+			in:   "POLYGON((0 0,10 0,10 10,0 10,0 0))",
+			want: "POLYGON((0 0,10 0,10 10,0 10,0 0))",
+		},
+		{
+			name: "нижний регистр, пробелы и дырка",
+			in:   "polygon ((0 0, 10 0, 10 10, 0 10, 0 0), (2 2, 2 4, 4 4, 2 2))",
+			want: "POLYGON((0 0,10 0,10 10,0 10,0 0),(2 2,2 4,4 4,2 2))",
+		},
+		{
+			name: "EWKT с мультиполигоном",
+			in:   "SRID=4326;MULTIPOLYGON(((37.61 55.75,37.62 55.75,37.62 55.76,37.61 55.75)),((1 1,2 1,2 2,1 1)))",
+			srid: 4326,
+			want: "SRID=4326;MULTIPOLYGON(((37.61 55.75,37.62 55.75,37.62 55.76,37.61 55.75)),((1 1,2 1,2 2,1 1)))",
+		},
+		{
+			name: "незамкнутое кольцо замыкается",
+			in:   "SRID=3857; POLYGON((0 0,1 0,1 1))",
+			srid: 3857,
+			want: "SRID=3857;POLYGON((0 0,1 0,1 1,0 0))",
+		},
+		{
+			name: "Z отбрасывается",
+			in:   "POLYGON Z((0 0 5,1 0 5,1 1 5,0 0 5))",
+			want: "POLYGON((0 0,1 0,1 1,0 0))",
+		},
+		{
+			name: "ZM слитно с типом",
+			in:   "MULTIPOLYGONZM(((0 0 1 2,1 0 1 2,1 1 1 2,0 0 1 2)))",
+			want: "POLYGON((0 0,1 0,1 1,0 0))",
+		},
+		{
+			name: "размерность без указания",
+			in:   "POLYGON((0 0 7,1 0 7,1 1 7,0 0 7))",
+			want: "POLYGON((0 0,1 0,1 1,0 0))",
+		},
+		{
+			name: "пустая геометрия",
+			in:   "MULTIPOLYGON EMPTY",
+			want: "POLYGON EMPTY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, srid, err := Unmarshal(tt.in)
+			if err != nil {
+				t.Fatalf("Unmarshal(%q): %v", tt.in, err)
+			}
+			if srid != tt.srid {
+				t.Errorf("SRID = %d, want %d", srid, tt.srid)
+			}
+			got := Marshal(m, srid)
+			if got != tt.want {
+				t.Errorf("Marshal = %s, want %s", got, tt.want)
+			}
+			again, srid2, err := Unmarshal(got)
+			if err != nil {
+				t.Fatalf("Unmarshal(%q): %v", got, err)
+			}
+			if Marshal(again, srid2) != got {
+				t.Errorf("повторное чтение дало %s", Marshal(again, srid2))
+			}
+		})
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"пустой текст", ""},
+		{"неподдерживаемый тип", "POINT(1 2)"},
+		{"незакрытая скобка", "POLYGON((0 0,1 0,1 1,0 0)"},
+		{"лишний текст", "POLYGON((0 0,1 0,1 1,0 0)) POLYGON"},
+		{"не число", "POLYGON((0 0,1 x,1 1,0 0))"},
+		{"одна координата", "POLYGON((0 0,1,1 1,0 0))"},
+		{"пять координат", "POLYGON((0 0 0 0 0,1 0 0 0 0,1 1 0 0 0,0 0 0 0 0))"},
+		{"Z без третьей координаты", "POLYGON Z((0 0,1 0 1,1 1 1,0 0 1))"},
+		{"ZM с тремя координатами", "POLYGON ZM((0 0 1,1 0 1,1 1 1,0 0 1))"},
+		{"некорректный SRID", "SRID=abc;POLYGON((0 0,1 0,1 1,0 0))"},
+		{"отрицательный SRID", "SRID=-1;POLYGON((0 0,1 0,1 1,0 0))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := Unmarshal(tt.in); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Unmarshal(%q) error = %v, want ErrInvalid", tt.in, err)
+			}
+		})
+	}
+}
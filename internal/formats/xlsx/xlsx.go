@@ -0,0 +1,278 @@
+// Package xlsx читает значения ячеек из книг Office Open XML (.xlsx).
+//
+// Поддерживается только чтение листа как таблицы строк: формулы, стили и
+// форматы чисел не интерпретируются, для ячеек с формулой берётся последнее
+// вычисленное значение, сохранённое в файле.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalid возвращается, если архив не является книгой XLSX.
+var ErrInvalid = errors.New("некорректный файл XLSX")
+
+// Row — строка листа с номером, под которым она видна в редакторе (с единицы).
+type Row struct {
+	Number int
+	Cells  []string
+}
+
+// ReadSheet возвращает непустые строки листа с именем sheet или первого
+// листа книги, если имя не указано.
+func ReadSheet(data []byte, sheet string) ([]Row, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	target, err := sheetPath(files, sheet)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(f); err != nil {
+			return nil, err
+		}
+	}
+	f, ok := files[target]
+	if !ok {
+		return nil, fmt.Errorf("%w: лист %s отсутствует в архиве", ErrInvalid, target)
+	}
+	return readRows(f, shared)
+}
+
+type workbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// sheetPath находит путь к XML листа через workbook.xml и его связи.
+func sheetPath(files map[string]*zip.File, name string) (string, error) {
+	var wb workbook
+	if err := decodeFile(files, "xl/workbook.xml", &wb); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", fmt.Errorf("%w: книга не содержит листов", ErrInvalid)
+	}
+	rid := wb.Sheets[0].RID
+	if name != "" {
+		rid = ""
+		for _, s := range wb.Sheets {
+			if strings.EqualFold(s.Name, name) {
+				rid = s.RID
+				break
+			}
+		}
+		if rid == "" {
+			return "", fmt.Errorf("лист %q не найден в книге", name)
+		}
+	}
+
+	var rels relationships
+	if err := decodeFile(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != rid {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "", fmt.Errorf("%w: не найдена связь %s для листа", ErrInvalid, rid)
+}
+
+func decodeFile(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%w: отсутствует %s", ErrInvalid, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalid, name, err)
+	}
+	return nil
+}
+
+// readSharedStrings читает таблицу общих строк. Форматированные строки
+// (<r>) склеиваются из всех фрагментов текста.
+func readSharedStrings(f *zip.File) ([]string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	defer rc.Close()
+
+	var (
+		result []string
+		cur    strings.Builder
+		inSI   bool
+		inT    bool
+	)
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: sharedStrings.xml: %v", ErrInvalid, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				inSI = true
+				cur.Reset()
+			case "t":
+				inT = inSI
+			case "rPh":
+				// Фонетические подсказки не являются частью значения.
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("%w: sharedStrings.xml: %v", ErrInvalid, err)
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				result = append(result, cur.String())
+				inSI = false
+			case "t":
+				inT = false
+			}
+		case xml.CharData:
+			if inT {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+type sheetXML struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+				Runs []struct {
+					Text string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readRows(f *zip.File, shared []string) ([]Row, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	defer rc.Close()
+	var sheet sheetXML
+	if err := xml.NewDecoder(rc).Decode(&sheet); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, f.Name, err)
+	}
+
+	rows := make([]Row, 0, len(sheet.Rows))
+	next := 1
+	for _, r := range sheet.Rows {
+		number := r.R
+		if number == 0 {
+			number = next
+		}
+		next = number + 1
+
+		var cells []string
+		for i, c := range r.Cells {
+			col := i
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			var value string
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(strings.TrimSpace(c.Value))
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return nil, fmt.Errorf("%w: ячейка %s ссылается на несуществующую строку", ErrInvalid, c.Ref)
+				}
+				value = shared[idx]
+			case "inlineStr":
+				value = c.Inline.Text
+				for _, run := range c.Inline.Runs {
+					value += run.Text
+				}
+			default:
+				value = c.Value
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			cells[col] = value
+		}
+		if !blank(cells) {
+			rows = append(rows, Row{Number: number, Cells: cells})
+		}
+	}
+	return rows, nil
+}
+
+// columnIndex возвращает номер столбца (с нуля) из ссылки на ячейку вида "AB12".
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%w: некорректная ссылка на ячейку %q", ErrInvalid, ref)
+	}
+	return col - 1, nil
+}
+
+func blank(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
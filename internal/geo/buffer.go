@@ -0,0 +1,101 @@
+package geo
+
+import "math"
+
+// DefaultQuadrantSegments — число отрезков, которыми по умолчанию
+// приближается четверть окружности при построении буфера.
+const DefaultQuadrantSegments = 8
+
+// Buffer строит буферную зону географической геометрии (долгота, широта
+// WGS84): при положительном distance — область на расстоянии не больше
+// distance метров от геометрии, при отрицательном — часть геометрии,
+// удалённую от границы больше чем на |distance| метров. Скругления у вершин
+// приближаются вписанными многоугольниками по quadrantSegments отрезков на
+// четверть окружности.
+//
+// Буфер строится как объединение «капсул» — полос шириной 2|distance| со
+// скруглёнными концами — вокруг всех рёбер, которое прибавляется к
+// геометрии или вычитается из неё. Вершины капсул находятся решением прямой
+// геодезической задачи от вершин геометрии, поэтому расстояние до границы
+// выдерживается на эллипсоиде, а не в проекции. Наложение выполняется в
+// локальной проекции геометрии (LocalFrame); вершины исходной геометрии
+// возвращаются без погрешности обратного пересчёта.
+func Buffer(m MultiPolygon, distance float64, quadrantSegments int) MultiPolygon {
+	if quadrantSegments <= 0 {
+		quadrantSegments = DefaultQuadrantSegments
+	}
+	shape := m.Oriented()
+	if distance == 0 || len(shape) == 0 {
+		return shape
+	}
+	frame := FrameFor(shape)
+	radius := math.Abs(distance)
+	var capsules []MultiPolygon
+	for _, poly := range shape {
+		for _, ring := range poly {
+			open := ring.Open()
+			for i, a := range open {
+				c := capsule(a, open[(i+1)%len(open)], radius, quadrantSegments)
+				for k, p := range c {
+					c[k] = frame.Forward(p)
+				}
+				capsules = append(capsules, MultiPolygon{Polygon{c}})
+			}
+		}
+	}
+	band := unionAll(capsules)
+
+	exact := make(map[Point]Point)
+	local := mapMulti(shape, func(p Point) Point {
+		q := frame.Forward(p)
+		exact[q] = p
+		return q
+	})
+	var result MultiPolygon
+	if distance > 0 {
+		result = Union(local, band)
+	} else {
+		result = Difference(local, band)
+	}
+	return mapMulti(result, func(p Point) Point {
+		if g, ok := exact[p]; ok {
+			return g
+		}
+		return frame.Inverse(p)
+	})
+}
+
+// capsule строит многоугольник, приближающий множество точек на расстоянии
+// не больше radius метров от геодезического отрезка ab, с обходом против
+// часовой стрелки. Азимуты отсчитываются от севера по часовой стрелке,
+// поэтому при обходе против часовой стрелки они убывают.
+func capsule(a, b Point, radius float64, quadrantSegments int) Ring {
+	step := 90 / float64(quadrantSegments)
+	arc := func(ring Ring, center Point, from float64) Ring {
+		for k := 0; k <= 2*quadrantSegments; k++ {
+			ring = append(ring, GeodesicDestination(center, from-float64(k)*step, radius))
+		}
+		return ring
+	}
+	if a == b {
+		circle := arc(nil, a, 0)
+		return arc(circle[:len(circle)-1], a, 180)[:4*quadrantSegments]
+	}
+	// Азимут линии ab в точке b — обратный азимут линии ba.
+	forward, backward := GeodesicAzimuth(a, b), GeodesicAzimuth(b, a)+180
+	ring := arc(make(Ring, 0, 4*quadrantSegments+2), b, backward+90)
+	return arc(ring, a, forward-90)
+}
+
+// unionAll объединяет геометрии попарно, чтобы размер промежуточных
+// результатов рос постепенно.
+func unionAll(shapes []MultiPolygon) MultiPolygon {
+	switch len(shapes) {
+	case 0:
+		return MultiPolygon{}
+	case 1:
+		return shapes[0]
+	}
+	mid := len(shapes) / 2
+	return Union(unionAll(shapes[:mid]), unionAll(shapes[mid:]))
+}
@@ -0,0 +1,314 @@
+// Package geo содержит геометрические примитивы и алгоритмы, общие для всех
+// сервисов «Земля просто».
+//
+// Пакет намеренно не зависит от доменных моделей: контуры, черновики и объекты
+// слоя преобразуются в типы geo на границе своих пакетов. Для географических
+// координат используется порядок GeoJSON: X — долгота, Y — широта.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point описывает точку на плоскости или на эллипсоиде.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Ring — кольцо полигона. Внутри пакета кольца хранятся незамкнутыми:
+// последняя точка не повторяет первую.
+type Ring []Point
+
+// Polygon — полигон, первое кольцо которого внешнее, а остальные описывают дырки.
+type Polygon []Ring
+
+// MultiPolygon — многоконтурная геометрия, состоящая из нескольких полигонов.
+type MultiPolygon []Polygon
+
+// IsFinite сообщает, что обе координаты точки являются конечными числами.
+func (p Point) IsFinite() bool {
+	return !math.IsNaN(p.X) && !math.IsNaN(p.Y) && !math.IsInf(p.X, 0) && !math.IsInf(p.Y, 0)
+}
+
+// Sub возвращает разность векторов p и q.
+func (p Point) Sub(q Point) Point { return Point{X: p.X - q.X, Y: p.Y - q.Y} }
+
+// Add возвращает сумму векторов p и q.
+func (p Point) Add(q Point) Point { return Point{X: p.X + q.X, Y: p.Y + q.Y} }
+
+// Scale умножает вектор на скаляр.
+func (p Point) Scale(k float64) Point { return Point{X: p.X * k, Y: p.Y * k} }
+
+// Dist возвращает евклидово расстояние между точками.
+func (p Point) Dist(q Point) float64 { return math.Hypot(p.X-q.X, p.Y-q.Y) }
+
+// Cross возвращает псевдоскалярное произведение векторов p и q.
+func Cross(p, q Point) float64 { return p.X*q.Y - p.Y*q.X }
+
+// Open возвращает кольцо без замыкающей точки, если она совпадает с первой.
+func (r Ring) Open() Ring {
+	if len(r) > 1 && r[0] == r[len(r)-1] {
+		return r[:len(r)-1]
+	}
+	return r
+}
+
+// Closed возвращает копию кольца, в которой последняя точка повторяет первую.
+func (r Ring) Closed() Ring {
+	open := r.Open()
+	out := make(Ring, 0, len(open)+1)
+	out = append(out, open...)
+	if len(open) > 0 {
+		out = append(out, open[0])
+	}
+	return out
+}
+
+// SignedArea вычисляет ориентированную площадь кольца по формуле Гаусса.
+// Положительное значение соответствует обходу против часовой стрелки.
+func (r Ring) SignedArea() float64 {
+	open := r.Open()
+	n := len(open)
+	if n < 3 {
+		return 0
+	}
+	var sum float64
+	for i := 0; i < n; i++ {
+		a, b := open[i], open[(i+1)%n]
+		sum += a.X*b.Y - b.X*a.Y
+	}
+	return sum / 2
+}
+
+// IsCCW сообщает, что кольцо обходится против часовой стрелки.
+func (r Ring) IsCCW() bool { return r.SignedArea() > 0 }
+
+// Reverse возвращает кольцо с обратным порядком обхода.
+func (r Ring) Reverse() Ring {
+	out := make(Ring, len(r))
+	for i, p := range r {
+		out[len(r)-1-i] = p
+	}
+	return out
+}
+
+// Perimeter возвращает длину границы кольца на плоскости.
+func (r Ring) Perimeter() float64 {
+	open := r.Open()
+	var sum float64
+	for i := range open {
+		sum += open[i].Dist(open[(i+1)%len(open)])
+	}
+	return sum
+}
+
+// Area возвращает площадь полигона на плоскости с учётом дырок.
+func (p Polygon) Area() float64 {
+	var area float64
+	for i, ring := range p {
+		if i == 0 {
+			area += math.Abs(ring.SignedArea())
+			continue
+		}
+		area -= math.Abs(ring.SignedArea())
+	}
+	return area
+}
+
+// Area возвращает суммарную площадь всех частей на плоскости.
+func (m MultiPolygon) Area() float64 {
+	var area float64
+	for _, p := range m {
+		area += p.Area()
+	}
+	return area
+}
+
+// Oriented возвращает копию полигона, у которой внешнее кольцо обходится против
+// часовой стрелки, а дырки — по часовой (правило правой руки RFC 7946).
+func (p Polygon) Oriented() Polygon {
+	out := make(Polygon, len(p))
+	for i, ring := range p {
+		ring = ring.Open()
+		ccw := ring.IsCCW()
+		if (i == 0) != ccw {
+			ring = ring.Reverse()
+		}
+		out[i] = append(Ring(nil), ring...)
+	}
+	return out
+}
+
+// Oriented применяет Polygon.Oriented к каждой части.
+func (m MultiPolygon) Oriented() MultiPolygon {
+	out := make(MultiPolygon, len(m))
+	for i, p := range m {
+		out[i] = p.Oriented()
+	}
+	return out
+}
+
+// Points возвращает все вершины геометрии в порядке обхода.
+func (m MultiPolygon) Points() []Point {
+	var pts []Point
+	for _, p := range m {
+		for _, r := range p {
+			pts = append(pts, r.Open()...)
+		}
+	}
+	return pts
+}
+
+// BBox описывает ограничивающий прямоугольник геометрии.
+type BBox struct {
+	MinX float64 `json:"min_x"`
+	MinY float64 `json:"min_y"`
+	MaxX float64 `json:"max_x"`
+	MaxY float64 `json:"max_y"`
+}
+
+// EmptyBBox возвращает «пустой» прямоугольник, который расширяется первой же точкой.
+func EmptyBBox() BBox {
+	return BBox{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+}
+
+// IsEmpty сообщает, что прямоугольник не содержит ни одной точки.
+func (b BBox) IsEmpty() bool { return b.MinX > b.MaxX || b.MinY > b.MaxY }
+
+// Extend расширяет прямоугольник так, чтобы он содержал точку p.
+func (b BBox) Extend(p Point) BBox {
+	b.MinX = math.Min(b.MinX, p.X)
+	b.MinY = math.Min(b.MinY, p.Y)
+	b.MaxX = math.Max(b.MaxX, p.X)
+	b.MaxY = math.Max(b.MaxY, p.Y)
+	return b
+}
+
+// Union возвращает прямоугольник, содержащий оба исходных.
+func (b BBox) Union(o BBox) BBox {
+	if o.IsEmpty() {
+		return b
+	}
+	return b.Extend(Point{X: o.MinX, Y: o.MinY}).Extend(Point{X: o.MaxX, Y: o.MaxY})
+}
+
+// Intersects сообщает, что прямоугольники пересекаются (включая касание).
+func (b BBox) Intersects(o BBox) bool {
+	return !b.IsEmpty() && !o.IsEmpty() &&
+		b.MinX <= o.MaxX && o.MinX <= b.MaxX && b.MinY <= o.MaxY && o.MinY <= b.MaxY
+}
+
+// Contains сообщает, что точка лежит внутри прямоугольника или на его границе.
+func (b BBox) Contains(p Point) bool {
+	return p.X >= b.MinX && p.X <= b.MaxX && p.Y >= b.MinY && p.Y <= b.MaxY
+}
+
+// Center возвращает центр прямоугольника.
+func (b BBox) Center() Point {
+	return Point{X: (b.MinX + b.MaxX) / 2, Y: (b.MinY + b.MaxY) / 2}
+}
+
+// Array возвращает прямоугольник в порядке GeoJSON: [minX, minY, maxX, maxY].
+// Для пустого прямоугольника возвращается nil.
+func (b BBox) Array() []float64 {
+	if b.IsEmpty() {
+		return nil
+	}
+	return []float64{b.MinX, b.MinY, b.MaxX, b.MaxY}
+}
+
+// ParseBBox разбирает прямоугольник из строки "minX,minY,maxX,maxY"
+// (формат параметра запроса ?bbox=).
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("bbox должен содержать четыре числа через запятую: %q", s)
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return BBox{}, fmt.Errorf("bbox содержит некорректное число %q", part)
+		}
+		v[i] = f
+	}
+	b := BBox{MinX: v[0], MinY: v[1], MaxX: v[2], MaxY: v[3]}
+	if b.IsEmpty() {
+		return BBox{}, fmt.Errorf("в bbox минимальные координаты превышают максимальные: %q", s)
+	}
+	return b, nil
+}
+
+// BoundsOf вычисляет ограничивающий прямоугольник набора точек.
+func BoundsOf(points []Point) BBox {
+	b := EmptyBBox()
+	for _, p := range points {
+		b = b.Extend(p)
+	}
+	return b
+}
+
+// Bounds возвращает ограничивающий прямоугольник кольца.
+func (r Ring) Bounds() BBox { return BoundsOf(r) }
+
+// Bounds возвращает ограничивающий прямоугольник полигона (по внешнему кольцу).
+func (p Polygon) Bounds() BBox {
+	if len(p) == 0 {
+		return EmptyBBox()
+	}
+	return p[0].Bounds()
+}
+
+// Bounds возвращает ограничивающий прямоугольник всех частей.
+func (m MultiPolygon) Bounds() BBox {
+	b := EmptyBBox()
+	for _, p := range m {
+		b = b.Union(p.Bounds())
+	}
+	return b
+}
+
+// ContainsPoint проверяет попадание точки внутрь кольца по правилу чётности.
+// Точки на границе могут быть отнесены к любой стороне.
+func (r Ring) ContainsPoint(p Point) bool {
+	open := r.Open()
+	inside := false
+	n := len(open)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := open[i], open[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) {
+			x := (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y) + a.X
+			if p.X < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// ContainsPoint проверяет, что точка лежит внутри полигона и вне его дырок.
+func (p Polygon) ContainsPoint(pt Point) bool {
+	if len(p) == 0 || !p[0].ContainsPoint(pt) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.ContainsPoint(pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsPoint проверяет попадание точки в любую из частей.
+func (m MultiPolygon) ContainsPoint(pt Point) bool {
+	for _, p := range m {
+		if p.ContainsPoint(pt) {
+			return true
+		}
+	}
+	return false
+}
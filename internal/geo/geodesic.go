@@ -0,0 +1,339 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// Measurements содержит метрики геометрии, вычисленные на эллипсоиде WGS84.
+type Measurements struct {
+	// AreaSqM — площадь в квадратных метрах с учётом дырок.
+	AreaSqM float64
+	// PerimeterM — суммарная длина всех колец по геодезическим линиям в метрах.
+	PerimeterM float64
+	// Centroid — центр тяжести фигуры; для невыпуклых фигур может лежать снаружи.
+	Centroid Point
+	// LabelPoint — точка для подписи, гарантированно лежащая внутри фигуры.
+	LabelPoint Point
+}
+
+// AreaHa возвращает площадь в гектарах.
+func (m Measurements) AreaHa() float64 { return m.AreaSqM / 10000 }
+
+// Measure вычисляет площадь, периметр, центр тяжести и точку подписи
+// географической геометрии (долгота, широта в градусах WGS84).
+func Measure(m MultiPolygon) Measurements {
+	var res Measurements
+	for _, poly := range m {
+		res.AreaSqM += poly.GeodesicArea()
+		for _, ring := range poly {
+			res.PerimeterM += ring.GeodesicLength()
+		}
+	}
+	if len(m.Points()) == 0 {
+		return res
+	}
+
+	frame := FrameFor(m)
+	local := frame.ForwardMulti(m)
+	centroid := local.Centroid()
+	res.Centroid = frame.Inverse(centroid)
+	res.LabelPoint = frame.Inverse(local.InteriorPoint(centroid))
+	return res
+}
+
+// GeodesicArea вычисляет площадь полигона на эллипсоиде WGS84 в квадратных метрах.
+//
+// Кольца переносятся на равновеликую (аутентическую) сферу, где площадь
+// вычисляется точно через сферический избыток. Для участков сервиса
+// относительная погрешность не превышает 10⁻⁶.
+func (p Polygon) GeodesicArea() float64 {
+	var area float64
+	for i, ring := range p {
+		a := ring.GeodesicArea()
+		if i == 0 {
+			area += a
+		} else {
+			area -= a
+		}
+	}
+	return math.Max(area, 0)
+}
+
+// GeodesicArea вычисляет суммарную площадь всех частей в квадратных метрах.
+func (m MultiPolygon) GeodesicArea() float64 {
+	var area float64
+	for _, p := range m {
+		area += p.GeodesicArea()
+	}
+	return area
+}
+
+// GeodesicArea вычисляет площадь, ограниченную кольцом, в квадратных метрах.
+func (r Ring) GeodesicArea() float64 {
+	open := r.Open()
+	n := len(open)
+	if n < 3 {
+		return 0
+	}
+	var excess float64
+	for i := 0; i < n; i++ {
+		a, b := open[i], open[(i+1)%n]
+		lambda := normalizeRad((b.X - a.X) * deg)
+		t1 := math.Tan(authalicLatitude(a.Y*deg) / 2)
+		t2 := math.Tan(authalicLatitude(b.Y*deg) / 2)
+		excess += 2 * math.Atan2(math.Tan(lambda/2)*(t1+t2), 1+t1*t2)
+	}
+	r2 := authalicRadius()
+	return math.Abs(excess) * r2 * r2
+}
+
+// GeodesicLength вычисляет длину замкнутого кольца по геодезическим линиям.
+func (r Ring) GeodesicLength() float64 {
+	open := r.Open()
+	n := len(open)
+	if n < 2 {
+		return 0
+	}
+	var length float64
+	for i := 0; i < n; i++ {
+		length += GeodesicDistance(open[i], open[(i+1)%n])
+	}
+	return length
+}
+
+// GeodesicDistance вычисляет длину геодезической линии между точками на
+// эллипсоиде WGS84 по формулам Винсенти. Для почти диаметрально
+// противоположных точек, где итерации не сходятся, используется сфера.
+func GeodesicDistance(p, q Point) float64 {
+	dist, _, _ := vincentyInverse(p, q)
+	return dist
+}
+
+// GeodesicAzimuth возвращает прямой азимут геодезической линии из p в q
+// в градусах, отсчитываемый от севера по часовой стрелке, в диапазоне [0, 360).
+func GeodesicAzimuth(p, q Point) float64 {
+	_, az, _ := vincentyInverse(p, q)
+	return az
+}
+
+// GeodesicDestination решает прямую геодезическую задачу на эллипсоиде WGS84
+// по формулам Винсенти: возвращает точку, удалённую от p на distance метров
+// по геодезической линии с начальным азимутом azimuth в градусах.
+func GeodesicDestination(p Point, azimuth, distance float64) Point {
+	const a = WGS84SemiMajor
+	const f = WGS84Flattening
+	b := a * (1 - f)
+
+	sinAlpha1, cosAlpha1 := math.Sincos(azimuth * deg)
+	u1 := math.Atan((1 - f) * math.Tan(p.Y*deg))
+	sinU1, cosU1 := math.Sincos(u1)
+	sigma1 := math.Atan2(math.Tan(u1), cosAlpha1)
+	sinAlpha := cosU1 * sinAlpha1
+	cos2Alpha := 1 - sinAlpha*sinAlpha
+	uSq := cos2Alpha * (a*a - b*b) / (b * b)
+	bigA := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	bigB := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+
+	sigma := distance / (b * bigA)
+	var sinSigma, cosSigma, cos2SigmaM float64
+	for i := 0; i < 200; i++ {
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		sinSigma, cosSigma = math.Sincos(sigma)
+		deltaSigma := bigB * sinSigma * (cos2SigmaM + bigB/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			bigB/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+		prev := sigma
+		sigma = distance/(b*bigA) + deltaSigma
+		if math.Abs(sigma-prev) < 1e-12 {
+			break
+		}
+	}
+	sinSigma, cosSigma = math.Sincos(sigma)
+	cos2SigmaM = math.Cos(2*sigma1 + sigma)
+
+	t := sinU1*sinSigma - cosU1*cosSigma*cosAlpha1
+	lat := math.Atan2(sinU1*cosSigma+cosU1*sinSigma*cosAlpha1, (1-f)*math.Hypot(sinAlpha, t))
+	lambda := math.Atan2(sinSigma*sinAlpha1, cosU1*cosSigma-sinU1*sinSigma*cosAlpha1)
+	c := f / 16 * cos2Alpha * (4 + f*(4-3*cos2Alpha))
+	l := lambda - (1-c)*f*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+	return Point{X: p.X + l/deg, Y: lat / deg}
+}
+
+func vincentyInverse(p, q Point) (dist, azimuth float64, ok bool) {
+	const a = WGS84SemiMajor
+	const f = WGS84Flattening
+	b := a * (1 - f)
+
+	L := normalizeRad((q.X - p.X) * deg)
+	u1 := math.Atan((1 - f) * math.Tan(p.Y*deg))
+	u2 := math.Atan((1 - f) * math.Tan(q.Y*deg))
+	sinU1, cosU1 := math.Sincos(u1)
+	sinU2, cosU2 := math.Sincos(u2)
+
+	lambda := L
+	var sinSigma, cosSigma, sigma, cos2Alpha, cos2SigmaM, sinLambda, cosLambda float64
+	converged := false
+	for i := 0; i < 200; i++ {
+		sinLambda, cosLambda = math.Sincos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0, 0, true
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cos2Alpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0
+		if cos2Alpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cos2Alpha
+		}
+		c := f / 16 * cos2Alpha * (4 + f*(4-3*cos2Alpha))
+		prev := lambda
+		lambda = L + (1-c)*f*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-prev) < 1e-12 {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return haversine(p, q), sphericalAzimuth(p, q), false
+	}
+
+	uSq := cos2Alpha * (a*a - b*b) / (b * b)
+	bigA := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	bigB := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	deltaSigma := bigB * sinSigma * (cos2SigmaM + bigB/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		bigB/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+	dist = b * bigA * (sigma - deltaSigma)
+
+	azimuth = math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda) / deg
+	if azimuth < 0 {
+		azimuth += 360
+	}
+	return dist, azimuth, true
+}
+
+func haversine(p, q Point) float64 {
+	dLat := (q.Y - p.Y) * deg
+	dLon := (q.X - p.X) * deg
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(p.Y*deg)*math.Cos(q.Y*deg)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * authalicRadius() * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func sphericalAzimuth(p, q Point) float64 {
+	dLon := (q.X - p.X) * deg
+	y := math.Sin(dLon) * math.Cos(q.Y*deg)
+	x := math.Cos(p.Y*deg)*math.Sin(q.Y*deg) - math.Sin(p.Y*deg)*math.Cos(q.Y*deg)*math.Cos(dLon)
+	az := math.Atan2(y, x) / deg
+	if az < 0 {
+		az += 360
+	}
+	return az
+}
+
+func wgs84Eccentricity() float64 {
+	return math.Sqrt(WGS84Flattening * (2 - WGS84Flattening))
+}
+
+// authalicQ — вспомогательная функция q(φ) для перехода к аутентической широте.
+func authalicQ(phi float64) float64 {
+	e := wgs84Eccentricity()
+	sin := math.Sin(phi)
+	es := e * sin
+	return (1 - e*e) * (sin/(1-es*es) - math.Log((1-es)/(1+es))/(2*e))
+}
+
+func authalicLatitude(phi float64) float64 {
+	ratio := authalicQ(phi) / authalicQ(math.Pi/2)
+	return math.Asin(math.Max(-1, math.Min(1, ratio)))
+}
+
+// authalicRadius — радиус сферы, равновеликой эллипсоиду WGS84.
+func authalicRadius() float64 {
+	return WGS84SemiMajor * math.Sqrt(authalicQ(math.Pi/2)/2)
+}
+
+// Centroid вычисляет центр тяжести геометрии на плоскости.
+func (m MultiPolygon) Centroid() Point {
+	var cx, cy, total float64
+	for _, poly := range m {
+		for i, ring := range poly {
+			x, y, a := ringMoments(ring.Open())
+			if (i == 0) != (a > 0) {
+				x, y, a = -x, -y, -a
+			}
+			cx += x
+			cy += y
+			total += a
+		}
+	}
+	if total == 0 {
+		return BoundsOf(m.Points()).Center()
+	}
+	return Point{X: cx / total, Y: cy / total}
+}
+
+// ringMoments возвращает первые моменты и ориентированную площадь кольца.
+func ringMoments(r Ring) (mx, my, area float64) {
+	n := len(r)
+	for i := 0; i < n; i++ {
+		a, b := r[i], r[(i+1)%n]
+		c := a.X*b.Y - b.X*a.Y
+		area += c
+		mx += (a.X + b.X) * c
+		my += (a.Y + b.Y) * c
+	}
+	area /= 2
+	return mx / 6, my / 6, area
+}
+
+// InteriorPoint возвращает точку, лежащую внутри геометрии.
+//
+// Если подсказка hint (обычно центр тяжести) уже внутри, она и возвращается.
+// Иначе геометрия рассекается горизонталями, и выбирается середина самого
+// широкого внутреннего отрезка.
+func (m MultiPolygon) InteriorPoint(hint Point) Point {
+	if m.ContainsPoint(hint) {
+		return hint
+	}
+	b := BoundsOf(m.Points())
+	best, bestWidth := hint, -1.0
+	const lines = 16
+	for k := 0; k <= lines; k++ {
+		y := hint.Y
+		if k > 0 {
+			y = b.MinY + (b.MaxY-b.MinY)*(float64(k)-0.5)/lines
+		}
+		for _, poly := range m {
+			xs := scanline(poly, y)
+			for i := 0; i+1 < len(xs); i += 2 {
+				if w := xs[i+1] - xs[i]; w > bestWidth {
+					bestWidth = w
+					best = Point{X: (xs[i] + xs[i+1]) / 2, Y: y}
+				}
+			}
+		}
+		if k == 0 && bestWidth > 0 {
+			return best
+		}
+	}
+	return best
+}
+
+// scanline возвращает отсортированные абсциссы пересечений горизонтали y с границами полигона.
+func scanline(p Polygon, y float64) []float64 {
+	var xs []float64
+	for _, ring := range p {
+		open := ring.Open()
+		n := len(open)
+		for i := 0; i < n; i++ {
+			a, b := open[i], open[(i+1)%n]
+			if (a.Y > y) != (b.Y > y) {
+				xs = append(xs, a.X+(y-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+	}
+	sort.Float64s(xs)
+	return xs
+}
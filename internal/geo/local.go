@@ -0,0 +1,77 @@
+package geo
+
+// Параметры эллипсоида WGS84, в котором хранятся все контуры сервиса.
+const (
+	WGS84SemiMajor  = 6378137.0
+	WGS84Flattening = 1 / 298.257223563
+)
+
+// LocalFrame — локальная конформная проекция в окрестности опорной точки:
+// поперечно-цилиндрическая проекция Меркатора с осевым меридианом и началом
+// координат в опорной точке и масштабом 1 на осевом меридиане.
+//
+// Углы в проекции сохраняются, а масштаб на удалении x от осевого меридиана
+// отличается от единицы на x²/2R², т. е. не более чем на 1,3·10⁻⁶ в полосе
+// ±10 км: искажение длин не превышает 1,3 мм на километр. Этого достаточно
+// для допусков валидации, привязки и топологических операций, задаваемых в
+// сантиметрах, если геометрия лежит в пределах 10 км от опорной точки.
+//
+// Вычисления выполняются по рядам Крюгера для эллипсоида WGS84.
+type LocalFrame struct {
+	Origin Point
+	// y0 — удаление опорной точки от экватора в проекции.
+	y0 float64
+}
+
+var wgs84Krueger = NewKrueger(WGS84SemiMajor, WGS84Flattening)
+
+// NewLocalFrame создаёт локальную проекцию с центром в точке origin (долгота, широта).
+func NewLocalFrame(origin Point) LocalFrame {
+	_, y0 := wgs84Krueger.Forward(origin.Y, 0)
+	return LocalFrame{Origin: origin, y0: y0}
+}
+
+// FrameFor создаёт локальную проекцию с центром в центре охвата геометрии.
+func FrameFor(m MultiPolygon) LocalFrame {
+	b := m.Bounds()
+	if b.IsEmpty() {
+		return NewLocalFrame(Point{})
+	}
+	return NewLocalFrame(b.Center())
+}
+
+// Forward переводит географические координаты в метры локальной плоскости.
+func (f LocalFrame) Forward(p Point) Point {
+	x, y := wgs84Krueger.Forward(p.Y, p.X-f.Origin.X)
+	return Point{X: x, Y: y - f.y0}
+}
+
+// Inverse переводит метры локальной плоскости в географические координаты.
+func (f LocalFrame) Inverse(p Point) Point {
+	lat, dLon := wgs84Krueger.Inverse(p.X, p.Y+f.y0)
+	return Point{X: f.Origin.X + dLon, Y: lat}
+}
+
+// ForwardMulti проецирует все вершины геометрии в локальную плоскость.
+func (f LocalFrame) ForwardMulti(m MultiPolygon) MultiPolygon {
+	return mapMulti(m, f.Forward)
+}
+
+// InverseMulti возвращает геометрию из локальной плоскости в градусы.
+func (f LocalFrame) InverseMulti(m MultiPolygon) MultiPolygon {
+	return mapMulti(m, f.Inverse)
+}
+
+func mapMulti(m MultiPolygon, fn func(Point) Point) MultiPolygon {
+	out := make(MultiPolygon, len(m))
+	for i, poly := range m {
+		out[i] = make(Polygon, len(poly))
+		for j, ring := range poly {
+			out[i][j] = make(Ring, len(ring))
+			for k, p := range ring {
+				out[i][j][k] = fn(p)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,373 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// OverlayOp — операция наложения двух полигональных геометрий.
+type OverlayOp int
+
+const (
+	// OpIntersection — общая часть геометрий.
+	OpIntersection OverlayOp = iota
+	// OpUnion — объединение геометрий.
+	OpUnion
+	// OpDifference — часть первой геометрии вне второй.
+	OpDifference
+	// OpSymDifference — части геометрий, не принадлежащие обеим сразу.
+	OpSymDifference
+)
+
+func (op OverlayOp) inside(a, b bool) bool {
+	switch op {
+	case OpIntersection:
+		return a && b
+	case OpUnion:
+		return a || b
+	case OpDifference:
+		return a && !b
+	default:
+		return a != b
+	}
+}
+
+// Intersection возвращает общую часть геометрий a и b.
+func Intersection(a, b MultiPolygon) MultiPolygon { return Overlay(OpIntersection, a, b) }
+
+// Union возвращает объединение геометрий a и b.
+func Union(a, b MultiPolygon) MultiPolygon { return Overlay(OpUnion, a, b) }
+
+// Difference возвращает часть геометрии a, не покрытую геометрией b.
+func Difference(a, b MultiPolygon) MultiPolygon { return Overlay(OpDifference, a, b) }
+
+// Overlay выполняет операцию наложения двух геометрий на плоскости.
+//
+// Границы обеих геометрий разбиваются во всех точках пересечения и касания,
+// после чего каждое ребро относится к результату по тому, какие геометрии
+// лежат слева и справа от него; из рёбер результата собираются кольца.
+// Вершины, совпадающие с точностью до миллиардной доли размера охвата,
+// объединяются, поэтому общие участки границ смежных участков
+// обрабатываются устойчиво.
+//
+// Географические координаты перед вызовом следует перевести в локальную
+// плоскость (LocalFrame). Результат ориентирован по правилу правой руки;
+// части, касающиеся друг друга в точке, возвращаются отдельными полигонами.
+func Overlay(op OverlayOp, a, b MultiPolygon) MultiPolygon {
+	o := newOverlay(a, b)
+	if o == nil {
+		return MultiPolygon{}
+	}
+	o.split()
+	edges := o.classify(op)
+	return o.assemble(edges)
+}
+
+type overlaySegment struct {
+	a, b   Point
+	source int
+	box    BBox
+	splits []Point
+}
+
+type overlay struct {
+	grid     float64
+	vertices map[[2]int64][]Point
+	shapes   [2]MultiPolygon
+	segments []overlaySegment
+}
+
+func newOverlay(a, b MultiPolygon) *overlay {
+	box := a.Bounds().Union(b.Bounds())
+	if box.IsEmpty() {
+		return nil
+	}
+	extent := math.Max(box.MaxX-box.MinX, box.MaxY-box.MinY)
+	magnitude := math.Max(math.Max(math.Abs(box.MinX), math.Abs(box.MaxX)), math.Max(math.Abs(box.MinY), math.Abs(box.MaxY)))
+	o := &overlay{
+		grid:     math.Max(extent*1e-9, magnitude*1e-14),
+		vertices: make(map[[2]int64][]Point),
+	}
+	if o.grid == 0 {
+		o.grid = 1e-12
+	}
+	for source, m := range [2]MultiPolygon{a, b} {
+		o.shapes[source] = o.snapShape(m.Oriented())
+		for _, poly := range o.shapes[source] {
+			for _, ring := range poly {
+				for i := range ring {
+					p, q := ring[i], ring[(i+1)%len(ring)]
+					o.segments = append(o.segments, overlaySegment{
+						a: p, b: q, source: source,
+						box:    EmptyBBox().Extend(p).Extend(q),
+						splits: []Point{p, q},
+					})
+				}
+			}
+		}
+	}
+	return o
+}
+
+// snap возвращает вершину, уже встречавшуюся на расстоянии не больше шага
+// сетки, или регистрирует p как новую. Так совпадающие с точностью до
+// допуска вершины объединяются без сдвига исходных координат.
+func (o *overlay) snap(p Point) Point {
+	cx, cy := int64(math.Floor(p.X/o.grid)), int64(math.Floor(p.Y/o.grid))
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, q := range o.vertices[[2]int64{cx + dx, cy + dy}] {
+				if q.Dist(p) <= o.grid {
+					return q
+				}
+			}
+		}
+	}
+	key := [2]int64{cx, cy}
+	o.vertices[key] = append(o.vertices[key], p)
+	return p
+}
+
+// snapShape объединяет близкие вершины и удаляет вырожденные кольца.
+func (o *overlay) snapShape(m MultiPolygon) MultiPolygon {
+	out := make(MultiPolygon, 0, len(m))
+	for _, poly := range m {
+		var snapped Polygon
+		for i, ring := range poly {
+			r := make(Ring, 0, len(ring))
+			for _, p := range ring {
+				p = o.snap(p)
+				if len(r) == 0 || r[len(r)-1] != p {
+					r = append(r, p)
+				}
+			}
+			r = r.Open()
+			if len(r) < 3 || r.SignedArea() == 0 {
+				if i == 0 {
+					break
+				}
+				continue
+			}
+			snapped = append(snapped, r)
+		}
+		if len(snapped) > 0 {
+			out = append(out, snapped)
+		}
+	}
+	return out
+}
+
+// split находит точки пересечения и касания всех пар отрезков. Кандидаты
+// отбираются проходом по отрезкам, упорядоченным по левой границе охвата.
+func (o *overlay) split() {
+	order := make([]int, len(o.segments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return o.segments[order[i]].box.MinX < o.segments[order[j]].box.MinX
+	})
+	tol := o.grid
+	for i, si := range order {
+		s := &o.segments[si]
+		for _, ti := range order[i+1:] {
+			t := &o.segments[ti]
+			if t.box.MinX > s.box.MaxX+tol {
+				break
+			}
+			if t.box.MinY > s.box.MaxY+tol || s.box.MinY > t.box.MaxY+tol {
+				continue
+			}
+			o.intersect(s, t)
+		}
+	}
+}
+
+func (o *overlay) intersect(s, t *overlaySegment) {
+	tol := o.grid
+	if rel, pt := IntersectSegments(s.a, s.b, t.a, t.b, tol); rel == SegmentsCross {
+		pt = o.snap(pt)
+		s.splits = append(s.splits, pt)
+		t.splits = append(t.splits, pt)
+	}
+	for _, p := range [2]Point{t.a, t.b} {
+		if d, _ := DistanceToSegment(p, s.a, s.b); d <= tol {
+			s.splits = append(s.splits, p)
+		}
+	}
+	for _, p := range [2]Point{s.a, s.b} {
+		if d, _ := DistanceToSegment(p, t.a, t.b); d <= tol {
+			t.splits = append(t.splits, p)
+		}
+	}
+}
+
+// overlayEdge — ребро разбиения, общее для всех отрезков, которые на нём
+// совпали. Направление ребра — от from к to; forward и backward считают
+// отрезки каждой геометрии, проходящие по ребру в этом и обратном
+// направлении.
+type overlayEdge struct {
+	from, to          Point
+	forward, backward [2]int
+}
+
+type edgeKey struct{ from, to Point }
+
+func (o *overlay) classify(op OverlayOp) []edgeKey {
+	var (
+		edges []*overlayEdge
+		index = make(map[edgeKey]*overlayEdge)
+	)
+	for _, s := range o.segments {
+		dir := s.b.Sub(s.a)
+		sort.Slice(s.splits, func(i, j int) bool {
+			return dot(s.splits[i].Sub(s.a), dir) < dot(s.splits[j].Sub(s.a), dir)
+		})
+		for i := 1; i < len(s.splits); i++ {
+			p, q := s.splits[i-1], s.splits[i]
+			if p == q {
+				continue
+			}
+			forward := true
+			if q.X < p.X || (q.X == p.X && q.Y < p.Y) {
+				p, q, forward = q, p, false
+			}
+			e, ok := index[edgeKey{p, q}]
+			if !ok {
+				e = &overlayEdge{from: p, to: q}
+				index[edgeKey{p, q}] = e
+				edges = append(edges, e)
+			}
+			if forward {
+				e.forward[s.source]++
+			} else {
+				e.backward[s.source]++
+			}
+		}
+	}
+
+	var result []edgeKey
+	for _, e := range edges {
+		var left, right [2]bool
+		for source := range o.shapes {
+			if e.forward[source]+e.backward[source] > 0 {
+				// Кольца ориентированы так, что внутренняя область лежит слева.
+				left[source] = e.forward[source] > 0
+				right[source] = e.backward[source] > 0
+				continue
+			}
+			mid := Point{X: (e.from.X + e.to.X) / 2, Y: (e.from.Y + e.to.Y) / 2}
+			in := o.shapes[source].ContainsPoint(mid)
+			left[source], right[source] = in, in
+		}
+		inLeft, inRight := op.inside(left[0], left[1]), op.inside(right[0], right[1])
+		switch {
+		case inLeft && !inRight:
+			result = append(result, edgeKey{e.from, e.to})
+		case inRight && !inLeft:
+			result = append(result, edgeKey{e.to, e.from})
+		}
+	}
+	return result
+}
+
+// assemble собирает кольца из рёбер, внутренняя область которых лежит слева.
+// В вершине с несколькими исходящими рёбрами выбирается ближайшее по часовой
+// стрелке к обратному направлению, так что кольца не пересекают друг друга.
+func (o *overlay) assemble(edges []edgeKey) MultiPolygon {
+	outgoing := make(map[Point][]int)
+	for i, e := range edges {
+		outgoing[e.from] = append(outgoing[e.from], i)
+	}
+	used := make([]bool, len(edges))
+
+	var shells, holes []Ring
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		var ring Ring
+		cur := start
+		closed := false
+		for !used[cur] {
+			used[cur] = true
+			e := edges[cur]
+			ring = append(ring, e.from)
+			if e.to == edges[start].from {
+				closed = true
+				break
+			}
+			next, best := -1, math.Inf(-1)
+			back := e.from.Sub(e.to)
+			for _, j := range outgoing[e.to] {
+				if used[j] {
+					continue
+				}
+				dir := edges[j].to.Sub(e.to)
+				angle := math.Atan2(Cross(back, dir), dot(back, dir))
+				if angle <= 0 {
+					angle += 2 * math.Pi
+				}
+				if angle > best {
+					next, best = j, angle
+				}
+			}
+			if next < 0 {
+				break
+			}
+			cur = next
+		}
+		if !closed {
+			continue
+		}
+		ring = o.simplifyRing(ring)
+		if len(ring) < 3 {
+			continue
+		}
+		if ring.IsCCW() {
+			shells = append(shells, ring)
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	result := make(MultiPolygon, len(shells))
+	for i, shell := range shells {
+		result[i] = Polygon{shell}
+	}
+	for _, hole := range holes {
+		probe := Point{X: (hole[0].X + hole[1].X) / 2, Y: (hole[0].Y + hole[1].Y) / 2}
+		owner, ownerArea := -1, math.Inf(1)
+		for i, shell := range shells {
+			if a := shell.SignedArea(); a < ownerArea && shell.ContainsPoint(probe) {
+				owner, ownerArea = i, a
+			}
+		}
+		if owner >= 0 {
+			result[owner] = append(result[owner], hole)
+		}
+	}
+	return result
+}
+
+// simplifyRing удаляет вершины, появившиеся при разбиении и лежащие на
+// прямой между соседними, а также вырожденные кольца.
+func (o *overlay) simplifyRing(r Ring) Ring {
+	for changed := true; changed && len(r) >= 3; {
+		changed = false
+		for i := 0; i < len(r) && len(r) >= 3; i++ {
+			prev, cur, next := r[(i+len(r)-1)%len(r)], r[i], r[(i+1)%len(r)]
+			if d, _ := DistanceToSegment(cur, prev, next); d <= o.grid/2 {
+				r = append(r[:i:i], r[i+1:]...)
+				changed = true
+				i--
+			}
+		}
+	}
+	if len(r) < 3 || math.Abs(r.SignedArea()) <= o.grid*r.Perimeter() {
+		return nil
+	}
+	return r
+}
+
+func dot(p, q Point) float64 { return p.X*q.X + p.Y*q.Y }
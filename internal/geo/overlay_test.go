@@ -0,0 +1,74 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func rect(minX, minY, maxX, maxY float64) Ring {
+	return Ring{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}}
+}
+
+func TestOverlayAreaIdentities(t *testing.T) {
+	square := MultiPolygon{{rect(0, 0, 10, 10)}}
+	holed := MultiPolygon{{rect(0, 0, 10, 10), rect(3, 3, 7, 7).Reverse()}}
+	tests := []struct {
+		name string
+		a, b MultiPolygon
+		// intersection — ожидаемая площадь общей части.
+		intersection float64
+	}{
+		{"частичное наложение", square, MultiPolygon{{rect(5, 5, 15, 15)}}, 25},
+		{"не пересекаются", square, MultiPolygon{{rect(20, 0, 30, 10)}}, 0},
+		{"касаются по ребру", square, MultiPolygon{{rect(10, 0, 20, 10)}}, 0},
+		{"касаются вершиной", square, MultiPolygon{{rect(10, 10, 20, 20)}}, 0},
+		{"совпадающие рёбра", square, MultiPolygon{{rect(0, 5, 10, 15)}}, 50},
+		{"вложенный", square, MultiPolygon{{rect(2, 2, 4, 6)}}, 8},
+		{"совпадают", square, square, 100},
+		{"дыра внутри второго", holed, MultiPolygon{{rect(2, 2, 8, 8)}}, 20},
+		{"второй целиком в дыре", holed, MultiPolygon{{rect(4, 4, 6, 6)}}, 0},
+		{"треугольник срезает углы", square, MultiPolygon{{Ring{{-5, 0}, {15, 0}, {5, 10}}}}, 75},
+		{"обход по часовой стрелке", square, MultiPolygon{{rect(5, -5, 15, 5).Reverse()}}, 25},
+		{
+			"многочастные",
+			MultiPolygon{{rect(0, 0, 4, 4)}, {rect(6, 0, 10, 4)}},
+			MultiPolygon{{rect(2, 2, 8, 3)}},
+			4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := tt.a.Area(), tt.b.Area()
+			check := func(op string, got, want float64) {
+				t.Helper()
+				if math.Abs(got-want) > 1e-9*math.Max(1, want) {
+					t.Errorf("%s: площадь %.12g, want %.12g", op, got, want)
+				}
+			}
+			check("A ∩ B", Intersection(tt.a, tt.b).Area(), tt.intersection)
+			check("B ∩ A", Intersection(tt.b, tt.a).Area(), tt.intersection)
+			check("A ∪ B", Union(tt.a, tt.b).Area(), a+b-tt.intersection)
+			check("B ∪ A", Union(tt.b, tt.a).Area(), a+b-tt.intersection)
+			check("A \\ B", Difference(tt.a, tt.b).Area(), a-tt.intersection)
+			check("B \\ A", Difference(tt.b, tt.a).Area(), b-tt.intersection)
+			check("A △ B", Overlay(OpSymDifference, tt.a, tt.b).Area(), a+b-2*tt.intersection)
+			check("(A \\ B) ∪ (A ∩ B)", Union(Difference(tt.a, tt.b), Intersection(tt.a, tt.b)).Area(), a)
+		})
+	}
+}
+
+func TestOverlayEmpty(t *testing.T) {
+	square := MultiPolygon{{rect(0, 0, 10, 10)}}
+	if got := Intersection(square, nil).Area(); got != 0 {
+		t.Errorf("A ∩ ∅: площадь %g", got)
+	}
+	if got := Union(nil, square).Area(); got != 100 {
+		t.Errorf("∅ ∪ A: площадь %g", got)
+	}
+	if got := Difference(square, nil).Area(); got != 100 {
+		t.Errorf("A \\ ∅: площадь %g", got)
+	}
+	if got := Difference(nil, square).Area(); got != 0 {
+		t.Errorf("∅ \\ A: площадь %g", got)
+	}
+}
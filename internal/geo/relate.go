@@ -0,0 +1,87 @@
+package geo
+
+// Ring возвращает прямоугольник кольцом против часовой стрелки.
+func (b BBox) Ring() Ring {
+	return Ring{
+		{X: b.MinX, Y: b.MinY},
+		{X: b.MaxX, Y: b.MinY},
+		{X: b.MaxX, Y: b.MaxY},
+		{X: b.MinX, Y: b.MaxY},
+	}
+}
+
+// Intersects сообщает, что геометрии имеют общие точки: пересекаются их
+// границы или одна лежит внутри другой. Касание границ считается
+// пересечением.
+func (m MultiPolygon) Intersects(o MultiPolygon) bool {
+	if !m.Bounds().Intersects(o.Bounds()) {
+		return false
+	}
+	for _, p := range m {
+		for _, q := range o {
+			if polygonsIntersect(p, q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IntersectsBBox сообщает, что геометрия имеет общие точки с прямоугольником.
+func (m MultiPolygon) IntersectsBBox(b BBox) bool {
+	if b.IsEmpty() {
+		return false
+	}
+	if b.MinX == b.MaxX && b.MinY == b.MaxY {
+		return m.ContainsPoint(Point{X: b.MinX, Y: b.MinY}) || m.touchesPoint(b.Center())
+	}
+	return m.Intersects(MultiPolygon{{b.Ring()}})
+}
+
+func polygonsIntersect(p, q Polygon) bool {
+	if len(p) == 0 || len(q) == 0 || !p.Bounds().Intersects(q.Bounds()) {
+		return false
+	}
+	for _, a := range p {
+		for _, b := range q {
+			if ringsTouch(a.Open(), b.Open()) {
+				return true
+			}
+		}
+	}
+	// Границы не пересекаются: полигоны либо не пересекаются, либо один
+	// целиком лежит внутри другого (в том числе не попадая в его дырки).
+	return p.ContainsPoint(q[0][0]) || q.ContainsPoint(p[0][0])
+}
+
+// ringsTouch проверяет, есть ли у границ колец общие точки.
+func ringsTouch(a, b Ring) bool {
+	if !a.Bounds().Intersects(b.Bounds()) {
+		return false
+	}
+	for i := range a {
+		a1, a2 := a[i], a[(i+1)%len(a)]
+		for j := range b {
+			rel, _ := IntersectSegments(a1, a2, b[j], b[(j+1)%len(b)], 0)
+			if rel != SegmentsDisjoint {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// touchesPoint сообщает, что точка лежит на границе геометрии.
+func (m MultiPolygon) touchesPoint(pt Point) bool {
+	for _, p := range m {
+		for _, r := range p {
+			r = r.Open()
+			for i := range r {
+				if d, _ := DistanceToSegment(pt, r[i], r[(i+1)%len(r)]); d == 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,524 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// RepairCode — вид исправления геометрии.
+type RepairCode string
+
+const (
+	// RepairDuplicateVertex — удалена вершина, совпадающая с соседней.
+	RepairDuplicateVertex RepairCode = "duplicate_vertex"
+	// RepairCollinearVertex — удалена вершина, лежащая на прямой между соседними.
+	RepairCollinearVertex RepairCode = "collinear_vertex"
+	// RepairSpike — удалена «шпора»: вершина, в которой граница разворачивается назад.
+	RepairSpike RepairCode = "spike"
+	// RepairUnclosedRing — кольцо замкнуто.
+	RepairUnclosedRing RepairCode = "unclosed_ring"
+	// RepairDegenerateRing — удалено кольцо нулевой площади.
+	RepairDegenerateRing RepairCode = "degenerate_ring"
+	// RepairDuplicateRing — удалено кольцо, повторяющее другое кольцо полигона.
+	RepairDuplicateRing RepairCode = "duplicate_ring"
+	// RepairSelfIntersection — самопересекающееся кольцо («бабочка») разделено
+	// в точках пересечения.
+	RepairSelfIntersection RepairCode = "self_intersection"
+	// RepairWrongWinding — изменён порядок обхода кольца.
+	RepairWrongWinding RepairCode = "wrong_winding"
+	// RepairHoleOutsideShell — удалено внутреннее кольцо, лежащее вне внешнего.
+	RepairHoleOutsideShell RepairCode = "hole_outside_shell"
+	// RepairRingsIntersect — внутреннее кольцо обрезано по внешнему или
+	// объединено с пересекающим его внутренним.
+	RepairRingsIntersect RepairCode = "rings_intersect"
+	// RepairPartsOverlap — перекрывающиеся или смежные части объединены.
+	RepairPartsOverlap RepairCode = "parts_overlap"
+	// RepairGap — устранена узкая щель между частями или внутри части.
+	RepairGap RepairCode = "gap"
+)
+
+// RepairFix описывает одно исправление геометрии.
+//
+// Part, Ring и Vertex указывают на положение во входной геометрии так же,
+// как в Violation. Для наложений и щелей между частями, которые возникают
+// при объединении частей, положение задаёт только Location.
+type RepairFix struct {
+	Code     RepairCode `json:"code"`
+	Part     int        `json:"part"`
+	Ring     int        `json:"ring"`
+	Vertex   int        `json:"vertex"`
+	Location Point      `json:"location"`
+	Message  string     `json:"message"`
+}
+
+// RepairOptions управляет исправлением геометрии.
+type RepairOptions struct {
+	// Geographic означает, что координаты заданы в градусах (долгота, широта),
+	// а Tolerance и MinArea — в метрах и квадратных метрах.
+	Geographic bool
+	// Tolerance — наибольшее отклонение, в пределах которого удаляются
+	// совпадающие и лежащие на одной прямой вершины и «шпоры» и
+	// устраняются щели.
+	Tolerance float64
+	// MinArea — площадь, меньше которой кольца и петли считаются вырожденными.
+	MinArea float64
+}
+
+// MaxRepairTolerance — наибольший допуск исправления геометрии в метрах,
+// который можно запросить при загрузке контура. При большем допуске
+// исправление начинает удалять характерные точки границы.
+const MaxRepairTolerance = 1.0
+
+// DefaultRepairOptions возвращает параметры исправления контуров в WGS84:
+// допуск 5 см и минимальная площадь 0,01 м².
+func DefaultRepairOptions() RepairOptions {
+	return RepairOptions{
+		Geographic: true,
+		Tolerance:  0.05,
+		MinArea:    0.01,
+	}
+}
+
+// Repair исправляет некорректную геометрию и возвращает её вместе с
+// перечнем выполненных исправлений.
+//
+// В каждом кольце удаляются совпадающие вершины, «шпоры» и вершины,
+// отклоняющиеся от прямой между соседними не больше допуска.
+// Самопересекающиеся кольца разделяются в точках пересечения на простые
+// петли, область кольца определяется по правилу чётности. Внутренние
+// кольца вне внешнего и повторяющиеся кольца удаляются, перекрывающиеся
+// части объединяются, а узкие щели между частями и внутри частей шириной не
+// больше допуска закрываются.
+//
+// Если исправлять нечего, возвращается исходная геометрия без изменений.
+// Геометрия с недопустимыми координатами или с числом вершин больше
+// MaxVertices не исправляется: такие ошибки сообщает Validate. Вершины, оставшиеся на месте, сохраняют исходные
+// координаты; замкнутые кольца остаются замкнутыми.
+func Repair(m MultiPolygon, opts RepairOptions) (MultiPolygon, []RepairFix) {
+	points := m.Points()
+	if len(points) > MaxVertices {
+		return m, nil
+	}
+	for _, p := range points {
+		if !p.IsFinite() || (opts.Geographic && (math.Abs(p.X) > 180 || math.Abs(p.Y) > 90)) {
+			return m, nil
+		}
+	}
+	r := &repairer{opts: opts, exact: make(map[Point]Point)}
+	if opts.Geographic && len(points) > 0 {
+		frame := FrameFor(m)
+		r.frame = &frame
+	}
+	for _, poly := range m {
+		for _, ring := range poly {
+			if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+				r.closed = true
+			}
+		}
+	}
+
+	var result MultiPolygon
+	for pi, poly := range m {
+		region := r.repairPolygon(pi, poly)
+		if len(region) == 0 {
+			continue
+		}
+		if len(result) > 0 {
+			merged := Union(result, region)
+			if overlap := Intersection(result, region).Area(); overlap > r.opts.MinArea {
+				r.add(RepairPartsOverlap, pi, 0, 0, region[0][0][0],
+					fmt.Sprintf("часть №%d перекрывается с другими частями на %.2f м²: части объединены", pi+1, overlap))
+			} else if len(merged) < len(result)+len(region) {
+				r.add(RepairPartsOverlap, pi, 0, 0, region[0][0][0],
+					fmt.Sprintf("часть №%d примыкает к другой части по общей границе: части объединены", pi+1))
+			}
+			result = merged
+		} else {
+			result = region
+		}
+	}
+	result = r.closeGaps(result)
+	if len(r.fixes) == 0 {
+		return m, nil
+	}
+	return r.output(result), r.fixes
+}
+
+type repairer struct {
+	opts   RepairOptions
+	frame  *LocalFrame
+	exact  map[Point]Point
+	closed bool
+	fixes  []RepairFix
+}
+
+// repairVertex — вершина кольца на плоскости и её номер во входных данных.
+type repairVertex struct {
+	p     Point
+	index int
+}
+
+func (r *repairer) add(code RepairCode, part, ring, vertex int, loc Point, msg string) {
+	r.fixes = append(r.fixes, RepairFix{
+		Code:     code,
+		Part:     part,
+		Ring:     ring,
+		Vertex:   vertex,
+		Location: r.unproject(loc),
+		Message:  msg,
+	})
+}
+
+func (r *repairer) project(p Point) Point {
+	if r.frame == nil {
+		return p
+	}
+	q := r.frame.Forward(p)
+	r.exact[q] = p
+	return q
+}
+
+func (r *repairer) unproject(p Point) Point {
+	if r.frame == nil {
+		return p
+	}
+	if g, ok := r.exact[p]; ok {
+		return g
+	}
+	return r.frame.Inverse(p)
+}
+
+func (r *repairer) tolerance() float64 {
+	if r.opts.Tolerance > 0 {
+		return r.opts.Tolerance
+	}
+	return 1e-9
+}
+
+// output возвращает геометрию в исходные координаты и замыкает кольца, если
+// кольца входной геометрии были замкнуты.
+func (r *repairer) output(m MultiPolygon) MultiPolygon {
+	out := make(MultiPolygon, len(m))
+	for i, poly := range m {
+		out[i] = make(Polygon, len(poly))
+		for j, ring := range poly {
+			res := make(Ring, 0, len(ring)+1)
+			for _, p := range ring {
+				res = append(res, r.unproject(p))
+			}
+			if r.closed {
+				res = append(res, res[0])
+			}
+			out[i][j] = res
+		}
+	}
+	return out
+}
+
+// repairPolygon исправляет кольца полигона и возвращает его область на
+// плоскости: область внешнего кольца за вычетом областей внутренних.
+func (r *repairer) repairPolygon(part int, poly Polygon) MultiPolygon {
+	var (
+		shell, holes MultiPolygon
+		seen         []string
+	)
+	for ri, raw := range poly {
+		cleaned, loops := r.repairRing(part, ri, raw)
+		if len(loops) == 0 {
+			if ri == 0 {
+				return nil
+			}
+			continue
+		}
+		key := ringKey(cleaned)
+		if slices.Contains(seen, key) {
+			r.add(RepairDuplicateRing, part, ri, 0, cleaned[0].p,
+				fmt.Sprintf("%s повторяет другое кольцо части №%d и удалено", ringName(ri), part+1))
+			continue
+		}
+		seen = append(seen, key)
+		if len(loops) == 1 && (ri == 0) != (loops[0].SignedArea() > 0) {
+			r.add(RepairWrongWinding, part, ri, 0, cleaned[0].p,
+				ringName(ri)+": изменён порядок обхода по правилу правой руки")
+		}
+
+		region := evenOdd(loops)
+		if ri == 0 {
+			shell = region
+			continue
+		}
+		if Intersection(region, shell).Area() <= r.opts.MinArea {
+			r.add(RepairHoleOutsideShell, part, ri, 0, cleaned[0].p,
+				fmt.Sprintf("%s лежит вне внешнего кольца и удалено", ringName(ri)))
+			continue
+		}
+		if Difference(region, shell).Area() > r.opts.MinArea {
+			r.add(RepairRingsIntersect, part, ri, 0, cleaned[0].p,
+				fmt.Sprintf("%s пересекает внешнее кольцо и обрезано по нему", ringName(ri)))
+		} else if Intersection(region, holes).Area() > r.opts.MinArea {
+			r.add(RepairRingsIntersect, part, ri, 0, cleaned[0].p,
+				fmt.Sprintf("%s пересекает другое внутреннее кольцо: кольца объединены", ringName(ri)))
+		}
+		holes = Union(holes, region)
+	}
+	if len(holes) == 0 {
+		return shell
+	}
+	return Difference(shell, holes)
+}
+
+// repairRing удаляет лишние вершины кольца и разделяет его на простые
+// петли. Возвращает очищенное кольцо и петли; для вырожденного кольца петли
+// не возвращаются.
+func (r *repairer) repairRing(part, ring int, raw Ring) ([]repairVertex, []Ring) {
+	name := ringName(ring)
+	n := len(raw)
+	if n > 1 && raw[0] == raw[n-1] {
+		n--
+	} else if r.closed && n > 0 {
+		r.add(RepairUnclosedRing, part, ring, n-1, r.project(raw[n-1]),
+			name+": последняя точка не совпадала с первой, кольцо замкнуто")
+	}
+	pts := make([]repairVertex, n)
+	for i := range pts {
+		pts[i] = repairVertex{p: r.project(raw[i]), index: i}
+	}
+
+	tol := r.tolerance()
+	kept := pts[:0]
+	for _, v := range pts {
+		if len(kept) > 0 && v.p.Dist(kept[len(kept)-1].p) <= tol {
+			r.add(RepairDuplicateVertex, part, ring, v.index, v.p,
+				fmt.Sprintf("%s: вершина №%d совпадает с предыдущей и удалена", name, v.index+1))
+			continue
+		}
+		kept = append(kept, v)
+	}
+	if last := len(kept) - 1; last > 0 && kept[last].p.Dist(kept[0].p) <= tol {
+		r.add(RepairDuplicateVertex, part, ring, kept[last].index, kept[last].p,
+			fmt.Sprintf("%s: вершина №%d совпадает с первой и удалена", name, kept[last].index+1))
+		kept = kept[:last]
+	}
+	pts = r.removeSpikes(part, ring, kept)
+
+	degenerate := func() ([]repairVertex, []Ring) {
+		loc := Point{}
+		if len(raw) > 0 {
+			loc = r.project(raw[0])
+		}
+		r.add(RepairDegenerateRing, part, ring, 0, loc,
+			name+": площадь кольца равна нулю, кольцо удалено")
+		return nil, nil
+	}
+	if len(pts) < 3 {
+		return degenerate()
+	}
+	loops := r.splitLoops(part, ring, pts)
+	if len(loops) == 0 {
+		return degenerate()
+	}
+	return pts, loops
+}
+
+// removeSpikes удаляет «шпоры» и вершины, лежащие на прямой между соседними
+// с точностью до допуска, пока такие вершины остаются.
+func (r *repairer) removeSpikes(part, ring int, pts []repairVertex) []repairVertex {
+	name := ringName(ring)
+	tol := r.tolerance()
+	for changed := true; changed && len(pts) >= 3; {
+		changed = false
+		for i := 0; i < len(pts) && len(pts) >= 3; i++ {
+			n := len(pts)
+			prev, cur, next := pts[(i+n-1)%n], pts[i], pts[(i+1)%n]
+			dPrev, _ := DistanceToSegment(prev.p, cur.p, next.p)
+			dNext, _ := DistanceToSegment(next.p, cur.p, prev.p)
+			dCur, _ := DistanceToSegment(cur.p, prev.p, next.p)
+			switch {
+			case dPrev <= tol || dNext <= tol:
+				r.add(RepairSpike, part, ring, cur.index, cur.p,
+					fmt.Sprintf("%s: в вершине №%d граница разворачивается назад, шпора удалена", name, cur.index+1))
+			case dCur <= tol:
+				r.add(RepairCollinearVertex, part, ring, cur.index, cur.p,
+					fmt.Sprintf("%s: вершина №%d лежит на прямой между соседними (отклонение %.3f м) и удалена", name, cur.index+1, dCur))
+			default:
+				continue
+			}
+			pts = append(pts[:i:i], pts[i+1:]...)
+			// После удаления шпоры соседние вершины могут совпасть.
+			if k := len(pts); k > 1 {
+				a, b := (i+k-1)%k, i%k
+				if pts[a].p.Dist(pts[b].p) <= tol {
+					pts = append(pts[:b:b], pts[b+1:]...)
+				}
+			}
+			changed = true
+			i = max(i-2, -1)
+		}
+	}
+	return pts
+}
+
+// splitLoops разбивает рёбра кольца во всех точках самопересечения и
+// самокасания и разделяет кольцо на простые петли. Петли нулевой площади
+// отбрасываются.
+func (r *repairer) splitLoops(part, ring int, pts []repairVertex) []Ring {
+	tol := r.tolerance()
+	n := len(pts)
+	splits := make([][]Point, n)
+	coords := make(Ring, n)
+	for i, v := range pts {
+		coords[i] = v.p
+	}
+	for _, pair := range edgePairs([]Ring{coords}, tol, true) {
+		i, j := pair[0].start, pair[1].start
+		if j == i+1 || (i == 0 && j == n-1) {
+			continue
+		}
+		a, b := coords[i], coords[(i+1)%n]
+		c, d := coords[j], coords[(j+1)%n]
+		rel, at := IntersectSegments(a, b, c, d, tol)
+		if rel == SegmentsDisjoint {
+			continue
+		}
+		if rel == SegmentsCross {
+			splits[i] = append(splits[i], at)
+			splits[j] = append(splits[j], at)
+		} else {
+			for _, p := range [2]Point{c, d} {
+				if dist, _ := DistanceToSegment(p, a, b); dist <= tol {
+					splits[i] = append(splits[i], p)
+					at = p
+				}
+			}
+			for _, p := range [2]Point{a, b} {
+				if dist, _ := DistanceToSegment(p, c, d); dist <= tol {
+					splits[j] = append(splits[j], p)
+					at = p
+				}
+			}
+		}
+		from, other := pts[i].index, pts[j].index
+		r.add(RepairSelfIntersection, part, ring, from, at,
+			fmt.Sprintf("%s: ребро %d–%d пересекает ребро %d–%d, кольцо разделено в точке пересечения",
+				ringName(ring), from+1, pts[(i+1)%n].index+1, other+1, pts[(j+1)%n].index+1))
+	}
+
+	var seq Ring
+	for i := 0; i < n; i++ {
+		a, b := pts[i].p, pts[(i+1)%n].p
+		seq = append(seq, a)
+		dir := b.Sub(a)
+		sort.Slice(splits[i], func(x, y int) bool {
+			return dot(splits[i][x].Sub(a), dir) < dot(splits[i][y].Sub(a), dir)
+		})
+		for _, p := range splits[i] {
+			if p != a && p != b && p != seq[len(seq)-1] {
+				seq = append(seq, p)
+			}
+		}
+	}
+
+	// Петля замыкается, как только точка встречается повторно.
+	var (
+		loops []Ring
+		stack Ring
+		pos   = make(map[Point]int)
+	)
+	emit := func(loop Ring) {
+		if len(loop) >= 3 && math.Abs(loop.SignedArea()) > r.opts.MinArea {
+			loops = append(loops, append(Ring(nil), loop...))
+		}
+	}
+	for _, p := range seq {
+		if at, ok := pos[p]; ok {
+			emit(stack[at:])
+			for _, q := range stack[at+1:] {
+				delete(pos, q)
+			}
+			stack = stack[:at+1]
+			continue
+		}
+		pos[p] = len(stack)
+		stack = append(stack, p)
+	}
+	emit(stack)
+	return loops
+}
+
+// evenOdd строит область, покрытую нечётным числом петель.
+func evenOdd(loops []Ring) MultiPolygon {
+	if len(loops) == 1 {
+		return MultiPolygon{Polygon{loops[0]}}.Oriented()
+	}
+	var region MultiPolygon
+	for _, loop := range loops {
+		region = Overlay(OpSymDifference, region, MultiPolygon{Polygon{loop}})
+	}
+	return region
+}
+
+// ringKey — ключ кольца, не зависящий от начальной вершины и направления обхода.
+func ringKey(pts []repairVertex) string {
+	sorted := make([]Point, len(pts))
+	for i, v := range pts {
+		sorted[i] = v.p
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].X != sorted[j].X {
+			return sorted[i].X < sorted[j].X
+		}
+		return sorted[i].Y < sorted[j].Y
+	})
+	return fmt.Sprint(sorted)
+}
+
+// closeGaps объединяет части, разделённые щелью не шире допуска, и удаляет
+// внутренние кольца, ширина которых не превышает допуска.
+func (r *repairer) closeGaps(m MultiPolygon) MultiPolygon {
+	tol := r.opts.Tolerance
+	if tol <= 0 {
+		return m
+	}
+	for merged := true; merged; {
+		merged = false
+	search:
+		for i := 0; i < len(m); i++ {
+			for j := i + 1; j < len(m); j++ {
+				a := MultiPolygon{m[i]}
+				snapped, moves := Snap(MultiPolygon{m[j]}, []MultiPolygon{a}, tol)
+				if len(moves) == 0 {
+					continue
+				}
+				union := Union(a, snapped)
+				if len(union) != 1 {
+					continue
+				}
+				width := 0.0
+				for _, move := range moves {
+					width = math.Max(width, move.Distance)
+				}
+				r.add(RepairGap, 0, 0, 0, moves[0].From,
+					fmt.Sprintf("щель шириной до %.3f м между частями устранена, части объединены", width))
+				m[i] = union[0]
+				m = append(m[:j], m[j+1:]...)
+				merged = true
+				break search
+			}
+		}
+	}
+	for pi, poly := range m {
+		kept := poly[:1]
+		for _, hole := range poly[1:] {
+			if width := 2 * math.Abs(hole.SignedArea()) / hole.Perimeter(); width <= tol {
+				r.add(RepairGap, 0, 0, 0, hole[0],
+					fmt.Sprintf("узкая щель шириной %.3f м внутри участка устранена", width))
+				continue
+			}
+			kept = append(kept, hole)
+		}
+		m[pi] = kept
+	}
+	return m
+}
@@ -0,0 +1,84 @@
+package geo
+
+import "math"
+
+// SegmentRelation описывает взаимное расположение двух отрезков.
+type SegmentRelation int
+
+const (
+	// SegmentsDisjoint — отрезки не имеют общих точек.
+	SegmentsDisjoint SegmentRelation = iota
+	// SegmentsTouch — отрезки касаются: общая точка лежит на конце хотя бы одного из них.
+	SegmentsTouch
+	// SegmentsCross — отрезки пересекаются во внутренней точке обоих.
+	SegmentsCross
+	// SegmentsOverlap — отрезки коллинеарны и перекрываются на ненулевой длине.
+	SegmentsOverlap
+)
+
+// IntersectSegments определяет расположение отрезков ab и cd с допуском eps
+// (в единицах координат) и возвращает характерную точку пересечения.
+// Для перекрывающихся отрезков возвращается начало общего участка.
+func IntersectSegments(a, b, c, d Point, eps float64) (SegmentRelation, Point) {
+	d1 := b.Sub(a)
+	d2 := d.Sub(c)
+	len1 := math.Hypot(d1.X, d1.Y)
+	len2 := math.Hypot(d2.X, d2.Y)
+	if len1 == 0 || len2 == 0 {
+		return SegmentsDisjoint, Point{}
+	}
+
+	distC := Cross(d1, c.Sub(a)) / len1
+	distD := Cross(d1, d.Sub(a)) / len1
+	if math.Abs(distC) <= eps && math.Abs(distD) <= eps {
+		return collinearRelation(a, d1, len1, c, d, eps)
+	}
+
+	denom := Cross(d1, d2)
+	if denom == 0 {
+		return SegmentsDisjoint, Point{}
+	}
+	ac := c.Sub(a)
+	t := Cross(ac, d2) / denom
+	u := Cross(ac, d1) / denom
+	tolT := eps / len1
+	tolU := eps / len2
+	if t < -tolT || t > 1+tolT || u < -tolU || u > 1+tolU {
+		return SegmentsDisjoint, Point{}
+	}
+	pt := a.Add(d1.Scale(t))
+	if t > tolT && t < 1-tolT && u > tolU && u < 1-tolU {
+		return SegmentsCross, pt
+	}
+	return SegmentsTouch, pt
+}
+
+func collinearRelation(a, dir Point, length float64, c, d Point, eps float64) (SegmentRelation, Point) {
+	lenSq := length * length
+	tc := (dir.X*(c.X-a.X) + dir.Y*(c.Y-a.Y)) / lenSq
+	td := (dir.X*(d.X-a.X) + dir.Y*(d.Y-a.Y)) / lenSq
+	lo := math.Max(0, math.Min(tc, td))
+	hi := math.Min(1, math.Max(tc, td))
+	tol := eps / length
+	if hi < lo-tol {
+		return SegmentsDisjoint, Point{}
+	}
+	start := a.Add(dir.Scale(lo))
+	if (hi-lo)*length <= eps {
+		return SegmentsTouch, start
+	}
+	return SegmentsOverlap, start
+}
+
+// DistanceToSegment возвращает расстояние от точки p до отрезка ab и ближайшую точку отрезка.
+func DistanceToSegment(p, a, b Point) (float64, Point) {
+	d := b.Sub(a)
+	lenSq := d.X*d.X + d.Y*d.Y
+	if lenSq == 0 {
+		return p.Dist(a), a
+	}
+	t := ((p.X-a.X)*d.X + (p.Y-a.Y)*d.Y) / lenSq
+	t = math.Max(0, math.Min(1, t))
+	closest := a.Add(d.Scale(t))
+	return p.Dist(closest), closest
+}
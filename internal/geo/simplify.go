@@ -0,0 +1,321 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// SimplifyMethod — алгоритм упрощения границы.
+type SimplifyMethod string
+
+const (
+	// SimplifyDouglasPeucker — алгоритм Дугласа — Пейкера: вершина удаляется,
+	// если отклоняется от упрощённой границы не больше допуска.
+	SimplifyDouglasPeucker SimplifyMethod = "douglas_peucker"
+	// SimplifyVisvalingam — алгоритм Висвалингам — Уайатта: удаляются вершины,
+	// образующие с соседними треугольник площадью меньше допуска.
+	SimplifyVisvalingam SimplifyMethod = "visvalingam"
+)
+
+// Simplify упрощает границы геометрии на плоскости.
+//
+// Для SimplifyDouglasPeucker tolerance — наибольшее отклонение в единицах
+// координат, для SimplifyVisvalingam — наименьшая площадь треугольника,
+// образуемого вершиной с соседними. В каждом кольце остаётся не менее трёх
+// вершин, оставшиеся вершины сохраняют исходные координаты, замкнутые кольца
+// остаются замкнутыми.
+//
+// Без preserveTopology кольца упрощаются независимо и могут начать
+// пересекать себя и друг друга. С preserveTopology вершина удаляется только
+// тогда, когда новое ребро не пересекает и не касается других рёбер, а
+// отсекаемая им область не содержит вершин других колец, поэтому корректная
+// геометрия остаётся корректной.
+func Simplify(m MultiPolygon, method SimplifyMethod, tolerance float64, preserveTopology bool) MultiPolygon {
+	s := newSimplifier(m, preserveTopology)
+	switch method {
+	case SimplifyVisvalingam:
+		s.visvalingam(tolerance)
+	default:
+		for _, r := range s.rings {
+			s.douglasPeucker(r, tolerance)
+		}
+	}
+	return s.result(m)
+}
+
+// simplifyRing — открытое кольцо и признаки оставленных вершин.
+type simplifyRing struct {
+	pts  Ring
+	keep []bool
+	kept int
+}
+
+type simplifier struct {
+	rings    []*simplifyRing
+	topology bool
+	eps      float64
+}
+
+func newSimplifier(m MultiPolygon, topology bool) *simplifier {
+	s := &simplifier{topology: topology}
+	box := m.Bounds()
+	if !box.IsEmpty() {
+		s.eps = math.Max(box.MaxX-box.MinX, box.MaxY-box.MinY) * 1e-12
+	}
+	for _, poly := range m {
+		for _, ring := range poly {
+			open := ring.Open()
+			r := &simplifyRing{pts: open, keep: make([]bool, len(open)), kept: len(open)}
+			for i := range r.keep {
+				r.keep[i] = true
+			}
+			s.rings = append(s.rings, r)
+		}
+	}
+	return s
+}
+
+// result собирает геометрию из оставленных вершин в порядке исходной.
+func (s *simplifier) result(m MultiPolygon) MultiPolygon {
+	out := make(MultiPolygon, len(m))
+	k := 0
+	for i, poly := range m {
+		out[i] = make(Polygon, len(poly))
+		for j, ring := range poly {
+			r := s.rings[k]
+			k++
+			res := make(Ring, 0, r.kept+1)
+			for v, p := range r.pts {
+				if r.keep[v] {
+					res = append(res, p)
+				}
+			}
+			if len(ring) > 1 && ring[0] == ring[len(ring)-1] && len(res) > 0 {
+				res = append(res, res[0])
+			}
+			out[i][j] = res
+		}
+	}
+	return out
+}
+
+// douglasPeucker упрощает кольцо: оно делится на два участка вершиной,
+// наиболее удалённой от первой, и каждый участок упрощается рекурсивно.
+func (s *simplifier) douglasPeucker(r *simplifyRing, tolerance float64) {
+	n := len(r.pts)
+	if n <= 3 {
+		return
+	}
+	far, farDist := 0, 0.0
+	for i, p := range r.pts {
+		if d := p.Dist(r.pts[0]); d > farDist {
+			far, farDist = i, d
+		}
+	}
+	if far == 0 {
+		return
+	}
+	s.simplifySection(r, 0, far, tolerance)
+	s.simplifySection(r, far, n, tolerance)
+}
+
+// simplifySection упрощает участок кольца от вершины i до вершины j
+// (индексы больше длины кольца отсчитываются по кругу).
+func (s *simplifier) simplifySection(r *simplifyRing, i, j int, tolerance float64) {
+	if j-i < 2 {
+		return
+	}
+	n := len(r.pts)
+	a, b := r.pts[i%n], r.pts[j%n]
+	far, farDist := -1, -1.0
+	for k := i + 1; k < j; k++ {
+		if d, _ := DistanceToSegment(r.pts[k%n], a, b); d > farDist {
+			far, farDist = k, d
+		}
+	}
+	if farDist <= tolerance && s.canReplace(r, i, j) {
+		for k := i + 1; k < j; k++ {
+			r.keep[k%n] = false
+		}
+		r.kept -= j - i - 1
+		return
+	}
+	s.simplifySection(r, i, far, tolerance)
+	s.simplifySection(r, far, j, tolerance)
+}
+
+// visvalingam последовательно удаляет вершину с наименьшей площадью
+// треугольника, пока такие площади меньше допуска.
+func (s *simplifier) visvalingam(minArea float64) {
+	type candidate struct {
+		ring          *simplifyRing
+		prev, v, next int
+		area          float64
+	}
+	for {
+		var candidates []candidate
+		for _, r := range s.rings {
+			if r.kept <= 3 {
+				continue
+			}
+			n := len(r.pts)
+			for v := range r.pts {
+				if !r.keep[v] {
+					continue
+				}
+				prev, next := r.prevKept(v), r.nextKept(v)
+				area := math.Abs(Cross(r.pts[prev].Sub(r.pts[v]), r.pts[next].Sub(r.pts[v]))) / 2
+				if area < minArea {
+					// Индексы участка идут по возрастанию, поэтому при переходе
+					// через начало кольца к ним добавляется длина кольца.
+					if prev > v {
+						prev -= n
+					}
+					if next < v {
+						next += n
+					}
+					candidates = append(candidates, candidate{ring: r, prev: prev, v: v, next: next, area: area})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].area < candidates[j].area })
+		removed := false
+		for _, c := range candidates {
+			n := len(c.ring.pts)
+			prev, next := c.prev, c.next
+			if prev < 0 {
+				prev, next = prev+n, next+n
+			}
+			if s.canReplace(c.ring, prev, next) {
+				c.ring.keep[c.v] = false
+				c.ring.kept--
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			return
+		}
+	}
+}
+
+func (r *simplifyRing) prevKept(v int) int {
+	n := len(r.pts)
+	for k := (v + n - 1) % n; k != v; k = (k + n - 1) % n {
+		if r.keep[k] {
+			return k
+		}
+	}
+	return v
+}
+
+func (r *simplifyRing) nextKept(v int) int {
+	n := len(r.pts)
+	for k := (v + 1) % n; k != v; k = (k + 1) % n {
+		if r.keep[k] {
+			return k
+		}
+	}
+	return v
+}
+
+// canReplace сообщает, можно ли заменить оставленные вершины кольца r между
+// i и j ребром ij. В кольце должно остаться не менее трёх вершин, а при
+// сохранении топологии ребро не должно пересекать и касаться других рёбер,
+// а отсекаемая область — содержать другие вершины.
+func (s *simplifier) canReplace(r *simplifyRing, i, j int) bool {
+	n := len(r.pts)
+	removed := 0
+	for k := i + 1; k < j; k++ {
+		if r.keep[k%n] {
+			removed++
+		}
+	}
+	if r.kept-removed < 3 {
+		return false
+	}
+	if !s.topology {
+		return true
+	}
+
+	a, b := r.pts[i%n], r.pts[j%n]
+	section := Ring{a}
+	inSection := make(map[int]bool)
+	for k := i + 1; k < j; k++ {
+		inSection[k%n] = true
+		if r.keep[k%n] {
+			section = append(section, r.pts[k%n])
+		}
+	}
+	section = append(section, b)
+
+	for _, other := range s.rings {
+		for v := range other.pts {
+			if !other.keep[v] {
+				continue
+			}
+			w := other.nextKept(v)
+			if other == r && (inSection[v] || inSection[w] || (v == i%n && w == j%n)) {
+				continue
+			}
+			p, q := other.pts[v], other.pts[w]
+			rel, at := IntersectSegments(a, b, p, q, s.eps)
+			switch rel {
+			case SegmentsDisjoint:
+			case SegmentsTouch:
+				// Допустимо только касание в общих концах смежных рёбер.
+				shared := other == r && ((w == i%n && at.Dist(a) <= s.eps) || (v == j%n && at.Dist(b) <= s.eps))
+				if !shared {
+					return false
+				}
+			default:
+				return false
+			}
+			if p != a && p != b && len(section) > 2 && (section.ContainsPoint(p) || onBoundary(section, p, s.eps)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// onBoundary сообщает, что точка лежит на границе кольца.
+func onBoundary(r Ring, p Point, eps float64) bool {
+	for i := range r {
+		if d, _ := DistanceToSegment(p, r[i], r[(i+1)%len(r)]); d <= eps {
+			return true
+		}
+	}
+	return false
+}
+
+// Densify добавляет в рёбра геометрии равномерно расположенные вершины так,
+// чтобы длина каждого ребра на плоскости не превышала maxSegment. Исходные
+// вершины сохраняются; замкнутые кольца остаются замкнутыми.
+func Densify(m MultiPolygon, maxSegment float64) MultiPolygon {
+	out := make(MultiPolygon, len(m))
+	for i, poly := range m {
+		out[i] = make(Polygon, len(poly))
+		for j, ring := range poly {
+			closed := len(ring) > 1 && ring[0] == ring[len(ring)-1]
+			open := ring.Open()
+			res := make(Ring, 0, len(open))
+			// Ребро от последней вершины к первой уплотняется и у колец,
+			// замкнутых неявно.
+			for k, a := range open {
+				res = append(res, a)
+				b := open[(k+1)%len(open)]
+				if steps := int(math.Ceil(a.Dist(b) / maxSegment)); maxSegment > 0 && steps > 1 {
+					for t := 1; t < steps; t++ {
+						res = append(res, a.Add(b.Sub(a).Scale(float64(t)/float64(steps))))
+					}
+				}
+			}
+			if closed {
+				res = append(res, res[0])
+			}
+			out[i][j] = res
+		}
+	}
+	return out
+}
@@ -0,0 +1,175 @@
+package geo
+
+import "sort"
+
+// SnapKind — способ, которым геометрия приведена к соседней границе.
+type SnapKind string
+
+const (
+	// SnapToVertex — вершина перенесена в вершину соседней границы.
+	SnapToVertex SnapKind = "vertex"
+	// SnapToEdge — вершина перенесена на ребро соседней границы.
+	SnapToEdge SnapKind = "edge"
+	// SnapInserted — вершина соседней границы добавлена в ребро геометрии.
+	SnapInserted SnapKind = "inserted"
+)
+
+// SnapMove описывает одно изменение геометрии при привязке.
+//
+// Part, Ring и Vertex указывают на вершину исходной геометрии, для
+// SnapInserted — на начало ребра, в которое добавлена точка. Target — номер
+// объекта в списке, к границе которого выполнена привязка.
+type SnapMove struct {
+	Part, Ring, Vertex int
+	Kind               SnapKind
+	From, To           Point
+	Distance           float64
+	Target             int
+}
+
+type snapVertex struct {
+	p      Point
+	target int
+}
+
+type snapSegment struct {
+	a, b   Point
+	target int
+}
+
+// Snap притягивает геометрию m к границам объектов targets в пределах
+// допуска tolerance.
+//
+// Сначала каждая вершина переносится в ближайшую вершину соседних границ, а
+// если такой нет — в ближайшую точку их рёбер. Затем в рёбра m добавляются
+// вершины соседних границ, лежащие от ребра не дальше допуска, чтобы общий
+// участок границы совпал целиком, без щелей и узких наложений. Координаты
+// задаются на плоскости (LocalFrame), допуск — в тех же единицах. Замкнутые
+// кольца остаются замкнутыми, вершины, совпавшие после переноса, объединяются.
+func Snap(m MultiPolygon, targets []MultiPolygon, tolerance float64) (MultiPolygon, []SnapMove) {
+	var (
+		vertices []snapVertex
+		segments []snapSegment
+	)
+	for t, shape := range targets {
+		for _, poly := range shape {
+			for _, ring := range poly {
+				r := ring.Open()
+				for i, p := range r {
+					vertices = append(vertices, snapVertex{p: p, target: t})
+					if q := r[(i+1)%len(r)]; q != p {
+						segments = append(segments, snapSegment{a: p, b: q, target: t})
+					}
+				}
+			}
+		}
+	}
+
+	var moves []SnapMove
+	out := make(MultiPolygon, len(m))
+	for pi, poly := range m {
+		out[pi] = make(Polygon, len(poly))
+		for ri, ring := range poly {
+			closed := len(ring) > 1 && ring[0] == ring[len(ring)-1]
+			open := ring
+			if closed {
+				open = ring[:len(ring)-1]
+			}
+			snapped := make(Ring, len(open))
+			for vi, p := range open {
+				snapped[vi] = p
+				move, ok := snapPoint(p, vertices, segments, tolerance)
+				if !ok {
+					continue
+				}
+				move.Part, move.Ring, move.Vertex = pi, ri, vi
+				snapped[vi] = move.To
+				moves = append(moves, move)
+			}
+
+			result := make(Ring, 0, len(snapped))
+			for vi, a := range snapped {
+				result = append(result, a)
+				b := snapped[(vi+1)%len(snapped)]
+				for _, v := range edgeInsertions(a, b, vertices, tolerance) {
+					d, _ := DistanceToSegment(v.p, a, b)
+					result = append(result, v.p)
+					moves = append(moves, SnapMove{
+						Part: pi, Ring: ri, Vertex: vi,
+						Kind: SnapInserted, From: v.p, To: v.p,
+						Distance: d, Target: v.target,
+					})
+				}
+			}
+			result = dedupeRing(result)
+			if closed && len(result) > 0 {
+				result = append(result, result[0])
+			}
+			out[pi][ri] = result
+		}
+	}
+	return out, moves
+}
+
+// snapPoint находит, куда перенести вершину p: в ближайшую вершину соседних
+// границ или, если её нет в пределах допуска, в ближайшую точку их рёбер.
+// Вершина, уже лежащая на границе, не переносится.
+func snapPoint(p Point, vertices []snapVertex, segments []snapSegment, tolerance float64) (SnapMove, bool) {
+	var (
+		best  SnapMove
+		found bool
+	)
+	closer := func(d float64) bool { return d <= tolerance && (!found || d < best.Distance) }
+	for _, v := range vertices {
+		if d := p.Dist(v.p); closer(d) {
+			best, found = SnapMove{Kind: SnapToVertex, From: p, To: v.p, Distance: d, Target: v.target}, true
+		}
+	}
+	if !found {
+		for _, s := range segments {
+			if d, c := DistanceToSegment(p, s.a, s.b); closer(d) {
+				best, found = SnapMove{Kind: SnapToEdge, From: p, To: c, Distance: d, Target: s.target}, true
+			}
+		}
+	}
+	if !found || best.Distance == 0 {
+		return SnapMove{}, false
+	}
+	return best, true
+}
+
+// edgeInsertions возвращает вершины соседних границ, лежащие у ребра ab
+// в пределах допуска, в порядке следования от a к b. Вершины у концов
+// ребра пропускаются: к ним уже притянуты сами концы.
+func edgeInsertions(a, b Point, vertices []snapVertex, tolerance float64) []snapVertex {
+	dir := b.Sub(a)
+	seen := make(map[Point]bool)
+	var found []snapVertex
+	for _, v := range vertices {
+		if seen[v.p] || v.p.Dist(a) <= tolerance || v.p.Dist(b) <= tolerance {
+			continue
+		}
+		if d, _ := DistanceToSegment(v.p, a, b); d <= tolerance {
+			seen[v.p] = true
+			found = append(found, v)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return dot(found[i].p.Sub(a), dir) < dot(found[j].p.Sub(a), dir)
+	})
+	return found
+}
+
+// dedupeRing удаляет совпадающие соседние вершины открытого кольца.
+func dedupeRing(r Ring) Ring {
+	out := r[:0]
+	for _, p := range r {
+		if len(out) == 0 || out[len(out)-1] != p {
+			out = append(out, p)
+		}
+	}
+	for len(out) > 1 && out[0] == out[len(out)-1] {
+		out = out[:len(out)-1]
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package geo
+
+import "math"
+
+const deg = math.Pi / 180
+
+// Krueger — ряды Крюгера шестого порядка для поперечно-цилиндрической
+// проекции Меркатора на эллипсоиде вращения. Ряды обеспечивают
+// миллиметровую точность в пределах ±4° от осевого меридиана; на них
+// построены LocalFrame и проекции Гаусса–Крюгера пакета crs.
+type Krueger struct {
+	a     float64
+	e     float64
+	alpha [6]float64
+	beta  [6]float64
+	delta [6]float64
+}
+
+// NewKrueger вычисляет коэффициенты рядов для эллипсоида с большой полуосью
+// a (метры) и сжатием f.
+func NewKrueger(a, f float64) Krueger {
+	n := f / (2 - f)
+	n2, n3 := n*n, n*n*n
+	n4, n5, n6 := n2*n2, n2*n3, n3*n3
+	return Krueger{
+		a: a / (1 + n) * (1 + n2/4 + n4/64 + n6/256),
+		e: math.Sqrt(f * (2 - f)),
+		alpha: [6]float64{
+			n/2 - 2*n2/3 + 5*n3/16 + 41*n4/180 - 127*n5/288 + 7891*n6/37800,
+			13*n2/48 - 3*n3/5 + 557*n4/1440 + 281*n5/630 - 1983433*n6/1935360,
+			61*n3/240 - 103*n4/140 + 15061*n5/26880 + 167603*n6/181440,
+			49561*n4/161280 - 179*n5/168 + 6601661*n6/7257600,
+			34729*n5/80640 - 3418889*n6/1995840,
+			212378941 * n6 / 319334400,
+		},
+		beta: [6]float64{
+			n/2 - 2*n2/3 + 37*n3/96 - n4/360 - 81*n5/512 + 96199*n6/604800,
+			n2/48 + n3/15 - 437*n4/1440 + 46*n5/105 - 1118711*n6/3870720,
+			17*n3/480 - 37*n4/840 - 209*n5/4480 + 5569*n6/90720,
+			4397*n4/161280 - 11*n5/504 - 830251*n6/7257600,
+			4583*n5/161280 - 108847*n6/3991680,
+			20648693 * n6 / 638668800,
+		},
+		delta: [6]float64{
+			2*n - 2*n2/3 - 2*n3 + 116*n4/45 + 26*n5/45 - 2854*n6/675,
+			7*n2/3 - 8*n3/5 - 227*n4/45 + 2704*n5/315 + 2323*n6/945,
+			56*n3/15 - 136*n4/35 - 1262*n5/105 + 73814*n6/2835,
+			4279*n4/630 - 332*n5/35 - 399572*n6/14175,
+			4174*n5/315 - 144838*n6/6237,
+			601676 * n6 / 22275,
+		},
+	}
+}
+
+// Forward переводит широту и разность долгот с осевым меридианом (градусы)
+// в прямоугольные координаты проекции с масштабом 1 на осевом меридиане:
+// x — удаление от осевого меридиана, y — от экватора, в метрах.
+func (k Krueger) Forward(lat, dLon float64) (x, y float64) {
+	sinLat := math.Sin(lat * deg)
+	lambda := normalizeRad(dLon * deg)
+	t := math.Sinh(math.Atanh(sinLat) - k.e*math.Atanh(k.e*sinLat))
+	xiP := math.Atan2(t, math.Cos(lambda))
+	etaP := math.Atanh(math.Sin(lambda) / math.Sqrt(1+t*t))
+	xi, eta := xiP, etaP
+	for j := range k.alpha {
+		m := float64(2 * (j + 1))
+		xi += k.alpha[j] * math.Sin(m*xiP) * math.Cosh(m*etaP)
+		eta += k.alpha[j] * math.Cos(m*xiP) * math.Sinh(m*etaP)
+	}
+	return k.a * eta, k.a * xi
+}
+
+// Inverse выполняет обратное преобразование: возвращает широту и разность
+// долгот с осевым меридианом в градусах.
+func (k Krueger) Inverse(x, y float64) (lat, dLon float64) {
+	xi, eta := y/k.a, x/k.a
+	xiP, etaP := xi, eta
+	for j := range k.beta {
+		m := float64(2 * (j + 1))
+		xiP -= k.beta[j] * math.Sin(m*xi) * math.Cosh(m*eta)
+		etaP -= k.beta[j] * math.Cos(m*xi) * math.Sinh(m*eta)
+	}
+	chi := math.Asin(math.Sin(xiP) / math.Cosh(etaP))
+	phi := chi
+	for j := range k.delta {
+		phi += k.delta[j] * math.Sin(float64(2*(j+1))*chi)
+	}
+	return phi / deg, math.Atan2(math.Sinh(etaP), math.Cos(xiP)) / deg
+}
+
+// normalizeRad приводит угол к диапазону (-π, π].
+func normalizeRad(a float64) float64 {
+	for a > math.Pi {
+		a -= 2 * math.Pi
+	}
+	for a <= -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
@@ -0,0 +1,542 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MaxVertices — наибольшее число вершин геометрии, принимаемой к проверке.
+// Контуры участков содержат единицы и сотни вершин; ограничение отсекает
+// заведомо ошибочные данные до дорогостоящих топологических проверок.
+const MaxVertices = 20000
+
+// ViolationCode — машиночитаемый код нарушения топологии.
+type ViolationCode string
+
+const (
+	// ViolationTooFewVertices — в кольце меньше трёх различных вершин.
+	ViolationTooFewVertices ViolationCode = "too_few_vertices"
+	// ViolationTooManyVertices — в геометрии больше MaxVertices вершин.
+	ViolationTooManyVertices ViolationCode = "too_many_vertices"
+	// ViolationInvalidCoordinate — координата не является конечным числом или вне допустимого диапазона.
+	ViolationInvalidCoordinate ViolationCode = "invalid_coordinate"
+	// ViolationUnclosedRing — последняя точка кольца не совпадает с первой.
+	ViolationUnclosedRing ViolationCode = "unclosed_ring"
+	// ViolationDuplicateVertex — две последовательные вершины совпадают.
+	ViolationDuplicateVertex ViolationCode = "duplicate_vertex"
+	// ViolationZeroArea — кольцо вырождено в линию или точку.
+	ViolationZeroArea ViolationCode = "zero_area"
+	// ViolationSelfIntersection — рёбра кольца пересекаются или образуют «шпору».
+	ViolationSelfIntersection ViolationCode = "self_intersection"
+	// ViolationWrongWinding — порядок обхода кольца не соответствует правилу правой руки.
+	ViolationWrongWinding ViolationCode = "wrong_winding"
+	// ViolationRingsIntersect — кольца одного полигона пересекают друг друга.
+	ViolationRingsIntersect ViolationCode = "rings_intersect"
+	// ViolationHoleOutsideShell — внутреннее кольцо лежит вне внешнего.
+	ViolationHoleOutsideShell ViolationCode = "hole_outside_shell"
+	// ViolationPartsOverlap — части многоконтурной геометрии перекрываются.
+	ViolationPartsOverlap ViolationCode = "parts_overlap"
+)
+
+// Severity определяет, блокирует ли нарушение сохранение геометрии.
+type Severity string
+
+const (
+	// SeverityError — геометрия не может быть сохранена.
+	SeverityError Severity = "error"
+	// SeverityWarning — нарушение исправляется автоматически или допустимо.
+	SeverityWarning Severity = "warning"
+)
+
+// Violation описывает отдельное нарушение с привязкой к вершине.
+//
+// Индексы Part, Ring и Vertex указывают на положение во входных данных
+// (с учётом замыкающей точки, если она была передана), чтобы клиент мог
+// подсветить конкретную вершину на карте.
+type Violation struct {
+	Code     ViolationCode `json:"code"`
+	Severity Severity      `json:"severity"`
+	Part     int           `json:"part"`
+	Ring     int           `json:"ring"`
+	Vertex   int           `json:"vertex"`
+	Location Point         `json:"location"`
+	Message  string        `json:"message"`
+}
+
+// ValidationOptions управляет строгостью проверки.
+type ValidationOptions struct {
+	// Geographic означает, что координаты заданы в градусах (долгота, широта),
+	// а допуски Tolerance и MinArea — в метрах и квадратных метрах.
+	Geographic bool
+	// RequireClosed требует явного замыкания колец, как в GeoJSON.
+	RequireClosed bool
+	// Tolerance — расстояние, при котором вершины считаются совпадающими.
+	Tolerance float64
+	// MinArea — минимальная площадь кольца; меньшие кольца считаются вырожденными.
+	MinArea float64
+}
+
+// DefaultValidationOptions возвращает параметры проверки контуров в WGS84:
+// совпадение вершин до миллиметра и минимальная площадь 0,01 м².
+func DefaultValidationOptions() ValidationOptions {
+	return ValidationOptions{
+		Geographic: true,
+		Tolerance:  0.001,
+		MinArea:    0.01,
+	}
+}
+
+// ValidationError возвращается, если среди нарушений есть блокирующие.
+type ValidationError struct {
+	Violations []Violation `json:"violations"`
+}
+
+// Error реализует интерфейс error.
+func (e *ValidationError) Error() string {
+	errs := 0
+	first := ""
+	for _, v := range e.Violations {
+		if v.Severity == SeverityError {
+			if errs == 0 {
+				first = v.Message
+			}
+			errs++
+		}
+	}
+	if errs == 1 {
+		return "геометрия контура некорректна: " + first
+	}
+	return fmt.Sprintf("геометрия контура некорректна: %s (всего ошибок: %d)", first, errs)
+}
+
+// HasErrors сообщает, что среди нарушений есть блокирующие.
+func HasErrors(violations []Violation) bool {
+	for _, v := range violations {
+		if v.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnings возвращает только неблокирующие нарушения.
+func Warnings(violations []Violation) []Violation {
+	var out []Violation
+	for _, v := range violations {
+		if v.Severity == SeverityWarning {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Check проверяет геометрию и возвращает все найденные нарушения, а также
+// *ValidationError, если хотя бы одно из них блокирующее.
+func Check(m MultiPolygon, opts ValidationOptions) ([]Violation, error) {
+	violations := Validate(m, opts)
+	if HasErrors(violations) {
+		return violations, &ValidationError{Violations: violations}
+	}
+	return violations, nil
+}
+
+// Validate выполняет топологическую проверку геометрии.
+func Validate(m MultiPolygon, opts ValidationOptions) []Violation {
+	v := &validator{opts: opts, out: make([]Violation, 0)}
+	points := m.Points()
+	if len(points) > MaxVertices {
+		v.add(ViolationTooManyVertices, SeverityError, 0, 0, 0, Point{},
+			fmt.Sprintf("число вершин геометрии (%d) превышает допустимое (%d)", len(points), MaxVertices))
+		return v.out
+	}
+	if opts.Geographic {
+		b := EmptyBBox()
+		for _, p := range points {
+			if p.IsFinite() {
+				b = b.Extend(p)
+			}
+		}
+		if !b.IsEmpty() {
+			frame := NewLocalFrame(b.Center())
+			v.frame = &frame
+		}
+	}
+
+	parts := make([][]cleanRing, len(m))
+	for pi, poly := range m {
+		if len(poly) == 0 {
+			v.add(ViolationTooFewVertices, SeverityError, pi, 0, 0, Point{}, "часть геометрии не содержит колец")
+			continue
+		}
+		rings := make([]cleanRing, len(poly))
+		for ri, ring := range poly {
+			rings[ri] = v.checkRing(pi, ri, ring)
+		}
+		v.checkRings(pi, rings)
+		parts[pi] = rings
+	}
+	v.checkParts(parts)
+	return v.out
+}
+
+// cleanRing — кольцо в метрической плоскости без дубликатов с отображением на входные индексы.
+type cleanRing struct {
+	pts   Ring
+	index []int
+	ok    bool
+}
+
+type validator struct {
+	opts  ValidationOptions
+	frame *LocalFrame
+	out   []Violation
+}
+
+func (v *validator) add(code ViolationCode, severity Severity, part, ring, vertex int, loc Point, msg string) {
+	v.out = append(v.out, Violation{
+		Code:     code,
+		Severity: severity,
+		Part:     part,
+		Ring:     ring,
+		Vertex:   vertex,
+		Location: loc,
+		Message:  msg,
+	})
+}
+
+func (v *validator) project(p Point) Point {
+	if v.frame == nil {
+		return p
+	}
+	return v.frame.Forward(p)
+}
+
+func (v *validator) unproject(p Point) Point {
+	if v.frame == nil {
+		return p
+	}
+	return v.frame.Inverse(p)
+}
+
+func (v *validator) tolerance() float64 {
+	if v.opts.Tolerance > 0 {
+		return v.opts.Tolerance
+	}
+	return 1e-9
+}
+
+func ringName(ring int) string {
+	if ring == 0 {
+		return "внешнее кольцо"
+	}
+	return fmt.Sprintf("внутреннее кольцо №%d", ring)
+}
+
+func (v *validator) checkRing(part, ring int, raw Ring) cleanRing {
+	name := ringName(ring)
+	if len(raw) == 0 {
+		v.add(ViolationTooFewVertices, SeverityError, part, ring, 0, Point{}, name+" не содержит вершин")
+		return cleanRing{}
+	}
+
+	n := len(raw)
+	closed := n > 1 && raw[0] == raw[n-1]
+	if closed {
+		n--
+	} else if v.opts.RequireClosed {
+		v.add(ViolationUnclosedRing, SeverityError, part, ring, n-1, raw[n-1],
+			name+": последняя точка должна совпадать с первой")
+	}
+
+	bad := false
+	for i := 0; i < n; i++ {
+		p := raw[i]
+		valid := p.IsFinite()
+		if valid && v.opts.Geographic {
+			valid = math.Abs(p.X) <= 180 && math.Abs(p.Y) <= 90
+		}
+		if !valid {
+			v.add(ViolationInvalidCoordinate, SeverityError, part, ring, i, p,
+				fmt.Sprintf("%s: вершина №%d имеет недопустимые координаты", name, i+1))
+			bad = true
+		}
+	}
+	if bad {
+		return cleanRing{}
+	}
+
+	tol := v.tolerance()
+	cr := cleanRing{}
+	for i := 0; i < n; i++ {
+		p := v.project(raw[i])
+		if last := len(cr.pts) - 1; last >= 0 && p.Dist(cr.pts[last]) <= tol {
+			v.add(ViolationDuplicateVertex, SeverityError, part, ring, i, raw[i],
+				fmt.Sprintf("%s: вершина №%d совпадает с предыдущей", name, i+1))
+			continue
+		}
+		cr.pts = append(cr.pts, p)
+		cr.index = append(cr.index, i)
+	}
+	if last := len(cr.pts) - 1; last > 0 && cr.pts[0].Dist(cr.pts[last]) <= tol {
+		v.add(ViolationDuplicateVertex, SeverityError, part, ring, cr.index[last], raw[cr.index[last]],
+			fmt.Sprintf("%s: вершина №%d совпадает с первой", name, cr.index[last]+1))
+		cr.pts = cr.pts[:last]
+		cr.index = cr.index[:last]
+	}
+
+	if len(cr.pts) < 3 {
+		v.add(ViolationTooFewVertices, SeverityError, part, ring, 0, raw[0],
+			name+": для построения кольца необходимо минимум 3 различные вершины")
+		return cr
+	}
+
+	zeroArea := func() {
+		v.add(ViolationZeroArea, SeverityError, part, ring, 0, raw[0],
+			name+": площадь кольца равна нулю, вершины лежат на одной линии")
+	}
+	if collinear(cr.pts, tol) {
+		zeroArea()
+		return cr
+	}
+	if !v.checkSelfIntersections(part, ring, raw, cr) {
+		return cr
+	}
+
+	area := cr.pts.SignedArea()
+	if math.Abs(area) <= v.opts.MinArea {
+		zeroArea()
+		return cr
+	}
+
+	if ring == 0 && area < 0 {
+		v.add(ViolationWrongWinding, SeverityWarning, part, ring, 0, raw[0],
+			name+" обходится по часовой стрелке, ожидается обход против часовой")
+	}
+	if ring > 0 && area > 0 {
+		v.add(ViolationWrongWinding, SeverityWarning, part, ring, 0, raw[0],
+			name+" обходится против часовой стрелки, ожидается обход по часовой")
+	}
+
+	cr.ok = true
+	return cr
+}
+
+// collinear сообщает, что все вершины лежат на одной прямой с точностью tol.
+func collinear(pts Ring, tol float64) bool {
+	far, farDist := 0, 0.0
+	for i, p := range pts {
+		if d := p.Dist(pts[0]); d > farDist {
+			far, farDist = i, d
+		}
+	}
+	if farDist <= tol {
+		return true
+	}
+	axis := pts[far].Sub(pts[0])
+	for _, p := range pts {
+		if math.Abs(Cross(axis, p.Sub(pts[0])))/farDist > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSelfIntersections ищет пересечения несмежных рёбер и «шпоры» смежных.
+func (v *validator) checkSelfIntersections(part, ring int, raw Ring, cr cleanRing) bool {
+	name := ringName(ring)
+	tol := v.tolerance()
+	pts := cr.pts
+	n := len(pts)
+	ok := true
+	for _, pair := range edgePairs([]Ring{pts}, tol, true) {
+		i, j := pair[0].start, pair[1].start
+		a, b := pts[i], pts[(i+1)%n]
+		c, d := pts[j], pts[(j+1)%n]
+		adjacent := j == i+1 || (i == 0 && j == n-1)
+		rel, at := IntersectSegments(a, b, c, d, tol)
+		if adjacent {
+			if rel != SegmentsOverlap {
+				continue
+			}
+			shared := j
+			if i == 0 && j == n-1 {
+				shared = 0
+			}
+			idx := cr.index[shared]
+			v.add(ViolationSelfIntersection, SeverityError, part, ring, idx, raw[idx],
+				fmt.Sprintf("%s: в вершине №%d граница разворачивается назад (шпора)", name, idx+1))
+			ok = false
+			continue
+		}
+		if rel == SegmentsDisjoint {
+			continue
+		}
+		from, to := cr.index[i], cr.index[(i+1)%n]
+		other, otherTo := cr.index[j], cr.index[(j+1)%n]
+		v.add(ViolationSelfIntersection, SeverityError, part, ring, from, v.unproject(at),
+			fmt.Sprintf("%s: ребро %d–%d пересекает ребро %d–%d", name, from+1, to+1, other+1, otherTo+1))
+		ok = false
+	}
+	return ok
+}
+
+// checkRings проверяет взаимное расположение внешнего кольца и дырок.
+func (v *validator) checkRings(part int, rings []cleanRing) {
+	if len(rings) < 2 || !rings[0].ok {
+		return
+	}
+	tol := v.tolerance()
+	for ri := 1; ri < len(rings); ri++ {
+		hole := rings[ri]
+		if !hole.ok {
+			continue
+		}
+		crossed := false
+		for rj := 0; rj < ri; rj++ {
+			if !rings[rj].ok {
+				continue
+			}
+			if idx, at, found := ringsCross(hole, rings[rj], tol); found {
+				v.add(ViolationRingsIntersect, SeverityError, part, ri, idx, v.unproject(at),
+					fmt.Sprintf("%s пересекает %s", ringName(ri), ringName(rj)))
+				crossed = true
+			}
+		}
+		if crossed {
+			continue
+		}
+		probe, idx := interiorProbe(hole, rings[0], tol)
+		if !rings[0].pts.ContainsPoint(probe) {
+			v.add(ViolationHoleOutsideShell, SeverityError, part, ri, idx, v.unproject(hole.pts[0]),
+				fmt.Sprintf("%s лежит вне внешнего кольца", ringName(ri)))
+		}
+	}
+}
+
+// checkParts убеждается, что части многоконтурной геометрии не перекрываются.
+func (v *validator) checkParts(parts [][]cleanRing) {
+	tol := v.tolerance()
+	for pi := 0; pi < len(parts); pi++ {
+		if len(parts[pi]) == 0 || !parts[pi][0].ok {
+			continue
+		}
+		for pj := pi + 1; pj < len(parts); pj++ {
+			if len(parts[pj]) == 0 || !parts[pj][0].ok {
+				continue
+			}
+			a, b := parts[pi][0], parts[pj][0]
+			if idx, at, found := ringsCross(b, a, tol); found {
+				v.add(ViolationPartsOverlap, SeverityError, pj, 0, idx, v.unproject(at),
+					fmt.Sprintf("часть №%d пересекает часть №%d", pj+1, pi+1))
+				continue
+			}
+			probeB, idxB := interiorProbe(b, a, tol)
+			probeA, _ := interiorProbe(a, b, tol)
+			if polygonContains(parts[pi], probeB) || polygonContains(parts[pj], probeA) {
+				v.add(ViolationPartsOverlap, SeverityError, pj, 0, idxB, v.unproject(b.pts[0]),
+					fmt.Sprintf("часть №%d перекрывается с частью №%d", pj+1, pi+1))
+			}
+		}
+	}
+}
+
+// ringsCross ищет пересечение или наложение рёбер двух колец. Касание в точке допустимо.
+func ringsCross(a, b cleanRing, tol float64) (int, Point, bool) {
+	na, nb := len(a.pts), len(b.pts)
+	for _, pair := range edgePairs([]Ring{a.pts, b.pts}, tol, false) {
+		i, j := pair[0].start, pair[1].start
+		rel, at := IntersectSegments(a.pts[i], a.pts[(i+1)%na], b.pts[j], b.pts[(j+1)%nb], tol)
+		if rel == SegmentsCross || rel == SegmentsOverlap {
+			return a.index[i], at, true
+		}
+	}
+	return 0, Point{}, false
+}
+
+// edgeRef ссылается на ребро кольца ring, начинающееся в вершине start.
+type edgeRef struct {
+	ring, start int
+	box         BBox
+}
+
+// edgePairs отбирает пары рёбер замкнутых колец, охваты которых сближаются не
+// более чем на tol; пары рёбер одного кольца включаются, только если
+// sameRing. Кандидаты находятся проходом по рёбрам, упорядоченным по левой
+// границе охвата, вместо перебора всех пар. Пары возвращаются в порядке
+// вложенных циклов по рёбрам, чтобы нарушения перечислялись от первой
+// вершины к последней.
+func edgePairs(rings []Ring, tol float64, sameRing bool) [][2]edgeRef {
+	var edges []edgeRef
+	for ri, r := range rings {
+		for i := range r {
+			box := EmptyBBox().Extend(r[i]).Extend(r[(i+1)%len(r)])
+			edges = append(edges, edgeRef{ring: ri, start: i, box: box})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].box.MinX < edges[j].box.MinX })
+
+	var pairs [][2]edgeRef
+	for i, s := range edges {
+		for _, t := range edges[i+1:] {
+			if t.box.MinX > s.box.MaxX+tol {
+				break
+			}
+			if t.box.MinY > s.box.MaxY+tol || s.box.MinY > t.box.MaxY+tol {
+				continue
+			}
+			if s.ring == t.ring && !sameRing {
+				continue
+			}
+			if t.ring < s.ring || (t.ring == s.ring && t.start < s.start) {
+				pairs = append(pairs, [2]edgeRef{t, s})
+			} else {
+				pairs = append(pairs, [2]edgeRef{s, t})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i], pairs[j]
+		if a[0].ring != b[0].ring {
+			return a[0].ring < b[0].ring
+		}
+		if a[0].start != b[0].start {
+			return a[0].start < b[0].start
+		}
+		if a[1].ring != b[1].ring {
+			return a[1].ring < b[1].ring
+		}
+		return a[1].start < b[1].start
+	})
+	return pairs
+}
+
+// interiorProbe выбирает вершину кольца ring, не лежащую на границе other,
+// чтобы надёжно проверить вложенность колец.
+func interiorProbe(ring, other cleanRing, tol float64) (Point, int) {
+	n := len(other.pts)
+	for k, p := range ring.pts {
+		onBoundary := false
+		for j := 0; j < n; j++ {
+			if d, _ := DistanceToSegment(p, other.pts[j], other.pts[(j+1)%n]); d <= tol {
+				onBoundary = true
+				break
+			}
+		}
+		if !onBoundary {
+			return p, ring.index[k]
+		}
+	}
+	// Все вершины лежат на границе: берём середину первого ребра.
+	return ring.pts[0].Add(ring.pts[1]).Scale(0.5), ring.index[0]
+}
+
+func polygonContains(rings []cleanRing, p Point) bool {
+	poly := make(Polygon, 0, len(rings))
+	for _, r := range rings {
+		if r.ok {
+			poly = append(poly, r.pts)
+		}
+	}
+	return poly.ContainsPoint(p)
+}
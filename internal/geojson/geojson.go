@@ -0,0 +1,330 @@
+// Package geojson разбирает и формирует полигональную геометрию в формате
+// GeoJSON (RFC 7946).
+//
+// Поддерживаются объекты Feature, FeatureCollection, Polygon и MultiPolygon.
+// Разбор проверяет структуру документа и диапазоны координат, правила колец и
+// топология проверяются через Validate, а NewGeometry приводит геометрию к
+// каноническому виду: правило правой руки и вычисленный bbox.
+package geojson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// ErrInvalid — базовая ошибка для всех нарушений структуры GeoJSON.
+var ErrInvalid = errors.New("некорректный GeoJSON")
+
+// Error указывает путь к элементу документа, который не удалось разобрать.
+type Error struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Error реализует интерфейс error.
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%v: %s", ErrInvalid, e.Message)
+	}
+	return fmt.Sprintf("%v: %s: %s", ErrInvalid, e.Path, e.Message)
+}
+
+// Unwrap позволяет сравнивать ошибку с ErrInvalid через errors.Is.
+func (e *Error) Unwrap() error { return ErrInvalid }
+
+// Типы объектов GeoJSON, поддерживаемые пакетом.
+const (
+	TypePolygon           = "Polygon"
+	TypeMultiPolygon      = "MultiPolygon"
+	TypeFeature           = "Feature"
+	TypeFeatureCollection = "FeatureCollection"
+)
+
+// Options управляет разбором документа.
+type Options struct {
+	// Projected отключает проверку диапазонов долготы и широты для геометрий,
+	// заданных в прямоугольной системе координат (метры).
+	Projected bool
+}
+
+// Geometry — типизированная полигональная геометрия.
+type Geometry struct {
+	Type  string
+	Shape geo.MultiPolygon
+}
+
+// NewGeometry создаёт нормализованную геометрию: внешние кольца обходятся
+// против часовой стрелки, дырки — по часовой, замыкающие точки удалены.
+// Для одной части используется тип Polygon, для нескольких — MultiPolygon.
+func NewGeometry(m geo.MultiPolygon) Geometry {
+	typ := TypeMultiPolygon
+	if len(m) == 1 {
+		typ = TypePolygon
+	}
+	return Geometry{Type: typ, Shape: m.Oriented()}
+}
+
+// BBox возвращает ограничивающий прямоугольник геометрии.
+func (g Geometry) BBox() geo.BBox { return g.Shape.Bounds() }
+
+// MarshalJSON сериализует геометрию с замкнутыми кольцами и полем bbox.
+func (g Geometry) MarshalJSON() ([]byte, error) {
+	polygons := make([][][][2]float64, len(g.Shape))
+	for i, poly := range g.Shape {
+		polygons[i] = make([][][2]float64, len(poly))
+		for j, ring := range poly {
+			closed := ring.Closed()
+			positions := make([][2]float64, len(closed))
+			for k, p := range closed {
+				positions[k] = [2]float64{p.X, p.Y}
+			}
+			polygons[i][j] = positions
+		}
+	}
+
+	var coords any = polygons
+	typ := g.Type
+	if typ == "" {
+		typ = TypeMultiPolygon
+	}
+	if typ == TypePolygon {
+		if len(polygons) != 1 {
+			return nil, fmt.Errorf("геометрия Polygon должна содержать одну часть, получено %d", len(polygons))
+		}
+		coords = polygons[0]
+	}
+
+	out := struct {
+		Type        string    `json:"type"`
+		BBox        []float64 `json:"bbox,omitempty"`
+		Coordinates any       `json:"coordinates"`
+	}{Type: typ, Coordinates: coords}
+	out.BBox = g.BBox().Array()
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON разбирает объект геометрии Polygon или MultiPolygon.
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var raw rawObject
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return &Error{Message: err.Error()}
+	}
+	parsed, err := parseGeometry(raw, "", Options{})
+	if err != nil {
+		return err
+	}
+	*g = parsed
+	return nil
+}
+
+// String возвращает GeoJSON-представление геометрии.
+func (g Geometry) String() string {
+	data, err := g.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Feature — объект GeoJSON с геометрией и свойствами.
+type Feature struct {
+	ID         any            `json:"id,omitempty"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// MarshalJSON добавляет обязательное поле type.
+func (f Feature) MarshalJSON() ([]byte, error) {
+	type plain Feature
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		plain
+	}{Type: TypeFeature, plain: plain(f)})
+}
+
+// Object — результат разбора документа GeoJSON.
+//
+// Геометрия хранится в том виде, в котором она пришла (включая замыкающие
+// точки и исходный порядок обхода), чтобы индексы нарушений при проверке
+// совпадали с индексами во входном документе.
+type Object struct {
+	Type     string
+	Features []Feature
+}
+
+// Shape объединяет геометрии всех объектов в одну многоконтурную геометрию.
+func (o *Object) Shape() geo.MultiPolygon {
+	var m geo.MultiPolygon
+	for _, f := range o.Features {
+		m = append(m, f.Geometry.Shape...)
+	}
+	return m
+}
+
+// Validate проверяет правила колец RFC 7946 (явное замыкание, не менее
+// четырёх позиций) и топологию геометрии.
+func (o *Object) Validate(opts Options) []geo.Violation {
+	return geo.Validate(o.Shape(), ValidationOptions(opts))
+}
+
+// ValidationOptions возвращает параметры топологической проверки для GeoJSON:
+// кольца должны быть явно замкнуты, допуски задаются в метрах.
+func ValidationOptions(opts Options) geo.ValidationOptions {
+	v := geo.DefaultValidationOptions()
+	v.RequireClosed = true
+	v.Geographic = !opts.Projected
+	return v
+}
+
+// Parse разбирает документ GeoJSON с географическими координатами.
+func Parse(data []byte) (*Object, error) {
+	return ParseOptions(data, Options{})
+}
+
+// ParseOptions разбирает документ GeoJSON с заданными параметрами.
+func ParseOptions(data []byte, opts Options) (*Object, error) {
+	var raw rawObject
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&raw); err != nil {
+		return nil, &Error{Message: err.Error()}
+	}
+
+	switch raw.Type {
+	case TypePolygon, TypeMultiPolygon:
+		g, err := parseGeometry(raw, "", opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Object{Type: raw.Type, Features: []Feature{{Geometry: g}}}, nil
+	case TypeFeature:
+		f, err := parseFeature(raw, "", opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Object{Type: raw.Type, Features: []Feature{f}}, nil
+	case TypeFeatureCollection:
+		if len(raw.Features) == 0 {
+			return nil, &Error{Path: "features", Message: "коллекция не содержит объектов"}
+		}
+		obj := &Object{Type: raw.Type, Features: make([]Feature, 0, len(raw.Features))}
+		for i, item := range raw.Features {
+			path := fmt.Sprintf("features[%d]", i)
+			var child rawObject
+			if err := json.Unmarshal(item, &child); err != nil {
+				return nil, &Error{Path: path, Message: err.Error()}
+			}
+			if child.Type != TypeFeature {
+				return nil, &Error{Path: path + ".type", Message: fmt.Sprintf("ожидается Feature, получено %q", child.Type)}
+			}
+			f, err := parseFeature(child, path, opts)
+			if err != nil {
+				return nil, err
+			}
+			obj.Features = append(obj.Features, f)
+		}
+		return obj, nil
+	case "":
+		return nil, &Error{Path: "type", Message: "не указан тип объекта"}
+	default:
+		return nil, &Error{Path: "type", Message: fmt.Sprintf("тип %q не поддерживается", raw.Type)}
+	}
+}
+
+// rawObject объединяет поля всех поддерживаемых объектов GeoJSON.
+type rawObject struct {
+	Type        string            `json:"type"`
+	ID          any               `json:"id"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Geometry    json.RawMessage   `json:"geometry"`
+	Properties  map[string]any    `json:"properties"`
+	Features    []json.RawMessage `json:"features"`
+}
+
+func parseFeature(raw rawObject, path string, opts Options) (Feature, error) {
+	geomPath := join(path, "geometry")
+	if len(raw.Geometry) == 0 || string(raw.Geometry) == "null" {
+		return Feature{}, &Error{Path: geomPath, Message: "объект не содержит геометрии"}
+	}
+	var geom rawObject
+	if err := json.Unmarshal(raw.Geometry, &geom); err != nil {
+		return Feature{}, &Error{Path: geomPath, Message: err.Error()}
+	}
+	g, err := parseGeometry(geom, geomPath, opts)
+	if err != nil {
+		return Feature{}, err
+	}
+	return Feature{ID: raw.ID, Geometry: g, Properties: raw.Properties}, nil
+}
+
+func parseGeometry(raw rawObject, path string, opts Options) (Geometry, error) {
+	coordsPath := join(path, "coordinates")
+	if len(raw.Coordinates) == 0 {
+		return Geometry{}, &Error{Path: coordsPath, Message: "не указаны координаты"}
+	}
+	switch raw.Type {
+	case TypePolygon:
+		var coords [][][]float64
+		if err := json.Unmarshal(raw.Coordinates, &coords); err != nil {
+			return Geometry{}, &Error{Path: coordsPath, Message: "ожидается массив колец из позиций [x, y]"}
+		}
+		poly, err := toPolygon(coords, coordsPath, opts)
+		if err != nil {
+			return Geometry{}, err
+		}
+		return Geometry{Type: TypePolygon, Shape: geo.MultiPolygon{poly}}, nil
+	case TypeMultiPolygon:
+		var coords [][][][]float64
+		if err := json.Unmarshal(raw.Coordinates, &coords); err != nil {
+			return Geometry{}, &Error{Path: coordsPath, Message: "ожидается массив полигонов из позиций [x, y]"}
+		}
+		if len(coords) == 0 {
+			return Geometry{}, &Error{Path: coordsPath, Message: "мультиполигон не содержит частей"}
+		}
+		multi := make(geo.MultiPolygon, 0, len(coords))
+		for i, c := range coords {
+			poly, err := toPolygon(c, fmt.Sprintf("%s[%d]", coordsPath, i), opts)
+			if err != nil {
+				return Geometry{}, err
+			}
+			multi = append(multi, poly)
+		}
+		return Geometry{Type: TypeMultiPolygon, Shape: multi}, nil
+	default:
+		return Geometry{}, &Error{Path: join(path, "type"), Message: fmt.Sprintf("тип геометрии %q не поддерживается", raw.Type)}
+	}
+}
+
+func toPolygon(coords [][][]float64, path string, opts Options) (geo.Polygon, error) {
+	if len(coords) == 0 {
+		return nil, &Error{Path: path, Message: "полигон не содержит колец"}
+	}
+	poly := make(geo.Polygon, 0, len(coords))
+	for i, ring := range coords {
+		r := make(geo.Ring, 0, len(ring))
+		for j, pos := range ring {
+			posPath := fmt.Sprintf("%s[%d][%d]", path, i, j)
+			if len(pos) < 2 {
+				return nil, &Error{Path: posPath, Message: "позиция должна содержать не менее двух координат"}
+			}
+			p := geo.Point{X: pos[0], Y: pos[1]}
+			if !opts.Projected && (math.Abs(p.X) > 180 || math.Abs(p.Y) > 90) {
+				return nil, &Error{Path: posPath, Message: "долгота должна быть в пределах ±180°, широта — ±90°"}
+			}
+			r = append(r, p)
+		}
+		poly = append(poly, r)
+	}
+	return poly, nil
+}
+
+func join(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
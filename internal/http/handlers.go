@@ -3,13 +3,27 @@ package httpapi
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"path"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
-	"zemlyaprosto/internal/assistant"
-	"zemlyaprosto/internal/model"
-	"zemlyaprosto/internal/service"
+	"zemlya-prosto/internal/assistant"
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/formats/table"
+	"zemlya-prosto/internal/formats/wkb"
+	"zemlya-prosto/internal/formats/wkt"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/service"
+	"zemlya-prosto/internal/spatial"
+	"zemlya-prosto/internal/store"
+	"zemlya-prosto/internal/templates"
 )
 
 // Handler инкапсулирует работу с HTTP-запросами.
@@ -26,14 +40,43 @@ func New(service *service.Service) *Handler {
 func (h *Handler) Register(mux *http.ServeMux) {
 	mux.HandleFunc("/api/contours/drawn", h.handleCreateDrawnContour)
 	mux.HandleFunc("/api/contours/coordinates", h.handleCreateContourFromCoordinates)
+	mux.HandleFunc("/api/contours/coordinates/upload", h.handleUploadCoordinates)
 	mux.HandleFunc("/api/contours/import", h.handleImportContour)
+	mux.HandleFunc("/api/contours/validate", h.handleValidateContour)
 	mux.HandleFunc("/api/contours", h.handleListContours)
+	mux.HandleFunc("/api/contours/{id}", h.handleGetContour)
+	mux.HandleFunc("/api/contours/{id}/coordinates", h.handleContourCoordinates)
+	mux.HandleFunc("/api/contours/{id}/conflicts", h.handleContourConflicts)
+	mux.HandleFunc("/api/contours/{id}/zones", h.handleContourZones)
+	mux.HandleFunc("/api/contours/{id}/zoning", h.handleContourZoning)
+	mux.HandleFunc("/api/contours/{id}/versions", h.handleContourVersions)
+	mux.HandleFunc("/api/contours/{id}/versions/{version}", h.handleContourVersion)
+	mux.HandleFunc("/api/contours/{id}/diff", h.handleContourDiff)
+	mux.HandleFunc("/api/contours/{id}/operations", h.handleGeometryOperation)
+	mux.HandleFunc("/api/contours/{id}/statement", h.handleCoordinateStatement)
+	mux.HandleFunc("/api/contours/{id}/scheme", h.handleLocationScheme)
+	mux.HandleFunc("/api/formations", h.handleFormParcels)
+	mux.HandleFunc("/api/formations/{id}", h.handleGetFormation)
+
+	mux.HandleFunc("/api/crs", h.handleListCRS)
 
 	mux.HandleFunc("/api/cards", h.handleCreateCard)
 
 	mux.HandleFunc("/api/parcels", h.handleListParcels)
+	mux.HandleFunc("/api/cadastre/parcels", h.handleListCadastralParcels)
+	mux.HandleFunc("/api/cadastre/parcels/{number}", h.handleGetCadastralParcel)
+	mux.HandleFunc("/api/zones", h.handleZones)
+	mux.HandleFunc("/api/zones/{id}", h.handleGetZone)
+	mux.HandleFunc("/api/zoning/zones", h.handleTerritorialZones)
+	mux.HandleFunc("/api/zoning/zones/{id}", h.handleGetTerritorialZone)
 
 	mux.HandleFunc("/api/document-packages", h.handleDocumentPackages)
+	mux.HandleFunc("/api/document-packages/{id}/files/{file}", h.handleDocumentFile)
+	mux.HandleFunc("/api/document-packages/{id}/archive", h.handleDocumentArchive)
+	mux.HandleFunc("/api/document-packages/{id}/manifest", h.handleDocumentManifest)
+	mux.HandleFunc("/api/templates", h.handleTemplates)
+	mux.HandleFunc("/api/templates/{id}", h.handleGetTemplate)
+	mux.HandleFunc("/api/templates/{id}/versions", h.handleTemplateVersions)
 
 	mux.HandleFunc("/api/assistant/suggest", h.handleAssistantSuggest)
 
@@ -41,6 +84,7 @@ func (h *Handler) Register(mux *http.ServeMux) {
 
 	mux.HandleFunc("/api/layer/publish", h.handlePublishLayerFeature)
 	mux.HandleFunc("/api/layer", h.handleGetLayer)
+	mux.HandleFunc("/api/layer/features/{id}", h.handleGetLayerFeature)
 }
 
 // writeJSON — вспомогательная функция для формирования ответа.
@@ -52,89 +96,907 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 	}
 }
 
-// writeError упрощает формирование сообщения об ошибке.
-func writeError(w http.ResponseWriter, status int, err error) {
-	writeJSON(w, status, map[string]string{"error": err.Error()})
-}
-
-// handleCreateDrawnContour обрабатывает создание контура методом рисования.
-func (h *Handler) handleCreateDrawnContour(w http.ResponseWriter, r *http.Request) {
+// writeError упрощает формирование сообщения об ошибке.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeContourError отвечает 422 с перечнем нарушений, если геометрия не прошла
+// топологическую проверку, и 400 в остальных случаях.
+func writeContourError(w http.ResponseWriter, err error) {
+	var verr *geo.ValidationError
+	if errors.As(err, &verr) {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":      verr.Error(),
+			"violations": verr.Violations,
+		})
+		return
+	}
+	writeError(w, http.StatusBadRequest, err)
+}
+
+// handleCreateDrawnContour обрабатывает создание контура методом рисования.
+func (h *Handler) handleCreateDrawnContour(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Однокольцевой контур передаётся в points, участок с анклавами или
+	// многоконтурный участок — в parts. snap_tolerance_m включает привязку
+	// к границам соседних участков.
+	var req struct {
+		Description    string  `json:"description"`
+		SnapToleranceM float64 `json:"snap_tolerance_m"`
+		model.ContourGeometry
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	contour, err := h.service.CreateContourFromDrawing(req.Description, req.ContourGeometry, service.SnapOptions{ToleranceM: req.SnapToleranceM})
+	if err != nil {
+		writeContourError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, contour)
+}
+
+// handleCreateContourFromCoordinates создаёт контур на основе координат.
+func (h *Handler) handleCreateContourFromCoordinates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	// Координаты принимаются либо как широта/долгота WGS 84 (points или parts),
+	// либо как X/Y в системе координат crs (coordinates для одного кольца,
+	// coordinate_parts для участков с анклавами и многоконтурных участков).
+	var req struct {
+		Description string `json:"description"`
+		model.ContourGeometry
+		CRS             string               `json:"crs"`
+		Coordinates     []model.PlanePoint   `json:"coordinates"`
+		CoordinateParts []model.PlanePolygon `json:"coordinate_parts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var (
+		contour model.Contour
+		err     error
+	)
+	if len(req.Coordinates) > 0 || len(req.CoordinateParts) > 0 {
+		contour, err = h.service.CreateContourFromCRSCoordinates(req.Description, model.ContourCoordinates{
+			CRS:    req.CRS,
+			Points: req.Coordinates,
+			Parts:  req.CoordinateParts,
+		})
+	} else {
+		contour, err = h.service.CreateContourFromCoordinates(req.Description, req.ContourGeometry)
+	}
+	if err != nil {
+		writeContourError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, contour)
+}
+
+// maxUploadSize ограничивает размер загружаемых файлов.
+const maxUploadSize = 10 << 20
+
+// handleUploadCoordinates создаёт контур по ведомости координат, загруженной
+// файлом CSV, TSV или XLSX (multipart/form-data, поле file).
+//
+// Необязательные поля формы: description, crs (система координат по
+// умолчанию), format, delimiter, header (true/false), sheet и столбцы
+// column_number, column_x, column_y, column_crs, column_contour — название
+// из заголовка или номер столбца с единицы.
+func (h *Handler) handleUploadCoordinates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("не передан файл ведомости: %w", err))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	opts, err := tableOptions(r, header.Filename, data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	contour, rowErrors, err := h.service.ImportCoordinateTable(r.FormValue("description"), data, opts, r.FormValue("crs"))
+	if err != nil {
+		var verr *geo.ValidationError
+		status := http.StatusBadRequest
+		payload := map[string]any{"error": err.Error(), "row_errors": rowErrors}
+		if errors.As(err, &verr) {
+			status = http.StatusUnprocessableEntity
+			payload["violations"] = verr.Violations
+		}
+		writeJSON(w, status, payload)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"contour":    contour,
+		"row_errors": rowErrors,
+	})
+}
+
+// tableOptions собирает параметры разбора ведомости из полей формы.
+func tableOptions(r *http.Request, filename string, data []byte) (table.Options, error) {
+	opts := table.Options{
+		Format: table.Format(strings.ToLower(r.FormValue("format"))),
+		Sheet:  r.FormValue("sheet"),
+		Columns: table.Columns{
+			Number:  r.FormValue("column_number"),
+			X:       r.FormValue("column_x"),
+			Y:       r.FormValue("column_y"),
+			CRS:     r.FormValue("column_crs"),
+			Contour: r.FormValue("column_contour"),
+		},
+	}
+	if opts.Format == "" {
+		opts.Format = table.DetectFormat(filename, data)
+	}
+	switch d := r.FormValue("delimiter"); {
+	case d == "":
+	case d == `\t` || strings.EqualFold(d, "tab"):
+		opts.Delimiter = '\t'
+	case utf8.RuneCountInString(d) == 1:
+		opts.Delimiter, _ = utf8.DecodeRuneInString(d)
+	default:
+		return table.Options{}, fmt.Errorf("разделитель должен быть одним символом: %q", d)
+	}
+	switch strings.ToLower(r.FormValue("header")) {
+	case "":
+	case "true", "1", "yes":
+		opts.Header = table.HeaderPresent
+	case "false", "0", "no":
+		opts.Header = table.HeaderAbsent
+	default:
+		return table.Options{}, fmt.Errorf("некорректное значение header: %q", r.FormValue("header"))
+	}
+	return opts, nil
+}
+
+// handleImportContour загружает контур из внешней системы.
+//
+// Формат тела определяется заголовком Content-Type: JSON с точками контура,
+// WKT/EWKT (text/plain, application/wkt), WKB/EWKB (application/wkb,
+// application/octet-stream), zip-архив шейп-файла (application/zip), KML или
+// KMZ (application/vnd.google-earth.kml+xml, application/vnd.google-earth.kmz)
+// GPX (application/gpx+xml), XML-документ Росреестра — КПТ или выписка
+// ЕГРН (application/xml, text/xml) либо чертёж DXF (image/vnd.dxf). Для этих
+// форматов описание и система координат передаются параметрами description и
+// crs, имя файла — параметром filename. Файл можно также передать формой
+// multipart/form-data в поле file, формат тогда определяется по расширению.
+//
+// Для DXF слои чертежа перечисляются через запятую в параметре layers,
+// единицы чертежа — параметром units, а если замкнутых полилиний несколько,
+// выбранная указывается параметром polyline (дескриптор или номер). Без
+// выбора возвращается 409 со списком полилиний в поле candidates.
+//
+// Для JSON, WKT и WKB исправление некорректной геометрии включается полем
+// или параметром repair, допуск задаётся в repair_tolerance_m.
+func (h *Handler) handleImportContour(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		parsed, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		mediaType = parsed
+	}
+	switch mediaType {
+	case "application/json":
+	case "text/plain", "application/wkt", "application/ewkt",
+		"application/wkb", "application/ewkb", "application/octet-stream":
+		h.importContourGeometry(w, r, mediaType)
+		return
+	case "application/zip", "application/x-zip-compressed",
+		kmlMediaType, kmzMediaType, gpxMediaType, "application/xml", "text/xml",
+		dxfMediaType, "application/dxf":
+		h.importContourFile(w, r, mediaType)
+		return
+	case "multipart/form-data":
+		h.importContourForm(w, r)
+		return
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("формат %s не поддерживается", mediaType))
+		return
+	}
+
+	var req struct {
+		Description      string  `json:"description"`
+		Repair           bool    `json:"repair"`
+		RepairToleranceM float64 `json:"repair_tolerance_m"`
+		model.ContourGeometry
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	contour, err := h.service.ImportContour(req.Description, req.ContourGeometry, service.RepairOptions{
+		Enabled:    req.Repair,
+		ToleranceM: req.RepairToleranceM,
+	})
+	if err != nil {
+		writeContourError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, contour)
+}
+
+// importContourGeometry загружает контур из тела запроса в формате WKT или WKB.
+func (h *Handler) importContourGeometry(w http.ResponseWriter, r *http.Request, mediaType string) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxUploadSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(data) > maxUploadSize {
+		writeError(w, http.StatusRequestEntityTooLarge, errors.New("размер геометрии превышает 10 МБ"))
+		return
+	}
+
+	query := r.URL.Query()
+	repair, err := repairOptions(query.Get)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var contour model.Contour
+	switch mediaType {
+	case "text/plain", "application/wkt", "application/ewkt":
+		if !utf8.Valid(data) {
+			writeError(w, http.StatusBadRequest, errors.New("текст WKT должен быть в кодировке UTF-8"))
+			return
+		}
+		contour, err = h.service.ImportContourWKT(query.Get("description"), string(data), query.Get("crs"), repair)
+	default:
+		contour, err = h.service.ImportContourWKB(query.Get("description"), data, query.Get("crs"), repair)
+	}
+	if err != nil {
+		writeContourError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, contour)
+}
+
+// repairOptions читает параметры исправления геометрии repair и
+// repair_tolerance_m.
+func repairOptions(param func(string) string) (service.RepairOptions, error) {
+	var opts service.RepairOptions
+	if v := param("repair"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("неверное значение параметра repair: %q", v)
+		}
+		opts.Enabled = enabled
+	}
+	if v := param("repair_tolerance_m"); v != "" {
+		tolerance, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("неверный допуск исправления: %q", v)
+		}
+		opts.ToleranceM = tolerance
+	}
+	return opts, nil
+}
+
+// importContourFile загружает объекты файла, переданного телом запроса.
+func (h *Handler) importContourFile(w http.ResponseWriter, r *http.Request, mediaType string) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	query := r.URL.Query()
+	filename := query.Get("filename")
+	if filename == "" {
+		filename = "import"
+		for ext, format := range fileFormats {
+			if format == mediaType {
+				filename += ext
+			}
+		}
+	}
+	h.importFile(w, filename, mediaType, data, query.Get)
+}
+
+// importContourForm загружает файл из поля file формы multipart/form-data.
+func (h *Handler) importContourForm(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("не передан файл: %w", err))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	mediaType := fileFormats[strings.ToLower(path.Ext(header.Filename))]
+	h.importFile(w, header.Filename, mediaType, data, r.FormValue)
+}
+
+// Типы содержимого форматов Google Earth и GPS-приёмников.
+const (
+	kmlMediaType = "application/vnd.google-earth.kml+xml"
+	kmzMediaType = "application/vnd.google-earth.kmz"
+	gpxMediaType = "application/gpx+xml"
+	dxfMediaType = "image/vnd.dxf"
+)
+
+// fileFormats сопоставляет расширения загружаемых файлов типам содержимого.
+var fileFormats = map[string]string{
+	".zip": "application/zip",
+	".kml": kmlMediaType,
+	".kmz": kmzMediaType,
+	".gpx": gpxMediaType,
+	".xml": "application/xml",
+	".dxf": dxfMediaType,
+}
+
+// importFile создаёт контуры по объектам файла и отвечает итогом импорта.
+// Параметры импорта (description, crs и параметры отдельных форматов)
+// читаются функцией param из строки запроса или полей формы.
+func (h *Handler) importFile(w http.ResponseWriter, filename, mediaType string, data []byte, param func(string) string) {
+	description, crsID := param("description"), param("crs")
+	var (
+		result model.ImportResult
+		err    error
+	)
+	switch mediaType {
+	case "application/zip", "application/x-zip-compressed":
+		result, err = h.service.ImportShapefile(description, filename, data, crsID)
+	case kmlMediaType, kmzMediaType:
+		result, err = h.service.ImportKML(description, filename, data)
+	case gpxMediaType:
+		result, err = h.service.ImportGPX(description, filename, data)
+	case "application/xml", "text/xml":
+		result, err = h.service.ImportRosreestrXML(description, filename, data, crsID)
+	case dxfMediaType, "application/dxf":
+		result, err = h.service.ImportDXF(description, filename, data, service.DXFOptions{
+			Layers:   splitList(param("layers")),
+			Polyline: param("polyline"),
+			Units:    param("units"),
+			CRS:      crsID,
+		})
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("формат файла %s не поддерживается", filename))
+		return
+	}
+	var choice *service.PolylineChoiceError
+	if errors.As(err, &choice) {
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error":      err.Error(),
+			"candidates": choice.Candidates,
+		})
+		return
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if len(result.Errors) > 0 {
+			status = http.StatusUnprocessableEntity
+		}
+		writeJSON(w, status, map[string]any{
+			"error":  err.Error(),
+			"errors": result.Errors,
+		})
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// splitList разбирает список значений, перечисленных через запятую.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// handleValidateContour проверяет топологию контура без сохранения.
+func (h *Handler) handleValidateContour(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var req model.ContourGeometry
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	violations := h.service.ValidateContour(req)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"valid":      !geo.HasErrors(violations),
+		"violations": violations,
+	})
+}
+
+// handleListContours возвращает контуры. Параметры bbox, point или intersects
+// ограничивают выборку видимой областью карты, точкой или геометрией.
+func (h *Handler) handleListContours(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	q, err := spatial.ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	contours := h.service.SearchContours(q)
+	writeJSON(w, http.StatusOK, contours)
+}
+
+// handleContourCoordinates выгружает координаты характерных точек контура в
+// системе координат, указанной параметром crs.
+func (h *Handler) handleContourCoordinates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	coords, err := h.service.ExportContourCoordinates(r.PathValue("id"), r.URL.Query().Get("crs"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, coords)
+}
+
+// handleContourConflicts заново проверяет наложения контура на другие контуры,
+// поданные обращения, готовые участки и участки справочника кадастровых данных.
+func (h *Handler) handleContourConflicts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	report, err := h.service.CheckContourConflicts(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleContourZones возвращает зоны с особыми условиями использования
+// территории, в границах которых расположен контур.
+func (h *Handler) handleContourZones(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	report, err := h.service.ContourZones(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleGetContour возвращает контур в формате, выбранном параметром format
+// или заголовком Accept: json (по умолчанию), wkt, ewkt, wkb, ewkb, kml. Для
+// WKT и WKB параметр crs задаёт систему координат выгрузки, KML всегда
+// выгружается в WGS 84. Метод PATCH изменяет контур (service.ContourEdit).
+func (h *Handler) handleGetContour(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPatch {
+		h.handleEditContour(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.PathValue("id")
+	format, err := geometryFormat(r)
+	if err != nil {
+		writeError(w, http.StatusNotAcceptable, err)
+		return
+	}
+	if format == "json" {
+		contour, err := h.service.GetContour(id)
+		if err != nil {
+			writeLookupError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, contour)
+		return
+	}
+	if format == "kml" {
+		doc, err := h.service.ContourKML(id)
+		if err != nil {
+			writeLookupError(w, err)
+			return
+		}
+		writeKML(w, doc)
+		return
+	}
+	shape, system, err := h.service.ContourShape(id, r.URL.Query().Get("crs"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeGeometry(w, format, shape, system)
+}
+
+// handleEditContour применяет изменение к контуру и возвращает его новую
+// версию. Если base_version не совпадает с текущей версией контура, ответ —
+// 409 Conflict.
+func (h *Handler) handleEditContour(w http.ResponseWriter, r *http.Request) {
+	var edit service.ContourEdit
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	contour, err := h.service.EditContour(r.PathValue("id"), edit)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrVersionConflict):
+			writeError(w, http.StatusConflict, err)
+		case errors.Is(err, store.ErrNotFound):
+			writeLookupError(w, err)
+		default:
+			writeContourError(w, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, contour)
+}
+
+// handleContourVersions возвращает историю изменений контура.
+func (h *Handler) handleContourVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	versions, err := h.service.ListContourVersions(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
+// handleContourVersion возвращает состояние контура в указанной версии.
+func (h *Handler) handleContourVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	version, err := strconv.Atoi(r.PathValue("version"))
+	if err != nil || version < 1 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("неверный номер версии: %q", r.PathValue("version")))
+		return
+	}
+	v, err := h.service.GetContourVersion(r.PathValue("id"), version)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// handleContourDiff сравнивает версии контура, заданные параметрами from и
+// to. По умолчанию текущая версия сравнивается с предыдущей.
+func (h *Handler) handleContourDiff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	var versions [2]int
+	for i, name := range [2]string{"from", "to"} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неверный номер версии в параметре %s: %q", name, raw))
+			return
+		}
+		versions[i] = v
+	}
+	diff, err := h.service.DiffContourVersions(r.PathValue("id"), versions[0], versions[1])
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, diff)
+}
+
+// handleCoordinateStatement возвращает ведомость координат характерных точек
+// контура в системе координат из параметра crs. Параметр mse_m задаёт
+// среднюю квадратическую погрешность положения точек, format=txt —
+// выгрузку ведомости для печати вместо JSON.
+func (h *Handler) handleCoordinateStatement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	opts := service.StatementOptions{CRS: query.Get("crs")}
+	if v := query.Get("mse_m"); v != "" {
+		mse, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неверная средняя квадратическая погрешность: %q", v))
+			return
+		}
+		opts.MeanSquareErrorM = mse
+	}
+	format := strings.ToLower(query.Get("format"))
+	if format != "" && format != "json" && format != "txt" {
+		writeError(w, http.StatusNotAcceptable, fmt.Errorf("неизвестный формат %q, ожидается json или txt", format))
+		return
+	}
+	statement, err := h.service.CoordinateStatement(r.PathValue("id"), opts)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	if format == "txt" {
+		writeFile(w, model.DocumentFile{Name: "vedomost-koordinat-" + statement.ContourID + ".txt", MediaType: "text/plain; charset=utf-8"},
+			service.StatementText(statement))
+		return
+	}
+	writeJSON(w, http.StatusOK, statement)
+}
+
+// handleLocationScheme возвращает схему расположения земельного участка в
+// PNG (по умолчанию) или PDF.
+func (h *Handler) handleLocationScheme(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	opts := service.SchemeOptions{CRS: query.Get("crs")}
+	if v := query.Get("scale"); v != "" {
+		scale, err := strconv.Atoi(strings.TrimPrefix(v, "1:"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неверный масштаб: %q", v))
+			return
+		}
+		opts.Scale = scale
+	}
+	format := strings.ToLower(query.Get("format"))
+	if format != "" && format != "png" && format != "pdf" {
+		writeError(w, http.StatusNotAcceptable, fmt.Errorf("неизвестный формат %q, ожидается png или pdf", format))
+		return
+	}
+	id := r.PathValue("id")
+	sheet, err := h.service.LocationScheme(id, opts)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	if format == "pdf" {
+		writeFile(w, model.DocumentFile{Name: "shema-raspolozheniya-" + id + ".pdf", MediaType: "application/pdf"}, service.SchemePDF(sheet))
+		return
+	}
+	data, err := service.SchemePNG(sheet)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeFile(w, model.DocumentFile{Name: "shema-raspolozheniya-" + id + ".png", MediaType: "image/png"}, data)
+}
+
+// handleGeometryOperation упрощает, уплотняет контур или строит вокруг него
+// буфер; результат сохраняется новым контуром.
+func (h *Handler) handleGeometryOperation(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-
-	var req struct {
-		Description string        `json:"description"`
-		Points      []model.Point `json:"points"`
-	}
+	var req service.GeometryOperationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
-
-	contour, err := h.service.CreateContourFromDrawing(req.Description, req.Points)
+	result, err := h.service.ApplyGeometryOperation(r.PathValue("id"), req)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		if errors.Is(err, store.ErrNotFound) {
+			writeLookupError(w, err)
+			return
+		}
+		writeContourError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, contour)
+	writeJSON(w, http.StatusCreated, result)
 }
 
-// handleCreateContourFromCoordinates создаёт контур на основе координат.
-func (h *Handler) handleCreateContourFromCoordinates(w http.ResponseWriter, r *http.Request) {
+// handleFormParcels выполняет раздел, объединение, перераспределение или
+// выдел участков (service.FormationRequest).
+func (h *Handler) handleFormParcels(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	var req struct {
-		Description string        `json:"description"`
-		Points      []model.Point `json:"points"`
-	}
+	var req service.FormationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
+	result, err := h.service.FormParcels(req)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeLookupError(w, err)
+			return
+		}
+		writeContourError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
 
-	contour, err := h.service.CreateContourFromCoordinates(req.Description, req.Points)
+// handleGetFormation возвращает операцию образования участков.
+func (h *Handler) handleGetFormation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	formation, err := h.service.GetFormation(r.PathValue("id"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeLookupError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, contour)
+	writeJSON(w, http.StatusOK, formation)
 }
 
-// handleImportContour загружает контур из внешней системы.
-func (h *Handler) handleImportContour(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// handleGetLayerFeature возвращает объект слоя в формате, выбранном так же,
+// как в handleGetContour.
+func (h *Handler) handleGetLayerFeature(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	var req struct {
-		Description string        `json:"description"`
-		Points      []model.Point `json:"points"`
+	id := r.PathValue("id")
+	format, err := geometryFormat(r)
+	if err != nil {
+		writeError(w, http.StatusNotAcceptable, err)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if format == "json" {
+		feature, err := h.service.GetLayerFeature(id)
+		if err != nil {
+			writeLookupError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, feature)
 		return
 	}
-
-	contour, err := h.service.ImportContour(req.Description, req.Points)
+	if format == "kml" {
+		doc, err := h.service.LayerFeatureKML(id)
+		if err != nil {
+			writeLookupError(w, err)
+			return
+		}
+		writeKML(w, doc)
+		return
+	}
+	shape, system, err := h.service.LayerFeatureShape(id, r.URL.Query().Get("crs"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeLookupError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, contour)
+	writeGeometry(w, format, shape, system)
 }
 
-// handleListContours возвращает все контуры.
-func (h *Handler) handleListContours(w http.ResponseWriter, r *http.Request) {
+// geometryFormats сопоставляет типы содержимого из заголовка Accept форматам выгрузки.
+var geometryFormats = map[string]string{
+	"application/json":         "json",
+	"application/geo+json":     "json",
+	"application/wkt":          "wkt",
+	"text/plain":               "wkt",
+	"application/ewkt":         "ewkt",
+	"application/wkb":          "wkb",
+	"application/octet-stream": "wkb",
+	"application/ewkb":         "ewkb",
+	kmlMediaType:               "kml",
+}
+
+// geometryFormat выбирает формат выгрузки: параметр format имеет приоритет
+// над заголовком Accept, в заголовке берётся первый известный тип.
+func geometryFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		switch format {
+		case "json", "wkt", "ewkt", "wkb", "ewkb", "kml":
+			return format, nil
+		}
+		return "", fmt.Errorf("неизвестный формат %q, ожидается json, wkt, ewkt, wkb, ewkb или kml", format)
+	}
+	for _, item := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		if format, ok := geometryFormats[mediaType]; ok {
+			return format, nil
+		}
+	}
+	return "json", nil
+}
+
+// writeGeometry записывает геометрию в формате WKT, EWKT, WKB или EWKB.
+// Для расширенных форматов SRID берётся из кода EPSG системы координат.
+func writeGeometry(w http.ResponseWriter, format string, shape geo.MultiPolygon, system *crs.CRS) {
+	srid := 0
+	if strings.HasPrefix(format, "ew") {
+		srid = system.SRID()
+	}
+	w.Header().Set("Content-Crs", system.ID)
+	switch format {
+	case "wkt", "ewkt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, wkt.Marshal(shape, srid))
+	default:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(wkb.Marshal(shape, srid))
+	}
+}
+
+// writeFile отдаёт файл для сохранения под его именем.
+func writeFile(w http.ResponseWriter, file model.DocumentFile, data []byte) {
+	w.Header().Set("Content-Type", file.MediaType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// writeKML записывает документ KML.
+func writeKML(w http.ResponseWriter, doc []byte) {
+	w.Header().Set("Content-Type", kmlMediaType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(doc)
+}
+
+// writeLookupError отвечает 404, если запрошенный объект не найден, и 400 в остальных случаях.
+func writeLookupError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrNotFound) || errors.Is(err, templates.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusBadRequest, err)
+}
+
+// handleListCRS возвращает поддерживаемые системы координат.
+func (h *Handler) handleListCRS(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	contours := h.service.ListContours()
-	writeJSON(w, http.StatusOK, contours)
+	writeJSON(w, http.StatusOK, h.service.ListCRS())
 }
 
 // handleCreateCard формирует информационную карточку.
@@ -161,31 +1023,208 @@ func (h *Handler) handleCreateCard(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusCreated, card)
 }
 
-// handleListParcels возвращает готовые участки.
+// handleListParcels возвращает готовые участки с фильтрами category и
+// пространственными параметрами bbox, point или intersects.
 func (h *Handler) handleListParcels(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
+	q, err := spatial.ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
 	category := model.ParcelCategory(r.URL.Query().Get("category"))
-	parcels := h.service.ListReadyParcels(category)
+	parcels := h.service.SearchReadyParcels(category, q)
 	writeJSON(w, http.StatusOK, parcels)
 }
 
+// handleListCadastralParcels возвращает участки справочника кадастровых данных,
+// загруженные из документов Росреестра.
+func (h *Handler) handleListCadastralParcels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.service.ListCadastralParcels())
+}
+
+// handleGetCadastralParcel возвращает участок справочника по кадастровому номеру.
+func (h *Handler) handleGetCadastralParcel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	parcel, err := h.service.GetCadastralParcel(r.PathValue("number"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, parcel)
+}
+
+// handleZones возвращает зоны реестра ЗОУИТ (с пространственным условием,
+// как в handleListContours) или загружает зоны из GeoJSON либо zip-архива
+// шейп-файла, переданного телом запроса или полем file формы. Параметры crs,
+// type, legal_basis и restrictions задают систему координат файла и сведения
+// для зон, в атрибутах которых они не указаны.
+func (h *Handler) handleZones(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q, err := spatial.ParseQuery(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, h.service.ListRestrictionZones(q))
+	case http.MethodPost:
+		filename, data, param, ok := readZoneUpload(w, r)
+		if !ok {
+			return
+		}
+		result, err := h.service.ImportRestrictionZones(filename, data, service.ZoneOptions{
+			CRS:          param("crs"),
+			Type:         param("type"),
+			LegalBasis:   param("legal_basis"),
+			Restrictions: param("restrictions"),
+		})
+		if err != nil {
+			status := http.StatusBadRequest
+			if len(result.Errors) > 0 {
+				status = http.StatusUnprocessableEntity
+			}
+			writeJSON(w, status, map[string]any{
+				"error":  err.Error(),
+				"errors": result.Errors,
+			})
+			return
+		}
+		writeJSON(w, http.StatusCreated, result)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// readZoneUpload читает загружаемый файл зон: тело запроса целиком или поле
+// file формы multipart/form-data. Возвращает функцию чтения параметров:
+// полей формы или параметров строки запроса соответственно.
+func readZoneUpload(w http.ResponseWriter, r *http.Request) (string, []byte, func(string) string, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	var (
+		data     []byte
+		filename string
+		err      error
+		param    = r.URL.Query().Get
+	)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, header, ferr := r.FormFile("file")
+		if ferr != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("не передан файл: %w", ferr))
+			return "", nil, nil, false
+		}
+		defer file.Close()
+		data, err = io.ReadAll(file)
+		filename, param = header.Filename, r.FormValue
+	} else {
+		data, err = io.ReadAll(r.Body)
+		filename = param("filename")
+	}
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return "", nil, nil, false
+	}
+	return filename, data, param, true
+}
+
+// handleTerritorialZones возвращает территориальные зоны ПЗЗ или загружает
+// их из файла.
+func (h *Handler) handleTerritorialZones(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q, err := spatial.ParseQuery(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, h.service.ListTerritorialZones(q))
+	case http.MethodPost:
+		filename, data, param, ok := readZoneUpload(w, r)
+		if !ok {
+			return
+		}
+		result, err := h.service.ImportTerritorialZones(filename, data, param("crs"))
+		if err != nil {
+			status := http.StatusBadRequest
+			if len(result.Errors) > 0 {
+				status = http.StatusUnprocessableEntity
+			}
+			writeJSON(w, status, map[string]any{
+				"error":  err.Error(),
+				"errors": result.Errors,
+			})
+			return
+		}
+		writeJSON(w, http.StatusCreated, result)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetTerritorialZone возвращает территориальную зону по идентификатору.
+func (h *Handler) handleGetTerritorialZone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	zone, err := h.service.GetTerritorialZone(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, zone)
+}
+
+// handleContourZoning проверяет контур по правилам землепользования и
+// застройки; вид разрешённого использования задаётся параметром use.
+func (h *Handler) handleContourZoning(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	report, err := h.service.CheckZoning(r.PathValue("id"), r.URL.Query().Get("use"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// handleGetZone возвращает зону реестра ЗОУИТ по идентификатору.
+func (h *Handler) handleGetZone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	zone, err := h.service.GetRestrictionZone(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, zone)
+}
+
 // handleDocumentPackages обрабатывает как создание, так и получение пакетов документов.
 func (h *Handler) handleDocumentPackages(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
-		var req struct {
-			ContourID string `json:"contour_id"`
-			ParcelID  string `json:"parcel_id"`
-		}
+		var req service.DocumentPackageRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeError(w, http.StatusBadRequest, err)
 			return
 		}
-		pkg, err := h.service.GenerateDocumentPackage(req.ContourID, req.ParcelID)
+		pkg, err := h.service.GenerateDocumentPackage(req)
 		if err != nil {
 			writeError(w, http.StatusBadRequest, err)
 			return
@@ -199,6 +1238,116 @@ func (h *Handler) handleDocumentPackages(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// handleDocumentFile выгружает файл документа из комплекта.
+func (h *Handler) handleDocumentFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	file, data, err := h.service.GetDocumentFile(r.PathValue("id"), r.PathValue("file"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeFile(w, file, data)
+}
+
+// handleDocumentArchive выгружает комплект документов архивом ZIP с описью
+// manifest.json. Архив передаётся по мере формирования, без Content-Length.
+func (h *Handler) handleDocumentArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	archive, err := h.service.DocumentArchive(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment",
+		map[string]string{"filename": "komplekt-" + archive.Manifest.PackageID + ".zip"}))
+	w.WriteHeader(http.StatusOK)
+	// Заголовки уже отправлены: при ошибке записи клиент получит
+	// незавершённый архив, который не пройдёт проверку.
+	_ = archive.WriteZIP(w)
+}
+
+// handleDocumentManifest возвращает опись архива комплекта документов.
+func (h *Handler) handleDocumentManifest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	archive, err := h.service.DocumentArchive(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, archive.Manifest)
+}
+
+// handleTemplates возвращает последние версии шаблонов документов (GET) или
+// сохраняет новую версию шаблона (POST).
+func (h *Handler) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.service.ListTemplates())
+	case http.MethodPost:
+		var t templates.Template
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		saved, err := h.service.SaveTemplate(t)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, saved)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetTemplate возвращает шаблон документа: последнюю версию или
+// версию из параметра version.
+func (h *Handler) handleGetTemplate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	version := 0
+	if raw := r.URL.Query().Get("version"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("неверный номер версии: %q", raw))
+			return
+		}
+		version = v
+	}
+	t, err := h.service.GetTemplate(r.PathValue("id"), version)
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// handleTemplateVersions возвращает все версии шаблона документа.
+func (h *Handler) handleTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	versions, err := h.service.TemplateVersions(r.PathValue("id"))
+	if err != nil {
+		writeLookupError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
 // handleAssistantSuggest возвращает подсказки цифрового помощника.
 func (h *Handler) handleAssistantSuggest(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -214,20 +1363,38 @@ func (h *Handler) handleAssistantSuggest(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, suggestions)
 }
 
-// handleBusinessProcesses обрабатывает создание и изменение процессов.
+// handleBusinessProcesses обрабатывает создание, получение и изменение процессов.
 func (h *Handler) handleBusinessProcesses(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
 		// POST /api/business/processes — создание нового процесса
 		var req struct {
-			Name string `json:"name"`
+			Name      string `json:"name"`
+			ContourID string `json:"contour_id"`
 		}
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeError(w, http.StatusBadRequest, err)
 			return
 		}
-		process := h.service.CreateBusinessProcess(req.Name)
+		process, err := h.service.CreateBusinessProcess(req.Name, req.ContourID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
 		writeJSON(w, http.StatusCreated, process)
+	case http.MethodGet:
+		// GET /api/business/processes[?id=...] — список процессов или один процесс
+		processID := r.URL.Query().Get("id")
+		if processID == "" {
+			writeJSON(w, http.StatusOK, h.service.ListBusinessProcesses())
+			return
+		}
+		process, err := h.service.GetBusinessProcess(processID)
+		if err != nil {
+			writeLookupError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, process)
 	case http.MethodPatch:
 		// PATCH /api/business/processes?id=...&action=advance
 		processID := r.URL.Query().Get("id")
@@ -279,12 +1446,33 @@ func (h *Handler) handlePublishLayerFeature(w http.ResponseWriter, r *http.Reque
 	writeJSON(w, http.StatusCreated, feature)
 }
 
-// handleGetLayer возвращает слой «Земля просто».
+// handleGetLayer возвращает слой «Земля просто» в JSON или, если запрошен
+// формат kml, документом KML. Параметры bbox, point или intersects
+// оставляют в слое только попадающие под условие объекты.
 func (h *Handler) handleGetLayer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	layer := h.service.GetLayer()
+	format, err := geometryFormat(r)
+	if err != nil {
+		writeError(w, http.StatusNotAcceptable, err)
+		return
+	}
+	q, err := spatial.ParseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	switch format {
+	case "json":
+	case "kml":
+		writeKML(w, h.service.LayerKML(q))
+		return
+	default:
+		writeError(w, http.StatusNotAcceptable, fmt.Errorf("слой выгружается только в json или kml, запрошен %s", format))
+		return
+	}
+	layer := h.service.SearchLayer(q)
 	writeJSON(w, http.StatusOK, layer)
 }
@@ -2,10 +2,11 @@
 package layer
 
 import (
+	"strconv"
 	"time"
 
-	"zemlyaprosto/internal/model"
-	"zemlyaprosto/internal/util"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/util"
 )
 
 // Manager агрегирует функциональность по управлению слоем.
@@ -19,15 +20,26 @@ func NewManager() *Manager {
 // BuildFeature формирует объект слоя по данным контура и дополнительным атрибутам.
 func (m *Manager) BuildFeature(contour model.Contour, attributes map[string]string) model.LayerFeature {
 	feature := model.LayerFeature{
-		ID:         util.NewID(),
-		Geometry:   contour,
-		Properties: make(map[string]string),
-		UpdatedAt:  time.Now(),
+		ID:             util.NewID(),
+		ContourID:      contour.ID,
+		ContourVersion: contour.Version,
+		Geometry:       contour,
+		Properties:     make(map[string]string),
+		UpdatedAt:      time.Now(),
 	}
 
 	for key, value := range attributes {
 		feature.Properties[key] = value
 	}
+	// Метрики контура перекрывают одноимённые пользовательские атрибуты,
+	// чтобы на портале отображались те же значения, что и в карточке.
+	for _, attr := range contour.Metrics.Attributes() {
+		feature.Properties[attr.Key] = attr.Value
+	}
+	// Число частей и анклавов позволяет порталу выбрать способ отрисовки
+	// без разбора геометрии.
+	feature.Properties["parts_count"] = strconv.Itoa(len(contour.Polygons()))
+	feature.Properties["holes_count"] = strconv.Itoa(contour.HoleCount())
 
 	return feature
 }
@@ -0,0 +1,274 @@
+package model
+
+import (
+	"math"
+	"strconv"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// XY переводит точку в координаты пакета geo (X — долгота, Y — широта).
+func (p Point) XY() geo.Point {
+	return geo.Point{X: p.Longitude, Y: p.Latitude}
+}
+
+// PointFromXY создаёт точку контура из координат пакета geo.
+func PointFromXY(p geo.Point) Point {
+	return Point{Latitude: p.Y, Longitude: p.X}
+}
+
+// XY переводит точку в порядок осей пакета geo: X — восточная координата, Y — северная.
+func (p PlanePoint) XY() geo.Point {
+	return geo.Point{X: p.Y, Y: p.X}
+}
+
+// PlanePointFromXY создаёт точку в геодезическом порядке осей из координат пакета geo.
+func PlanePointFromXY(number string, p geo.Point) PlanePoint {
+	return PlanePoint{Number: number, X: p.Y, Y: p.X}
+}
+
+// Polygons возвращает части геометрии: Parts, если они заданы, иначе
+// единственную часть с внешней границей Points.
+func (g ContourGeometry) Polygons() []Polygon {
+	if len(g.Parts) > 0 {
+		return g.Parts
+	}
+	if len(g.Points) == 0 {
+		return nil
+	}
+	return []Polygon{{Exterior: g.Points}}
+}
+
+// IsEmpty сообщает, что геометрия не содержит ни одной точки.
+func (g ContourGeometry) IsEmpty() bool {
+	for _, poly := range g.Polygons() {
+		if len(poly.Exterior) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Shape возвращает геометрию в виде, пригодном для геометрических алгоритмов.
+//
+// Замыкающие точки, если они есть, сохраняются, чтобы индексы нарушений
+// совпадали с индексами во входных данных.
+func (g ContourGeometry) Shape() geo.MultiPolygon {
+	polys := g.Polygons()
+	m := make(geo.MultiPolygon, len(polys))
+	for i, poly := range polys {
+		m[i] = poly.Shape()
+	}
+	return m
+}
+
+// GeometryFromShape создаёт геометрию контура из координат пакета geo.
+func GeometryFromShape(m geo.MultiPolygon) ContourGeometry {
+	parts := make([]Polygon, len(m))
+	for i, poly := range m {
+		parts[i] = PolygonFromShape(poly)
+	}
+	return ContourGeometry{Parts: parts}
+}
+
+// Shape переводит полигон в координаты пакета geo: кольцо 0 — внешняя граница,
+// далее внутренние границы в исходном порядке.
+func (p Polygon) Shape() geo.Polygon {
+	poly := make(geo.Polygon, 0, 1+len(p.Holes))
+	poly = append(poly, ringXY(p.Exterior))
+	for _, hole := range p.Holes {
+		poly = append(poly, ringXY(hole))
+	}
+	return poly
+}
+
+// PolygonFromShape создаёт полигон контура из координат пакета geo.
+func PolygonFromShape(p geo.Polygon) Polygon {
+	var poly Polygon
+	for i, ring := range p {
+		points := make([]Point, len(ring))
+		for j, pt := range ring {
+			points[j] = PointFromXY(pt)
+		}
+		if i == 0 {
+			poly.Exterior = points
+		} else {
+			poly.Holes = append(poly.Holes, points)
+		}
+	}
+	return poly
+}
+
+func ringXY(points []Point) geo.Ring {
+	ring := make(geo.Ring, len(points))
+	for i, p := range points {
+		ring[i] = p.XY()
+	}
+	return ring
+}
+
+// Geometry возвращает геометрию контура.
+func (c Contour) Geometry() ContourGeometry {
+	return ContourGeometry{Points: c.Points, Parts: c.Parts}
+}
+
+// SetGeometry заменяет геометрию контура.
+//
+// Points всегда содержит внешнюю границу первой части. Parts заполняется
+// только для участков с внутренними границами и многоконтурных участков,
+// поэтому ответы для однокольцевых контуров не меняются.
+func (c *Contour) SetGeometry(g ContourGeometry) {
+	polys := g.Polygons()
+	c.Points, c.Parts = nil, nil
+	if len(polys) == 0 {
+		return
+	}
+	c.Points = polys[0].Exterior
+	if len(polys) > 1 || len(polys[0].Holes) > 0 {
+		c.Parts = polys
+	}
+}
+
+// Polygons возвращает части контура.
+func (c Contour) Polygons() []Polygon { return c.Geometry().Polygons() }
+
+// Shape возвращает геометрию контура в виде, пригодном для геометрических алгоритмов.
+func (c Contour) Shape() geo.MultiPolygon { return c.Geometry().Shape() }
+
+// Shape возвращает геометрию зоны с особыми условиями использования территории.
+func (z RestrictionZone) Shape() geo.MultiPolygon { return z.Geometry.Shape() }
+
+// Shape возвращает геометрию территориальной зоны.
+func (z TerritorialZone) Shape() geo.MultiPolygon { return z.Geometry.Shape() }
+
+// HoleCount возвращает общее число внутренних границ во всех частях контура.
+func (c Contour) HoleCount() int {
+	n := 0
+	for _, poly := range c.Polygons() {
+		n += len(poly.Holes)
+	}
+	return n
+}
+
+// Clone возвращает копию контура, не разделяющую срезы с исходным.
+func (c Contour) Clone() Contour {
+	clone := c
+	clone.Points = append([]Point(nil), c.Points...)
+	if c.Parts != nil {
+		clone.Parts = make([]Polygon, len(c.Parts))
+		for i, poly := range c.Parts {
+			clone.Parts[i].Exterior = append([]Point(nil), poly.Exterior...)
+			for _, hole := range poly.Holes {
+				clone.Parts[i].Holes = append(clone.Parts[i].Holes, append([]Point(nil), hole...))
+			}
+		}
+	}
+	clone.Warnings = append([]geo.Violation(nil), c.Warnings...)
+	clone.Conflicts = append([]ContourConflict(nil), c.Conflicts...)
+	clone.ParentIDs = append([]string(nil), c.ParentIDs...)
+	if c.Snapping != nil {
+		snapping := *c.Snapping
+		snapping.Moves = append([]SnapMove(nil), c.Snapping.Moves...)
+		clone.Snapping = &snapping
+	}
+	if c.Repair != nil {
+		repair := *c.Repair
+		repair.Fixes = append([]geo.RepairFix(nil), c.Repair.Fixes...)
+		clone.Repair = &repair
+	}
+	return clone
+}
+
+// Shape переводит координаты в порядок осей пакета geo. Используется Parts,
+// если они заданы, иначе Points как единственная внешняя граница.
+func (c ContourCoordinates) Shape() geo.MultiPolygon {
+	polys := c.Parts
+	if len(polys) == 0 {
+		if len(c.Points) == 0 {
+			return nil
+		}
+		polys = []PlanePolygon{{Exterior: c.Points}}
+	}
+	m := make(geo.MultiPolygon, len(polys))
+	for i, poly := range polys {
+		m[i] = append(m[i], planeRingXY(poly.Exterior))
+		for _, hole := range poly.Holes {
+			m[i] = append(m[i], planeRingXY(hole))
+		}
+	}
+	return m
+}
+
+func planeRingXY(points []PlanePoint) geo.Ring {
+	ring := make(geo.Ring, len(points))
+	for i, p := range points {
+		ring[i] = p.XY()
+	}
+	return ring
+}
+
+// CoordinatesFromShape формирует ведомость координат со сквозной нумерацией
+// точек. Points содержит внешнюю границу первой части; при withParts
+// заполняются также все части с внутренними границами.
+func CoordinatesFromShape(m geo.MultiPolygon, withParts bool) ContourCoordinates {
+	var result ContourCoordinates
+	number := 0
+	ring := func(r geo.Ring) []PlanePoint {
+		points := make([]PlanePoint, len(r))
+		for i, p := range r {
+			number++
+			points[i] = PlanePointFromXY(strconv.Itoa(number), p)
+		}
+		return points
+	}
+	parts := make([]PlanePolygon, len(m))
+	for i, poly := range m {
+		for j, r := range poly {
+			if j == 0 {
+				parts[i].Exterior = ring(r)
+			} else {
+				parts[i].Holes = append(parts[i].Holes, ring(r))
+			}
+		}
+	}
+	if len(parts) > 0 {
+		result.Points = parts[0].Exterior
+	}
+	if withParts {
+		result.Parts = parts
+	}
+	return result
+}
+
+// Measure вычисляет метрики контура на эллипсоиде WGS84.
+//
+// Площадь округляется до 0,01 м², гектары — до 0,0001 га, периметр — до сантиметра.
+func (c Contour) Measure() ContourMetrics {
+	m := geo.Measure(c.Shape())
+	return ContourMetrics{
+		AreaSqM:    round(m.AreaSqM, 2),
+		AreaHa:     round(m.AreaHa(), 4),
+		PerimeterM: round(m.PerimeterM, 2),
+		Centroid:   PointFromXY(m.Centroid),
+		LabelPoint: PointFromXY(m.LabelPoint),
+	}
+}
+
+// Attributes представляет метрики в виде атрибутов информационной карточки
+// с источником AttributeSourceComputed.
+func (m ContourMetrics) Attributes() []Attribute {
+	format := func(v float64, digits int) string { return strconv.FormatFloat(v, 'f', digits, 64) }
+	point := func(p Point) string { return format(p.Latitude, 7) + ", " + format(p.Longitude, 7) }
+	return []Attribute{
+		{Key: "area_sq_m", Value: format(m.AreaSqM, 2), Source: AttributeSourceComputed, Comment: "Площадь, м²"},
+		{Key: "area_ha", Value: format(m.AreaHa, 4), Source: AttributeSourceComputed, Comment: "Площадь, га"},
+		{Key: "perimeter_m", Value: format(m.PerimeterM, 2), Source: AttributeSourceComputed, Comment: "Периметр, м"},
+		{Key: "centroid", Value: point(m.Centroid), Source: AttributeSourceComputed, Comment: "Центр тяжести (широта, долгота)"},
+		{Key: "label_point", Value: point(m.LabelPoint), Source: AttributeSourceComputed, Comment: "Точка подписи (широта, долгота)"},
+	}
+}
+
+func round(v float64, digits int) float64 {
+	k := math.Pow(10, float64(digits))
+	return math.Round(v*k) / k
+}
@@ -6,13 +6,19 @@
 // погрузиться в предметную область.
 package model
 
-import "time"
+import (
+	"time"
+
+	"zemlya-prosto/internal/geo"
+)
 
 // ContourSource описывает источник, из которого был создан контур участка.
 //
 // Значение "drawn" соответствует интерактивному рисованию пользователем на карте.
 // Значение "coordinates" указывает на загрузку списка координат характерных точек.
 // Значение "imported" обозначает импорт границы из внешней ГИС.
+// Значение "formation" — контур образован из других контуров (раздел,
+// объединение, перераспределение, выдел).
 type ContourSource string
 
 const (
@@ -22,6 +28,11 @@ const (
 	ContourSourceCoordinates ContourSource = "coordinates"
 	// ContourSourceImported — контур загружен из внешней геоинформационной системы.
 	ContourSourceImported ContourSource = "imported"
+	// ContourSourceFormation — контур образован из других контуров.
+	ContourSourceFormation ContourSource = "formation"
+	// ContourSourceOperation — контур получен из другого контура
+	// геометрической операцией (упрощение, уплотнение, буфер).
+	ContourSourceOperation ContourSource = "operation"
 )
 
 // Point описывает координату характерной точки границы земельного участка.
@@ -30,6 +41,51 @@ type Point struct {
 	Longitude float64 `json:"longitude"`
 }
 
+// PlanePoint описывает координаты характерной точки в системе координат заявителя.
+//
+// По геодезической традиции X — северная координата, Y — восточная. Для
+// геодезических систем (например, СК-42) X — широта, Y — долгота в градусах.
+type PlanePoint struct {
+	Number string  `json:"number,omitempty"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+}
+
+// PlanePolygon описывает полигон в системе координат заявителя.
+type PlanePolygon struct {
+	Exterior []PlanePoint   `json:"exterior"`
+	Holes    [][]PlanePoint `json:"holes,omitempty"`
+}
+
+// ContourCoordinates содержит координаты характерных точек контура в заданной системе координат.
+//
+// Points содержит внешнюю границу первой части и сохранён для клиентов,
+// работающих с однокольцевыми контурами. Для участков с внутренними границами
+// и многоконтурных участков заполняется Parts; нумерация точек сквозная.
+type ContourCoordinates struct {
+	ContourID string         `json:"contour_id,omitempty"`
+	CRS       string         `json:"crs"`
+	Points    []PlanePoint   `json:"points"`
+	Parts     []PlanePolygon `json:"parts,omitempty"`
+}
+
+// Polygon описывает одну часть контура: внешнюю границу и внутренние границы
+// (анклавы, исключаемые из площади участка).
+type Polygon struct {
+	Exterior []Point   `json:"exterior"`
+	Holes    [][]Point `json:"holes,omitempty"`
+}
+
+// ContourGeometry — геометрия контура во входных данных API.
+//
+// Однокольцевой контур передаётся списком Points, как и раньше; участок с
+// анклавами или многоконтурный участок — списком частей Parts. Если заданы
+// оба поля, используется Parts.
+type ContourGeometry struct {
+	Points []Point   `json:"points,omitempty"`
+	Parts  []Polygon `json:"parts,omitempty"`
+}
+
 // Contour содержит описание границ земельного участка.
 //
 // В боевой системе идентификатор генерировался бы с помощью базы данных, здесь
@@ -39,7 +95,210 @@ type Contour struct {
 	Source      ContourSource `json:"source"`
 	Description string        `json:"description"`
 	Points      []Point       `json:"points"`
-	CreatedAt   time.Time     `json:"created_at"`
+	// Parts содержит части контура с внутренними границами. Если поле
+	// заполнено, оно является основным, а Points повторяет внешнюю границу
+	// первой части для совместимости с однокольцевыми клиентами.
+	Parts     []Polygon `json:"parts,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Version — номер текущей версии контура, начиная с 1. Каждое изменение
+	// создаёт новую версию, прежние сохраняются в истории.
+	Version int `json:"version"`
+	// CRS — система координат, в которой заявитель передал точки. Сами точки
+	// всегда хранятся в WGS 84, а CRS используется для обратной выгрузки.
+	CRS string `json:"crs,omitempty"`
+	// Metrics вычисляются автоматически при сохранении контура.
+	Metrics ContourMetrics `json:"metrics"`
+	// Warnings содержит неблокирующие замечания топологической проверки
+	// (например, обход границы по часовой стрелке), которые стоит показать заявителю.
+	Warnings []geo.Violation `json:"warnings,omitempty"`
+	// Conflicts — наложения контура на другие объекты, найденные при
+	// создании или последней проверке контура.
+	Conflicts []ContourConflict `json:"conflicts,omitempty"`
+	// Snapping — отчёт о привязке вершин нарисованного контура к границам
+	// соседних объектов, если привязка выполнялась.
+	Snapping *SnapReport `json:"snapping,omitempty"`
+	// Repair — отчёт об исправлении геометрии импортированного контура,
+	// если заявитель запросил исправление.
+	Repair *RepairReport `json:"repair,omitempty"`
+	// ParentIDs — контуры, из которых образован контур, FormationID —
+	// операция образования. Для контуров, созданных заявителем, не заполняются;
+	// у контура, полученного геометрической операцией, ParentIDs содержит
+	// исходный контур.
+	ParentIDs   []string `json:"parent_ids,omitempty"`
+	FormationID string   `json:"formation_id,omitempty"`
+}
+
+// ContourVersion — неизменяемая запись истории контура: состояние контура
+// после изменения, его автор, время и причина.
+type ContourVersion struct {
+	Version int `json:"version"`
+	// Operation — вид изменения: create при создании контура, далее
+	// операции редактирования (replace_geometry, move_vertex и т. д.).
+	Operation string    `json:"operation"`
+	Author    string    `json:"author,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Contour — состояние контура в этой версии; в списке версий не заполняется.
+	Contour *Contour `json:"contour,omitempty"`
+}
+
+// ContourDiff — различия двух версий контура.
+//
+// Перемещённая вершина отображается как удалённая и добавленная. Приращения
+// площади и периметра вычисляются как значение в версии To минус значение
+// в версии From.
+type ContourDiff struct {
+	ContourID          string  `json:"contour_id"`
+	From               int     `json:"from"`
+	To                 int     `json:"to"`
+	AddedVertices      []Point `json:"added_vertices"`
+	RemovedVertices    []Point `json:"removed_vertices"`
+	AreaDeltaSqM       float64 `json:"area_delta_sq_m"`
+	PerimeterDeltaM    float64 `json:"perimeter_delta_m"`
+	DescriptionChanged bool    `json:"description_changed"`
+}
+
+// SnapReport — изменения, внесённые в нарисованный контур при привязке к
+// границам соседних контуров, готовых участков и участков ЕГРН. Заявитель
+// подтверждает их или перерисовывает контур.
+type SnapReport struct {
+	ToleranceM   float64    `json:"tolerance_m"`
+	Moves        []SnapMove `json:"moves"`
+	AreaDeltaSqM float64    `json:"area_delta_sq_m"`
+}
+
+// RepairReport — исправления, внесённые в геометрию контура при импорте.
+// Индексы частей, колец и вершин в Fixes относятся к исходной геометрии.
+type RepairReport struct {
+	ToleranceM   float64         `json:"tolerance_m"`
+	Fixes        []geo.RepairFix `json:"fixes"`
+	AreaDeltaSqM float64         `json:"area_delta_sq_m"`
+}
+
+// SnapMove — перенос или добавление вершины при привязке.
+//
+// Kind: vertex — вершина перенесена в вершину соседней границы, edge — на
+// её ребро, inserted — вершина соседней границы добавлена в ребро контура
+// после вершины Vertex. Индексы относятся к нарисованной геометрии.
+type SnapMove struct {
+	Part       int          `json:"part"`
+	Ring       int          `json:"ring"`
+	Vertex     int          `json:"vertex"`
+	Kind       string       `json:"kind"`
+	From       Point        `json:"from"`
+	To         Point        `json:"to"`
+	DistanceM  float64      `json:"distance_m"`
+	ObjectKind ConflictKind `json:"object_kind"`
+	ObjectID   string       `json:"object_id"`
+}
+
+// FormationKind — способ образования земельных участков.
+type FormationKind string
+
+const (
+	// FormationSplit — раздел участка на несколько участков.
+	FormationSplit FormationKind = "split"
+	// FormationMerge — объединение смежных участков.
+	FormationMerge FormationKind = "merge"
+	// FormationRedistribute — перераспределение участков с изменением общей границы.
+	FormationRedistribute FormationKind = "redistribute"
+	// FormationCarveOut — выдел участка из исходного, который сохраняется в
+	// изменённых границах.
+	FormationCarveOut FormationKind = "carve_out"
+)
+
+// Formation — выполненная операция образования участков: исходные и
+// образованные контуры и сверка их площадей.
+type Formation struct {
+	ID        string        `json:"id"`
+	Kind      FormationKind `json:"kind"`
+	SourceIDs []string      `json:"source_ids"`
+	ResultIDs []string      `json:"result_ids"`
+	// SourceAreaSqM — площадь исходных участков, ResultAreaSqM — сумма
+	// площадей образованных, DiscrepancySqM — их расхождение по модулю.
+	SourceAreaSqM  float64   `json:"source_area_sq_m"`
+	ResultAreaSqM  float64   `json:"result_area_sq_m"`
+	DiscrepancySqM float64   `json:"discrepancy_sq_m"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// FormationResult — операция образования вместе с образованными контурами.
+type FormationResult struct {
+	Formation Formation `json:"formation"`
+	Contours  []Contour `json:"contours"`
+}
+
+// GeometryOperationKind — вид геометрической операции над контуром.
+type GeometryOperationKind string
+
+const (
+	// OperationSimplify — упрощение границы с заданным допуском.
+	OperationSimplify GeometryOperationKind = "simplify"
+	// OperationDensify — уплотнение границы: добавление вершин в длинные рёбра.
+	OperationDensify GeometryOperationKind = "densify"
+	// OperationBuffer — буферная зона на заданном расстоянии от границы.
+	OperationBuffer GeometryOperationKind = "buffer"
+)
+
+// GeometryOperationResult — контур, полученный геометрической операцией, и
+// сводка изменений относительно исходного контура.
+type GeometryOperationResult struct {
+	Operation      GeometryOperationKind `json:"operation"`
+	SourceID       string                `json:"source_id"`
+	Contour        Contour               `json:"contour"`
+	VerticesBefore int                   `json:"vertices_before"`
+	VerticesAfter  int                   `json:"vertices_after"`
+	AreaDeltaSqM   float64               `json:"area_delta_sq_m"`
+}
+
+// ConflictKind описывает вид объекта, на который накладывается контур.
+type ConflictKind string
+
+const (
+	// ConflictContour — контур другого заявителя.
+	ConflictContour ConflictKind = "contour"
+	// ConflictApplication — контур, по которому уже подано обращение:
+	// сформирован комплект документов или запущен бизнес-процесс.
+	ConflictApplication ConflictKind = "application"
+	// ConflictReadyParcel — участок из перечня готовых участков.
+	ConflictReadyParcel ConflictKind = "ready_parcel"
+	// ConflictCadastralParcel — участок из справочника кадастровых данных.
+	ConflictCadastralParcel ConflictKind = "cadastral_parcel"
+)
+
+// ContourConflict описывает наложение контура на другой объект.
+//
+// Доли указываются в процентах от площади проверяемого контура
+// (ContourPercent) и от площади объекта (ObjectPercent); по ним оператор
+// отличает погрешность оцифровки смежной границы от захвата чужого участка.
+type ContourConflict struct {
+	Kind ConflictKind `json:"kind"`
+	// ObjectID — идентификатор контура или готового участка либо кадастровый номер.
+	ObjectID       string          `json:"object_id"`
+	Name           string          `json:"name,omitempty"`
+	AreaSqM        float64         `json:"area_sq_m"`
+	ContourPercent float64         `json:"contour_percent"`
+	ObjectPercent  float64         `json:"object_percent"`
+	Overlap        ContourGeometry `json:"overlap"`
+}
+
+// ConflictReport — результат проверки контура на наложения.
+type ConflictReport struct {
+	ContourID string            `json:"contour_id"`
+	Conflicts []ContourConflict `json:"conflicts"`
+	CheckedAt time.Time         `json:"checked_at"`
+}
+
+// ContourMetrics содержит метрики контура, вычисленные на эллипсоиде WGS84.
+//
+// Значения рассчитываются один раз при сохранении контура, поэтому карточки,
+// документы и объекты слоя используют одни и те же числа.
+type ContourMetrics struct {
+	AreaSqM    float64 `json:"area_sq_m"`
+	AreaHa     float64 `json:"area_ha"`
+	PerimeterM float64 `json:"perimeter_m"`
+	Centroid   Point   `json:"centroid"`
+	LabelPoint Point   `json:"label_point"`
 }
 
 // Attribute описывает пару ключ-значение в информационной карточке земельного участка.
@@ -54,6 +313,9 @@ type Attribute struct {
 	Comment string `json:"comment,omitempty"`
 }
 
+// AttributeSourceComputed обозначает атрибуты, вычисленные сервисом по геометрии контура.
+const AttributeSourceComputed = "computed"
+
 // InformationCard хранит набор атрибутов для созданного контура земельного участка.
 //
 // Поля AutoAttributes и ManualAttributes разделены для того, чтобы можно было
@@ -66,6 +328,46 @@ type InformationCard struct {
 	CreatedAt        time.Time   `json:"created_at"`
 }
 
+// ImportedContour — контур, созданный из объекта импортированного файла,
+// вместе с карточкой, в которую перенесены атрибуты объекта.
+type ImportedContour struct {
+	// Layer и Record указывают на объект в файле: слой и номер записи с единицы.
+	Layer   string          `json:"layer,omitempty"`
+	Record  int             `json:"record"`
+	Contour Contour         `json:"contour"`
+	Card    InformationCard `json:"card"`
+}
+
+// ImportError описывает объект файла, который не удалось импортировать.
+type ImportError struct {
+	Layer      string          `json:"layer,omitempty"`
+	Record     int             `json:"record"`
+	Message    string          `json:"message"`
+	Violations []geo.Violation `json:"violations,omitempty"`
+}
+
+// ImportResult — итог импорта файла с несколькими объектами: каждый объект
+// становится отдельным контуром, ошибочные объекты перечисляются в Errors.
+type ImportResult struct {
+	Contours []ImportedContour `json:"contours"`
+	Errors   []ImportError     `json:"errors,omitempty"`
+	// Parcels — участки, сведения о которых сохранены в справочник кадастровых
+	// данных (заполняется при импорте документов Росреестра).
+	Parcels []CadastralParcel `json:"parcels,omitempty"`
+}
+
+// DrawingPolyline — замкнутая полилиния чертежа DXF, которую можно выбрать
+// контуром участка.
+type DrawingPolyline struct {
+	// Number — номер полилинии среди подходящих с единицы, Handle — её
+	// дескриптор в чертеже; любой из них указывается при выборе.
+	Number   int     `json:"number"`
+	Handle   string  `json:"handle,omitempty"`
+	Layer    string  `json:"layer"`
+	Vertices int     `json:"vertices"`
+	AreaSqM  float64 `json:"area_sq_m"`
+}
+
 // ParcelCategory определяет категорию готового земельного участка, доступного для выбора.
 type ParcelCategory string
 
@@ -87,22 +389,332 @@ type ReadyParcel struct {
 	Available   bool           `json:"available"`
 }
 
+// CadastralParcel — земельный участок из сведений ЕГРН, загруженный из
+// кадастрового плана территории или выписки.
+//
+// Участки образуют локальный справочник кадастровых данных, с которым
+// сверяются контуры заявителей. Повторная загрузка участка с тем же
+// кадастровым номером заменяет прежние сведения.
+type CadastralParcel struct {
+	CadastralNumber  string        `json:"cadastral_number"`
+	CadastralBlock   string        `json:"cadastral_block,omitempty"`
+	CategoryCode     string        `json:"category_code,omitempty"`
+	Category         string        `json:"category,omitempty"`
+	PermittedUse     string        `json:"permitted_use,omitempty"`
+	PermittedUseCode string        `json:"permitted_use_code,omitempty"`
+	AreaSqM          float64       `json:"area_sq_m,omitempty"`
+	AreaInaccuracy   float64       `json:"area_inaccuracy,omitempty"`
+	Address          string        `json:"address,omitempty"`
+	Encumbrances     []Encumbrance `json:"encumbrances,omitempty"`
+	// ContourID и Geometry — контур, созданный при загрузке; пусты, если
+	// границы участка не установлены.
+	ContourID string   `json:"contour_id,omitempty"`
+	Geometry  *Contour `json:"geometry,omitempty"`
+	// Source — имя загруженного файла, SourceKind — вид документа (КПТ, выписка ЕГРН),
+	// SourceDate — дата его формирования.
+	Source     string    `json:"source"`
+	SourceKind string    `json:"source_kind"`
+	SourceDate string    `json:"source_date,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Encumbrance — ограничение прав или обременение земельного участка.
+type Encumbrance struct {
+	Number           string `json:"number,omitempty"`
+	TypeCode         string `json:"type_code,omitempty"`
+	Type             string `json:"type"`
+	RegistrationDate string `json:"registration_date,omitempty"`
+}
+
+// RestrictionZone — зона с особыми условиями использования территории
+// (ЗОУИТ): охранная зона инженерных сетей, водоохранная зона, зона охраны
+// объекта культурного наследия и т. п.
+//
+// Зоны образуют реестр, с которым сверяются контуры заявителей. Повторная
+// загрузка зоны с тем же идентификатором заменяет прежние сведения.
+type RestrictionZone struct {
+	// ID — реестровый номер зоны, а если он не указан в файле — присвоенный
+	// сервисом идентификатор.
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// Type — вид зоны, например «Охранная зона объектов электросетевого хозяйства».
+	Type string `json:"type"`
+	// LegalBasis — реквизиты документа, которым установлена зона.
+	LegalBasis string `json:"legal_basis,omitempty"`
+	// Restrictions — ограничения использования земельных участков в границах зоны.
+	Restrictions string          `json:"restrictions,omitempty"`
+	Geometry     ContourGeometry `json:"geometry"`
+	AreaSqM      float64         `json:"area_sq_m"`
+	// Source — имя файла, из которого загружена зона.
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ZoneIntersection описывает часть контура, попадающую в зону с особыми
+// условиями использования территории.
+type ZoneIntersection struct {
+	ZoneID       string  `json:"zone_id"`
+	Name         string  `json:"name,omitempty"`
+	Type         string  `json:"type"`
+	LegalBasis   string  `json:"legal_basis,omitempty"`
+	Restrictions string  `json:"restrictions,omitempty"`
+	AreaSqM      float64 `json:"area_sq_m"`
+	// ContourPercent — доля площади контура в границах зоны, в процентах.
+	ContourPercent float64         `json:"contour_percent"`
+	Overlap        ContourGeometry `json:"overlap"`
+}
+
+// ZoneReport — зоны с особыми условиями использования территории, в границах
+// которых расположен контур. EncumberedAreaSqM учитывает перекрытие зон
+// между собой только один раз.
+type ZoneReport struct {
+	ContourID         string             `json:"contour_id"`
+	Zones             []ZoneIntersection `json:"zones"`
+	EncumberedAreaSqM float64            `json:"encumbered_area_sq_m"`
+	EncumberedPercent float64            `json:"encumbered_percent"`
+}
+
+// ZoneImportResult — итог загрузки зон в реестр: сохранённые зоны и объекты
+// файла, которые не удалось загрузить.
+type ZoneImportResult struct {
+	Zones  []RestrictionZone `json:"zones"`
+	Errors []ImportError     `json:"errors,omitempty"`
+}
+
+// PermittedUseKind — вид разрешённого использования по отношению к
+// территориальной зоне.
+type PermittedUseKind string
+
+const (
+	// PermittedUseMain — основной вид разрешённого использования.
+	PermittedUseMain PermittedUseKind = "main"
+	// PermittedUseConditional — условно разрешённый вид; требует отдельного
+	// разрешения после общественных обсуждений.
+	PermittedUseConditional PermittedUseKind = "conditional"
+	// PermittedUseAuxiliary — вспомогательный вид, допустимый только вместе
+	// с основным или условно разрешённым.
+	PermittedUseAuxiliary PermittedUseKind = "auxiliary"
+)
+
+// ZonePermittedUse — вид разрешённого использования, установленный для
+// территориальной зоны, с предельными размерами участков для этого вида.
+// Нулевой предел означает, что для вида действует предел зоны.
+type ZonePermittedUse struct {
+	// Code — код по классификатору видов разрешённого использования, например «2.1».
+	Code       string           `json:"code"`
+	Name       string           `json:"name,omitempty"`
+	Kind       PermittedUseKind `json:"kind"`
+	MinAreaSqM float64          `json:"min_area_sq_m,omitempty"`
+	MaxAreaSqM float64          `json:"max_area_sq_m,omitempty"`
+}
+
+// TerritorialZone — территориальная зона правил землепользования и застройки
+// (ПЗЗ) с градостроительным регламентом: видами разрешённого использования и
+// предельными размерами участков. Нулевой предел размера не ограничивает.
+type TerritorialZone struct {
+	// ID — реестровый номер зоны, а если он не указан — присвоенный сервисом
+	// идентификатор. Code — индекс зоны в ПЗЗ, например «Ж-1»; одна зона
+	// ПЗЗ может состоять из нескольких объектов с одним индексом.
+	ID         string             `json:"id"`
+	Code       string             `json:"code"`
+	Name       string             `json:"name,omitempty"`
+	Uses       []ZonePermittedUse `json:"uses"`
+	MinAreaSqM float64            `json:"min_area_sq_m,omitempty"`
+	MaxAreaSqM float64            `json:"max_area_sq_m,omitempty"`
+	Geometry   ContourGeometry    `json:"geometry"`
+	AreaSqM    float64            `json:"area_sq_m"`
+	Source     string             `json:"source"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// TerritorialZoneImportResult — итог загрузки территориальных зон.
+type TerritorialZoneImportResult struct {
+	Zones  []TerritorialZone `json:"zones"`
+	Errors []ImportError     `json:"errors,omitempty"`
+}
+
+// ZoningViolationCode — машиночитаемый код несоответствия участка ПЗЗ.
+type ZoningViolationCode string
+
+const (
+	// ZoningOutsideZones — участок вне границ территориальных зон.
+	ZoningOutsideZones ZoningViolationCode = "outside_zones"
+	// ZoningPartiallyOutside — часть участка вне границ территориальных зон.
+	ZoningPartiallyOutside ZoningViolationCode = "partially_outside"
+	// ZoningCrossesZones — участок пересекает границу территориальных зон.
+	ZoningCrossesZones ZoningViolationCode = "crosses_zone_boundary"
+	// ZoningUseNotDeclared — не указан вид разрешённого использования.
+	ZoningUseNotDeclared ZoningViolationCode = "use_not_declared"
+	// ZoningUseNotPermitted — вид использования не предусмотрен регламентом зоны.
+	ZoningUseNotPermitted ZoningViolationCode = "use_not_permitted"
+	// ZoningConditionalUse — вид использования условно разрешённый.
+	ZoningConditionalUse ZoningViolationCode = "conditional_use"
+	// ZoningAuxiliaryUse — вид использования только вспомогательный.
+	ZoningAuxiliaryUse ZoningViolationCode = "auxiliary_use"
+	// ZoningAreaBelowMinimum — площадь участка меньше предельного минимума.
+	ZoningAreaBelowMinimum ZoningViolationCode = "area_below_minimum"
+	// ZoningAreaAboveMaximum — площадь участка больше предельного максимума.
+	ZoningAreaAboveMaximum ZoningViolationCode = "area_above_maximum"
+)
+
+// ZoningViolation — несоответствие участка градостроительному регламенту.
+// Нарушения с уровнем error препятствуют образованию участка, warning
+// требуют внимания заявителя.
+type ZoningViolation struct {
+	Code     ZoningViolationCode `json:"code"`
+	Severity geo.Severity        `json:"severity"`
+	ZoneID   string              `json:"zone_id,omitempty"`
+	Message  string              `json:"message"`
+}
+
+// ZoningMatch — часть участка в границах территориальной зоны.
+type ZoningMatch struct {
+	ZoneID         string  `json:"zone_id"`
+	Code           string  `json:"code"`
+	Name           string  `json:"name,omitempty"`
+	AreaSqM        float64 `json:"area_sq_m"`
+	ContourPercent float64 `json:"contour_percent"`
+}
+
+// ZoningReport — результат проверки участка и заявленного вида разрешённого
+// использования по правилам землепользования и застройки. Регламент
+// проверяется по зоне, в которой расположена большая часть участка;
+// MinAreaSqM и MaxAreaSqM — применённые предельные размеры.
+type ZoningReport struct {
+	ContourID    string            `json:"contour_id"`
+	PermittedUse string            `json:"permitted_use,omitempty"`
+	AreaSqM      float64           `json:"area_sq_m"`
+	Zones        []ZoningMatch     `json:"zones"`
+	MinAreaSqM   float64           `json:"min_area_sq_m,omitempty"`
+	MaxAreaSqM   float64           `json:"max_area_sq_m,omitempty"`
+	Violations   []ZoningViolation `json:"violations"`
+	Compliant    bool              `json:"compliant"`
+}
+
 // Document описывает отдельный документ или материал, входящий в комплект.
 type Document struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Source      string `json:"source"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Source      string      `json:"source"`
+	Attributes  []Attribute `json:"attributes,omitempty"`
+	// Files — сформированные файлы документа. Содержимое файлов хранится
+	// отдельно и выгружается по идентификатору.
+	Files []DocumentFile `json:"files,omitempty"`
+}
+
+// DocumentFile описывает файл документа из комплекта.
+type DocumentFile struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	MediaType string `json:"media_type"`
+	Size      int    `json:"size"`
+}
+
+// CoordinateStatement — ведомость координат характерных точек границ
+// участка в прямоугольной системе координат (как правило, МСК).
+//
+// Координаты, длины линий и средние квадратические погрешности округляются
+// до 0,01 м, дирекционные углы — до секунды, площадь и её погрешность — до
+// 1 м². Дирекционные углы, длины линий и площадь вычисляются по округлённым
+// координатам, поэтому согласуются с ведомостью.
+type CoordinateStatement struct {
+	ContourID      string `json:"contour_id"`
+	ContourVersion int    `json:"contour_version"`
+	Description    string `json:"description,omitempty"`
+	CRS            string `json:"crs"`
+	CRSName        string `json:"crs_name"`
+	// Contours — границы по порядку: внешняя граница каждой части и её
+	// внутренние границы.
+	Contours     []StatementContour `json:"contours"`
+	AreaSqM      float64            `json:"area_sq_m"`
+	AreaErrorSqM float64            `json:"area_error_sq_m"`
+	PerimeterM   float64            `json:"perimeter_m"`
+	// MeanSquareErrorM — средняя квадратическая погрешность положения
+	// характерных точек, по которой вычислена погрешность площади.
+	MeanSquareErrorM float64 `json:"mean_square_error_m"`
+}
+
+// StatementContour — одна граница в ведомости координат. Points начинается
+// и заканчивается одной и той же точкой.
+type StatementContour struct {
+	Part   int              `json:"part"`
+	Ring   int              `json:"ring"`
+	Points []StatementPoint `json:"points"`
+}
+
+// StatementPoint — строка ведомости координат: характерная точка, а также
+// дирекционный угол и длина линии до следующей точки. У замыкающей точки
+// границы угол и длина не заполняются.
+type StatementPoint struct {
+	Number string  `json:"number"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	// DirectionalAngle — дирекционный угол в виде «ГГГ°ММ'СС"»,
+	// DirectionalAngleDeg — он же в градусах.
+	DirectionalAngle    string  `json:"directional_angle,omitempty"`
+	DirectionalAngleDeg float64 `json:"directional_angle_deg"`
+	DistanceM           float64 `json:"distance_m"`
+	MeanSquareErrorM    float64 `json:"mean_square_error_m"`
+}
+
+// Applicant — сведения о заявителе, которые подставляются в заявление и
+// согласие на обработку персональных данных.
+type Applicant struct {
+	FullName         string `json:"full_name"`
+	IdentityDocument string `json:"identity_document,omitempty"`
+	Address          string `json:"address,omitempty"`
+	Phone            string `json:"phone,omitempty"`
+	Email            string `json:"email,omitempty"`
+	SNILS            string `json:"snils,omitempty"`
+	INN              string `json:"inn,omitempty"`
 }
 
 // DocumentPackage представляет комплект документов для подачи обращения.
 type DocumentPackage struct {
-	ID          string     `json:"id"`
-	ParcelID    string     `json:"parcel_id"`
-	ContourID   string     `json:"contour_id"`
-	Documents   []Document `json:"documents"`
-	CreatedAt   time.Time  `json:"created_at"`
-	GeneratedBy string     `json:"generated_by"`
+	ID        string `json:"id"`
+	ParcelID  string `json:"parcel_id"`
+	ContourID string `json:"contour_id"`
+	// ContourVersion — версия контура, по которой сформирован комплект.
+	ContourVersion int        `json:"contour_version,omitempty"`
+	Documents      []Document `json:"documents"`
+	CreatedAt      time.Time  `json:"created_at"`
+	GeneratedBy    string     `json:"generated_by"`
+}
+
+// PackageManifest — опись архива комплекта документов: по ней получатель
+// проверяет, что комплект передан полностью и без искажений.
+type PackageManifest struct {
+	ManifestVersion int    `json:"manifest_version"`
+	PackageID       string `json:"package_id"`
+	ContourID       string `json:"contour_id,omitempty"`
+	ContourVersion  int    `json:"contour_version,omitempty"`
+	ParcelID        string `json:"parcel_id,omitempty"`
+	// GeneratedBy повторяет одноимённое поле комплекта: источники данных и
+	// версии шаблонов.
+	GeneratedBy string            `json:"generated_by"`
+	Generator   ManifestGenerator `json:"generator"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Files       []ManifestFile    `json:"files"`
+}
+
+// ManifestGenerator — программа, сформировавшая комплект.
+type ManifestGenerator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ManifestFile — файл архива комплекта.
+type ManifestFile struct {
+	// Path — путь к файлу в архиве.
+	Path         string `json:"path"`
+	FileID       string `json:"file_id"`
+	DocumentID   string `json:"document_id"`
+	DocumentName string `json:"document_name"`
+	MediaType    string `json:"media_type"`
+	Size         int    `json:"size"`
+	// SHA256 — контрольная сумма содержимого в шестнадцатеричном виде.
+	SHA256 string `json:"sha256"`
 }
 
 // BusinessStageStatus описывает состояние этапа бизнес-процесса.
@@ -130,10 +742,15 @@ type BusinessStage struct {
 
 // BusinessProcess агрегирует этапы государственной или муниципальной услуги.
 type BusinessProcess struct {
-	ID        string          `json:"id"`
-	Name      string          `json:"name"`
-	Stages    []BusinessStage `json:"stages"`
-	CreatedAt time.Time       `json:"created_at"`
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Stages []BusinessStage `json:"stages"`
+	// ContourID — контур, по которому ведётся процесс; Conflicts — наложения
+	// контура по последней проверке, которые оператор учитывает при
+	// рассмотрении обращения.
+	ContourID string            `json:"contour_id,omitempty"`
+	Conflicts []ContourConflict `json:"conflicts,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
 }
 
 // AssistantSuggestion описывает подсказку цифрового помощника.
@@ -145,10 +762,14 @@ type AssistantSuggestion struct {
 
 // LayerFeature описывает объект слоя «Земля просто» для отображения на публичном портале.
 type LayerFeature struct {
-	ID         string            `json:"id"`
-	Geometry   Contour           `json:"geometry"`
-	Properties map[string]string `json:"properties"`
-	UpdatedAt  time.Time         `json:"updated_at"`
+	ID string `json:"id"`
+	// ContourID и ContourVersion указывают контур и его версию, по которой
+	// опубликован объект.
+	ContourID      string            `json:"contour_id,omitempty"`
+	ContourVersion int               `json:"contour_version,omitempty"`
+	Geometry       Contour           `json:"geometry"`
+	Properties     map[string]string `json:"properties"`
+	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
 // Layer агрегирует набор объектов слоя «Земля просто».
@@ -0,0 +1,138 @@
+package plot
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/formats/gpx"
+	"zemlya-prosto/internal/formats/kml"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/geojson"
+)
+
+// ErrInvalidGeometry возвращается, если геометрию черновика не удалось разобрать.
+var ErrInvalidGeometry = errors.New("invalid geometry")
+
+// Форматы геометрии черновика контура.
+const (
+	// FormatGeoJSON — геометрия GeoJSON (по умолчанию).
+	FormatGeoJSON = "geojson"
+	// FormatKML — документ KML из Google Earth.
+	FormatKML = "kml"
+	// FormatKMZ — архив KMZ в кодировке base64.
+	FormatKMZ = "kmz"
+	// FormatGPX — трек, маршрут или путевые точки GPS-приёмника.
+	FormatGPX = "gpx"
+)
+
+// draftRepairOptions возвращает параметры исправления геометрии черновика
+// или nil, если исправление не запрошено.
+func draftRepairOptions(draft ContourDraft) (*geo.RepairOptions, error) {
+	if !draft.Repair {
+		return nil, nil
+	}
+	if math.IsNaN(draft.RepairToleranceM) || draft.RepairToleranceM < 0 || draft.RepairToleranceM > geo.MaxRepairTolerance {
+		return nil, fmt.Errorf("%w: repair tolerance must be between 0 and %g m", ErrInvalidGeometry, geo.MaxRepairTolerance)
+	}
+	opts := geo.DefaultRepairOptions()
+	if draft.RepairToleranceM > 0 {
+		opts.Tolerance = draft.RepairToleranceM
+	}
+	return &opts, nil
+}
+
+// parseDraftGeometry разбирает геометрию черновика, переводит её в WGS 84 и
+// проверяет топологию. Если задан repair, геометрия перед проверкой
+// исправляется. Возвращается нормализованная геометрия, предупреждения и
+// выполненные исправления.
+func parseDraftGeometry(raw, format string, system *crs.CRS, repair *geo.RepairOptions) (geojson.Geometry, []geo.Violation, []geo.RepairFix, error) {
+	var shape geo.MultiPolygon
+	// Кольца GeoJSON должны быть замкнуты явно, KML и GPX замыкаются при чтении.
+	validation := geo.DefaultValidationOptions()
+	switch strings.ToLower(format) {
+	case "", FormatGeoJSON:
+		validation = geojson.ValidationOptions(geojson.Options{})
+		opts := geojson.Options{Projected: !system.IsGeographic()}
+		obj, err := geojson.ParseOptions([]byte(raw), opts)
+		if err != nil {
+			return geojson.Geometry{}, nil, nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+		shape = system.ToWGS84Multi(obj.Shape())
+	case FormatKML, FormatKMZ, FormatGPX:
+		if system != crs.WGS84 {
+			return geojson.Geometry{}, nil, nil, fmt.Errorf("%w: %s coordinates are always WGS 84, got crs %s", ErrInvalidGeometry, format, system.ID)
+		}
+		var err error
+		if shape, err = parseDeviceGeometry(raw, strings.ToLower(format)); err != nil {
+			return geojson.Geometry{}, nil, nil, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+		}
+	default:
+		return geojson.Geometry{}, nil, nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidGeometry, format)
+	}
+
+	var fixes []geo.RepairFix
+	if repair != nil {
+		shape, fixes = geo.Repair(shape, *repair)
+	}
+	violations, err := geo.Check(shape, validation)
+	if err != nil {
+		return geojson.Geometry{}, nil, nil, err
+	}
+	return geojson.NewGeometry(shape), geo.Warnings(violations), fixes, nil
+}
+
+// parseDeviceGeometry читает KML, KMZ или GPX. Все полигоны документа (или
+// кольца треков) объединяются в один многоконтурный участок.
+func parseDeviceGeometry(raw, format string) (geo.MultiPolygon, error) {
+	var shape geo.MultiPolygon
+	switch format {
+	case FormatKML, FormatKMZ:
+		data := []byte(raw)
+		if format == FormatKMZ {
+			decoded, err := base64.StdEncoding.DecodeString(raw)
+			if err != nil {
+				return nil, fmt.Errorf("kmz must be base64-encoded: %v", err)
+			}
+			data = decoded
+		}
+		placemarks, err := kml.Read(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, pm := range placemarks {
+			shape = append(shape, pm.Shape...)
+		}
+	case FormatGPX:
+		boundaries, err := gpx.Unmarshal([]byte(raw))
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range boundaries {
+			shape = append(shape, geo.Polygon{b.Ring})
+		}
+	}
+	return shape, nil
+}
+
+// KML возвращает контур документом KML в WGS 84.
+func (c Contour) KML() []byte {
+	data := make([]kml.Data, 0, len(c.Attributes)+1)
+	data = append(data, kml.Data{Name: "id", Value: c.ID})
+	keys := make([]string, 0, len(c.Attributes))
+	for key := range c.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		data = append(data, kml.Data{Name: key, Value: c.Attributes[key]})
+	}
+	return kml.Marshal(kml.Document{
+		Name:       c.ID,
+		Placemarks: []kml.Placemark{{Name: c.ID, Data: data, Shape: c.Shape.Shape}},
+	})
+}
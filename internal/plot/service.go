@@ -6,6 +6,11 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/geojson"
+	"zemlya-prosto/internal/spatial"
 )
 
 // Contour описывает сохранённый контур земельного участка.
@@ -15,6 +20,23 @@ type Contour struct {
 	Geometry   string            `json:"geometry"`
 	Attributes map[string]string `json:"attributes"`
 	CreatedAt  time.Time         `json:"createdAt"`
+	// CRS — система координат, в которой заявитель передал геометрию.
+	// Geometry всегда хранится в WGS 84.
+	CRS string `json:"crs,omitempty"`
+	// BBox — охват геометрии в порядке GeoJSON: [minX, minY, maxX, maxY].
+	BBox []float64 `json:"bbox,omitempty"`
+	// AreaSqM, PerimeterM и Centroid вычисляются функцией geo.Measure на
+	// эллипсоиде WGS 84, как и метрики участков каталога.
+	AreaSqM    float64   `json:"areaSqM"`
+	PerimeterM float64   `json:"perimeterM"`
+	Centroid   geo.Point `json:"centroid"`
+	// Warnings — неблокирующие замечания топологической проверки геометрии.
+	Warnings []geo.Violation `json:"warnings,omitempty"`
+	// Repairs — исправления, внесённые в геометрию, если черновик
+	// запрашивал исправление.
+	Repairs []geo.RepairFix `json:"repairs,omitempty"`
+	// Shape — типизированная нормализованная геометрия, соответствующая Geometry.
+	Shape geojson.Geometry `json:"-"`
 }
 
 // ContourDraft содержит данные для создания контура.
@@ -22,20 +44,39 @@ type ContourDraft struct {
 	OwnerID    string            `json:"ownerId"`
 	Geometry   string            `json:"geometry"`
 	Attributes map[string]string `json:"attributes"`
+	// CRS — идентификатор системы координат геометрии (например, EPSG:28407
+	// или MSK-50-1). По умолчанию используется WGS 84.
+	CRS string `json:"crs"`
+	// Format — формат Geometry: geojson (по умолчанию), kml, kmz (base64)
+	// или gpx. KML и GPX всегда задаются в WGS 84.
+	Format string `json:"format,omitempty"`
+	// Repair включает исправление некорректной геометрии (самопересечений,
+	// шпор, лишних вершин, узких щелей) перед проверкой топологии.
+	// RepairToleranceM — допуск исправления в метрах, по умолчанию 5 см.
+	Repair           bool    `json:"repair,omitempty"`
+	RepairToleranceM float64 `json:"repairToleranceM,omitempty"`
 }
 
 // Service определяет операции сервиса моделирования участков.
 type Service interface {
 	CreateContour(ctx context.Context, draft ContourDraft) (Contour, error)
 	ListContours(ctx context.Context, ownerID string) ([]Contour, error)
+	// SearchContours возвращает контуры владельца ownerID (или всех владельцев),
+	// попадающие под пространственное условие q.
+	SearchContours(ctx context.Context, ownerID string, q spatial.Query) ([]Contour, error)
+	ExportContour(ctx context.Context, id, crsID string) (Contour, error)
 }
 
+// ErrNotFound возвращается, если контур с указанным идентификатором не найден.
+var ErrNotFound = errors.New("contour not found")
+
 // InMemoryService — временная реализация для прототипирования.
 type InMemoryService struct {
 	mu        sync.RWMutex
 	sequence  int
 	contours  map[string]Contour
 	byOwnerID map[string][]string
+	index     *spatial.Index
 }
 
 // NewInMemoryService создаёт in-memory реализацию.
@@ -43,6 +84,7 @@ func NewInMemoryService() *InMemoryService {
 	return &InMemoryService{
 		contours:  make(map[string]Contour),
 		byOwnerID: make(map[string][]string),
+		index:     spatial.NewIndex(),
 	}
 }
 
@@ -54,6 +96,19 @@ func (s *InMemoryService) CreateContour(ctx context.Context, draft ContourDraft)
 	if err := ctx.Err(); err != nil {
 		return Contour{}, err
 	}
+	system, err := crs.Lookup(draft.CRS)
+	if err != nil {
+		return Contour{}, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+	repair, err := draftRepairOptions(draft)
+	if err != nil {
+		return Contour{}, err
+	}
+	shape, warnings, repairs, err := parseDraftGeometry(draft.Geometry, draft.Format, system, repair)
+	if err != nil {
+		return Contour{}, err
+	}
+	metrics := geo.Measure(shape.Shape)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -62,11 +117,20 @@ func (s *InMemoryService) CreateContour(ctx context.Context, draft ContourDraft)
 	contour := Contour{
 		ID:         fmt.Sprintf("ctr-%d", s.sequence),
 		OwnerID:    draft.OwnerID,
-		Geometry:   draft.Geometry,
+		Geometry:   shape.String(),
 		Attributes: draft.Attributes,
 		CreatedAt:  time.Now().UTC(),
+		CRS:        system.ID,
+		BBox:       shape.BBox().Array(),
+		AreaSqM:    metrics.AreaSqM,
+		PerimeterM: metrics.PerimeterM,
+		Centroid:   metrics.Centroid,
+		Warnings:   warnings,
+		Repairs:    repairs,
+		Shape:      shape,
 	}
 	s.contours[contour.ID] = contour
+	s.index.Insert(contour.ID, shape.BBox())
 	if contour.OwnerID != "" {
 		s.byOwnerID[contour.OwnerID] = append(s.byOwnerID[contour.OwnerID], contour.ID)
 	}
@@ -98,4 +162,55 @@ func (s *InMemoryService) ListContours(ctx context.Context, ownerID string) ([]C
 	return result, nil
 }
 
+// SearchContours возвращает контуры, отобранные по индексу охватов и
+// проверенные по точной геометрии.
+func (s *InMemoryService) SearchContours(ctx context.Context, ownerID string, q spatial.Query) ([]Contour, error) {
+	if q.IsZero() {
+		return s.ListContours(ctx, ownerID)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Contour, 0)
+	for _, id := range s.index.Search(q.Bounds()) {
+		c := s.contours[id]
+		if (ownerID == "" || c.OwnerID == ownerID) && q.Match(c.Shape.Shape) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// ExportContour возвращает контур с геометрией, переведённой в систему crsID.
+// Если система не указана, используется система, в которой контур был создан.
+func (s *InMemoryService) ExportContour(ctx context.Context, id, crsID string) (Contour, error) {
+	if err := ctx.Err(); err != nil {
+		return Contour{}, err
+	}
+
+	s.mu.RLock()
+	contour, ok := s.contours[id]
+	s.mu.RUnlock()
+	if !ok {
+		return Contour{}, ErrNotFound
+	}
+
+	if crsID == "" {
+		crsID = contour.CRS
+	}
+	system, err := crs.Lookup(crsID)
+	if err != nil {
+		return Contour{}, fmt.Errorf("%w: %v", ErrInvalidGeometry, err)
+	}
+	shape := geojson.NewGeometry(system.FromWGS84Multi(contour.Shape.Shape))
+	contour.Geometry = shape.String()
+	contour.BBox = shape.BBox().Array()
+	contour.CRS = system.ID
+	return contour, nil
+}
+
 var _ Service = (*InMemoryService)(nil)
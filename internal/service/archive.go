@@ -0,0 +1,111 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/model"
+)
+
+// Version — версия сервиса, записываемая в опись архива комплекта. При
+// сборке задаётся флагом -ldflags "-X zemlya-prosto/internal/service.Version=...".
+var Version = "dev"
+
+const (
+	// generatorName — наименование программы в описи архива.
+	generatorName = "zemlya-prosto"
+	// manifestVersion — версия формата описи.
+	manifestVersion = 1
+	// ManifestName — имя файла описи в архиве комплекта.
+	ManifestName = "manifest.json"
+)
+
+// DocumentArchive — комплект документов, подготовленный к выгрузке в ZIP:
+// опись и содержимое файлов в порядке описи.
+type DocumentArchive struct {
+	Manifest model.PackageManifest
+	data     [][]byte
+}
+
+// DocumentArchive собирает файлы комплекта и составляет опись с
+// контрольными суммами SHA-256. Имена файлов, совпадающие у разных
+// документов, дополняются номером.
+func (s *Service) DocumentArchive(packageID string) (*DocumentArchive, error) {
+	pkg, err := s.store.GetDocumentPackage(packageID)
+	if err != nil {
+		return nil, fmt.Errorf("комплект документов не найден: %w", err)
+	}
+	archive := &DocumentArchive{Manifest: model.PackageManifest{
+		ManifestVersion: manifestVersion,
+		PackageID:       pkg.ID,
+		ContourID:       pkg.ContourID,
+		ContourVersion:  pkg.ContourVersion,
+		ParcelID:        pkg.ParcelID,
+		GeneratedBy:     pkg.GeneratedBy,
+		Generator:       model.ManifestGenerator{Name: generatorName, Version: Version},
+		CreatedAt:       pkg.CreatedAt,
+		Files:           make([]model.ManifestFile, 0),
+	}}
+	used := map[string]bool{ManifestName: true}
+	for _, doc := range pkg.Documents {
+		for _, f := range doc.Files {
+			file, data, err := s.store.GetDocumentFile(f.ID)
+			if err != nil {
+				return nil, fmt.Errorf("файл %s документа «%s»: %w", f.ID, doc.Name, err)
+			}
+			name := file.Name
+			ext := path.Ext(name)
+			for n := 2; used[name]; n++ {
+				name = strings.TrimSuffix(file.Name, ext) + "-" + strconv.Itoa(n) + ext
+			}
+			used[name] = true
+			sum := sha256.Sum256(data)
+			archive.Manifest.Files = append(archive.Manifest.Files, model.ManifestFile{
+				Path:         name,
+				FileID:       file.ID,
+				DocumentID:   doc.ID,
+				DocumentName: doc.Name,
+				MediaType:    file.MediaType,
+				Size:         len(data),
+				SHA256:       hex.EncodeToString(sum[:]),
+			})
+			archive.data = append(archive.data, data)
+		}
+	}
+	return archive, nil
+}
+
+// WriteZIP записывает архив в w по мере сжатия файлов: первой — опись
+// manifest.json, затем файлы комплекта.
+func (a *DocumentArchive) WriteZIP(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifest, err := json.MarshalIndent(a.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := a.writeEntry(zw, ManifestName, manifest); err != nil {
+		return err
+	}
+	for i, f := range a.Manifest.Files {
+		if err := a.writeEntry(zw, f.Path, a.data[i]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (a *DocumentArchive) writeEntry(zw *zip.Writer, name string, data []byte) error {
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.Manifest.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(data)
+	return err
+}
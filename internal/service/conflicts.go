@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+)
+
+// minConflictArea — наименьшая площадь наложения в м², которая считается
+// конфликтом. Меньшие пересечения возникают из-за округления координат
+// смежных границ и заявителю не показываются.
+const minConflictArea = 0.01
+
+// CheckContourConflicts заново проверяет наложения контура на другие контуры,
+// поданные обращения, готовые участки и участки справочника кадастровых
+// данных. Результат сохраняется в контуре и в бизнес-процессах по нему.
+func (s *Service) CheckContourConflicts(contourID string) (model.ConflictReport, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.ConflictReport{}, fmt.Errorf("контур не найден: %w", err)
+	}
+	conflicts := s.findConflicts(contour)
+	if _, err := s.store.SetContourConflicts(contourID, conflicts); err != nil {
+		return model.ConflictReport{}, fmt.Errorf("контур не найден: %w", err)
+	}
+	for _, process := range s.store.ListBusinessProcesses() {
+		if process.ContourID == contourID {
+			process.Conflicts = conflicts
+			s.store.SaveBusinessProcess(process)
+		}
+	}
+	return model.ConflictReport{
+		ContourID: contourID,
+		Conflicts: conflicts,
+		CheckedAt: time.Now(),
+	}, nil
+}
+
+// findConflicts вычисляет наложения контура. Кандидаты отбираются по
+// пространственному индексу, пересечение строится в локальной плоскости
+// контура, а площадь наложения считается на эллипсоиде, как и площадь
+// самого контура.
+func (s *Service) findConflicts(contour model.Contour) []model.ContourConflict {
+	q := spatial.Intersecting(contour.Shape())
+	check := newOverlapCheck(contour)
+
+	cadastral := s.cadastralByContour()
+	applications := make(map[string]struct{})
+	for _, pkg := range s.store.ListDocumentPackages() {
+		applications[pkg.ContourID] = struct{}{}
+	}
+	for _, process := range s.store.ListBusinessProcesses() {
+		applications[process.ContourID] = struct{}{}
+	}
+
+	conflicts := make([]model.ContourConflict, 0)
+	for _, other := range s.store.SearchContours(q) {
+		if other.ID == contour.ID || related(contour, other) {
+			continue
+		}
+		conflict, ok := check.overlap(other)
+		if !ok {
+			continue
+		}
+		conflict.ObjectID, conflict.Kind, conflict.Name = other.ID, model.ConflictContour, other.Description
+		if parcel, ok := cadastral[other.ID]; ok {
+			conflict.ObjectID, conflict.Kind, conflict.Name = parcel.CadastralNumber, model.ConflictCadastralParcel, parcel.Address
+		} else if _, ok := applications[other.ID]; ok {
+			conflict.Kind = model.ConflictApplication
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	for _, parcel := range s.store.SearchReadyParcels("", q) {
+		conflict, ok := check.overlap(parcel.Contour)
+		if !ok {
+			continue
+		}
+		conflict.ObjectID, conflict.Kind, conflict.Name = parcel.ID, model.ConflictReadyParcel, parcel.Name
+		conflicts = append(conflicts, conflict)
+	}
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		return conflicts[i].AreaSqM > conflicts[j].AreaSqM
+	})
+	return conflicts
+}
+
+// related сообщает, что один из контуров образован из другого: исходный
+// участок и образованные из него накладываются по определению.
+func related(a, b model.Contour) bool {
+	return slices.Contains(a.ParentIDs, b.ID) || slices.Contains(b.ParentIDs, a.ID)
+}
+
+// overlapCheck хранит проверяемый контур в локальной плоскости, чтобы не
+// проецировать его заново для каждого кандидата.
+type overlapCheck struct {
+	frame geo.LocalFrame
+	local geo.MultiPolygon
+	area  float64
+}
+
+func newOverlapCheck(contour model.Contour) overlapCheck {
+	shape := contour.Shape()
+	check := overlapCheck{frame: geo.FrameFor(shape), area: contour.Metrics.AreaSqM}
+	check.local = check.frame.ForwardMulti(shape)
+	if check.area == 0 {
+		check.area = shape.GeodesicArea()
+	}
+	return check
+}
+
+// overlapPart — общая часть контура и другого объекта в WGS 84.
+type overlapPart struct {
+	shape geo.MultiPolygon
+	area  float64
+}
+
+// intersect строит общую часть контура с геометрией other. Наложения
+// меньше minConflictArea не учитываются.
+func (c overlapCheck) intersect(other geo.MultiPolygon) (overlapPart, bool) {
+	overlap := geo.Intersection(c.local, c.frame.ForwardMulti(other))
+	if overlap.Area() < minConflictArea {
+		return overlapPart{}, false
+	}
+	shape := c.frame.InverseMulti(overlap)
+	return overlapPart{shape: shape, area: shape.GeodesicArea()}, true
+}
+
+func (c overlapCheck) overlap(other model.Contour) (model.ContourConflict, bool) {
+	part, ok := c.intersect(other.Shape())
+	if !ok {
+		return model.ContourConflict{}, false
+	}
+	otherArea := other.Metrics.AreaSqM
+	if otherArea == 0 {
+		otherArea = other.Shape().GeodesicArea()
+	}
+	return model.ContourConflict{
+		AreaSqM:        roundTo(part.area, 2),
+		ContourPercent: percent(part.area, c.area),
+		ObjectPercent:  percent(part.area, otherArea),
+		Overlap:        model.GeometryFromShape(part.shape),
+	}, true
+}
+
+func percent(part, whole float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return roundTo(math.Min(100, part/whole*100), 2)
+}
+
+func roundTo(v float64, digits int) float64 {
+	k := math.Pow(10, float64(digits))
+	return math.Round(v*k) / k
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/store"
+)
+
+// EditOperation — вид изменения контура.
+type EditOperation string
+
+const (
+	// EditReplaceGeometry заменяет геометрию контура целиком.
+	EditReplaceGeometry EditOperation = "replace_geometry"
+	// EditMoveVertex переносит вершину в точку Point.
+	EditMoveVertex EditOperation = "move_vertex"
+	// EditInsertVertex вставляет точку Point перед вершиной Vertex; индекс,
+	// равный числу вершин кольца, добавляет точку в конец кольца.
+	EditInsertVertex EditOperation = "insert_vertex"
+	// EditDeleteVertex удаляет вершину.
+	EditDeleteVertex EditOperation = "delete_vertex"
+	// EditDescription меняет описание контура.
+	EditDescription EditOperation = "set_description"
+)
+
+// ContourEdit описывает изменение контура.
+//
+// Вершина задаётся номерами части Part, кольца Ring (0 — внешняя граница,
+// далее внутренние) и вершины Vertex с нуля; замыкающая точка кольца не
+// считается отдельной вершиной. BaseVersion, если указан, — версия, которую
+// видел автор изменения: при расхождении с текущей изменение отклоняется.
+type ContourEdit struct {
+	Operation   EditOperation          `json:"operation"`
+	Author      string                 `json:"author"`
+	Reason      string                 `json:"reason"`
+	BaseVersion int                    `json:"base_version,omitempty"`
+	Geometry    *model.ContourGeometry `json:"geometry,omitempty"`
+	Part        int                    `json:"part"`
+	Ring        int                    `json:"ring"`
+	Vertex      int                    `json:"vertex"`
+	Point       *model.Point           `json:"point,omitempty"`
+	Description *string                `json:"description,omitempty"`
+}
+
+// EditContour применяет изменение к контуру и сохраняет результат новой
+// версией. Изменённая геометрия проходит ту же топологическую проверку, что
+// и при создании контура; метрики и наложения пересчитываются.
+func (s *Service) EditContour(contourID string, edit ContourEdit) (model.Contour, error) {
+	if strings.TrimSpace(edit.Author) == "" {
+		return model.Contour{}, errors.New("не указан автор изменения")
+	}
+	if strings.TrimSpace(edit.Reason) == "" {
+		return model.Contour{}, errors.New("не указана причина изменения")
+	}
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.Contour{}, fmt.Errorf("контур не найден: %w", err)
+	}
+	if edit.BaseVersion != 0 && edit.BaseVersion != contour.Version {
+		return model.Contour{}, fmt.Errorf("контур изменён, текущая версия %d: %w", contour.Version, store.ErrVersionConflict)
+	}
+
+	switch edit.Operation {
+	case EditDescription:
+		if edit.Description == nil {
+			return model.Contour{}, errors.New("не указано новое описание контура")
+		}
+		contour.Description = *edit.Description
+	case EditReplaceGeometry:
+		if edit.Geometry == nil || edit.Geometry.IsEmpty() {
+			return model.Contour{}, errors.New("не указана новая геометрия контура")
+		}
+		contour.SetGeometry(*edit.Geometry)
+	case EditMoveVertex, EditInsertVertex, EditDeleteVertex:
+		polys, err := editVertex(contour.Polygons(), edit)
+		if err != nil {
+			return model.Contour{}, err
+		}
+		contour.SetGeometry(model.ContourGeometry{Parts: polys})
+	default:
+		return model.Contour{}, fmt.Errorf("неизвестная операция изменения контура: %q", edit.Operation)
+	}
+
+	if edit.Operation != EditDescription {
+		if err := checkContour(&contour); err != nil {
+			return model.Contour{}, err
+		}
+		contour.Conflicts = s.findConflicts(contour)
+		contour.Snapping = nil
+		contour.Repair = nil
+	}
+	updated, err := s.store.UpdateContour(contour, model.ContourVersion{
+		Operation: string(edit.Operation),
+		Author:    strings.TrimSpace(edit.Author),
+		Reason:    strings.TrimSpace(edit.Reason),
+	})
+	if err != nil {
+		return model.Contour{}, fmt.Errorf("контур изменён другим запросом, изменение не сохранено: %w", err)
+	}
+	return updated, nil
+}
+
+// editVertex применяет операцию над вершиной к копии частей контура.
+// Замкнутые кольца остаются замкнутыми: замыкающая точка следует за первой.
+func editVertex(polys []model.Polygon, edit ContourEdit) ([]model.Polygon, error) {
+	if edit.Part < 0 || edit.Part >= len(polys) {
+		return nil, fmt.Errorf("в контуре нет части %d", edit.Part)
+	}
+	poly := polys[edit.Part]
+	if edit.Ring < 0 || edit.Ring > len(poly.Holes) {
+		return nil, fmt.Errorf("в части %d нет кольца %d", edit.Part, edit.Ring)
+	}
+	ring := poly.Exterior
+	if edit.Ring > 0 {
+		ring = poly.Holes[edit.Ring-1]
+	}
+	closed := len(ring) > 1 && ring[0] == ring[len(ring)-1]
+	if closed {
+		ring = ring[:len(ring)-1]
+	}
+	points := append([]model.Point(nil), ring...)
+
+	n := len(points)
+	limit := n
+	if edit.Operation == EditInsertVertex {
+		limit = n + 1
+	}
+	if edit.Vertex < 0 || edit.Vertex >= limit {
+		return nil, fmt.Errorf("в кольце %d части %d нет вершины %d", edit.Ring, edit.Part, edit.Vertex)
+	}
+	if edit.Operation != EditDeleteVertex && edit.Point == nil {
+		return nil, errors.New("не указаны координаты вершины")
+	}
+	switch edit.Operation {
+	case EditMoveVertex:
+		points[edit.Vertex] = *edit.Point
+	case EditInsertVertex:
+		points = append(points[:edit.Vertex], append([]model.Point{*edit.Point}, points[edit.Vertex:]...)...)
+	case EditDeleteVertex:
+		if n <= 3 {
+			return nil, errors.New("в кольце должно остаться не менее 3 вершин")
+		}
+		points = append(points[:edit.Vertex], points[edit.Vertex+1:]...)
+	}
+	if closed {
+		points = append(points, points[0])
+	}
+
+	out := make([]model.Polygon, len(polys))
+	copy(out, polys)
+	edited := model.Polygon{Exterior: poly.Exterior, Holes: append([][]model.Point(nil), poly.Holes...)}
+	if edit.Ring == 0 {
+		edited.Exterior = points
+	} else {
+		edited.Holes[edit.Ring-1] = points
+	}
+	out[edit.Part] = edited
+	return out, nil
+}
+
+// ListContourVersions возвращает историю изменений контура.
+func (s *Service) ListContourVersions(contourID string) ([]model.ContourVersion, error) {
+	versions, err := s.store.ListContourVersions(contourID)
+	if err != nil {
+		return nil, fmt.Errorf("контур не найден: %w", err)
+	}
+	return versions, nil
+}
+
+// GetContourVersion возвращает состояние контура в указанной версии.
+func (s *Service) GetContourVersion(contourID string, version int) (model.ContourVersion, error) {
+	v, err := s.store.GetContourVersion(contourID, version)
+	if err != nil {
+		return model.ContourVersion{}, fmt.Errorf("версия %d контура %s не найдена: %w", version, contourID, err)
+	}
+	return v, nil
+}
+
+// DiffContourVersions сравнивает две версии контура. Если to не указана,
+// используется текущая версия, если from — предыдущая перед to.
+func (s *Service) DiffContourVersions(contourID string, from, to int) (model.ContourDiff, error) {
+	if to == 0 {
+		contour, err := s.store.GetContourByID(contourID)
+		if err != nil {
+			return model.ContourDiff{}, fmt.Errorf("контур не найден: %w", err)
+		}
+		to = contour.Version
+	}
+	if from == 0 {
+		from = max(to-1, 1)
+	}
+	a, err := s.GetContourVersion(contourID, from)
+	if err != nil {
+		return model.ContourDiff{}, err
+	}
+	b, err := s.GetContourVersion(contourID, to)
+	if err != nil {
+		return model.ContourDiff{}, err
+	}
+	before, after := a.Contour, b.Contour
+	return model.ContourDiff{
+		ContourID:          contourID,
+		From:               from,
+		To:                 to,
+		AddedVertices:      vertexDifference(after.Shape(), before.Shape()),
+		RemovedVertices:    vertexDifference(before.Shape(), after.Shape()),
+		AreaDeltaSqM:       roundTo(after.Metrics.AreaSqM-before.Metrics.AreaSqM, 2),
+		PerimeterDeltaM:    roundTo(after.Metrics.PerimeterM-before.Metrics.PerimeterM, 2),
+		DescriptionChanged: before.Description != after.Description,
+	}, nil
+}
+
+// vertexDifference возвращает вершины a, которых нет в b, с учётом
+// кратности совпадающих точек.
+func vertexDifference(a, b geo.MultiPolygon) []model.Point {
+	count := make(map[geo.Point]int)
+	for _, p := range b.Points() {
+		count[p]++
+	}
+	diff := make([]model.Point, 0)
+	for _, p := range a.Points() {
+		if count[p] > 0 {
+			count[p]--
+			continue
+		}
+		diff = append(diff, model.PointFromXY(p))
+	}
+	return diff
+}
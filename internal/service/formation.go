@@ -0,0 +1,339 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/util"
+)
+
+// FormationRequest описывает операцию образования участков.
+//
+// SourceIDs — исходные контуры: один для раздела и выдела, два и более для
+// объединения и перераспределения. Назначение Parts зависит от вида операции:
+//   - split — границы образуемых участков; они обрезаются по исходному
+//     участку, а не покрытая ими часть становится ещё одним участком;
+//   - merge — не задаётся;
+//   - redistribute — новые границы всех образуемых участков;
+//   - carve_out — граница выделяемого участка, обрезаемая по исходному.
+//
+// Descriptions — описания образуемых участков по порядку; по умолчанию
+// участки обозначаются «:ЗУ1», «:ЗУ2» и т. д.
+type FormationRequest struct {
+	Kind         model.FormationKind     `json:"kind"`
+	SourceIDs    []string                `json:"source_ids"`
+	Parts        []model.ContourGeometry `json:"parts,omitempty"`
+	Descriptions []string                `json:"descriptions,omitempty"`
+}
+
+// FormParcels выполняет раздел, объединение, перераспределение или выдел
+// участков. Образованные участки сохраняются новыми контурами со ссылками на
+// исходные; сами исходные контуры не меняются.
+//
+// Результат принимается, только если образуемые участки не накладываются
+// друг на друга, в точности покрывают исходные участки и сумма их площадей
+// совпадает с площадью исходных с точностью до formationAreaTolerance.
+func (s *Service) FormParcels(req FormationRequest) (model.FormationResult, error) {
+	sources, err := s.formationSources(req)
+	if err != nil {
+		return model.FormationResult{}, err
+	}
+	var all geo.MultiPolygon
+	for _, c := range sources {
+		all = append(all, c.Shape()...)
+	}
+	plane := newPlanar(geo.FrameFor(all))
+
+	local := make([]geo.MultiPolygon, len(sources))
+	var union geo.MultiPolygon
+	sum := 0.0
+	for i, c := range sources {
+		local[i] = plane.forward(c.Shape().Oriented())
+		union = geo.Union(union, local[i])
+		sum += local[i].Area()
+	}
+	if overlap := sum - union.Area(); overlap >= minConflictArea {
+		return model.FormationResult{}, fmt.Errorf("исходные участки накладываются друг на друга (%.2f м²)", overlap)
+	}
+	parts := make([]geo.MultiPolygon, len(req.Parts))
+	for i, g := range req.Parts {
+		if g.IsEmpty() {
+			return model.FormationResult{}, fmt.Errorf("не задана граница участка %d", i+1)
+		}
+		parts[i] = plane.forward(g.Shape().Oriented())
+	}
+
+	results, err := formationShapes(req.Kind, union, parts)
+	if err != nil {
+		return model.FormationResult{}, err
+	}
+	if err := checkFormation(union, results); err != nil {
+		return model.FormationResult{}, err
+	}
+
+	formation := model.Formation{
+		ID:        util.NewID(),
+		Kind:      req.Kind,
+		SourceIDs: req.SourceIDs,
+	}
+	sourceArea, resultArea := plane.inverse(union).GeodesicArea(), 0.0
+	contours := make([]model.Contour, len(results))
+	for i, shape := range results {
+		contour := model.Contour{
+			Source:      model.ContourSourceFormation,
+			Description: formationDescription(req, sources, i),
+			CRS:         commonCRS(sources),
+			FormationID: formation.ID,
+		}
+		for j, source := range sources {
+			if geo.Intersection(shape, local[j]).Area() >= minConflictArea {
+				contour.ParentIDs = append(contour.ParentIDs, source.ID)
+			}
+		}
+		result := plane.inverse(shape)
+		contour.SetGeometry(model.GeometryFromShape(result))
+		if err := checkContour(&contour); err != nil {
+			return model.FormationResult{}, fmt.Errorf("участок %s: %w", contour.Description, err)
+		}
+		resultArea += result.GeodesicArea()
+		contours[i] = contour
+	}
+	formation.SourceAreaSqM = roundTo(sourceArea, 2)
+	formation.ResultAreaSqM = roundTo(resultArea, 2)
+	formation.DiscrepancySqM = roundTo(math.Abs(resultArea-sourceArea), 2)
+	if math.Abs(resultArea-sourceArea) > formationAreaTolerance(sourceArea) {
+		return model.FormationResult{}, fmt.Errorf("площадь образуемых участков %.2f м² не совпадает с площадью исходных %.2f м²",
+			formation.ResultAreaSqM, formation.SourceAreaSqM)
+	}
+
+	for i, contour := range contours {
+		contour.Conflicts = s.findConflicts(contour)
+		contours[i] = s.store.SaveContour(contour)
+		formation.ResultIDs = append(formation.ResultIDs, contours[i].ID)
+	}
+	formation = s.store.SaveFormation(formation)
+	return model.FormationResult{Formation: formation, Contours: contours}, nil
+}
+
+// formationAreaTolerance — допустимое расхождение суммы площадей
+// образуемых участков с площадью исходных: миллионная доля площади, но не
+// менее minConflictArea. Расхождение возникает из-за того, что площадь на
+// эллипсоиде немного меняется при добавлении вершин на прямые рёбра.
+func formationAreaTolerance(area float64) float64 {
+	return math.Max(minConflictArea, area*1e-6)
+}
+
+// formationSources загружает исходные контуры и проверяет их число для
+// выбранного вида операции.
+func (s *Service) formationSources(req FormationRequest) ([]model.Contour, error) {
+	switch req.Kind {
+	case model.FormationSplit, model.FormationCarveOut:
+		if len(req.SourceIDs) != 1 {
+			return nil, fmt.Errorf("для операции %s нужен один исходный участок", req.Kind)
+		}
+	case model.FormationMerge, model.FormationRedistribute:
+		if len(req.SourceIDs) < 2 {
+			return nil, fmt.Errorf("для операции %s нужно не менее двух исходных участков", req.Kind)
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный способ образования участков: %q", req.Kind)
+	}
+	seen := make(map[string]bool)
+	sources := make([]model.Contour, 0, len(req.SourceIDs))
+	for _, id := range req.SourceIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("исходный участок %s указан дважды", id)
+		}
+		seen[id] = true
+		contour, err := s.store.GetContourByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("контур %s не найден: %w", id, err)
+		}
+		sources = append(sources, contour)
+	}
+	return sources, nil
+}
+
+// formationShapes строит границы образуемых участков в локальной плоскости.
+func formationShapes(kind model.FormationKind, union geo.MultiPolygon, parts []geo.MultiPolygon) ([]geo.MultiPolygon, error) {
+	var results []geo.MultiPolygon
+	switch kind {
+	case model.FormationSplit:
+		if len(parts) == 0 {
+			return nil, errors.New("не заданы границы образуемых участков")
+		}
+		var covered geo.MultiPolygon
+		for i, part := range parts {
+			clipped := geo.Intersection(part, union)
+			if clipped.Area() < minConflictArea {
+				return nil, fmt.Errorf("участок %d не пересекается с исходным участком", i+1)
+			}
+			results = append(results, clipped)
+			covered = geo.Union(covered, clipped)
+		}
+		if rest := geo.Difference(union, covered); rest.Area() >= minConflictArea {
+			results = append(results, rest)
+		}
+		if len(results) < 2 {
+			return nil, errors.New("при разделе должно образоваться не менее двух участков")
+		}
+	case model.FormationMerge:
+		if len(parts) != 0 {
+			return nil, errors.New("при объединении границы образуемого участка не задаются")
+		}
+		results = append(results, union)
+	case model.FormationRedistribute:
+		if len(parts) < 2 {
+			return nil, errors.New("при перераспределении нужны границы не менее двух образуемых участков")
+		}
+		for i, part := range parts {
+			if part.Area() < minConflictArea {
+				return nil, fmt.Errorf("участок %d имеет нулевую площадь", i+1)
+			}
+		}
+		results = parts
+	case model.FormationCarveOut:
+		if len(parts) != 1 {
+			return nil, errors.New("при выделе задаётся граница одного выделяемого участка")
+		}
+		carved := geo.Intersection(parts[0], union)
+		if carved.Area() < minConflictArea {
+			return nil, errors.New("выделяемый участок не пересекается с исходным участком")
+		}
+		rest := geo.Difference(union, carved)
+		if rest.Area() < minConflictArea {
+			return nil, errors.New("выделяемый участок занимает весь исходный участок")
+		}
+		results = append(results, carved, rest)
+	}
+	return results, nil
+}
+
+// checkFormation проверяет, что образуемые участки не накладываются друг на
+// друга и в точности покрывают исходные.
+func checkFormation(union geo.MultiPolygon, results []geo.MultiPolygon) error {
+	var covered geo.MultiPolygon
+	for i, a := range results {
+		for j := i + 1; j < len(results); j++ {
+			if area := geo.Intersection(a, results[j]).Area(); area >= minConflictArea {
+				return fmt.Errorf("образуемые участки %d и %d накладываются друг на друга (%.2f м²)", i+1, j+1, area)
+			}
+		}
+		covered = geo.Union(covered, a)
+	}
+	if area := geo.Difference(covered, union).Area(); area >= minConflictArea {
+		return fmt.Errorf("образуемые участки выходят за границы исходных на %.2f м²", area)
+	}
+	if area := geo.Difference(union, covered).Area(); area >= minConflictArea {
+		return fmt.Errorf("часть исходных участков площадью %.2f м² не вошла в образуемые участки", area)
+	}
+	return nil
+}
+
+// formationDescription возвращает описание i-го образуемого участка. При
+// выделе второй участок — исходный в изменённых границах.
+func formationDescription(req FormationRequest, sources []model.Contour, i int) string {
+	if i < len(req.Descriptions) && req.Descriptions[i] != "" {
+		return req.Descriptions[i]
+	}
+	if req.Kind == model.FormationCarveOut && i == 1 {
+		name := sources[0].Description
+		if name == "" {
+			name = sources[0].ID
+		}
+		return name + " в изменённых границах"
+	}
+	return fmt.Sprintf(":ЗУ%d", i+1)
+}
+
+// commonCRS возвращает систему координат исходных контуров, если она у всех
+// одинакова.
+func commonCRS(sources []model.Contour) string {
+	for _, c := range sources[1:] {
+		if c.CRS != sources[0].CRS {
+			return ""
+		}
+	}
+	return sources[0].CRS
+}
+
+// GetFormation возвращает операцию образования участков.
+func (s *Service) GetFormation(formationID string) (model.Formation, error) {
+	formation, err := s.store.GetFormation(formationID)
+	if err != nil {
+		return model.Formation{}, fmt.Errorf("операция образования не найдена: %w", err)
+	}
+	return formation, nil
+}
+
+// planar переводит контуры в локальную плоскость и обратно. Вершины,
+// переведённые в плоскость методом forward, возвращаются в исходных
+// координатах без погрешности обратного пересчёта, поэтому общие границы
+// с соседями совпадают точно.
+//
+// Если задана прямоугольная система координат system, плоскостью служит
+// она, а не локальная система frame.
+type planar struct {
+	frame  geo.LocalFrame
+	system *crs.CRS
+	exact  map[geo.Point]geo.Point
+}
+
+func newPlanar(frame geo.LocalFrame) *planar {
+	return &planar{frame: frame, exact: make(map[geo.Point]geo.Point)}
+}
+
+// newPlanarCRS создаёт переход в плоскость прямоугольной системы координат.
+func newPlanarCRS(system *crs.CRS) *planar {
+	return &planar{system: system, exact: make(map[geo.Point]geo.Point)}
+}
+
+func (p *planar) forwardPoint(pt geo.Point) geo.Point {
+	if p.system != nil {
+		return p.system.FromWGS84(pt)
+	}
+	return p.frame.Forward(pt)
+}
+
+func (p *planar) forward(m geo.MultiPolygon) geo.MultiPolygon {
+	out := make(geo.MultiPolygon, len(m))
+	for i, poly := range m {
+		out[i] = make(geo.Polygon, len(poly))
+		for j, ring := range poly {
+			out[i][j] = make(geo.Ring, len(ring))
+			for k, pt := range ring {
+				out[i][j][k] = p.forwardPoint(pt)
+				p.exact[out[i][j][k]] = pt
+			}
+		}
+	}
+	return out
+}
+
+func (p *planar) inversePoint(pt geo.Point) geo.Point {
+	if g, ok := p.exact[pt]; ok {
+		return g
+	}
+	if p.system != nil {
+		return p.system.ToWGS84(pt)
+	}
+	return p.frame.Inverse(pt)
+}
+
+func (p *planar) inverse(m geo.MultiPolygon) geo.MultiPolygon {
+	out := make(geo.MultiPolygon, len(m))
+	for i, poly := range m {
+		out[i] = make(geo.Polygon, len(poly))
+		for j, ring := range poly {
+			out[i][j] = make(geo.Ring, len(ring))
+			for k, pt := range ring {
+				out[i][j][k] = p.inversePoint(pt)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,441 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/formats/dxf"
+	"zemlya-prosto/internal/formats/gpx"
+	"zemlya-prosto/internal/formats/kml"
+	"zemlya-prosto/internal/formats/rosreestr"
+	"zemlya-prosto/internal/formats/shapefile"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+)
+
+// importFeature — объект импортируемого файла: геометрия в системе
+// координат файла и атрибуты, которые переносятся в карточку контура.
+type importFeature struct {
+	layer      string
+	record     int
+	shape      geo.MultiPolygon
+	attributes []model.Attribute
+}
+
+// ImportShapefile создаёт по контуру на каждую запись шейп-файла из
+// zip-архива. Система координат берётся из crsID, а если он не указан — из
+// файла .prj слоя. Атрибуты из .dbf сохраняются в карточке контура с
+// источником, равным имени загруженного файла.
+func (s *Service) ImportShapefile(description, filename string, data []byte, crsID string) (model.ImportResult, error) {
+	layers, err := shapefile.ReadZip(data)
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+
+	var result model.ImportResult
+	for _, layer := range layers {
+		system, err := layerCRS(layer, crsID)
+		if err != nil {
+			return model.ImportResult{}, fmt.Errorf("слой %s: %w", layer.Name, err)
+		}
+		source := filename
+		if source == "" {
+			source = layer.Name + ".shp"
+		}
+		features := make([]importFeature, 0, len(layer.Records))
+		for _, rec := range layer.Records {
+			feature := importFeature{layer: layer.Name, record: rec.Number, shape: rec.Shape}
+			for i, value := range rec.Values {
+				if value == "" {
+					continue
+				}
+				feature.attributes = append(feature.attributes, model.Attribute{
+					Key:    layer.Fields[i].Name,
+					Value:  value,
+					Source: source,
+				})
+			}
+			features = append(features, feature)
+		}
+		s.importFeatures(&result, description, system, features)
+	}
+	return importResult(result)
+}
+
+// ImportKML создаёт по контуру на каждую метку с полигоном из документа KML
+// или архива KMZ. Название, описание и ExtendedData метки переносятся в
+// карточку контура с источником, равным имени файла.
+func (s *Service) ImportKML(description, filename string, data []byte) (model.ImportResult, error) {
+	placemarks, err := kml.Read(data)
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+	layer := fileLayer(filename)
+	features := make([]importFeature, 0, len(placemarks))
+	for i, pm := range placemarks {
+		feature := importFeature{layer: layer, record: i + 1, shape: pm.Shape}
+		add := func(key, value, comment string) {
+			if value != "" {
+				feature.attributes = append(feature.attributes, model.Attribute{Key: key, Value: value, Source: filename, Comment: comment})
+			}
+		}
+		add("name", pm.Name, "Название метки")
+		add("description", pm.Description, "Описание метки")
+		for _, d := range pm.Data {
+			add(d.Name, d.Value, "")
+		}
+		features = append(features, feature)
+	}
+
+	var result model.ImportResult
+	s.importFeatures(&result, description, crs.WGS84, features)
+	return importResult(result)
+}
+
+// ImportGPX создаёт контуры по трекам, маршрутам и путевым точкам файла GPX,
+// замыкая их в кольца.
+func (s *Service) ImportGPX(description, filename string, data []byte) (model.ImportResult, error) {
+	boundaries, err := gpx.Unmarshal(data)
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+	layer := fileLayer(filename)
+	features := make([]importFeature, 0, len(boundaries))
+	for i, b := range boundaries {
+		feature := importFeature{layer: layer, record: i + 1, shape: geo.MultiPolygon{{b.Ring}}}
+		feature.attributes = append(feature.attributes, model.Attribute{
+			Key: "gpx_element", Value: string(b.Kind), Source: filename, Comment: "Элемент GPX (trk, rte, wpt)",
+		})
+		if b.Name != "" {
+			feature.attributes = append(feature.attributes, model.Attribute{
+				Key: "name", Value: b.Name, Source: filename, Comment: "Название трека или маршрута",
+			})
+		}
+		features = append(features, feature)
+	}
+
+	var result model.ImportResult
+	s.importFeatures(&result, description, crs.WGS84, features)
+	return importResult(result)
+}
+
+// ImportRosreestrXML загружает кадастровый план территории или выписку ЕГРН.
+// По каждому участку с установленными границами создаётся контур, а
+// кадастровый номер, категория, вид разрешённого использования, площадь,
+// адрес и обременения переносятся в его карточку. Все участки документа,
+// включая участки без границ, сохраняются в справочник кадастровых данных.
+//
+// Система координат берётся из crsID, а если он не указан — из sk_id
+// контуров документа (например, «МСК-50, зона 2»).
+func (s *Service) ImportRosreestrXML(description, filename string, data []byte, crsID string) (model.ImportResult, error) {
+	doc, err := rosreestr.Parse(data)
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+	var override *crs.CRS
+	if crsID != "" {
+		if override, err = crs.Lookup(crsID); err != nil {
+			return model.ImportResult{}, err
+		}
+	}
+	source := filename
+	if source == "" {
+		source = string(doc.Kind)
+	}
+	layer := fileLayer(source)
+
+	var result model.ImportResult
+	for i, p := range doc.Parcels {
+		entry := model.CadastralParcel{
+			CadastralNumber:  p.CadastralNumber,
+			CadastralBlock:   p.CadastralBlock,
+			CategoryCode:     p.CategoryCode,
+			Category:         p.Category,
+			PermittedUse:     p.PermittedUse,
+			PermittedUseCode: p.PermittedUseCode,
+			AreaSqM:          p.Area,
+			AreaInaccuracy:   p.AreaInaccuracy,
+			Address:          p.Address,
+			Source:           source,
+			SourceKind:       string(doc.Kind),
+			SourceDate:       doc.Date,
+		}
+		for _, e := range p.Encumbrances {
+			entry.Encumbrances = append(entry.Encumbrances, model.Encumbrance(e))
+		}
+
+		if len(p.Shape) > 0 {
+			system, err := parcelCRS(p, override)
+			if err != nil {
+				result.Errors = append(result.Errors, model.ImportError{
+					Layer: layer, Record: i + 1, Message: fmt.Sprintf("%s: %v", p.CadastralNumber, err),
+				})
+			} else {
+				desc := description
+				if desc == "" {
+					desc = "Земельный участок " + p.CadastralNumber
+				}
+				imported := len(result.Contours)
+				s.importFeatures(&result, desc, system, []importFeature{{
+					layer:      layer,
+					record:     i + 1,
+					shape:      p.Shape,
+					attributes: parcelAttributes(p, source),
+				}})
+				if len(result.Contours) > imported {
+					contour := result.Contours[imported].Contour
+					entry.ContourID = contour.ID
+					entry.Geometry = &contour
+				}
+			}
+		}
+		if entry.Geometry == nil {
+			// Выписка без координат не должна стирать границы, загруженные ранее.
+			if prev, err := s.store.GetCadastralParcel(entry.CadastralNumber); err == nil {
+				entry.ContourID, entry.Geometry = prev.ContourID, prev.Geometry
+			}
+		}
+		result.Parcels = append(result.Parcels, s.store.SaveCadastralParcel(entry))
+	}
+	if len(result.Parcels) == 0 {
+		return result, errors.New("документ не содержит сведений о земельных участках")
+	}
+	return result, nil
+}
+
+// parcelCRS выбирает систему координат участка: явно указанную или
+// записанную в документе.
+func parcelCRS(p rosreestr.Parcel, override *crs.CRS) (*crs.CRS, error) {
+	if override != nil {
+		return override, nil
+	}
+	if p.CoordinateSystem == "" {
+		return nil, errors.New("в документе не указана система координат, укажите её параметром crs")
+	}
+	system, err := crs.Lookup(p.CoordinateSystem)
+	if err != nil {
+		return nil, fmt.Errorf("система координат %q не распознана, укажите её параметром crs", p.CoordinateSystem)
+	}
+	return system, nil
+}
+
+// parcelAttributes формирует атрибуты карточки из сведений ЕГРН об участке.
+func parcelAttributes(p rosreestr.Parcel, source string) []model.Attribute {
+	var attrs []model.Attribute
+	add := func(key, value, comment string) {
+		if value != "" {
+			attrs = append(attrs, model.Attribute{Key: key, Value: value, Source: source, Comment: comment})
+		}
+	}
+	number := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	add("cadastral_number", p.CadastralNumber, "Кадастровый номер")
+	add("cadastral_block", p.CadastralBlock, "Кадастровый квартал")
+	add("land_category", p.Category, "Категория земель")
+	add("land_category_code", p.CategoryCode, "Код категории земель")
+	add("permitted_use", p.PermittedUse, "Вид разрешённого использования")
+	add("permitted_use_code", p.PermittedUseCode, "Код вида разрешённого использования")
+	add("egrn_area_sq_m", number(p.Area), "Площадь по сведениям ЕГРН, м²")
+	add("egrn_area_inaccuracy", number(p.AreaInaccuracy), "Погрешность площади, м²")
+	add("address", p.Address, "Адрес (местоположение)")
+	for i, e := range p.Encumbrances {
+		value := e.Type
+		if e.Number != "" {
+			value += ", № " + e.Number
+		}
+		if e.RegistrationDate != "" {
+			value += " от " + e.RegistrationDate
+		}
+		add(fmt.Sprintf("encumbrance_%d", i+1), value, "Ограничение прав или обременение")
+	}
+	return attrs
+}
+
+// DXFOptions задаёт выбор полилинии и привязку чертежа DXF.
+type DXFOptions struct {
+	// Layers ограничивает поиск полилиний слоями чертежа; пусто — все слои.
+	Layers []string
+	// Polyline — дескриптор или номер с единицы полилинии, которая станет
+	// контуром. Обязателен, если подходящих полилиний несколько.
+	Polyline string
+	// Units — единицы чертежа (mm, cm, m, km, in, ft), если они не заданы
+	// в файле; без них чертёж считается выполненным в метрах.
+	Units string
+	// CRS — система координат, в которой выполнен чертёж. В DXF она не хранится.
+	CRS string
+}
+
+// PolylineChoiceError сообщает, что в чертеже несколько замкнутых полилиний и
+// контур нужно выбрать явно.
+type PolylineChoiceError struct {
+	Candidates []model.DrawingPolyline
+}
+
+func (e *PolylineChoiceError) Error() string {
+	return fmt.Sprintf("в чертеже %d замкнутых полилиний, выберите контур параметром polyline", len(e.Candidates))
+}
+
+// ImportDXF создаёт контур по замкнутой полилинии LWPOLYLINE или POLYLINE
+// чертежа DXF. Координаты чертежа (X — восток, Y — север) переводятся из
+// единиц чертежа в метры и из системы opts.CRS в WGS 84. Слой и дескриптор
+// полилинии сохраняются в карточке контура.
+func (s *Service) ImportDXF(description, filename string, data []byte, opts DXFOptions) (model.ImportResult, error) {
+	drawing, err := dxf.Parse(data)
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+	if opts.CRS == "" {
+		return model.ImportResult{}, errors.New("в чертеже DXF не хранится система координат, укажите её параметром crs")
+	}
+	system, err := crs.Lookup(opts.CRS)
+	if err != nil {
+		return model.ImportResult{}, err
+	}
+	units := drawing.Units
+	if opts.Units != "" {
+		if units, err = dxf.ParseUnit(opts.Units); err != nil {
+			return model.ImportResult{}, err
+		}
+	}
+	scale := units.Metres()
+	if scale == 0 || system.IsGeographic() {
+		// Без единиц чертёж считается выполненным в метрах, а в
+		// географической системе координаты задаются в градусах.
+		scale = 1
+	}
+
+	polylines := drawing.Contours(opts.Layers...)
+	if len(polylines) == 0 {
+		if len(opts.Layers) > 0 {
+			return model.ImportResult{}, fmt.Errorf("на слоях %s нет замкнутых полилиний, слои чертежа: %s",
+				strings.Join(opts.Layers, ", "), strings.Join(drawing.Layers(), ", "))
+		}
+		return model.ImportResult{}, errors.New("в чертеже нет замкнутых полилиний")
+	}
+	shapes := make([]geo.MultiPolygon, len(polylines))
+	candidates := make([]model.DrawingPolyline, len(polylines))
+	for i, pl := range polylines {
+		ring := make(geo.Ring, len(pl.Ring))
+		for j, p := range pl.Ring {
+			ring[j] = geo.Point{X: p.X * scale, Y: p.Y * scale}
+		}
+		shapes[i] = geo.MultiPolygon{{ring}}
+		candidates[i] = model.DrawingPolyline{
+			Number:   i + 1,
+			Handle:   pl.Handle,
+			Layer:    pl.Layer,
+			Vertices: len(ring),
+			AreaSqM:  math.Round(system.ToWGS84Multi(shapes[i]).GeodesicArea()*100) / 100,
+		}
+	}
+
+	chosen := -1
+	switch {
+	case opts.Polyline != "":
+		for i, c := range candidates {
+			if strings.EqualFold(c.Handle, opts.Polyline) || strconv.Itoa(c.Number) == opts.Polyline {
+				chosen = i
+				break
+			}
+		}
+		if chosen < 0 {
+			return model.ImportResult{}, fmt.Errorf("полилиния %s не найдена среди замкнутых полилиний чертежа", opts.Polyline)
+		}
+	case len(candidates) == 1:
+		chosen = 0
+	default:
+		return model.ImportResult{}, &PolylineChoiceError{Candidates: candidates}
+	}
+
+	pl, c := polylines[chosen], candidates[chosen]
+	feature := importFeature{layer: pl.Layer, record: c.Number, shape: shapes[chosen]}
+	add := func(key, value, comment string) {
+		if value != "" {
+			feature.attributes = append(feature.attributes, model.Attribute{Key: key, Value: value, Source: filename, Comment: comment})
+		}
+	}
+	add("dxf_layer", pl.Layer, "Слой чертежа")
+	add("dxf_handle", pl.Handle, "Дескриптор полилинии в чертеже")
+
+	var result model.ImportResult
+	s.importFeatures(&result, description, system, []importFeature{feature})
+	return importResult(result)
+}
+
+// fileLayer возвращает имя слоя для файла без собственных слоёв — имя файла
+// без расширения.
+func fileLayer(filename string) string {
+	return strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+}
+
+// importResult возвращает ошибку, если ни один объект файла не импортирован.
+func importResult(result model.ImportResult) (model.ImportResult, error) {
+	if len(result.Contours) == 0 {
+		return result, errors.New("в файле нет объектов, пригодных для импорта")
+	}
+	return result, nil
+}
+
+// layerCRS выбирает систему координат слоя: явно указанную или описанную в .prj.
+func layerCRS(layer shapefile.Layer, crsID string) (*crs.CRS, error) {
+	if crsID != "" {
+		return crs.Lookup(crsID)
+	}
+	if layer.PRJ == "" {
+		return nil, errors.New("в архиве нет файла .prj, укажите систему координат параметром crs")
+	}
+	return crs.ParsePRJ(layer.PRJ)
+}
+
+// importFeatures сохраняет объекты файла как отдельные контуры с карточками.
+// Объекты без геометрии или с блокирующими нарушениями топологии
+// пропускаются и попадают в result.Errors.
+func (s *Service) importFeatures(result *model.ImportResult, description string, system *crs.CRS, features []importFeature) {
+	for _, f := range features {
+		if len(f.shape.Points()) == 0 {
+			result.Errors = append(result.Errors, model.ImportError{
+				Layer: f.layer, Record: f.record, Message: "объект не содержит геометрии",
+			})
+			continue
+		}
+		desc := description
+		if desc == "" {
+			desc = fmt.Sprintf("%s, объект %d", f.layer, f.record)
+		}
+		contour := model.Contour{
+			Description: desc,
+			Source:      model.ContourSourceImported,
+			CRS:         system.ID,
+		}
+		contour.SetGeometry(model.GeometryFromShape(system.ToWGS84Multi(f.shape)))
+		contour, err := s.saveContour(contour)
+		if err != nil {
+			importErr := model.ImportError{Layer: f.layer, Record: f.record, Message: err.Error()}
+			var verr *geo.ValidationError
+			if errors.As(err, &verr) {
+				importErr.Violations = verr.Violations
+			}
+			result.Errors = append(result.Errors, importErr)
+			continue
+		}
+		card := s.store.SaveInformationCard(model.InformationCard{
+			ContourID:      contour.ID,
+			AutoAttributes: mergeAttributes(f.attributes, s.computedAttributes(contour, declaredUse(f.attributes))),
+		})
+		result.Contours = append(result.Contours, model.ImportedContour{
+			Layer:   f.layer,
+			Record:  f.record,
+			Contour: contour,
+			Card:    card,
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+)
+
+const (
+	// maxBufferDistance — наибольшее расстояние буфера в метрах.
+	maxBufferDistance = 5000.0
+	// maxQuadrantSegments — наибольшее число отрезков на четверть окружности
+	// в скруглениях буфера.
+	maxQuadrantSegments = 32
+)
+
+// GeometryOperationRequest описывает геометрическую операцию над контуром.
+//
+// Для simplify задаются Method (по умолчанию douglas_peucker), Tolerance и
+// PreserveTopology. Tolerance для douglas_peucker — наибольшее отклонение
+// упрощённой границы в метрах, для visvalingam — наименьшая площадь
+// треугольника, образуемого вершиной с соседними, в квадратных метрах.
+//
+// Для densify задаётся MaxSegmentM — наибольшая длина ребра в метрах.
+//
+// Для buffer задаются DistanceM — расстояние в метрах (положительное
+// расширяет участок, например до зоны отступа вокруг него, отрицательное
+// сужает) и QuadrantSegments — число отрезков на четверть окружности в
+// скруглениях, по умолчанию geo.DefaultQuadrantSegments.
+//
+// Description — описание нового контура; по умолчанию составляется из
+// описания исходного контура и параметров операции.
+type GeometryOperationRequest struct {
+	Operation        model.GeometryOperationKind `json:"operation"`
+	Method           geo.SimplifyMethod          `json:"method,omitempty"`
+	Tolerance        float64                     `json:"tolerance,omitempty"`
+	PreserveTopology bool                        `json:"preserve_topology,omitempty"`
+	MaxSegmentM      float64                     `json:"max_segment_m,omitempty"`
+	DistanceM        float64                     `json:"distance_m,omitempty"`
+	QuadrantSegments int                         `json:"quadrant_segments,omitempty"`
+	Description      string                      `json:"description,omitempty"`
+}
+
+// ApplyGeometryOperation упрощает, уплотняет контур или строит вокруг него
+// буфер. Результат сохраняется новым контуром со ссылкой на исходный; сам
+// исходный контур не меняется.
+//
+// Упрощение строится в локальной плоскости контура, поэтому допуск
+// соответствует метрам на местности. Вершины буфера находятся по
+// геодезическим линиям на эллипсоиде (см. geo.Buffer). Уплотнение
+// выполняется в системе координат, в которой заявитель передал контур, если
+// она прямоугольная: добавленные вершины лежат на прямых рёбрах в этой
+// системе и при выгрузке координат граница не меняется.
+func (s *Service) ApplyGeometryOperation(contourID string, req GeometryOperationRequest) (model.GeometryOperationResult, error) {
+	if err := checkGeometryOperation(&req); err != nil {
+		return model.GeometryOperationResult{}, err
+	}
+	source, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.GeometryOperationResult{}, fmt.Errorf("контур %s не найден: %w", contourID, err)
+	}
+	shape := source.Shape()
+	plane := newPlanar(geo.FrameFor(shape))
+	if req.Operation == model.OperationDensify {
+		if system, err := crs.Lookup(source.CRS); err == nil && !system.IsGeographic() {
+			plane = newPlanarCRS(system)
+		}
+	}
+	local := plane.forward(shape)
+
+	var result geo.MultiPolygon
+	switch req.Operation {
+	case model.OperationSimplify:
+		result = geo.Simplify(local, req.Method, req.Tolerance, req.PreserveTopology)
+	case model.OperationDensify:
+		// Уплотнённый контур не должен превышать предел числа вершин,
+		// после которого проверка топологии отклоняет геометрию.
+		if densifiedVertices(local, req.MaxSegmentM) > geo.MaxVertices {
+			return model.GeometryOperationResult{}, fmt.Errorf("при длине ребра %g м в контуре будет более %d вершин",
+				req.MaxSegmentM, geo.MaxVertices)
+		}
+		result = geo.Densify(local, req.MaxSegmentM)
+	case model.OperationBuffer:
+		// Скругления соседних рёбер пересекаются почти в одних и тех же
+		// точках, поэтому близкие вершины результата удаляются с допуском
+		// проверки топологии.
+		buffer := plane.forward(geo.Buffer(shape, req.DistanceM, req.QuadrantSegments))
+		result, _ = geo.Repair(buffer, geo.RepairOptions{
+			Tolerance: contourValidation.Tolerance,
+			MinArea:   contourValidation.MinArea,
+		})
+		if result.Area() < minConflictArea {
+			return model.GeometryOperationResult{}, fmt.Errorf("буфер %g м не оставляет от участка ничего", req.DistanceM)
+		}
+	}
+
+	contour := model.Contour{
+		Source:      model.ContourSourceOperation,
+		Description: operationDescription(req, source),
+		CRS:         source.CRS,
+		ParentIDs:   []string{source.ID},
+	}
+	wgs := plane.inverse(result)
+	contour.SetGeometry(model.GeometryFromShape(wgs))
+	contour, err = s.saveContour(contour)
+	if err != nil {
+		return model.GeometryOperationResult{}, err
+	}
+	return model.GeometryOperationResult{
+		Operation:      req.Operation,
+		SourceID:       source.ID,
+		Contour:        contour,
+		VerticesBefore: vertexCount(shape),
+		VerticesAfter:  vertexCount(wgs),
+		AreaDeltaSqM:   roundTo(wgs.GeodesicArea()-shape.GeodesicArea(), 2),
+	}, nil
+}
+
+// checkGeometryOperation проверяет параметры операции и подставляет
+// значения по умолчанию.
+func checkGeometryOperation(req *GeometryOperationRequest) error {
+	positive := func(v float64) bool { return v > 0 && !math.IsInf(v, 1) }
+	switch req.Operation {
+	case model.OperationSimplify:
+		switch req.Method {
+		case "":
+			req.Method = geo.SimplifyDouglasPeucker
+		case geo.SimplifyDouglasPeucker, geo.SimplifyVisvalingam:
+		default:
+			return fmt.Errorf("неизвестный алгоритм упрощения: %q", req.Method)
+		}
+		if !positive(req.Tolerance) {
+			return errors.New("допуск упрощения должен быть больше нуля")
+		}
+	case model.OperationDensify:
+		if !positive(req.MaxSegmentM) {
+			return errors.New("длина ребра при уплотнении должна быть больше нуля")
+		}
+	case model.OperationBuffer:
+		if req.DistanceM == 0 || math.IsNaN(req.DistanceM) || math.Abs(req.DistanceM) > maxBufferDistance {
+			return fmt.Errorf("расстояние буфера должно быть ненулевым и не больше %g м по модулю", maxBufferDistance)
+		}
+		if req.QuadrantSegments < 0 || req.QuadrantSegments > maxQuadrantSegments {
+			return fmt.Errorf("число отрезков на четверть окружности должно быть от 0 (по умолчанию %d) до %d",
+				geo.DefaultQuadrantSegments, maxQuadrantSegments)
+		}
+		if req.QuadrantSegments == 0 {
+			req.QuadrantSegments = geo.DefaultQuadrantSegments
+		}
+	default:
+		return fmt.Errorf("неизвестная геометрическая операция: %q", req.Operation)
+	}
+	return nil
+}
+
+// operationDescription возвращает описание контура, полученного операцией.
+func operationDescription(req GeometryOperationRequest, source model.Contour) string {
+	if req.Description != "" {
+		return req.Description
+	}
+	name := source.Description
+	if name == "" {
+		name = source.ID
+	}
+	switch req.Operation {
+	case model.OperationSimplify:
+		if req.Method == geo.SimplifyVisvalingam {
+			return fmt.Sprintf("%s — упрощение с допуском %g м²", name, req.Tolerance)
+		}
+		return fmt.Sprintf("%s — упрощение с допуском %g м", name, req.Tolerance)
+	case model.OperationDensify:
+		return fmt.Sprintf("%s — уплотнение до %g м", name, req.MaxSegmentM)
+	default:
+		return fmt.Sprintf("%s — буфер %g м", name, req.DistanceM)
+	}
+}
+
+// densifiedVertices возвращает число вершин геометрии после уплотнения
+// (см. geo.Densify). Подсчёт прекращается, как только число превысит
+// geo.MaxVertices.
+func densifiedVertices(m geo.MultiPolygon, maxSegment float64) int {
+	n := 0
+	for _, poly := range m {
+		for _, ring := range poly {
+			open := ring.Open()
+			for i, a := range open {
+				n += int(math.Max(1, math.Ceil(a.Dist(open[(i+1)%len(open)])/maxSegment)))
+				if n > geo.MaxVertices {
+					return n
+				}
+			}
+		}
+	}
+	return n
+}
+
+// vertexCount возвращает число вершин геометрии без замыкающих точек колец.
+func vertexCount(m geo.MultiPolygon) int {
+	n := 0
+	for _, poly := range m {
+		for _, ring := range poly {
+			n += len(ring.Open())
+		}
+	}
+	return n
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+)
+
+// RepairOptions управляет исправлением геометрии импортируемого контура.
+type RepairOptions struct {
+	// Enabled включает исправление; по умолчанию геометрия сохраняется как
+	// есть, а ошибки топологии возвращаются заявителю.
+	Enabled bool
+	// ToleranceM — допуск исправления в метрах; 0 — допуск по умолчанию
+	// (см. geo.DefaultRepairOptions).
+	ToleranceM float64
+}
+
+// repairContour исправляет геометрию контура (см. geo.Repair) и записывает
+// в контур отчёт о выполненных исправлениях.
+func repairContour(contour *model.Contour, opts RepairOptions) error {
+	if !opts.Enabled {
+		return nil
+	}
+	if math.IsNaN(opts.ToleranceM) || opts.ToleranceM < 0 || opts.ToleranceM > geo.MaxRepairTolerance {
+		return fmt.Errorf("допуск исправления геометрии должен быть от 0 до %g м", geo.MaxRepairTolerance)
+	}
+	params := geo.DefaultRepairOptions()
+	if opts.ToleranceM > 0 {
+		params.Tolerance = opts.ToleranceM
+	}
+	shape := contour.Shape()
+	repaired, fixes := geo.Repair(shape, params)
+	report := &model.RepairReport{ToleranceM: params.Tolerance, Fixes: make([]geo.RepairFix, 0, len(fixes))}
+	report.Fixes = append(report.Fixes, fixes...)
+	if len(fixes) > 0 {
+		report.AreaDeltaSqM = roundTo(repaired.GeodesicArea()-shape.GeodesicArea(), 2)
+		contour.SetGeometry(model.GeometryFromShape(repaired))
+	}
+	contour.Repair = report
+	return nil
+}
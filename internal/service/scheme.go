@@ -0,0 +1,346 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/drawing"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+)
+
+// Схема выводится на лист A4 книжной ориентации. Размеры — в миллиметрах.
+const (
+	schemeWidth  = 210.0
+	schemeHeight = 297.0
+	// schemeFill — доля поля карты, которую может занимать контур; остаток
+	// оставляет место для смежных участков и подписей точек.
+	schemeFill = 0.8
+	// schemeDPI — разрешение схемы в PNG.
+	schemeDPI = 150
+)
+
+var (
+	// schemeFrame — рамка листа с полем для подшивки слева.
+	schemeFrame = drawing.Rect{MinX: 20, MinY: 5, MaxX: 205, MaxY: 292}
+	// schemeMap — поле карты.
+	schemeMap = drawing.Rect{MinX: 25, MinY: 95, MaxX: 200, MaxY: 258}
+	// schemeScales — масштабы, в которых составляется схема: от 1:500 для
+	// участков в населённых пунктах до 1:100000 для крупных участков
+	// сельскохозяйственного назначения.
+	schemeScales = []int{500, 1000, 2000, 5000, 10000, 25000, 50000, 100000}
+)
+
+var (
+	schemeBlack   = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	schemeGray    = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	schemeWhite   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	schemeRed     = color.RGBA{0xd0, 0x10, 0x10, 0xff}
+	schemeMagenta = color.RGBA{0xb0, 0x20, 0xb0, 0xff}
+	schemeZone    = color.RGBA{0xb0, 0x20, 0xb0, 0x30}
+)
+
+// SchemeOptions управляет построением схемы расположения.
+type SchemeOptions struct {
+	// CRS — прямоугольная система координат схемы; по умолчанию та, в
+	// которой заявитель передал контур, а если она не задана или
+	// географическая — условная локальная система.
+	CRS string
+	// Scale — знаменатель масштаба; 0 — наименьший из schemeScales, в
+	// котором контур помещается на схеме.
+	Scale int
+}
+
+// LocationScheme строит схему расположения земельного участка на
+// кадастровом плане территории: границу образуемого участка с номерами
+// характерных точек, смежные участки из справочника кадастровых данных,
+// зоны с особыми условиями использования территории, масштаб, масштабную
+// линейку, направление на север и условные обозначения.
+func (s *Service) LocationScheme(contourID string, opts SchemeOptions) (*drawing.Drawing, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return nil, fmt.Errorf("контур не найден: %w", err)
+	}
+	return s.locationScheme(contour, opts)
+}
+
+// SchemePNG выводит схему в PNG.
+func SchemePNG(sheet *drawing.Drawing) ([]byte, error) {
+	return sheet.PNG(schemeDPI)
+}
+
+// SchemePDF выводит схему в PDF.
+func SchemePDF(sheet *drawing.Drawing) []byte {
+	return drawing.PDF("Схема расположения земельного участка", sheet)
+}
+
+func (s *Service) locationScheme(contour model.Contour, opts SchemeOptions) (*drawing.Drawing, error) {
+	shape := contour.Shape()
+	if len(shape) == 0 {
+		return nil, errors.New("у контура нет геометрии")
+	}
+	plane, crsName, err := schemePlane(contour, opts.CRS)
+	if err != nil {
+		return nil, err
+	}
+	local := plane.forward(shape)
+	scale, err := schemeScale(local.Bounds(), opts.Scale)
+	if err != nil {
+		return nil, err
+	}
+
+	// Лист связан с плоскостью преобразованием подобия: центр охвата контура
+	// совпадает с центром поля карты, 1 м на местности — 1000/scale мм.
+	k := 1000 / float64(scale)
+	center := local.Bounds().Center()
+	mapCenter := drawing.Point{X: (schemeMap.MinX + schemeMap.MaxX) / 2, Y: (schemeMap.MinY + schemeMap.MaxY) / 2}
+	toSheet := func(p geo.Point) drawing.Point {
+		return drawing.Point{X: mapCenter.X + (p.X-center.X)*k, Y: mapCenter.Y + (p.Y-center.Y)*k}
+	}
+	rings := func(m geo.MultiPolygon) [][]drawing.Point {
+		var out [][]drawing.Point
+		for _, poly := range m {
+			for _, ring := range poly {
+				pts := make([]drawing.Point, 0, len(ring))
+				for _, p := range ring.Open() {
+					pts = append(pts, toSheet(p))
+				}
+				out = append(out, pts)
+			}
+		}
+		return out
+	}
+
+	// Охват поля карты в WGS 84 для отбора смежных объектов.
+	bounds := geo.EmptyBBox()
+	halfW, halfH := schemeMap.Width()/2/k, schemeMap.Height()/2/k
+	for _, fx := range []float64{-1, 0, 1} {
+		for _, fy := range []float64{-1, 0, 1} {
+			bounds = bounds.Extend(plane.inversePoint(geo.Point{X: center.X + fx*halfW, Y: center.Y + fy*halfH}))
+		}
+	}
+	q := spatial.InBBox(bounds)
+
+	sheet := drawing.New(schemeWidth, schemeHeight)
+	sheet.SetClip(schemeMap)
+
+	zones := s.store.SearchRestrictionZones(q)
+	for _, zone := range zones {
+		zoneRings := rings(plane.forward(zone.Shape()))
+		sheet.Fill(zoneRings, schemeZone)
+		for _, ring := range zoneRings {
+			sheet.Line(ring, true, drawing.LineStyle{Width: 0.3, Color: schemeMagenta, Dash: []float64{2, 1}})
+		}
+	}
+
+	cadastral := s.cadastralByContour()
+	neighbours := 0
+	for _, other := range s.store.SearchContours(q) {
+		parcel, ok := cadastral[other.ID]
+		if !ok || other.ID == contour.ID || related(contour, other) {
+			continue
+		}
+		neighbours++
+		otherLocal := plane.forward(other.Shape())
+		for _, ring := range rings(otherLocal) {
+			sheet.Line(ring, true, drawing.LineStyle{Width: 0.2, Color: schemeBlack})
+		}
+		label := toSheet(otherLocal.InteriorPoint(otherLocal.Centroid()))
+		if schemeMap.Contains(label) {
+			sheet.Text(parcel.CadastralNumber, drawing.Point{X: label.X, Y: label.Y - 1}, 2, drawing.AlignCenter, schemeGray)
+		}
+	}
+
+	for _, ring := range rings(local) {
+		sheet.Line(ring, true, drawing.LineStyle{Width: 0.5, Color: schemeRed})
+	}
+	// Точки нумеруются так же, как в ведомости координат.
+	centroid := toSheet(local.Centroid())
+	number := 0
+	for _, poly := range local {
+		for _, ring := range poly {
+			for _, p := range statementRing(ring) {
+				number++
+				at := toSheet(p)
+				sheet.Circle(at, 0.6, schemeRed)
+				dx, dy := at.X-centroid.X, at.Y-centroid.Y
+				if l := math.Hypot(dx, dy); l > 0 {
+					dx, dy = dx/l, dy/l
+				} else {
+					dx, dy = 0, 1
+				}
+				sheet.Text("н"+strconv.Itoa(number), drawing.Point{X: at.X + dx*3, Y: at.Y + dy*3 - 1}, 2, drawing.AlignCenter, schemeRed)
+			}
+		}
+	}
+	sheet.ResetClip()
+
+	sheet.Frame(schemeFrame, drawing.LineStyle{Width: 0.5, Color: schemeBlack})
+	sheet.Frame(schemeMap, drawing.LineStyle{Width: 0.3, Color: schemeBlack})
+	schemeNorthArrow(sheet)
+	schemeTitle(sheet, contour, crsName)
+	schemeScaleBar(sheet, scale)
+	schemeLegend(sheet, neighbours > 0, len(zones) > 0)
+	return sheet, nil
+}
+
+// schemePlane выбирает плоскость схемы. Явно заданная система координат
+// должна быть прямоугольной; система, в которой передан контур, используется,
+// если она прямоугольная, иначе схема строится в условной локальной системе.
+func schemePlane(contour model.Contour, crsID string) (*planar, string, error) {
+	if crsID != "" {
+		system, err := crs.Lookup(crsID)
+		if err != nil {
+			return nil, "", err
+		}
+		if system.IsGeographic() {
+			return nil, "", fmt.Errorf("схема составляется в прямоугольной системе координат, %s — географическая", system.Name)
+		}
+		return newPlanarCRS(system), system.Name, nil
+	}
+	if contour.CRS != "" {
+		if system, err := crs.Lookup(contour.CRS); err == nil && !system.IsGeographic() {
+			return newPlanarCRS(system), system.Name, nil
+		}
+	}
+	return newPlanar(geo.FrameFor(contour.Shape())), "условная", nil
+}
+
+// schemeScale проверяет заданный масштаб или подбирает наименьший
+// стандартный, в котором охват контура b помещается на поле карты.
+func schemeScale(b geo.BBox, requested int) (int, error) {
+	fits := func(scale int) bool {
+		k := 1000 / float64(scale)
+		return (b.MaxX-b.MinX)*k <= schemeMap.Width()*schemeFill && (b.MaxY-b.MinY)*k <= schemeMap.Height()*schemeFill
+	}
+	largest := schemeScales[len(schemeScales)-1]
+	if requested != 0 {
+		if requested < schemeScales[0] || requested > largest {
+			return 0, fmt.Errorf("масштаб схемы должен быть от 1:%d до 1:%d", schemeScales[0], largest)
+		}
+		if !fits(requested) {
+			return 0, fmt.Errorf("контур не помещается на схеме в масштабе 1:%d", requested)
+		}
+		return requested, nil
+	}
+	for _, scale := range schemeScales {
+		if fits(scale) {
+			return scale, nil
+		}
+	}
+	return 0, fmt.Errorf("контур не помещается на схеме даже в масштабе 1:%d", largest)
+}
+
+// schemeTitle выводит заголовок схемы и сведения об участке над полем карты.
+func schemeTitle(sheet *drawing.Drawing, contour model.Contour, crsName string) {
+	x := (schemeFrame.MinX + schemeFrame.MaxX) / 2
+	width := schemeFrame.Width() - 10
+	fitText(sheet, "СХЕМА РАСПОЛОЖЕНИЯ ЗЕМЕЛЬНОГО УЧАСТКА", drawing.Point{X: x, Y: 284}, 4, width, drawing.AlignCenter)
+	fitText(sheet, "на кадастровом плане территории", drawing.Point{X: x, Y: 278}, 3, width, drawing.AlignCenter)
+	if contour.Description != "" {
+		fitText(sheet, contour.Description, drawing.Point{X: x, Y: 271.5}, 3, width, drawing.AlignCenter)
+	}
+	area := contour.Metrics.AreaSqM
+	if area == 0 {
+		area = contour.Shape().GeodesicArea()
+	}
+	info := fmt.Sprintf("Площадь: %s м²   Система координат: %s", strconv.FormatFloat(math.Round(area), 'f', 0, 64), crsName)
+	fitText(sheet, info, drawing.Point{X: x, Y: 265}, 2.5, width, drawing.AlignCenter)
+	sheet.Line([]drawing.Point{{X: schemeFrame.MinX, Y: 261}, {X: schemeFrame.MaxX, Y: 261}}, false,
+		drawing.LineStyle{Width: 0.3, Color: schemeBlack})
+}
+
+// schemeNorthArrow выводит стрелку направления на север в правом верхнем
+// углу поля карты.
+func schemeNorthArrow(sheet *drawing.Drawing) {
+	box := drawing.Rect{MinX: schemeMap.MaxX - 12, MinY: schemeMap.MaxY - 22, MaxX: schemeMap.MaxX, MaxY: schemeMap.MaxY}
+	sheet.FillRect(box, schemeWhite)
+	sheet.Frame(box, drawing.LineStyle{Width: 0.2, Color: schemeBlack})
+	x := (box.MinX + box.MaxX) / 2
+	sheet.Fill([][]drawing.Point{{{X: x - 3, Y: box.MinY + 3}, {X: x, Y: box.MinY + 15}, {X: x + 3, Y: box.MinY + 3}, {X: x, Y: box.MinY + 6}}}, schemeBlack)
+	sheet.Text("С", drawing.Point{X: x, Y: box.MinY + 16.5}, 3, drawing.AlignCenter, schemeBlack)
+}
+
+// schemeScaleBar выводит численный масштаб и масштабную линейку под полем
+// карты. Длина линейки — круглое число метров, занимающее от 20 до 50 мм.
+func schemeScaleBar(sheet *drawing.Drawing, scale int) {
+	left, y := schemeMap.MinX, schemeMap.MinY-9
+	sheet.Text("Масштаб 1:"+strconv.Itoa(scale), drawing.Point{X: left, Y: schemeMap.MinY - 5}, 3, drawing.AlignLeft, schemeBlack)
+	k := 1000 / float64(scale)
+	length := 1.0
+	for step := 0; length*k < 20; step++ {
+		length *= []float64{2, 2.5, 2}[step%3]
+	}
+	const cells = 4
+	cell := length * k / cells
+	for i := 0; i < cells; i++ {
+		r := drawing.Rect{MinX: left + float64(i)*cell, MinY: y - 1.5, MaxX: left + float64(i+1)*cell, MaxY: y}
+		if i%2 == 0 {
+			sheet.FillRect(r, schemeBlack)
+		}
+		sheet.Frame(r, drawing.LineStyle{Width: 0.2, Color: schemeBlack})
+	}
+	unit := strconv.FormatFloat(length, 'f', -1, 64) + " м"
+	if length >= 1000 {
+		unit = strconv.FormatFloat(length/1000, 'f', -1, 64) + " км"
+	}
+	sheet.Text("0", drawing.Point{X: left, Y: y - 5}, 2, drawing.AlignCenter, schemeBlack)
+	sheet.Text(unit, drawing.Point{X: left + length*k, Y: y - 5}, 2, drawing.AlignCenter, schemeBlack)
+}
+
+// schemeLegend выводит условные обозначения. Смежные участки и зоны
+// упоминаются, только если они есть на схеме.
+func schemeLegend(sheet *drawing.Drawing, neighbours, zones bool) {
+	x, y := schemeMap.MinX, schemeMap.MinY-25
+	sheet.Text("Условные обозначения:", drawing.Point{X: x, Y: y}, 3, drawing.AlignLeft, schemeBlack)
+	item := func(text string, symbol func(r drawing.Rect)) {
+		y -= 7
+		symbol(drawing.Rect{MinX: x, MinY: y - 0.5, MaxX: x + 12, MaxY: y + 2.5})
+		sheet.Text(text, drawing.Point{X: x + 16, Y: y}, 2.5, drawing.AlignLeft, schemeBlack)
+	}
+	middle := func(r drawing.Rect) []drawing.Point {
+		cy := (r.MinY + r.MaxY) / 2
+		return []drawing.Point{{X: r.MinX, Y: cy}, {X: r.MaxX, Y: cy}}
+	}
+	item("граница образуемого земельного участка", func(r drawing.Rect) {
+		sheet.Line(middle(r), false, drawing.LineStyle{Width: 0.5, Color: schemeRed})
+	})
+	item("характерная точка границы и её номер", func(r drawing.Rect) {
+		sheet.Circle(drawing.Point{X: r.MinX + 2, Y: (r.MinY + r.MaxY) / 2}, 0.6, schemeRed)
+		sheet.Text("н1", drawing.Point{X: r.MinX + 5, Y: r.MinY + 0.5}, 2, drawing.AlignLeft, schemeRed)
+	})
+	if neighbours {
+		item("граница земельного участка по сведениям ЕГРН", func(r drawing.Rect) {
+			sheet.Line(middle(r), false, drawing.LineStyle{Width: 0.2, Color: schemeBlack})
+		})
+		item("кадастровый номер земельного участка", func(r drawing.Rect) {
+			sheet.Text("50:01", drawing.Point{X: r.MinX, Y: r.MinY + 0.5}, 2, drawing.AlignLeft, schemeGray)
+		})
+	}
+	if zones {
+		item("зона с особыми условиями использования территории", func(r drawing.Rect) {
+			sheet.FillRect(r, schemeZone)
+			sheet.Frame(r, drawing.LineStyle{Width: 0.3, Color: schemeMagenta, Dash: []float64{2, 1}})
+		})
+	}
+}
+
+// fitText выводит строку, при необходимости уменьшая шрифт, чтобы она
+// поместилась по ширине; слишком длинная строка обрезается многоточием.
+func fitText(sheet *drawing.Drawing, s string, at drawing.Point, size, width float64, align drawing.Align) {
+	const minSize = 2.0
+	if w := drawing.TextWidth(s, size); w > width {
+		size = math.Max(size*width/w, minSize)
+	}
+	for drawing.TextWidth(s, size) > width {
+		runes := []rune(strings.TrimSuffix(s, "..."))
+		s = string(runes[:len(runes)-1]) + "..."
+	}
+	sheet.Text(s, at, size, align, schemeBlack)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"zemlya-prosto/internal/formats/rosreestr"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+	"zemlya-prosto/internal/util"
+)
+
+// blockSearchRadius — на сколько градусов расширяется охват контура при
+// поиске кадастрового квартала по соседним участкам.
+const blockSearchRadius = 0.02
+
+// locationSchemeXML формирует XML-документ схемы расположения контура для
+// представления в Росреестр. Координаты и описание границ берутся из
+// ведомости координат, категория земель, вид разрешённого использования,
+// адрес и кадастровый квартал — из информационной карточки контура
+// (атрибуты land_category_code, permitted_use, address, cadastral_block).
+// Вид разрешённого использования по умолчанию — purpose из metadata, а
+// квартал — квартал ближайшего участка из справочника кадастровых данных.
+// Документ проверяется по правилам XML-схемы; нарушения возвращаются в
+// *rosreestr.SchemaError.
+func (s *Service) locationSchemeXML(contour model.Contour, applicant model.Applicant, opts StatementOptions, metadata map[string]string) ([]byte, error) {
+	statement, err := coordinateStatement(contour, opts)
+	if err != nil {
+		return nil, err
+	}
+	card := make(map[string]string)
+	if c, ok := s.store.GetInformationCardByContour(contour.ID); ok {
+		for _, list := range [][]model.Attribute{c.AutoAttributes, c.ManualAttributes} {
+			for _, attr := range list {
+				card[attr.Key] = attr.Value
+			}
+		}
+	}
+	permittedUse := card["permitted_use"]
+	if permittedUse == "" {
+		permittedUse = metadata["purpose"]
+	}
+	block := card["cadastral_block"]
+	if block == "" {
+		block = s.nearestCadastralBlock(contour)
+	}
+
+	parcel := rosreestr.SchemeParcel{
+		Definition:       ":ЗУ1",
+		CadastralBlock:   block,
+		CategoryCode:     card["land_category_code"],
+		PermittedUse:     permittedUse,
+		Address:          card["address"],
+		Area:             statement.AreaSqM,
+		AreaInaccuracy:   statement.AreaErrorSqM,
+		CoordinateSystem: statement.CRSName,
+	}
+	for _, c := range statement.Contours {
+		if c.Ring == 0 {
+			parcel.Contours = append(parcel.Contours, rosreestr.SchemeContour{})
+		}
+		border := make([]rosreestr.SchemePoint, 0, len(c.Points))
+		for _, p := range c.Points {
+			border = append(border, rosreestr.SchemePoint{
+				Number:           p.Number,
+				X:                p.X,
+				Y:                p.Y,
+				MeanSquareError:  p.MeanSquareErrorM,
+				Length:           p.DistanceM,
+				DirectionalAngle: p.DirectionalAngle,
+			})
+		}
+		last := &parcel.Contours[len(parcel.Contours)-1]
+		last.Borders = append(last.Borders, border)
+	}
+
+	// ФИО записывается по частям: фамилия, имя, отчество.
+	name := strings.Fields(applicant.FullName)
+	person := rosreestr.Person{
+		SNILS:            applicant.SNILS,
+		IdentityDocument: applicant.IdentityDocument,
+		Address:          applicant.Address,
+		Phone:            applicant.Phone,
+		Email:            applicant.Email,
+	}
+	if len(name) > 0 {
+		person.Surname = name[0]
+	}
+	if len(name) > 1 {
+		person.Name = name[1]
+	}
+	if len(name) > 2 {
+		person.Patronymic = strings.Join(name[2:], " ")
+	}
+
+	id := util.NewID()
+	scheme := rosreestr.Scheme{
+		GUID:      fmt.Sprintf("%s-%s-%s-%s-%s", id[:8], id[8:12], id[12:16], id[16:20], id[20:32]),
+		Date:      time.Now().Format("2006-01-02"),
+		Applicant: person,
+		Parcel:    parcel,
+	}
+	return scheme.XML()
+}
+
+// schemeXMLDocument формирует документ «Схема расположения земельного
+// участка (XML)». Если документ не удалось сформировать или он не прошёл
+// проверку по XML-схеме, он включается в комплект без файла с перечнем
+// нарушений.
+func (s *Service) schemeXMLDocument(contour model.Contour, req DocumentPackageRequest) model.Document {
+	doc := model.Document{
+		ID:          util.NewID(),
+		Name:        "Схема расположения земельного участка (XML)",
+		Description: "XML-документ схемы расположения для представления в орган регистрации прав",
+		Source:      "generated_from_contour",
+	}
+	data, err := s.locationSchemeXML(contour, req.Applicant, StatementOptions{CRS: req.CRS, MeanSquareErrorM: req.MeanSquareErrorM}, req.Metadata)
+	if err != nil {
+		doc.Description = "XML-документ схемы расположения не сформирован: " + err.Error()
+		var schemaErr *rosreestr.SchemaError
+		if errors.As(err, &schemaErr) {
+			for _, v := range schemaErr.Violations {
+				doc.Attributes = append(doc.Attributes, model.Attribute{
+					Key: v.Path, Value: v.Message, Source: "xml_schema", Comment: "Нарушение XML-схемы",
+				})
+			}
+		}
+		return doc
+	}
+	doc.Files = []model.DocumentFile{
+		s.store.SaveDocumentFile(model.DocumentFile{Name: "shema-raspolozheniya.xml", MediaType: "application/xml"}, data),
+	}
+	return doc
+}
+
+// nearestCadastralBlock возвращает кадастровый квартал ближайшего к контуру
+// участка из справочника кадастровых данных или пустую строку.
+func (s *Service) nearestCadastralBlock(contour model.Contour) string {
+	bounds := contour.Shape().Bounds()
+	if bounds.IsEmpty() {
+		return ""
+	}
+	bounds = geo.BBox{
+		MinX: bounds.MinX - blockSearchRadius, MinY: bounds.MinY - blockSearchRadius,
+		MaxX: bounds.MaxX + blockSearchRadius, MaxY: bounds.MaxY + blockSearchRadius,
+	}
+	cadastral := s.cadastralByContour()
+	center := contour.Metrics.Centroid
+	block, best := "", math.Inf(1)
+	for _, other := range s.store.SearchContours(spatial.InBBox(bounds)) {
+		parcel, ok := cadastral[other.ID]
+		if !ok || parcel.CadastralBlock == "" || other.ID == contour.ID {
+			continue
+		}
+		// Для выбора ближайшего участка достаточно расстояния в градусах с
+		// поправкой долготы на широту.
+		c := other.Metrics.Centroid
+		dx := (c.Longitude - center.Longitude) * math.Cos(center.Latitude*math.Pi/180)
+		dy := c.Latitude - center.Latitude
+		if d := dx*dx + dy*dy; d < best {
+			block, best = parcel.CadastralBlock, d
+		}
+	}
+	return block
+}
@@ -2,16 +2,27 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
-	"zemlyaprosto/internal/assistant"
-	"zemlyaprosto/internal/business"
-	"zemlyaprosto/internal/layer"
-	"zemlyaprosto/internal/model"
-	"zemlyaprosto/internal/store"
-	"zemlyaprosto/internal/util"
+	"zemlya-prosto/internal/assistant"
+	"zemlya-prosto/internal/business"
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/formats/kml"
+	"zemlya-prosto/internal/formats/table"
+	"zemlya-prosto/internal/formats/wkb"
+	"zemlya-prosto/internal/formats/wkt"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/layer"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+	"zemlya-prosto/internal/store"
+	"zemlya-prosto/internal/templates"
+	"zemlya-prosto/internal/util"
 )
 
 // Service объединяет работу хранилища, цифрового помощника и других компонентов.
@@ -19,151 +30,637 @@ type Service struct {
 	store        *store.MemoryStore
 	assistant    *assistant.DigitalAssistant
 	layerManager *layer.Manager
+	templates    *templates.Store
 }
 
 // New создаёт новый экземпляр бизнес-сервиса.
+//
+// Сервис подключает к помощнику проверку по ПЗЗ, чтобы тот мог вызывать её
+// для контура заявителя.
 func New(store *store.MemoryStore, assistant *assistant.DigitalAssistant, layerManager *layer.Manager) *Service {
-	return &Service{
+	s := &Service{
 		store:        store,
 		assistant:    assistant,
 		layerManager: layerManager,
+		templates:    templates.NewStore(),
 	}
+	if assistant != nil {
+		assistant.SetZoningChecker(s.CheckZoning)
+	}
+	return s
 }
 
 // CreateContourFromDrawing регистрирует контур, нарисованный пользователем на карте.
-func (s *Service) CreateContourFromDrawing(description string, points []model.Point) (model.Contour, error) {
-	if len(points) < 3 {
+// Если в snap задан допуск, вершины контура перед сохранением притягиваются к
+// границам соседних объектов, а перечень изменений возвращается в Snapping.
+func (s *Service) CreateContourFromDrawing(description string, geometry model.ContourGeometry, snap SnapOptions) (model.Contour, error) {
+	polys := geometry.Polygons()
+	if len(polys) == 0 {
 		return model.Contour{}, errors.New("для построения контура необходимо минимум 3 точки")
 	}
+	for _, poly := range polys {
+		if len(poly.Exterior) < 3 {
+			return model.Contour{}, errors.New("для построения контура необходимо минимум 3 точки")
+		}
+	}
 	contour := model.Contour{
 		Description: description,
-		Points:      points,
 		Source:      model.ContourSourceDrawn,
 	}
-	return s.store.SaveContour(contour), nil
+	contour.SetGeometry(geometry)
+	if err := s.snapContour(&contour, snap); err != nil {
+		return model.Contour{}, err
+	}
+	return s.saveContour(contour)
 }
 
 // CreateContourFromCoordinates создаёт контур на основе списка координат.
-func (s *Service) CreateContourFromCoordinates(description string, points []model.Point) (model.Contour, error) {
-	if len(points) == 0 {
+func (s *Service) CreateContourFromCoordinates(description string, geometry model.ContourGeometry) (model.Contour, error) {
+	if geometry.IsEmpty() {
 		return model.Contour{}, errors.New("список координат не может быть пустым")
 	}
 	contour := model.Contour{
 		Description: description,
-		Points:      points,
 		Source:      model.ContourSourceCoordinates,
 	}
-	return s.store.SaveContour(contour), nil
+	contour.SetGeometry(geometry)
+	return s.saveContour(contour)
+}
+
+// CreateContourFromCRSCoordinates создаёт контур по координатам характерных точек,
+// заданным в системе координат заявителя (МСК, зона Гаусса–Крюгера, UTM и т. д.).
+//
+// Однокольцевой контур передаётся в coords.Points, участок с внутренними
+// границами или многоконтурный участок — в coords.Parts. Точки переводятся в
+// WGS 84, а идентификатор исходной системы сохраняется в контуре, чтобы
+// координаты можно было выгрузить обратно.
+func (s *Service) CreateContourFromCRSCoordinates(description string, coords model.ContourCoordinates) (model.Contour, error) {
+	shape := coords.Shape()
+	if len(shape.Points()) == 0 {
+		return model.Contour{}, errors.New("список координат не может быть пустым")
+	}
+	system, err := crs.Lookup(coords.CRS)
+	if err != nil {
+		return model.Contour{}, err
+	}
+	contour := model.Contour{
+		Description: description,
+		Source:      model.ContourSourceCoordinates,
+		CRS:         system.ID,
+	}
+	contour.SetGeometry(model.GeometryFromShape(system.ToWGS84Multi(shape)))
+	return s.saveContour(contour)
+}
+
+// ImportCoordinateTable создаёт контур по ведомости координат в формате CSV,
+// TSV или XLSX.
+//
+// Каждый номер контура в ведомости становится отдельной частью многоконтурного
+// участка. Система координат берётся из столбца ведомости, а если он не задан —
+// из defaultCRS. Строки, которые не удалось разобрать, пропускаются и
+// возвращаются вместе с контуром, чтобы заявитель мог их исправить.
+func (s *Service) ImportCoordinateTable(description string, data []byte, opts table.Options, defaultCRS string) (model.Contour, []table.RowError, error) {
+	sheet, err := table.Parse(data, opts)
+	if err != nil {
+		return model.Contour{}, nil, err
+	}
+	if len(sheet.Contours) == 0 {
+		return model.Contour{}, sheet.Errors, errors.New("в файле нет ни одной корректной строки с координатами")
+	}
+
+	coords := model.ContourCoordinates{CRS: defaultCRS}
+	fileCRS := ""
+	for _, c := range sheet.Contours {
+		if c.CRS != "" {
+			if fileCRS != "" && !strings.EqualFold(c.CRS, fileCRS) {
+				return model.Contour{}, sheet.Errors, fmt.Errorf("контуры ведомости заданы в разных системах координат: %s и %s", fileCRS, c.CRS)
+			}
+			fileCRS = c.CRS
+		}
+		points := make([]model.PlanePoint, len(c.Points))
+		for j, p := range c.Points {
+			points[j] = model.PlanePoint{Number: p.Number, X: p.X, Y: p.Y}
+		}
+		coords.Parts = append(coords.Parts, model.PlanePolygon{Exterior: points})
+	}
+	if fileCRS != "" {
+		coords.CRS = fileCRS
+	}
+	contour, err := s.CreateContourFromCRSCoordinates(description, coords)
+	return contour, sheet.Errors, err
+}
+
+// ExportContourCoordinates возвращает координаты характерных точек контура в
+// системе crsID. Если система не указана, используется система, в которой
+// контур был загружен, а для нарисованных контуров — WGS 84.
+func (s *Service) ExportContourCoordinates(contourID, crsID string) (model.ContourCoordinates, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.ContourCoordinates{}, fmt.Errorf("контур не найден: %w", err)
+	}
+	if crsID == "" {
+		crsID = contour.CRS
+	}
+	system, err := crs.Lookup(crsID)
+	if err != nil {
+		return model.ContourCoordinates{}, err
+	}
+	result := model.CoordinatesFromShape(system.FromWGS84Multi(contour.Shape()), len(contour.Parts) > 0)
+	result.ContourID = contour.ID
+	result.CRS = system.ID
+	return result, nil
+}
+
+// ListCRS возвращает системы координат, доступные для ввода и выгрузки координат.
+func (s *Service) ListCRS() []*crs.CRS {
+	return crs.List()
 }
 
 // ImportContour загружает контур из внешней системы.
-func (s *Service) ImportContour(description string, points []model.Point) (model.Contour, error) {
-	if len(points) == 0 {
+//
+// Геометрия из других ГИС часто некорректна. Если в repair включено
+// исправление, самопересечения, шпоры, лишние вершины и узкие щели
+// устраняются перед проверкой топологии, а перечень исправлений
+// возвращается в Repair.
+func (s *Service) ImportContour(description string, geometry model.ContourGeometry, repair RepairOptions) (model.Contour, error) {
+	if geometry.IsEmpty() {
+		return model.Contour{}, errors.New("импортированный контур не содержит точек")
+	}
+	contour := model.Contour{
+		Description: description,
+		Source:      model.ContourSourceImported,
+	}
+	contour.SetGeometry(geometry)
+	if err := repairContour(&contour, repair); err != nil {
+		return model.Contour{}, err
+	}
+	return s.saveContour(contour)
+}
+
+// ImportContourWKT загружает контур из WKT или EWKT (POLYGON, MULTIPOLYGON).
+//
+// Система координат берётся из crsID, а если он не указан — из SRID записи
+// EWKT; без того и другого координаты считаются долготой и широтой WGS 84.
+// Исправление геометрии выполняется так же, как в ImportContour.
+func (s *Service) ImportContourWKT(description, text, crsID string, repair RepairOptions) (model.Contour, error) {
+	shape, srid, err := wkt.Unmarshal(text)
+	if err != nil {
+		return model.Contour{}, err
+	}
+	return s.importShape(description, shape, srid, crsID, repair)
+}
+
+// ImportContourWKB загружает контур из WKB или EWKB в двоичной либо
+// шестнадцатеричной записи. Система координат и исправление геометрии
+// определяются так же, как в ImportContourWKT.
+func (s *Service) ImportContourWKB(description string, data []byte, crsID string, repair RepairOptions) (model.Contour, error) {
+	shape, srid, err := wkb.Unmarshal(data)
+	if err != nil {
+		return model.Contour{}, err
+	}
+	return s.importShape(description, shape, srid, crsID, repair)
+}
+
+// importShape переводит импортированную геометрию в WGS 84 и сохраняет контур.
+func (s *Service) importShape(description string, shape geo.MultiPolygon, srid int, crsID string, repair RepairOptions) (model.Contour, error) {
+	if len(shape.Points()) == 0 {
 		return model.Contour{}, errors.New("импортированный контур не содержит точек")
 	}
+	if crsID == "" && srid > 0 {
+		crsID = strconv.Itoa(srid)
+	}
+	system, err := crs.Lookup(crsID)
+	if err != nil {
+		return model.Contour{}, err
+	}
 	contour := model.Contour{
 		Description: description,
-		Points:      points,
 		Source:      model.ContourSourceImported,
+		CRS:         system.ID,
+	}
+	contour.SetGeometry(model.GeometryFromShape(system.ToWGS84Multi(shape)))
+	if err := repairContour(&contour, repair); err != nil {
+		return model.Contour{}, err
+	}
+	return s.saveContour(contour)
+}
+
+// ContourShape возвращает геометрию контура в системе координат crsID. Если
+// система не указана, используется система, в которой контур был загружен.
+func (s *Service) ContourShape(contourID, crsID string) (geo.MultiPolygon, *crs.CRS, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("контур не найден: %w", err)
+	}
+	return shapeIn(contour, crsID)
+}
+
+// LayerFeatureShape возвращает геометрию объекта слоя в системе координат crsID.
+func (s *Service) LayerFeatureShape(featureID, crsID string) (geo.MultiPolygon, *crs.CRS, error) {
+	feature, err := s.store.GetLayerFeature(featureID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("объект слоя не найден: %w", err)
+	}
+	return shapeIn(feature.Geometry, crsID)
+}
+
+// ContourKML возвращает контур документом KML для просмотра в Google Earth.
+func (s *Service) ContourKML(contourID string) ([]byte, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return nil, fmt.Errorf("контур не найден: %w", err)
+	}
+	return kml.Marshal(kml.Document{
+		Name:       contour.Description,
+		Placemarks: []kml.Placemark{contourPlacemark(contour)},
+	}), nil
+}
+
+// LayerFeatureKML возвращает объект слоя «Земля просто» документом KML.
+func (s *Service) LayerFeatureKML(featureID string) ([]byte, error) {
+	feature, err := s.store.GetLayerFeature(featureID)
+	if err != nil {
+		return nil, fmt.Errorf("объект слоя не найден: %w", err)
+	}
+	return kml.Marshal(kml.Document{
+		Name:       feature.ID,
+		Placemarks: []kml.Placemark{featurePlacemark(feature)},
+	}), nil
+}
+
+// LayerKML возвращает слой «Земля просто» документом KML: каждый объект
+// слоя, удовлетворяющий условию q, — отдельная метка со свойствами в
+// ExtendedData. Нулевое условие выгружает весь слой.
+func (s *Service) LayerKML(q spatial.Query) []byte {
+	layer := s.store.SearchLayer(q)
+	doc := kml.Document{Name: layer.Name}
+	for _, feature := range layer.Features {
+		doc.Placemarks = append(doc.Placemarks, featurePlacemark(feature))
+	}
+	return kml.Marshal(doc)
+}
+
+func contourPlacemark(contour model.Contour) kml.Placemark {
+	name := contour.Description
+	if name == "" {
+		name = contour.ID
+	}
+	return kml.Placemark{
+		Name: name,
+		Data: []kml.Data{
+			{Name: "id", Value: contour.ID},
+			{Name: "source", Value: string(contour.Source)},
+			{Name: "area_sq_m", Value: strconv.FormatFloat(contour.Metrics.AreaSqM, 'f', 2, 64)},
+		},
+		Shape: contour.Shape(),
+	}
+}
+
+func featurePlacemark(feature model.LayerFeature) kml.Placemark {
+	pm := contourPlacemark(feature.Geometry)
+	pm.Data = []kml.Data{{Name: "id", Value: feature.ID}}
+	keys := make([]string, 0, len(feature.Properties))
+	for key := range feature.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		pm.Data = append(pm.Data, kml.Data{Name: key, Value: feature.Properties[key]})
+	}
+	return pm
+}
+
+func shapeIn(contour model.Contour, crsID string) (geo.MultiPolygon, *crs.CRS, error) {
+	if crsID == "" {
+		crsID = contour.CRS
+	}
+	system, err := crs.Lookup(crsID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return system.FromWGS84Multi(contour.Shape()), system, nil
+}
+
+// GetContour возвращает контур по идентификатору.
+func (s *Service) GetContour(contourID string) (model.Contour, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.Contour{}, fmt.Errorf("контур не найден: %w", err)
 	}
+	return contour, nil
+}
+
+// GetLayerFeature возвращает объект слоя «Земля просто» по идентификатору.
+func (s *Service) GetLayerFeature(featureID string) (model.LayerFeature, error) {
+	feature, err := s.store.GetLayerFeature(featureID)
+	if err != nil {
+		return model.LayerFeature{}, fmt.Errorf("объект слоя не найден: %w", err)
+	}
+	return feature, nil
+}
+
+// contourValidation задаёт параметры топологической проверки контуров в WGS84.
+var contourValidation = geo.DefaultValidationOptions()
+
+// ValidateContour выполняет топологическую проверку геометрии без сохранения контура.
+//
+// Метод позволяет клиенту подсветить ошибочные вершины ещё до отправки контура.
+// Индексы частей и колец в нарушениях соответствуют Parts (кольцо 0 — внешняя
+// граница, далее внутренние границы).
+func (s *Service) ValidateContour(geometry model.ContourGeometry) []geo.Violation {
+	return geo.Validate(geometry.Shape(), contourValidation)
+}
+
+// saveContour проверяет топологию контура и сохраняет его в хранилище.
+//
+// При блокирующих нарушениях возвращается *geo.ValidationError со списком
+// нарушений по вершинам; предупреждения и найденные наложения на другие
+// объекты сохраняются вместе с контуром.
+func (s *Service) saveContour(contour model.Contour) (model.Contour, error) {
+	if err := checkContour(&contour); err != nil {
+		return model.Contour{}, err
+	}
+	contour.Conflicts = s.findConflicts(contour)
 	return s.store.SaveContour(contour), nil
 }
 
+// checkContour проверяет топологию контура, сохраняет в нём неблокирующие
+// замечания и вычисляет метрики.
+func checkContour(contour *model.Contour) error {
+	violations, err := geo.Check(contour.Shape(), contourValidation)
+	if err != nil {
+		return err
+	}
+	contour.Warnings = geo.Warnings(violations)
+	contour.Metrics = contour.Measure()
+	return nil
+}
+
 // ListContours возвращает все созданные контуры.
 func (s *Service) ListContours() []model.Contour {
 	return s.store.ListContours()
 }
 
+// SearchContours возвращает контуры, попадающие под пространственное условие
+// (видимую область карты, точку или пересечение с геометрией).
+func (s *Service) SearchContours(q spatial.Query) []model.Contour {
+	return s.store.SearchContours(q)
+}
+
 // CreateInformationCard формирует информационную карточку для контура.
 func (s *Service) CreateInformationCard(contourID string, autoAttrs, manualAttrs []model.Attribute) (model.InformationCard, error) {
 	if contourID == "" {
 		return model.InformationCard{}, errors.New("не указан идентификатор контура")
 	}
-	if _, err := s.store.GetContourByID(contourID); err != nil {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
 		return model.InformationCard{}, fmt.Errorf("контур не найден: %w", err)
 	}
 
 	card := model.InformationCard{
 		ContourID:        contourID,
-		AutoAttributes:   autoAttrs,
+		AutoAttributes:   mergeAttributes(autoAttrs, s.computedAttributes(contour, declaredUse(manualAttrs, autoAttrs))),
 		ManualAttributes: manualAttrs,
 	}
 
 	return s.store.SaveInformationCard(card), nil
 }
 
+// mergeAttributes дополняет атрибуты вычисленными значениями. Вычисленные
+// значения имеют приоритет над переданными с тем же ключом, чтобы площадь,
+// периметр и сведения о ЗОУИТ в карточке совпадали с расчётом по контуру.
+func mergeAttributes(attrs, computed []model.Attribute) []model.Attribute {
+	keys := make(map[string]struct{}, len(computed))
+	for _, attr := range computed {
+		keys[attr.Key] = struct{}{}
+	}
+	merged := make([]model.Attribute, 0, len(attrs)+len(computed))
+	for _, attr := range attrs {
+		if _, ok := keys[attr.Key]; !ok {
+			merged = append(merged, attr)
+		}
+	}
+	return append(merged, computed...)
+}
+
 // ListReadyParcels возвращает перечень готовых участков по категории.
 func (s *Service) ListReadyParcels(category model.ParcelCategory) []model.ReadyParcel {
 	return s.store.ListReadyParcels(category)
 }
 
+// SearchReadyParcels возвращает готовые участки категории, попадающие под
+// пространственное условие.
+func (s *Service) SearchReadyParcels(category model.ParcelCategory, q spatial.Query) []model.ReadyParcel {
+	return s.store.SearchReadyParcels(category, q)
+}
+
+// ListCadastralParcels возвращает участки справочника кадастровых данных.
+func (s *Service) ListCadastralParcels() []model.CadastralParcel {
+	return s.store.ListCadastralParcels()
+}
+
+// GetCadastralParcel возвращает участок справочника по кадастровому номеру.
+func (s *Service) GetCadastralParcel(number string) (model.CadastralParcel, error) {
+	parcel, err := s.store.GetCadastralParcel(number)
+	if err != nil {
+		return model.CadastralParcel{}, fmt.Errorf("участок %s не найден в справочнике: %w", number, err)
+	}
+	return parcel, nil
+}
+
+// DocumentPackageRequest описывает комплект документов: контур или готовый
+// участок, по которому он формируется, параметры схемы расположения и
+// ведомости координат и данные для заявления и согласия.
+type DocumentPackageRequest struct {
+	ContourID string `json:"contour_id"`
+	ParcelID  string `json:"parcel_id"`
+	// CRS — система координат схемы расположения и ведомости координат
+	// характерных точек; по умолчанию та, в которой заявитель передал контур.
+	CRS string `json:"crs,omitempty"`
+	// MeanSquareErrorM — средняя квадратическая погрешность положения
+	// характерных точек для ведомости координат, м.
+	MeanSquareErrorM float64 `json:"mean_square_error_m,omitempty"`
+	// SchemeScale — знаменатель масштаба схемы расположения; по умолчанию
+	// подбирается по размерам участка.
+	SchemeScale int `json:"scheme_scale,omitempty"`
+	// Applicant — сведения о заявителе.
+	Applicant model.Applicant `json:"applicant"`
+	// Metadata — дополнительные поля шаблонов, доступные как
+	// metadata.<ключ>, например authority — орган власти, в который
+	// подаётся заявление, и purpose — цель использования участка.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
 // GenerateDocumentPackage собирает комплект документов для обращения.
-func (s *Service) GenerateDocumentPackage(contourID, parcelID string) (model.DocumentPackage, error) {
+func (s *Service) GenerateDocumentPackage(req DocumentPackageRequest) (model.DocumentPackage, error) {
+	contourID, parcelID := req.ContourID, req.ParcelID
 	if contourID == "" && parcelID == "" {
 		return model.DocumentPackage{}, errors.New("необходимо указать контур или готовый участок")
 	}
 
 	documents := make([]model.Document, 0)
 	generator := make([]string, 0)
+	contourVersion := 0
+	var contour *model.Contour
+	var parcel *model.ReadyParcel
 
 	if contourID != "" {
-		contour, err := s.store.GetContourByID(contourID)
+		c, err := s.store.GetContourByID(contourID)
 		if err != nil {
 			return model.DocumentPackage{}, fmt.Errorf("контур не найден: %w", err)
 		}
-		documents = append(documents, model.Document{
-			ID:          util.NewID(),
-			Name:        "Схема расположения земельного участка",
-			Description: "Схема автоматически сформирована на основании созданного контура",
-			Source:      "generated_from_contour",
-		})
-		documents = append(documents, model.Document{
-			ID:          util.NewID(),
-			Name:        "Координаты характерных точек",
-			Description: "Ведомость координат для подачи в органы кадастрового учёта",
-			Source:      "generated_from_contour",
-		})
-		generator = append(generator, "contour:"+contour.ID)
+		contour = &c
+		metrics := c.Metrics.Attributes()
+		scheme, err := s.schemeDocument(c, SchemeOptions{CRS: req.CRS, Scale: req.SchemeScale})
+		if err != nil {
+			return model.DocumentPackage{}, err
+		}
+		scheme.Attributes = metrics
+		documents = append(documents, scheme)
+		statement, err := s.statementDocument(c, StatementOptions{CRS: req.CRS, MeanSquareErrorM: req.MeanSquareErrorM})
+		if err != nil {
+			return model.DocumentPackage{}, err
+		}
+		statement.Attributes = metrics
+		documents = append(documents, statement, s.schemeXMLDocument(c, req))
+		documents = append(documents, explanatoryNote(s.zoneReport(c)))
+		contourVersion = c.Version
+		generator = append(generator, fmt.Sprintf("contour:%s@v%d", c.ID, c.Version))
 	}
 
 	if parcelID != "" {
-		parcel, err := s.store.GetReadyParcelByID(parcelID)
+		p, err := s.store.GetReadyParcelByID(parcelID)
 		if err != nil {
 			return model.DocumentPackage{}, fmt.Errorf("готовый участок не найден: %w", err)
 		}
+		parcel = &p
 		documents = append(documents, model.Document{
 			ID:          util.NewID(),
 			Name:        "Выписка из перечня готовых участков",
 			Description: "Документ подтверждает параметры участка из перечня",
 			Source:      "ready_parcel_registry",
+			Attributes:  parcel.Contour.Metrics.Attributes(),
 		})
+		if contourID == "" {
+			documents = append(documents, explanatoryNote(s.zoneReport(parcel.Contour)))
+		}
 		generator = append(generator, "ready_parcel:"+parcel.ID)
 	}
 
 	// Общие документы, необходимые для подачи обращений.
-	documents = append(documents, model.Document{
-		ID:          util.NewID(),
-		Name:        "Заявление",
-		Description: "Черновик заявления на предоставление земельного участка",
-		Source:      "template",
-	})
-	documents = append(documents, model.Document{
-		ID:          util.NewID(),
-		Name:        "Согласие на обработку персональных данных",
-		Description: "Обязательный документ для подачи обращения",
-		Source:      "template",
-	})
+	common, templateVersions, err := s.applicationDocuments(req, contour, parcel)
+	if err != nil {
+		return model.DocumentPackage{}, err
+	}
+	documents = append(documents, common...)
+	generator = append(generator, templateVersions...)
 
 	pkg := model.DocumentPackage{
-		ParcelID:    parcelID,
-		ContourID:   contourID,
-		Documents:   documents,
-		GeneratedBy: strings.Join(generator, ";"),
+		ParcelID:       parcelID,
+		ContourID:      contourID,
+		ContourVersion: contourVersion,
+		Documents:      documents,
+		GeneratedBy:    strings.Join(generator, ";"),
 	}
 
 	return s.store.SaveDocumentPackage(pkg), nil
 }
 
+// schemeDocument формирует документ «Схема расположения земельного участка»
+// с листом схемы в PNG и PDF. Ошибка в явно заданных параметрах прерывает
+// формирование комплекта, в остальных случаях документ включается без
+// файлов с указанием причины.
+func (s *Service) schemeDocument(contour model.Contour, opts SchemeOptions) (model.Document, error) {
+	doc := model.Document{
+		ID:          util.NewID(),
+		Name:        "Схема расположения земельного участка",
+		Description: "Схема автоматически сформирована на основании созданного контура",
+		Source:      "generated_from_contour",
+	}
+	sheet, err := s.locationScheme(contour, opts)
+	if err != nil {
+		if opts.CRS != "" || opts.Scale != 0 {
+			return model.Document{}, fmt.Errorf("схема расположения: %w", err)
+		}
+		doc.Description = "Схема расположения не сформирована: " + err.Error()
+		return doc, nil
+	}
+	image, err := SchemePNG(sheet)
+	if err != nil {
+		return model.Document{}, err
+	}
+	doc.Files = []model.DocumentFile{
+		s.store.SaveDocumentFile(model.DocumentFile{Name: "shema-raspolozheniya.png", MediaType: "image/png"}, image),
+		s.store.SaveDocumentFile(model.DocumentFile{Name: "shema-raspolozheniya.pdf", MediaType: "application/pdf"}, SchemePDF(sheet)),
+	}
+	return doc, nil
+}
+
+// statementDocument формирует документ «Координаты характерных точек» с
+// ведомостью координат в JSON и в виде для печати. Если система координат
+// не задана ни в запросе, ни в контуре, документ включается в комплект без
+// файлов с указанием причины; ошибка в явно заданных параметрах прерывает
+// формирование комплекта.
+func (s *Service) statementDocument(contour model.Contour, opts StatementOptions) (model.Document, error) {
+	doc := model.Document{
+		ID:          util.NewID(),
+		Name:        "Координаты характерных точек",
+		Description: "Ведомость координат для подачи в органы кадастрового учёта",
+		Source:      "generated_from_contour",
+	}
+	statement, err := coordinateStatement(contour, opts)
+	if err != nil {
+		if opts.CRS != "" || opts.MeanSquareErrorM != 0 {
+			return model.Document{}, fmt.Errorf("ведомость координат: %w", err)
+		}
+		doc.Description = "Ведомость координат не сформирована: " + err.Error()
+		return doc, nil
+	}
+	data, err := json.MarshalIndent(statement, "", "  ")
+	if err != nil {
+		return model.Document{}, err
+	}
+	doc.Files = []model.DocumentFile{
+		s.store.SaveDocumentFile(model.DocumentFile{Name: "vedomost-koordinat.json", MediaType: "application/json"}, data),
+		s.store.SaveDocumentFile(model.DocumentFile{Name: "vedomost-koordinat.txt", MediaType: "text/plain; charset=utf-8"}, StatementText(statement)),
+	}
+	return doc, nil
+}
+
+// GetDocumentFile возвращает файл документа из комплекта.
+func (s *Service) GetDocumentFile(packageID, fileID string) (model.DocumentFile, []byte, error) {
+	pkg, err := s.store.GetDocumentPackage(packageID)
+	if err != nil {
+		return model.DocumentFile{}, nil, fmt.Errorf("комплект документов не найден: %w", err)
+	}
+	for _, doc := range pkg.Documents {
+		for _, file := range doc.Files {
+			if file.ID == fileID {
+				return s.store.GetDocumentFile(fileID)
+			}
+		}
+	}
+	return model.DocumentFile{}, nil, fmt.Errorf("файл %s не входит в комплект документов: %w", fileID, store.ErrNotFound)
+}
+
+// explanatoryNote формирует пояснительную записку со сведениями о зонах с
+// особыми условиями использования территории, в границах которых расположен
+// участок.
+func explanatoryNote(report model.ZoneReport) model.Document {
+	description := "Участок не расположен в границах зон с особыми условиями использования территории"
+	if len(report.Zones) > 0 {
+		description = fmt.Sprintf("Участок частично или полностью расположен в границах зон с особыми условиями "+
+			"использования территории (%d), площадь обременённой части %.2f м²", len(report.Zones), report.EncumberedAreaSqM)
+	}
+	return model.Document{
+		ID:          util.NewID(),
+		Name:        "Пояснительная записка",
+		Description: description,
+		Source:      "generated_from_contour",
+		Attributes:  zoneAttributes(report),
+	}
+}
+
 // GetDocumentPackages возвращает сформированные ранее комплекты документов.
 func (s *Service) GetDocumentPackages() []model.DocumentPackage {
 	return s.store.ListDocumentPackages()
@@ -176,9 +673,34 @@ func (s *Service) GetAssistantSuggestions(req assistant.Request) []model.Assista
 }
 
 // CreateBusinessProcess создаёт новый бизнес-процесс и сохраняет его в хранилище.
-func (s *Service) CreateBusinessProcess(name string) model.BusinessProcess {
+//
+// Если указан контур, процесс получает результаты его проверки на наложения,
+// чтобы оператор видел их при рассмотрении обращения.
+func (s *Service) CreateBusinessProcess(name, contourID string) (model.BusinessProcess, error) {
 	process := business.NewDefaultProcess(name)
-	return s.store.SaveBusinessProcess(process)
+	if contourID != "" {
+		contour, err := s.store.GetContourByID(contourID)
+		if err != nil {
+			return model.BusinessProcess{}, fmt.Errorf("контур не найден: %w", err)
+		}
+		process.ContourID = contour.ID
+		process.Conflicts = contour.Conflicts
+	}
+	return s.store.SaveBusinessProcess(process), nil
+}
+
+// GetBusinessProcess возвращает бизнес-процесс по идентификатору.
+func (s *Service) GetBusinessProcess(processID string) (model.BusinessProcess, error) {
+	process, err := s.store.GetBusinessProcessByID(processID)
+	if err != nil {
+		return model.BusinessProcess{}, fmt.Errorf("процесс не найден: %w", err)
+	}
+	return process, nil
+}
+
+// ListBusinessProcesses возвращает все бизнес-процессы.
+func (s *Service) ListBusinessProcesses() []model.BusinessProcess {
+	return s.store.ListBusinessProcesses()
 }
 
 // AdvanceBusinessProcess переводит следующий этап процесса в работу.
@@ -222,3 +744,9 @@ func (s *Service) PublishContourToLayer(contourID string, attributes map[string]
 func (s *Service) GetLayer() model.Layer {
 	return s.store.GetLayer()
 }
+
+// SearchLayer возвращает слой только с объектами, попадающими под
+// пространственное условие.
+func (s *Service) SearchLayer(q spatial.Query) model.Layer {
+	return s.store.SearchLayer(q)
+}
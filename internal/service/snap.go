@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+)
+
+// maxSnapTolerance — наибольший допуск привязки в метрах. При большем
+// допуске привязка начинает менять форму участка, а не устранять щели.
+const maxSnapTolerance = 10.0
+
+// SnapOptions управляет привязкой нарисованного контура к соседним границам.
+type SnapOptions struct {
+	// ToleranceM — допуск привязки в метрах; 0 отключает привязку.
+	ToleranceM float64
+}
+
+// snapTarget — объект, к границе которого притягивается контур.
+type snapTarget struct {
+	kind  model.ConflictKind
+	id    string
+	shape geo.MultiPolygon
+}
+
+// snapContour притягивает вершины и рёбра контура к границам существующих
+// контуров, готовых участков и участков справочника кадастровых данных,
+// расположенных не дальше допуска, и записывает в контур отчёт о привязке.
+//
+// Перенесённые вершины получают в точности координаты соседней границы
+// (см. planar), поэтому общий участок границы не образует щелей и наложений.
+func (s *Service) snapContour(contour *model.Contour, opts SnapOptions) error {
+	if opts.ToleranceM == 0 {
+		return nil
+	}
+	if math.IsNaN(opts.ToleranceM) || opts.ToleranceM < 0 || opts.ToleranceM > maxSnapTolerance {
+		return fmt.Errorf("допуск привязки должен быть от 0 до %g м", maxSnapTolerance)
+	}
+	shape := contour.Shape()
+	plane := newPlanar(geo.FrameFor(shape))
+	local := plane.forward(shape)
+
+	// Кандидаты отбираются по охвату контура, расширенному на допуск.
+	box := local.Bounds()
+	q := spatial.InBBox(geo.EmptyBBox().
+		Extend(plane.frame.Inverse(geo.Point{X: box.MinX - opts.ToleranceM, Y: box.MinY - opts.ToleranceM})).
+		Extend(plane.frame.Inverse(geo.Point{X: box.MaxX + opts.ToleranceM, Y: box.MaxY + opts.ToleranceM})))
+	cadastral := s.cadastralByContour()
+	var targets []snapTarget
+	for _, other := range s.store.SearchContours(q) {
+		target := snapTarget{kind: model.ConflictContour, id: other.ID, shape: other.Shape()}
+		if parcel, ok := cadastral[other.ID]; ok {
+			target.kind, target.id = model.ConflictCadastralParcel, parcel.CadastralNumber
+		}
+		targets = append(targets, target)
+	}
+	for _, parcel := range s.store.SearchReadyParcels("", q) {
+		targets = append(targets, snapTarget{kind: model.ConflictReadyParcel, id: parcel.ID, shape: parcel.Contour.Shape()})
+	}
+	locals := make([]geo.MultiPolygon, len(targets))
+	for i, t := range targets {
+		locals[i] = plane.forward(t.shape)
+	}
+
+	snapped, moves := geo.Snap(local, locals, opts.ToleranceM)
+	report := &model.SnapReport{ToleranceM: opts.ToleranceM, Moves: make([]model.SnapMove, 0, len(moves))}
+	for _, move := range moves {
+		target := targets[move.Target]
+		report.Moves = append(report.Moves, model.SnapMove{
+			Part:       move.Part,
+			Ring:       move.Ring,
+			Vertex:     move.Vertex,
+			Kind:       string(move.Kind),
+			From:       model.PointFromXY(plane.inversePoint(move.From)),
+			To:         model.PointFromXY(plane.inversePoint(move.To)),
+			DistanceM:  roundTo(move.Distance, 3),
+			ObjectKind: target.kind,
+			ObjectID:   target.id,
+		})
+	}
+	if len(moves) > 0 {
+		result := plane.inverse(snapped)
+		report.AreaDeltaSqM = roundTo(result.GeodesicArea()-shape.GeodesicArea(), 2)
+		contour.SetGeometry(model.GeometryFromShape(result))
+	}
+	contour.Snapping = report
+	return nil
+}
+
+// cadastralByContour сопоставляет контурам справочника кадастровых данных
+// участки ЕГРН, к которым они относятся.
+func (s *Service) cadastralByContour() map[string]model.CadastralParcel {
+	cadastral := make(map[string]model.CadastralParcel)
+	for _, parcel := range s.store.ListCadastralParcels() {
+		if parcel.ContourID != "" {
+			cadastral[parcel.ContourID] = parcel
+		}
+	}
+	return cadastral
+}
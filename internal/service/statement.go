@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+)
+
+const (
+	// defaultMeanSquareError — средняя квадратическая погрешность положения
+	// характерных точек по умолчанию, м: наиболее строгое из значений,
+	// установленных требованиями к точности (приказ Росреестра от 23.10.2020
+	// № П/0393), — для земель населённых пунктов.
+	defaultMeanSquareError = 0.10
+	// maxMeanSquareError — наибольшая допустимая погрешность, м (земли
+	// лесного и водного фондов, земли запаса).
+	maxMeanSquareError = 5.0
+)
+
+// StatementOptions управляет составлением ведомости координат.
+type StatementOptions struct {
+	// CRS — прямоугольная система координат ведомости; по умолчанию та, в
+	// которой заявитель передал контур.
+	CRS string
+	// MeanSquareErrorM — средняя квадратическая погрешность положения
+	// характерных точек, м; 0 — defaultMeanSquareError.
+	MeanSquareErrorM float64
+}
+
+// CoordinateStatement составляет ведомость координат характерных точек
+// границ контура.
+func (s *Service) CoordinateStatement(contourID string, opts StatementOptions) (model.CoordinateStatement, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.CoordinateStatement{}, fmt.Errorf("контур не найден: %w", err)
+	}
+	return coordinateStatement(contour, opts)
+}
+
+// coordinateStatement составляет ведомость координат по правилам,
+// принятым в документах для кадастрового учёта:
+//   - точки всех границ нумеруются сквозной нумерацией с префиксом «н»
+//     (вновь образуемые точки); обход каждой границы — по часовой стрелке,
+//     начиная с самой северо-западной точки, граница замыкается первой
+//     точкой;
+//   - координаты округляются до 0,01 м, совпавшие после округления
+//     соседние точки объединяются;
+//   - погрешность площади вычисляется по формуле ΔP = 3,5·Mt·√P.
+func coordinateStatement(contour model.Contour, opts StatementOptions) (model.CoordinateStatement, error) {
+	crsID := opts.CRS
+	if crsID == "" {
+		crsID = contour.CRS
+	}
+	if crsID == "" {
+		return model.CoordinateStatement{}, errors.New("не указана система координат ведомости (МСК)")
+	}
+	system, err := crs.Lookup(crsID)
+	if err != nil {
+		return model.CoordinateStatement{}, err
+	}
+	if system.IsGeographic() {
+		return model.CoordinateStatement{}, fmt.Errorf("ведомость координат составляется в прямоугольной системе координат, %s — географическая", system.Name)
+	}
+	mse := opts.MeanSquareErrorM
+	if mse == 0 {
+		mse = defaultMeanSquareError
+	}
+	if !(mse >= 0.01 && mse <= maxMeanSquareError) {
+		return model.CoordinateStatement{}, fmt.Errorf("средняя квадратическая погрешность должна быть от 0,01 до %g м", maxMeanSquareError)
+	}
+	mse = roundTo(mse, 2)
+
+	st := model.CoordinateStatement{
+		ContourID:        contour.ID,
+		ContourVersion:   contour.Version,
+		Description:      contour.Description,
+		CRS:              system.ID,
+		CRSName:          system.Name,
+		MeanSquareErrorM: mse,
+	}
+	number := 0
+	area, perimeter := 0.0, 0.0
+	for i, poly := range system.FromWGS84Multi(contour.Shape()) {
+		for j, ring := range poly {
+			pts := statementRing(ring)
+			if len(pts) < 3 {
+				return model.CoordinateStatement{}, fmt.Errorf("часть %d, граница %d: после округления координат до 0,01 м осталось меньше трёх точек", i+1, j+1)
+			}
+			if j == 0 {
+				area -= pts.SignedArea()
+			} else {
+				area += pts.SignedArea()
+			}
+			c := model.StatementContour{Part: i, Ring: j, Points: make([]model.StatementPoint, 0, len(pts)+1)}
+			for k, p := range pts {
+				number++
+				seconds := directionalAngleSeconds(p, pts[(k+1)%len(pts)])
+				distance := roundTo(p.Dist(pts[(k+1)%len(pts)]), 2)
+				perimeter += distance
+				c.Points = append(c.Points, model.StatementPoint{
+					Number:              "н" + strconv.Itoa(number),
+					X:                   p.Y,
+					Y:                   p.X,
+					DirectionalAngle:    formatAngle(seconds),
+					DirectionalAngleDeg: roundTo(float64(seconds)/3600, 6),
+					DistanceM:           distance,
+					MeanSquareErrorM:    mse,
+				})
+			}
+			first := c.Points[0]
+			c.Points = append(c.Points, model.StatementPoint{Number: first.Number, X: first.X, Y: first.Y, MeanSquareErrorM: mse})
+			st.Contours = append(st.Contours, c)
+		}
+	}
+	st.AreaSqM = math.Round(area)
+	st.AreaErrorSqM = math.Round(3.5 * mse * math.Sqrt(math.Max(area, 0)))
+	st.PerimeterM = roundTo(perimeter, 2)
+	return st, nil
+}
+
+// statementRing округляет координаты границы до 0,01 м, объединяет
+// совпавшие соседние точки и упорядочивает границу по часовой стрелке от
+// самой северо-западной точки.
+func statementRing(ring geo.Ring) geo.Ring {
+	var pts geo.Ring
+	for _, p := range ring.Open() {
+		p = geo.Point{X: roundTo(p.X, 2), Y: roundTo(p.Y, 2)}
+		if len(pts) == 0 || pts[len(pts)-1] != p {
+			pts = append(pts, p)
+		}
+	}
+	for len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	if pts.SignedArea() > 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	start := 0
+	for i, p := range pts {
+		// Северная координата — Y, восточная — X.
+		if p.Y-p.X > pts[start].Y-pts[start].X {
+			start = i
+		}
+	}
+	return append(pts[start:], pts[:start]...)
+}
+
+// directionalAngleSeconds возвращает дирекционный угол линии ab — угол от
+// северного направления оси абсцисс по часовой стрелке — в целых секундах.
+func directionalAngleSeconds(a, b geo.Point) int {
+	angle := math.Atan2(b.X-a.X, b.Y-a.Y) * 180 / math.Pi
+	if angle < 0 {
+		angle += 360
+	}
+	seconds := int(math.Round(angle * 3600))
+	return seconds % (360 * 3600)
+}
+
+// formatAngle записывает угол, заданный в секундах, в виде «ГГГ°ММ'СС"».
+func formatAngle(seconds int) string {
+	return fmt.Sprintf("%d°%02d'%02d\"", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// StatementText формирует ведомость координат для печати: таблицу точек
+// каждой границы с итоговыми площадью и периметром. Десятичный разделитель —
+// запятая.
+func StatementText(st model.CoordinateStatement) []byte {
+	var buf bytes.Buffer
+	decimal := func(v float64, digits int) string {
+		return strings.Replace(strconv.FormatFloat(v, 'f', digits, 64), ".", ",", 1)
+	}
+	buf.WriteString("ВЕДОМОСТЬ КООРДИНАТ ХАРАКТЕРНЫХ ТОЧЕК ГРАНИЦ ЗЕМЕЛЬНОГО УЧАСТКА\n\n")
+	if st.Description != "" {
+		fmt.Fprintf(&buf, "Участок: %s\n", st.Description)
+	}
+	fmt.Fprintf(&buf, "Контур: %s, версия %d\n", st.ContourID, st.ContourVersion)
+	fmt.Fprintf(&buf, "Система координат: %s\n", st.CRSName)
+	fmt.Fprintf(&buf, "Средняя квадратическая погрешность положения характерных точек (Mt): %s м\n", decimal(st.MeanSquareErrorM, 2))
+
+	parts := 0
+	for _, c := range st.Contours {
+		parts = max(parts, c.Part+1)
+	}
+	for _, c := range st.Contours {
+		title := "Внешняя граница"
+		if c.Ring > 0 {
+			title = fmt.Sprintf("Внутренняя граница %d", c.Ring)
+		}
+		if parts > 1 {
+			title += fmt.Sprintf(" контура %d", c.Part+1)
+		}
+		fmt.Fprintf(&buf, "\n%s\n\n", title)
+		tw := tabwriter.NewWriter(&buf, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "Обозначение\tX, м\tY, м\tДирекционный угол\tДлина линии, м\tMt, м")
+		for _, p := range c.Points {
+			distance := ""
+			if p.DirectionalAngle != "" {
+				distance = decimal(p.DistanceM, 2)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", p.Number, decimal(p.X, 2), decimal(p.Y, 2),
+				p.DirectionalAngle, distance, decimal(p.MeanSquareErrorM, 2))
+		}
+		tw.Flush()
+	}
+	fmt.Fprintf(&buf, "\nПлощадь: %s ± %s м²\n", decimal(st.AreaSqM, 0), decimal(st.AreaErrorSqM, 0))
+	fmt.Fprintf(&buf, "Периметр: %s м\n", decimal(st.PerimeterM, 2))
+	return buf.Bytes()
+}
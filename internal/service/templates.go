@@ -0,0 +1,160 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/templates"
+	"zemlya-prosto/internal/util"
+)
+
+const mediaTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+// applicationTemplates — шаблоны общих документов комплекта и описания
+// документов, которые по ним формируются.
+var applicationTemplates = []struct {
+	id, name, description string
+}{
+	{"application", "Заявление", "Заявление на предоставление земельного участка"},
+	{"consent", "Согласие на обработку персональных данных", "Обязательный документ для подачи обращения"},
+}
+
+// ListTemplates возвращает последние версии шаблонов документов.
+func (s *Service) ListTemplates() []templates.Template {
+	return s.templates.List()
+}
+
+// GetTemplate возвращает версию шаблона; version 0 — последняя версия.
+func (s *Service) GetTemplate(id string, version int) (templates.Template, error) {
+	return s.templates.Get(id, version)
+}
+
+// TemplateVersions возвращает все версии шаблона, начиная с первой.
+func (s *Service) TemplateVersions(id string) ([]templates.Template, error) {
+	return s.templates.Versions(id)
+}
+
+// SaveTemplate сохраняет новую версию шаблона. Комплекты, сформированные
+// ранее, остаются привязаны к версиям, по которым они собраны.
+func (s *Service) SaveTemplate(t templates.Template) (templates.Template, error) {
+	return s.templates.Save(t)
+}
+
+// applicationDocuments формирует по последним версиям шаблонов заявление и
+// согласие на обработку персональных данных в DOCX и PDF. Вместе с
+// документами возвращаются шаблоны с версиями для GeneratedBy комплекта.
+func (s *Service) applicationDocuments(req DocumentPackageRequest, contour *model.Contour, parcel *model.ReadyParcel) ([]model.Document, []string, error) {
+	data := s.templateData(req, contour, parcel)
+	documents := make([]model.Document, 0, len(applicationTemplates))
+	generator := make([]string, 0, len(applicationTemplates))
+	for _, a := range applicationTemplates {
+		t, err := s.templates.Get(a.id, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		rendered := t.Render(data)
+		docx, err := rendered.DOCX()
+		if err != nil {
+			return nil, nil, fmt.Errorf("шаблон %s: %w", t.ID, err)
+		}
+		base := fmt.Sprintf("%s-v%d", t.ID, t.Version)
+		documents = append(documents, model.Document{
+			ID:          util.NewID(),
+			Name:        a.name,
+			Description: a.description,
+			Source:      "template",
+			Files: []model.DocumentFile{
+				s.store.SaveDocumentFile(model.DocumentFile{Name: base + ".docx", MediaType: mediaTypeDOCX}, docx),
+				s.store.SaveDocumentFile(model.DocumentFile{Name: base + ".pdf", MediaType: "application/pdf"}, rendered.PDF()),
+			},
+		})
+		generator = append(generator, fmt.Sprintf("template:%s@v%d", t.ID, t.Version))
+	}
+	return documents, generator, nil
+}
+
+// templateData собирает данные, доступные шаблонам: applicant, contour,
+// card, parcel, metadata и date. Координаты характерных точек берутся из
+// ведомости координат и приводятся, только если её удалось сформировать.
+func (s *Service) templateData(req DocumentPackageRequest, contour *model.Contour, parcel *model.ReadyParcel) map[string]any {
+	metadata := make(map[string]any, len(req.Metadata))
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	data := map[string]any{
+		"date": time.Now().Format("02.01.2006"),
+		"applicant": map[string]any{
+			"full_name":         req.Applicant.FullName,
+			"identity_document": req.Applicant.IdentityDocument,
+			"address":           req.Applicant.Address,
+			"phone":             req.Applicant.Phone,
+			"email":             req.Applicant.Email,
+			"snils":             req.Applicant.SNILS,
+			"inn":               req.Applicant.INN,
+		},
+		"metadata": metadata,
+	}
+	if parcel != nil {
+		data["parcel"] = map[string]any{
+			"id":      parcel.ID,
+			"name":    parcel.Name,
+			"purpose": parcel.Description,
+			"address": parcel.Location,
+		}
+		if contour == nil {
+			contour = &parcel.Contour
+		}
+	}
+	if contour == nil {
+		return data
+	}
+
+	c := map[string]any{
+		"id":          contour.ID,
+		"description": contour.Description,
+		"area_sq_m":   math.Round(contour.Metrics.AreaSqM),
+		"perimeter_m": math.Round(contour.Metrics.PerimeterM*100) / 100,
+		"crs":         contour.CRS,
+	}
+	if statement, err := coordinateStatement(*contour, StatementOptions{CRS: req.CRS}); err == nil {
+		c["crs"], c["crs_name"] = statement.CRS, statement.CRSName
+		var points []any
+		for _, ring := range statement.Contours {
+			// Последняя точка границы повторяет первую.
+			for _, p := range ring.Points[:len(ring.Points)-1] {
+				points = append(points, map[string]any{
+					"number": p.Number,
+					"x":      templateDecimal(p.X),
+					"y":      templateDecimal(p.Y),
+				})
+			}
+		}
+		c["points"] = points
+	}
+	data["contour"] = c
+
+	var attributes []any
+	if card, ok := s.store.GetInformationCardByContour(contour.ID); ok {
+		for _, list := range [][]model.Attribute{card.AutoAttributes, card.ManualAttributes} {
+			for _, attr := range list {
+				// Вычисленные атрибуты подписаны в Comment, их ключи служебные.
+				name := attr.Key
+				if attr.Comment != "" {
+					name = attr.Comment
+				}
+				attributes = append(attributes, map[string]any{"name": name, "value": attr.Value})
+			}
+		}
+	}
+	data["card"] = map[string]any{"attributes": attributes}
+	return data
+}
+
+// templateDecimal записывает координату с точностью 0,01 м и запятой.
+func templateDecimal(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', 2, 64), ".", ",", 1)
+}
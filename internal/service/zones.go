@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/formats/shapefile"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/geojson"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+)
+
+// ZoneOptions задаёт систему координат файла зон и сведения, общие для всех
+// зон файла. Значения используются для объектов, в атрибутах которых
+// соответствующие сведения не указаны.
+type ZoneOptions struct {
+	CRS          string
+	Type         string
+	LegalBasis   string
+	Restrictions string
+}
+
+// Атрибуты файла, из которых берутся сведения о зоне. Имена сравниваются без
+// учёта регистра; короткие варианты рассчитаны на поля DBF длиной до 10 символов.
+var (
+	zoneIDFields           = []string{"reg_number", "registry_number", "reg_numb", "regnumber", "реестровый_номер", "рег_номер"}
+	zoneNameFields         = []string{"name", "zone_name", "наименование", "название"}
+	zoneTypeFields         = []string{"type", "zone_type", "kind", "вид", "вид_зоны", "тип"}
+	zoneLegalBasisFields   = []string{"legal_basis", "basis", "document", "основание", "документ"}
+	zoneRestrictionsFields = []string{"restrictions", "restrictio", "restrict", "ограничения", "ограничен"}
+)
+
+// zoneFeature — объект файла зон: геометрия в системе координат файла и
+// атрибуты с именами в нижнем регистре. Для GeoJSON в properties
+// сохраняются исходные значения свойств, в том числе массивы и объекты.
+type zoneFeature struct {
+	layer      string
+	record     int
+	id         string
+	shape      geo.MultiPolygon
+	attributes map[string]string
+	properties map[string]any
+}
+
+// ImportRestrictionZones загружает зоны с особыми условиями использования
+// территории из GeoJSON или zip-архива шейп-файла. Каждый полигональный
+// объект файла становится зоной реестра; вид зоны, основание и ограничения
+// берутся из атрибутов объекта или из opts. Зона с тем же реестровым номером
+// заменяет загруженную ранее.
+func (s *Service) ImportRestrictionZones(filename string, data []byte, opts ZoneOptions) (model.ZoneImportResult, error) {
+	features, system, err := readZoneFeatures(filename, data, opts.CRS)
+	if err != nil {
+		return model.ZoneImportResult{}, err
+	}
+
+	result := model.ZoneImportResult{Zones: make([]model.RestrictionZone, 0, len(features))}
+	for _, f := range features {
+		zone, err := newRestrictionZone(f, system, filename, opts)
+		if err != nil {
+			result.Errors = append(result.Errors, f.importError(err))
+			continue
+		}
+		result.Zones = append(result.Zones, s.store.SaveRestrictionZone(zone))
+	}
+	if len(result.Zones) == 0 {
+		return result, errors.New("в файле нет зон, пригодных для загрузки")
+	}
+	return result, nil
+}
+
+// importError описывает ошибку загрузки объекта с перечнем нарушений
+// топологии, если они есть.
+func (f zoneFeature) importError(err error) model.ImportError {
+	importErr := model.ImportError{Layer: f.layer, Record: f.record, Message: err.Error()}
+	var verr *geo.ValidationError
+	if errors.As(err, &verr) {
+		importErr.Violations = verr.Violations
+	}
+	return importErr
+}
+
+// readZoneFeatures читает объекты файла зон: GeoJSON или zip-архива
+// шейп-файла с единственным слоем. Система координат берётся из .prj
+// шейп-файла или из crsID.
+func readZoneFeatures(filename string, data []byte, crsID string) ([]zoneFeature, *crs.CRS, error) {
+	var features []zoneFeature
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		layers, err := shapefile.ReadZip(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(layers) != 1 {
+			return nil, nil, fmt.Errorf("архив должен содержать один слой зон, найдено %d", len(layers))
+		}
+		layer := layers[0]
+		system, err := layerCRS(layer, crsID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("слой %s: %w", layer.Name, err)
+		}
+		for _, rec := range layer.Records {
+			f := zoneFeature{layer: layer.Name, record: rec.Number, shape: rec.Shape, attributes: make(map[string]string)}
+			for i, value := range rec.Values {
+				f.attributes[strings.ToLower(layer.Fields[i].Name)] = value
+			}
+			features = append(features, f)
+		}
+		return features, system, nil
+	}
+
+	system, err := crs.Lookup(crsID)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := geojson.ParseOptions(data, geojson.Options{Projected: !system.IsGeographic()})
+	if err != nil {
+		return nil, nil, err
+	}
+	layer := fileLayer(filename)
+	for i, feature := range obj.Features {
+		f := zoneFeature{
+			layer:      layer,
+			record:     i + 1,
+			shape:      feature.Geometry.Shape,
+			attributes: make(map[string]string),
+			properties: make(map[string]any),
+		}
+		if feature.ID != nil {
+			f.id = propertyString(feature.ID)
+		}
+		for key, value := range feature.Properties {
+			f.attributes[strings.ToLower(key)] = propertyString(value)
+			f.properties[strings.ToLower(key)] = value
+		}
+		features = append(features, f)
+	}
+	return features, system, nil
+}
+
+// newRestrictionZone проверяет объект файла и переводит его в зону реестра.
+func newRestrictionZone(f zoneFeature, system *crs.CRS, filename string, opts ZoneOptions) (model.RestrictionZone, error) {
+	if len(f.shape.Points()) == 0 {
+		return model.RestrictionZone{}, errors.New("объект не содержит геометрии")
+	}
+	shape := system.ToWGS84Multi(f.shape)
+	if _, err := geo.Check(shape, contourValidation); err != nil {
+		return model.RestrictionZone{}, err
+	}
+	zone := model.RestrictionZone{
+		ID:           firstAttribute(f.attributes, zoneIDFields, f.id),
+		Name:         firstAttribute(f.attributes, zoneNameFields, ""),
+		Type:         firstAttribute(f.attributes, zoneTypeFields, opts.Type),
+		LegalBasis:   firstAttribute(f.attributes, zoneLegalBasisFields, opts.LegalBasis),
+		Restrictions: firstAttribute(f.attributes, zoneRestrictionsFields, opts.Restrictions),
+		Geometry:     model.GeometryFromShape(shape.Oriented()),
+		AreaSqM:      roundTo(shape.GeodesicArea(), 2),
+		Source:       filename,
+	}
+	if zone.Type == "" {
+		return model.RestrictionZone{}, errors.New("не указан вид зоны: задайте атрибут type или параметр type")
+	}
+	return zone, nil
+}
+
+func firstAttribute(attrs map[string]string, names []string, fallback string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(attrs[name]); value != "" {
+			return value
+		}
+	}
+	return strings.TrimSpace(fallback)
+}
+
+// propertyString переводит значение свойства GeoJSON в строку.
+func propertyString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ListRestrictionZones возвращает зоны реестра ЗОУИТ, попадающие под
+// пространственное условие.
+func (s *Service) ListRestrictionZones(q spatial.Query) []model.RestrictionZone {
+	return s.store.SearchRestrictionZones(q)
+}
+
+// GetRestrictionZone возвращает зону реестра ЗОУИТ по идентификатору.
+func (s *Service) GetRestrictionZone(zoneID string) (model.RestrictionZone, error) {
+	zone, err := s.store.GetRestrictionZone(zoneID)
+	if err != nil {
+		return model.RestrictionZone{}, fmt.Errorf("зона не найдена: %w", err)
+	}
+	return zone, nil
+}
+
+// ContourZones определяет зоны с особыми условиями использования территории,
+// в границах которых полностью или частично расположен контур.
+func (s *Service) ContourZones(contourID string) (model.ZoneReport, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.ZoneReport{}, fmt.Errorf("контур не найден: %w", err)
+	}
+	return s.zoneReport(contour), nil
+}
+
+// zoneReport вычисляет пересечения контура с зонами реестра. Площадь
+// обременённой части считается по объединению пересечений, чтобы
+// перекрывающиеся зоны не учитывались дважды.
+func (s *Service) zoneReport(contour model.Contour) model.ZoneReport {
+	report := model.ZoneReport{ContourID: contour.ID, Zones: make([]model.ZoneIntersection, 0)}
+	check := newOverlapCheck(contour)
+	var encumbered geo.MultiPolygon
+	for _, zone := range s.store.SearchRestrictionZones(spatial.Intersecting(contour.Shape())) {
+		part, ok := check.intersect(zone.Shape())
+		if !ok {
+			continue
+		}
+		report.Zones = append(report.Zones, model.ZoneIntersection{
+			ZoneID:         zone.ID,
+			Name:           zone.Name,
+			Type:           zone.Type,
+			LegalBasis:     zone.LegalBasis,
+			Restrictions:   zone.Restrictions,
+			AreaSqM:        roundTo(part.area, 2),
+			ContourPercent: percent(part.area, check.area),
+			Overlap:        model.GeometryFromShape(part.shape),
+		})
+		encumbered = geo.Union(encumbered, check.frame.ForwardMulti(part.shape))
+	}
+	sort.SliceStable(report.Zones, func(i, j int) bool {
+		return report.Zones[i].AreaSqM > report.Zones[j].AreaSqM
+	})
+	if len(encumbered) > 0 {
+		area := check.frame.InverseMulti(encumbered).GeodesicArea()
+		report.EncumberedAreaSqM = roundTo(area, 2)
+		report.EncumberedPercent = percent(area, check.area)
+	}
+	return report
+}
+
+// zoneAttributes представляет пересечения с зонами атрибутами информационной
+// карточки и пояснительной записки.
+func zoneAttributes(report model.ZoneReport) []model.Attribute {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	attrs := []model.Attribute{
+		{Key: "zouit_count", Value: strconv.Itoa(len(report.Zones)), Source: model.AttributeSourceComputed,
+			Comment: "Число зон с особыми условиями использования территории"},
+	}
+	if len(report.Zones) == 0 {
+		return attrs
+	}
+	attrs = append(attrs,
+		model.Attribute{Key: "zouit_area_sq_m", Value: format(report.EncumberedAreaSqM), Source: model.AttributeSourceComputed,
+			Comment: "Площадь части участка в границах ЗОУИТ, м²"},
+		model.Attribute{Key: "zouit_percent", Value: format(report.EncumberedPercent), Source: model.AttributeSourceComputed,
+			Comment: "Доля площади участка в границах ЗОУИТ, %"},
+	)
+	for i, z := range report.Zones {
+		value := z.Type
+		if z.Name != "" {
+			value += " «" + z.Name + "»"
+		}
+		value += fmt.Sprintf(" (%s): %s м², %s %%", z.ZoneID, format(z.AreaSqM), format(z.ContourPercent))
+		var comment []string
+		if z.LegalBasis != "" {
+			comment = append(comment, "Основание: "+z.LegalBasis)
+		}
+		if z.Restrictions != "" {
+			comment = append(comment, "Ограничения: "+z.Restrictions)
+		}
+		attrs = append(attrs, model.Attribute{
+			Key:     fmt.Sprintf("zouit_%d", i+1),
+			Value:   value,
+			Source:  model.AttributeSourceComputed,
+			Comment: strings.Join(comment, "; "),
+		})
+	}
+	return attrs
+}
+
+// computedAttributes возвращает атрибуты карточки, вычисляемые сервисом по
+// контуру: метрики, сведения о зонах с особыми условиями использования и
+// результат проверки по ПЗЗ для заявленного вида разрешённого использования.
+func (s *Service) computedAttributes(contour model.Contour, permittedUse string) []model.Attribute {
+	attrs := append(contour.Metrics.Attributes(), zoneAttributes(s.zoneReport(contour))...)
+	return append(attrs, zoningAttributes(s.zoningReport(contour, permittedUse))...)
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/crs"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+)
+
+// Атрибуты файла территориальных зон. Виды разрешённого использования
+// задаются свойством uses GeoJSON — массивом объектов ZonePermittedUse —
+// либо перечнями кодов через запятую или точку с запятой в отдельных полях
+// для основных, условно разрешённых и вспомогательных видов.
+var (
+	pzzCodeFields           = []string{"code", "zone_code", "zone_index", "index", "код", "индекс", "код_зоны"}
+	pzzMinAreaFields        = []string{"min_area_sq_m", "min_area", "min_area_m", "минимальная_площадь", "мин_площ"}
+	pzzMaxAreaFields        = []string{"max_area_sq_m", "max_area", "max_area_m", "максимальная_площадь", "макс_площ"}
+	pzzMainUseFields        = []string{"uses", "main_uses", "main_use", "основные_ври", "основные"}
+	pzzConditionalUseFields = []string{"conditional_uses", "cond_uses", "условные_ври", "условные"}
+	pzzAuxiliaryUseFields   = []string{"auxiliary_uses", "aux_uses", "вспомогательные_ври", "вспомогат"}
+)
+
+// ImportTerritorialZones загружает территориальные зоны правил
+// землепользования и застройки из GeoJSON или zip-архива шейп-файла. Зона с
+// тем же реестровым номером заменяет загруженную ранее.
+func (s *Service) ImportTerritorialZones(filename string, data []byte, crsID string) (model.TerritorialZoneImportResult, error) {
+	features, system, err := readZoneFeatures(filename, data, crsID)
+	if err != nil {
+		return model.TerritorialZoneImportResult{}, err
+	}
+	result := model.TerritorialZoneImportResult{Zones: make([]model.TerritorialZone, 0, len(features))}
+	for _, f := range features {
+		zone, err := newTerritorialZone(f, system, filename)
+		if err != nil {
+			result.Errors = append(result.Errors, f.importError(err))
+			continue
+		}
+		result.Zones = append(result.Zones, s.store.SaveTerritorialZone(zone))
+	}
+	if len(result.Zones) == 0 {
+		return result, errors.New("в файле нет территориальных зон, пригодных для загрузки")
+	}
+	return result, nil
+}
+
+// newTerritorialZone проверяет объект файла и переводит его в
+// территориальную зону с градостроительным регламентом.
+func newTerritorialZone(f zoneFeature, system *crs.CRS, filename string) (model.TerritorialZone, error) {
+	if len(f.shape.Points()) == 0 {
+		return model.TerritorialZone{}, errors.New("объект не содержит геометрии")
+	}
+	shape := system.ToWGS84Multi(f.shape)
+	if _, err := geo.Check(shape, contourValidation); err != nil {
+		return model.TerritorialZone{}, err
+	}
+	zone := model.TerritorialZone{
+		ID:       firstAttribute(f.attributes, zoneIDFields, f.id),
+		Code:     firstAttribute(f.attributes, pzzCodeFields, ""),
+		Name:     firstAttribute(f.attributes, zoneNameFields, ""),
+		Geometry: model.GeometryFromShape(shape.Oriented()),
+		AreaSqM:  roundTo(shape.GeodesicArea(), 2),
+		Source:   filename,
+	}
+	if zone.Code == "" {
+		return model.TerritorialZone{}, errors.New("не указан индекс территориальной зоны: задайте атрибут code")
+	}
+	var err error
+	if zone.MinAreaSqM, err = parseArea(firstAttribute(f.attributes, pzzMinAreaFields, "")); err != nil {
+		return model.TerritorialZone{}, fmt.Errorf("минимальная площадь: %w", err)
+	}
+	if zone.MaxAreaSqM, err = parseArea(firstAttribute(f.attributes, pzzMaxAreaFields, "")); err != nil {
+		return model.TerritorialZone{}, fmt.Errorf("максимальная площадь: %w", err)
+	}
+	if zone.Uses, err = zoneUses(f); err != nil {
+		return model.TerritorialZone{}, err
+	}
+	if len(zone.Uses) == 0 {
+		return model.TerritorialZone{}, errors.New("не указаны виды разрешённого использования зоны")
+	}
+	if err := checkAreaLimits(zone.MinAreaSqM, zone.MaxAreaSqM); err != nil {
+		return model.TerritorialZone{}, err
+	}
+	for _, use := range zone.Uses {
+		if err := checkAreaLimits(use.MinAreaSqM, use.MaxAreaSqM); err != nil {
+			return model.TerritorialZone{}, fmt.Errorf("вид %s: %w", use.Code, err)
+		}
+	}
+	return zone, nil
+}
+
+func checkAreaLimits(minArea, maxArea float64) error {
+	if minArea < 0 || maxArea < 0 || (maxArea > 0 && minArea > maxArea) {
+		return fmt.Errorf("неверные предельные размеры участка: от %g до %g м²", minArea, maxArea)
+	}
+	return nil
+}
+
+// zoneUses читает виды разрешённого использования объекта: массив uses
+// GeoJSON или перечни кодов в полях атрибутов.
+func zoneUses(f zoneFeature) ([]model.ZonePermittedUse, error) {
+	if list, ok := f.properties["uses"].([]any); ok {
+		raw, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+		var uses []model.ZonePermittedUse
+		if err := json.Unmarshal(raw, &uses); err != nil {
+			return nil, fmt.Errorf("неверный перечень видов разрешённого использования: %w", err)
+		}
+		for i := range uses {
+			use := &uses[i]
+			use.Code = strings.TrimSpace(use.Code)
+			if use.Kind == "" {
+				use.Kind = model.PermittedUseMain
+			}
+			if use.Code == "" {
+				return nil, fmt.Errorf("не указан код вида разрешённого использования %d", i+1)
+			}
+			switch use.Kind {
+			case model.PermittedUseMain, model.PermittedUseConditional, model.PermittedUseAuxiliary:
+			default:
+				return nil, fmt.Errorf("неизвестный вид использования %q для кода %s", use.Kind, use.Code)
+			}
+		}
+		return uses, nil
+	}
+	var uses []model.ZonePermittedUse
+	for _, group := range []struct {
+		fields []string
+		kind   model.PermittedUseKind
+	}{
+		{pzzMainUseFields, model.PermittedUseMain},
+		{pzzConditionalUseFields, model.PermittedUseConditional},
+		{pzzAuxiliaryUseFields, model.PermittedUseAuxiliary},
+	} {
+		codes := strings.FieldsFunc(firstAttribute(f.attributes, group.fields, ""), func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\n'
+		})
+		for _, code := range codes {
+			uses = append(uses, model.ZonePermittedUse{Code: code, Kind: group.kind})
+		}
+	}
+	return uses, nil
+}
+
+// parseArea читает площадь в квадратных метрах; пустое значение — без ограничения.
+func parseArea(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("неверное значение %q", value)
+	}
+	return v, nil
+}
+
+// ListTerritorialZones возвращает территориальные зоны, попадающие под
+// пространственное условие.
+func (s *Service) ListTerritorialZones(q spatial.Query) []model.TerritorialZone {
+	return s.store.SearchTerritorialZones(q)
+}
+
+// GetTerritorialZone возвращает территориальную зону по идентификатору.
+func (s *Service) GetTerritorialZone(zoneID string) (model.TerritorialZone, error) {
+	zone, err := s.store.GetTerritorialZone(zoneID)
+	if err != nil {
+		return model.TerritorialZone{}, fmt.Errorf("территориальная зона не найдена: %w", err)
+	}
+	return zone, nil
+}
+
+// CheckZoning проверяет контур и заявленный вид разрешённого использования
+// (код по классификатору) на соответствие правилам землепользования и
+// застройки.
+func (s *Service) CheckZoning(contourID, permittedUse string) (model.ZoningReport, error) {
+	contour, err := s.store.GetContourByID(contourID)
+	if err != nil {
+		return model.ZoningReport{}, fmt.Errorf("контур не найден: %w", err)
+	}
+	return s.zoningReport(contour, permittedUse), nil
+}
+
+// zoningReport определяет территориальные зоны, в которых расположен
+// контур, и проверяет регламент зоны, занимающей большую часть контура.
+func (s *Service) zoningReport(contour model.Contour, permittedUse string) model.ZoningReport {
+	use := strings.TrimSpace(permittedUse)
+	report := model.ZoningReport{
+		ContourID:    contour.ID,
+		PermittedUse: use,
+		AreaSqM:      contour.Metrics.AreaSqM,
+		Zones:        make([]model.ZoningMatch, 0),
+		Violations:   make([]model.ZoningViolation, 0),
+	}
+	add := func(code model.ZoningViolationCode, severity geo.Severity, zoneID, format string, args ...any) {
+		report.Violations = append(report.Violations, model.ZoningViolation{
+			Code: code, Severity: severity, ZoneID: zoneID, Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	check := newOverlapCheck(contour)
+	zones := make(map[string]model.TerritorialZone)
+	var covered geo.MultiPolygon
+	for _, zone := range s.store.SearchTerritorialZones(spatial.Intersecting(contour.Shape())) {
+		part, ok := check.intersect(zone.Shape())
+		if !ok {
+			continue
+		}
+		zones[zone.ID] = zone
+		report.Zones = append(report.Zones, model.ZoningMatch{
+			ZoneID:         zone.ID,
+			Code:           zone.Code,
+			Name:           zone.Name,
+			AreaSqM:        roundTo(part.area, 2),
+			ContourPercent: percent(part.area, check.area),
+		})
+		covered = geo.Union(covered, check.frame.ForwardMulti(part.shape))
+	}
+	sort.SliceStable(report.Zones, func(i, j int) bool {
+		return report.Zones[i].AreaSqM > report.Zones[j].AreaSqM
+	})
+	if len(report.Zones) == 0 {
+		add(model.ZoningOutsideZones, geo.SeverityWarning, "",
+			"Участок расположен вне границ территориальных зон: градостроительный регламент не проверен")
+		report.Compliant = true
+		return report
+	}
+
+	var codes []string
+	for _, m := range report.Zones {
+		if !slices.Contains(codes, m.Code) {
+			codes = append(codes, m.Code)
+		}
+	}
+	if len(codes) > 1 {
+		add(model.ZoningCrossesZones, geo.SeverityError, "",
+			"Участок пересекает границы территориальных зон %s: образование участка в таких границах не допускается",
+			strings.Join(codes, ", "))
+	}
+	if outside := check.area - check.frame.InverseMulti(covered).GeodesicArea(); outside >= minConflictArea {
+		add(model.ZoningPartiallyOutside, geo.SeverityWarning, "",
+			"Часть участка площадью %.2f м² расположена вне границ территориальных зон", outside)
+	}
+
+	zone := zones[report.Zones[0].ZoneID]
+	report.MinAreaSqM, report.MaxAreaSqM = zone.MinAreaSqM, zone.MaxAreaSqM
+	if use == "" {
+		add(model.ZoningUseNotDeclared, geo.SeverityWarning, zone.ID,
+			"Не указан вид разрешённого использования: проверены только предельные размеры зоны %s", zone.Code)
+	} else if permitted, ok := findUse(zone, use); !ok {
+		add(model.ZoningUseNotPermitted, geo.SeverityError, zone.ID,
+			"Вид разрешённого использования %s не предусмотрен регламентом зоны %s", use, zone.Code)
+	} else {
+		switch permitted.Kind {
+		case model.PermittedUseConditional:
+			add(model.ZoningConditionalUse, geo.SeverityWarning, zone.ID,
+				"Вид %s в зоне %s условно разрешённый: требуется разрешение на условно разрешённый вид использования", use, zone.Code)
+		case model.PermittedUseAuxiliary:
+			add(model.ZoningAuxiliaryUse, geo.SeverityError, zone.ID,
+				"Вид %s в зоне %s вспомогательный и не может быть установлен без основного", use, zone.Code)
+		}
+		if permitted.MinAreaSqM > 0 {
+			report.MinAreaSqM = permitted.MinAreaSqM
+		}
+		if permitted.MaxAreaSqM > 0 {
+			report.MaxAreaSqM = permitted.MaxAreaSqM
+		}
+	}
+	if report.MinAreaSqM > 0 && report.AreaSqM < report.MinAreaSqM {
+		add(model.ZoningAreaBelowMinimum, geo.SeverityError, zone.ID,
+			"Площадь участка %.2f м² меньше минимальной %.2f м², установленной для зоны %s", report.AreaSqM, report.MinAreaSqM, zone.Code)
+	}
+	if report.MaxAreaSqM > 0 && report.AreaSqM > report.MaxAreaSqM {
+		add(model.ZoningAreaAboveMaximum, geo.SeverityError, zone.ID,
+			"Площадь участка %.2f м² больше максимальной %.2f м², установленной для зоны %s", report.AreaSqM, report.MaxAreaSqM, zone.Code)
+	}
+
+	report.Compliant = true
+	for _, v := range report.Violations {
+		if v.Severity == geo.SeverityError {
+			report.Compliant = false
+		}
+	}
+	return report
+}
+
+// findUse ищет вид разрешённого использования в регламенте зоны.
+func findUse(zone model.TerritorialZone, code string) (model.ZonePermittedUse, bool) {
+	for _, use := range zone.Uses {
+		if use.Code == code {
+			return use, true
+		}
+	}
+	return model.ZonePermittedUse{}, false
+}
+
+// declaredUse возвращает код вида разрешённого использования из атрибутов
+// карточки (permitted_use_code); атрибуты просматриваются по порядку.
+func declaredUse(attrs ...[]model.Attribute) string {
+	for _, list := range attrs {
+		for _, attr := range list {
+			if attr.Key == "permitted_use_code" && strings.TrimSpace(attr.Value) != "" {
+				return strings.TrimSpace(attr.Value)
+			}
+		}
+	}
+	return ""
+}
+
+// zoningAttributes представляет результат проверки по ПЗЗ атрибутами
+// информационной карточки. Для участков вне территориальных зон атрибуты не
+// формируются.
+func zoningAttributes(report model.ZoningReport) []model.Attribute {
+	if len(report.Zones) == 0 {
+		return nil
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	zones := make([]string, len(report.Zones))
+	for i, z := range report.Zones {
+		zones[i] = z.Code
+		if z.Name != "" {
+			zones[i] += " «" + z.Name + "»"
+		}
+	}
+	attrs := []model.Attribute{
+		{Key: "pzz_zone", Value: strings.Join(zones, "; "), Source: model.AttributeSourceComputed,
+			Comment: "Территориальная зона по ПЗЗ"},
+	}
+	if report.PermittedUse != "" {
+		attrs = append(attrs, model.Attribute{Key: "pzz_permitted_use", Value: report.PermittedUse, Source: model.AttributeSourceComputed,
+			Comment: "Проверенный вид разрешённого использования"})
+	}
+	if report.MinAreaSqM > 0 {
+		attrs = append(attrs, model.Attribute{Key: "pzz_min_area_sq_m", Value: format(report.MinAreaSqM), Source: model.AttributeSourceComputed,
+			Comment: "Минимальная площадь участка по ПЗЗ, м²"})
+	}
+	if report.MaxAreaSqM > 0 {
+		attrs = append(attrs, model.Attribute{Key: "pzz_max_area_sq_m", Value: format(report.MaxAreaSqM), Source: model.AttributeSourceComputed,
+			Comment: "Максимальная площадь участка по ПЗЗ, м²"})
+	}
+	compliant := "да"
+	if !report.Compliant {
+		compliant = "нет"
+	}
+	attrs = append(attrs, model.Attribute{Key: "pzz_compliant", Value: compliant, Source: model.AttributeSourceComputed,
+		Comment: "Соответствие градостроительному регламенту"})
+	for i, v := range report.Violations {
+		attrs = append(attrs, model.Attribute{
+			Key:     fmt.Sprintf("pzz_violation_%d", i+1),
+			Value:   v.Message,
+			Source:  model.AttributeSourceComputed,
+			Comment: string(v.Severity) + ": " + string(v.Code),
+		})
+	}
+	return attrs
+}
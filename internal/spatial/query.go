@@ -0,0 +1,146 @@
+package spatial
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"zemlya-prosto/internal/formats/wkt"
+	"zemlya-prosto/internal/geo"
+	"zemlya-prosto/internal/geojson"
+)
+
+// Query — пространственное условие отбора объектов в WGS 84.
+// Нулевое значение не ограничивает выборку.
+type Query struct {
+	kind  queryKind
+	box   geo.BBox
+	point geo.Point
+	shape geo.MultiPolygon
+}
+
+type queryKind int
+
+const (
+	queryAll queryKind = iota
+	queryBBox
+	queryPoint
+	queryIntersects
+)
+
+// InBBox отбирает объекты, геометрия которых пересекает прямоугольник
+// (например, видимую область карты).
+func InBBox(b geo.BBox) Query { return Query{kind: queryBBox, box: b} }
+
+// AtPoint отбирает объекты, внутри которых лежит точка.
+func AtPoint(p geo.Point) Query {
+	return Query{kind: queryPoint, point: p, box: geo.EmptyBBox().Extend(p)}
+}
+
+// Intersecting отбирает объекты, пересекающиеся с геометрией m.
+func Intersecting(m geo.MultiPolygon) Query {
+	return Query{kind: queryIntersects, shape: m, box: m.Bounds()}
+}
+
+// IsZero сообщает, что условие не задано.
+func (q Query) IsZero() bool { return q.kind == queryAll }
+
+// Bounds возвращает прямоугольник для поиска кандидатов в индексе.
+func (q Query) Bounds() geo.BBox { return q.box }
+
+// Match проверяет условие по точной геометрии объекта.
+func (q Query) Match(m geo.MultiPolygon) bool {
+	switch q.kind {
+	case queryBBox:
+		return m.IntersectsBBox(q.box)
+	case queryPoint:
+		return m.ContainsPoint(q.point)
+	case queryIntersects:
+		return m.Intersects(q.shape)
+	default:
+		return true
+	}
+}
+
+// ParseQuery читает условие из параметров запроса: bbox=minX,minY,maxX,maxY,
+// point=x,y или intersects=<геометрия WKT или GeoJSON>. Координаты задаются
+// в WGS 84 (долгота, широта). Без параметров возвращается нулевое условие;
+// одновременно можно указать только один параметр.
+func ParseQuery(values url.Values) (Query, error) {
+	var (
+		q   Query
+		set []string
+	)
+	if raw := values.Get("bbox"); raw != "" {
+		b, err := geo.ParseBBox(raw)
+		if err != nil {
+			return Query{}, err
+		}
+		q, set = InBBox(b), append(set, "bbox")
+	}
+	if raw := values.Get("point"); raw != "" {
+		p, err := parsePoint(raw)
+		if err != nil {
+			return Query{}, err
+		}
+		q, set = AtPoint(p), append(set, "point")
+	}
+	if raw := values.Get("intersects"); raw != "" {
+		m, err := parseGeometry(raw)
+		if err != nil {
+			return Query{}, err
+		}
+		q, set = Intersecting(m), append(set, "intersects")
+	}
+	if len(set) > 1 {
+		return Query{}, fmt.Errorf("параметры %s нельзя указывать одновременно", strings.Join(set, ", "))
+	}
+	return q, nil
+}
+
+// parsePoint разбирает точку из строки "x,y".
+func parsePoint(s string) (geo.Point, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return geo.Point{}, fmt.Errorf("point должен содержать два числа через запятую: %q", s)
+	}
+	var v [2]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return geo.Point{}, fmt.Errorf("point содержит некорректное число %q", part)
+		}
+		v[i] = f
+	}
+	p := geo.Point{X: v[0], Y: v[1]}
+	if !p.IsFinite() {
+		return geo.Point{}, fmt.Errorf("point содержит некорректное число: %q", s)
+	}
+	return p, nil
+}
+
+// parseGeometry разбирает геометрию условия intersects: объект GeoJSON или
+// WKT/EWKT в WGS 84.
+func parseGeometry(s string) (geo.MultiPolygon, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") {
+		obj, err := geojson.Parse([]byte(s))
+		if err != nil {
+			return nil, fmt.Errorf("intersects: %w", err)
+		}
+		return obj.Shape(), nil
+	}
+	m, srid, err := wkt.Unmarshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("intersects: %w", err)
+	}
+	if srid != 0 && srid != 4326 {
+		return nil, fmt.Errorf("intersects: геометрия должна быть задана в WGS 84 (SRID 4326), указан SRID %d", srid)
+	}
+	if len(m.Points()) == 0 {
+		return nil, errors.New("intersects: пустая геометрия")
+	}
+	return m, nil
+}
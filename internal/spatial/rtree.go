@@ -0,0 +1,257 @@
+// Package spatial содержит пространственный индекс объектов карты и
+// условия отбора по охвату, точке и пересечению с геометрией.
+//
+// Индекс — R-дерево Гуттмана с квадратичным разделением узлов. Он хранит
+// только ограничивающие прямоугольники объектов по их идентификаторам и
+// возвращает кандидатов, которые затем проверяются по точной геометрии.
+// Индекс не потокобезопасен: его защищает блокировка владельца (хранилища
+// или сервиса), изменяющего индекс вместе с данными.
+package spatial
+
+import (
+	"math"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// Ограничения на число записей в узле дерева.
+const (
+	maxEntries = 9
+	minEntries = 4
+)
+
+type entry struct {
+	box   geo.BBox
+	child *node  // для внутренних узлов
+	id    string // для листьев
+}
+
+type node struct {
+	entries []entry
+}
+
+func (n *node) bounds() geo.BBox {
+	b := geo.EmptyBBox()
+	for _, e := range n.entries {
+		b = b.Union(e.box)
+	}
+	return b
+}
+
+// Index — R-дерево идентификаторов объектов по их охватам.
+type Index struct {
+	root *node
+	// height — число уровней дерева; листья находятся на уровне 0.
+	height int
+	boxes  map[string]geo.BBox
+}
+
+// NewIndex создаёт пустой индекс.
+func NewIndex() *Index {
+	return &Index{root: &node{}, height: 1, boxes: make(map[string]geo.BBox)}
+}
+
+// Len возвращает число объектов в индексе.
+func (x *Index) Len() int { return len(x.boxes) }
+
+// Insert добавляет объект или обновляет охват уже проиндексированного.
+// Объекты с пустым охватом в индекс не попадают.
+func (x *Index) Insert(id string, box geo.BBox) {
+	x.Remove(id)
+	if box.IsEmpty() {
+		return
+	}
+	x.boxes[id] = box
+	x.insert(entry{box: box, id: id}, 0)
+}
+
+// Remove удаляет объект из индекса и сообщает, был ли он там.
+func (x *Index) Remove(id string) bool {
+	box, ok := x.boxes[id]
+	if !ok {
+		return false
+	}
+	delete(x.boxes, id)
+
+	var orphans []orphan
+	x.remove(x.root, x.height-1, id, box, &orphans)
+	for _, o := range orphans {
+		for _, e := range o.entries {
+			x.insert(e, o.level)
+		}
+	}
+	for x.height > 1 && len(x.root.entries) == 1 {
+		x.root = x.root.entries[0].child
+		x.height--
+	}
+	if len(x.root.entries) == 0 {
+		x.root, x.height = &node{}, 1
+	}
+	return true
+}
+
+// Search возвращает идентификаторы объектов, охват которых пересекает box.
+func (x *Index) Search(box geo.BBox) []string {
+	var ids []string
+	if box.IsEmpty() {
+		return ids
+	}
+	var walk func(n *node, level int)
+	walk = func(n *node, level int) {
+		for _, e := range n.entries {
+			if !e.box.Intersects(box) {
+				continue
+			}
+			if level == 0 {
+				ids = append(ids, e.id)
+			} else {
+				walk(e.child, level-1)
+			}
+		}
+	}
+	walk(x.root, x.height-1)
+	return ids
+}
+
+// insert добавляет запись в узел уровня level, расщепляя переполненные узлы.
+func (x *Index) insert(e entry, level int) {
+	if sibling := x.insertInto(x.root, x.height-1, e, level); sibling != nil {
+		x.root = &node{entries: []entry{
+			{box: x.root.bounds(), child: x.root},
+			{box: sibling.bounds(), child: sibling},
+		}}
+		x.height++
+	}
+}
+
+func (x *Index) insertInto(n *node, nodeLevel int, e entry, level int) *node {
+	if nodeLevel == level {
+		n.entries = append(n.entries, e)
+	} else {
+		i := chooseSubtree(n, e.box)
+		sibling := x.insertInto(n.entries[i].child, nodeLevel-1, e, level)
+		n.entries[i].box = n.entries[i].child.bounds()
+		if sibling != nil {
+			n.entries = append(n.entries, entry{box: sibling.bounds(), child: sibling})
+		}
+	}
+	if len(n.entries) > maxEntries {
+		return split(n)
+	}
+	return nil
+}
+
+// orphan — записи узла, удалённого из-за недозаполнения, для повторной вставки.
+type orphan struct {
+	entries []entry
+	level   int
+}
+
+func (x *Index) remove(n *node, level int, id string, box geo.BBox, orphans *[]orphan) bool {
+	if level == 0 {
+		for i, e := range n.entries {
+			if e.id == id {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+	for i := range n.entries {
+		e := &n.entries[i]
+		if !contains(e.box, box) || !x.remove(e.child, level-1, id, box, orphans) {
+			continue
+		}
+		if len(e.child.entries) < minEntries {
+			*orphans = append(*orphans, orphan{entries: e.child.entries, level: level - 1})
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+		} else {
+			e.box = e.child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+// chooseSubtree выбирает запись, охват которой увеличится меньше всего.
+func chooseSubtree(n *node, box geo.BBox) int {
+	best, bestGrowth, bestArea := 0, math.Inf(1), math.Inf(1)
+	for i, e := range n.entries {
+		a, g := area(e.box), growth(e.box, box)
+		if g < bestGrowth || (g == bestGrowth && a < bestArea) {
+			best, bestGrowth, bestArea = i, g, a
+		}
+	}
+	return best
+}
+
+// split делит переполненный узел квадратичным методом Гуттмана: в n
+// остаётся первая группа, вторая возвращается новым узлом.
+func split(n *node) *node {
+	entries := n.entries
+	s1, s2 := pickSeeds(entries)
+	groupA, groupB := []entry{entries[s1]}, []entry{entries[s2]}
+	boxA, boxB := entries[s1].box, entries[s2].box
+	rest := make([]entry, 0, len(entries)-2)
+	for i, e := range entries {
+		if i != s1 && i != s2 {
+			rest = append(rest, e)
+		}
+	}
+
+	for len(rest) > 0 {
+		if len(groupA)+len(rest) == minEntries {
+			groupA = append(groupA, rest...)
+			break
+		}
+		if len(groupB)+len(rest) == minEntries {
+			groupB = append(groupB, rest...)
+			break
+		}
+		// Следующей распределяется запись с наибольшей разницей в приросте площади.
+		next, maxDiff := 0, -1.0
+		for i, e := range rest {
+			diff := math.Abs(growth(boxA, e.box) - growth(boxB, e.box))
+			if diff > maxDiff {
+				next, maxDiff = i, diff
+			}
+		}
+		e := rest[next]
+		rest = append(rest[:next], rest[next+1:]...)
+
+		dA, dB := growth(boxA, e.box), growth(boxB, e.box)
+		toA := dA < dB ||
+			(dA == dB && (area(boxA) < area(boxB) || (area(boxA) == area(boxB) && len(groupA) <= len(groupB))))
+		if toA {
+			groupA, boxA = append(groupA, e), boxA.Union(e.box)
+		} else {
+			groupB, boxB = append(groupB, e), boxB.Union(e.box)
+		}
+	}
+	n.entries = groupA
+	return &node{entries: groupB}
+}
+
+// pickSeeds выбирает пару записей, объединение которых даёт больше всего
+// пустого пространства.
+func pickSeeds(entries []entry) (int, int) {
+	s1, s2, worst := 0, 1, math.Inf(-1)
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			waste := area(entries[i].box.Union(entries[j].box)) - area(entries[i].box) - area(entries[j].box)
+			if waste > worst {
+				s1, s2, worst = i, j, waste
+			}
+		}
+	}
+	return s1, s2
+}
+
+func area(b geo.BBox) float64 { return (b.MaxX - b.MinX) * (b.MaxY - b.MinY) }
+
+func growth(b, add geo.BBox) float64 { return area(b.Union(add)) - area(b) }
+
+func contains(outer, inner geo.BBox) bool {
+	return inner.MinX >= outer.MinX && inner.MaxX <= outer.MaxX &&
+		inner.MinY >= outer.MinY && inner.MaxY <= outer.MaxY
+}
@@ -0,0 +1,92 @@
+package spatial
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"zemlya-prosto/internal/geo"
+)
+
+// bruteSearch возвращает отсортированные идентификаторы объектов, охват
+// которых пересекает box, полным перебором.
+func bruteSearch(boxes map[string]geo.BBox, box geo.BBox) []string {
+	ids := []string{}
+	for id, b := range boxes {
+		if b.Intersects(box) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func randomBox(r *rand.Rand, maxSize float64) geo.BBox {
+	x, y := 37+r.Float64(), 55+r.Float64()
+	return geo.BBox{MinX: x, MinY: y, MaxX: x + r.Float64()*maxSize, MaxY: y + r.Float64()*maxSize}
+}
+
+func TestIndexSearchMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	x := NewIndex()
+	boxes := make(map[string]geo.BBox)
+
+	check := func(stage string) {
+		t.Helper()
+		if x.Len() != len(boxes) {
+			t.Fatalf("%s: Len() = %d, want %d", stage, x.Len(), len(boxes))
+		}
+		queries := []geo.BBox{
+			{MinX: 36, MinY: 54, MaxX: 39, MaxY: 57},
+			{MinX: 37.5, MinY: 55.5, MaxX: 37.5, MaxY: 55.5},
+			{MinX: 40, MinY: 60, MaxX: 41, MaxY: 61},
+		}
+		for range 200 {
+			queries = append(queries, randomBox(r, 0.1))
+		}
+		for _, q := range queries {
+			got := x.Search(q)
+			slices.Sort(got)
+			if want := bruteSearch(boxes, q); !slices.Equal(got, want) {
+				t.Fatalf("%s: Search(%+v) вернул %d объектов, перебор — %d", stage, q, len(got), len(want))
+			}
+		}
+	}
+
+	for i := range 2000 {
+		id := fmt.Sprintf("c%d", i)
+		boxes[id] = randomBox(r, 0.01)
+		x.Insert(id, boxes[id])
+	}
+	check("вставка")
+
+	// Обновление охвата и удаление части объектов.
+	for i := 0; i < 2000; i += 3 {
+		id := fmt.Sprintf("c%d", i)
+		boxes[id] = randomBox(r, 0.05)
+		x.Insert(id, boxes[id])
+	}
+	for i := 1; i < 2000; i += 4 {
+		id := fmt.Sprintf("c%d", i)
+		if !x.Remove(id) {
+			t.Fatalf("Remove(%s) = false", id)
+		}
+		delete(boxes, id)
+	}
+	if x.Remove("c1") {
+		t.Fatal("повторный Remove вернул true")
+	}
+	check("обновление и удаление")
+
+	// Объект с пустым охватом удаляется из индекса.
+	x.Insert("c0", geo.EmptyBBox())
+	delete(boxes, "c0")
+	check("пустой охват")
+
+	for id := range boxes {
+		x.Remove(id)
+	}
+	clear(boxes)
+	check("удаление всех")
+}
@@ -7,22 +7,38 @@ package store
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
-	"zemlyaprosto/internal/model"
-	"zemlyaprosto/internal/util"
+	"zemlya-prosto/internal/model"
+	"zemlya-prosto/internal/spatial"
+	"zemlya-prosto/internal/util"
 )
 
 // MemoryStore — простое потокобезопасное хранилище данных в памяти процесса.
 type MemoryStore struct {
 	mu           sync.RWMutex
 	contours     map[string]model.Contour
+	versions     map[string][]model.ContourVersion
 	cards        map[string]model.InformationCard
 	readyParcels map[string]model.ReadyParcel
+	cadastre     map[string]model.CadastralParcel
+	zones        map[string]model.RestrictionZone
+	formations   map[string]model.Formation
+	territorial  map[string]model.TerritorialZone
 	docPackages  map[string]model.DocumentPackage
+	docFiles     map[string]documentFile
 	processes    map[string]model.BusinessProcess
 	layer        model.Layer
+
+	// Пространственные индексы охватов контуров, готовых участков, объектов
+	// слоя и зон обновляются вместе с данными под той же блокировкой.
+	contourIndex *spatial.Index
+	parcelIndex  *spatial.Index
+	featureIndex *spatial.Index
+	zoneIndex    *spatial.Index
+	pzzIndex     *spatial.Index
 }
 
 // NewMemoryStore инициализирует хранилище с небольшим набором демонстрационных данных.
@@ -35,20 +51,40 @@ func NewMemoryStore() *MemoryStore {
 
 	store := &MemoryStore{
 		contours:     make(map[string]model.Contour),
+		versions:     make(map[string][]model.ContourVersion),
 		cards:        make(map[string]model.InformationCard),
 		readyParcels: make(map[string]model.ReadyParcel),
+		cadastre:     make(map[string]model.CadastralParcel),
+		zones:        make(map[string]model.RestrictionZone),
+		formations:   make(map[string]model.Formation),
+		territorial:  make(map[string]model.TerritorialZone),
 		docPackages:  make(map[string]model.DocumentPackage),
+		docFiles:     make(map[string]documentFile),
 		processes:    make(map[string]model.BusinessProcess),
 		layer:        layer,
+		contourIndex: spatial.NewIndex(),
+		parcelIndex:  spatial.NewIndex(),
+		featureIndex: spatial.NewIndex(),
+		zoneIndex:    spatial.NewIndex(),
+		pzzIndex:     spatial.NewIndex(),
 	}
 
 	store.seedReadyParcels()
 	return store
 }
 
+// seedParcel добавляет готовый участок, предварительно нормализуя геометрию
+// и вычисляя метрики его контура.
+func (m *MemoryStore) seedParcel(parcel model.ReadyParcel) {
+	parcel.Contour.SetGeometry(parcel.Contour.Geometry())
+	parcel.Contour.Metrics = parcel.Contour.Measure()
+	m.readyParcels[parcel.ID] = parcel
+	m.parcelIndex.Insert(parcel.ID, parcel.Contour.Shape().Bounds())
+}
+
 // seedReadyParcels заполняет хранилище примерами готовых участков для стройки и туризма.
 func (m *MemoryStore) seedReadyParcels() {
-	m.readyParcels["construction-1"] = model.ReadyParcel{
+	m.seedParcel(model.ReadyParcel{
 		ID:       "construction-1",
 		Name:     "Промышленный парк «Северный»",
 		Category: model.ParcelCategoryConstruction,
@@ -60,12 +96,17 @@ func (m *MemoryStore) seedReadyParcels() {
 			Source:      model.ContourSourceImported,
 			CreatedAt:   time.Now(),
 			Description: "Импортированная граница промышленного участка",
-			Points:      []model.Point{{Latitude: 64.54, Longitude: 40.55}, {Latitude: 64.55, Longitude: 40.56}},
+			Points: []model.Point{
+				{Latitude: 64.54, Longitude: 40.55},
+				{Latitude: 64.54, Longitude: 40.56},
+				{Latitude: 64.55, Longitude: 40.56},
+				{Latitude: 64.55, Longitude: 40.55},
+			},
 		},
 		Available: true,
-	}
+	})
 
-	m.readyParcels["tourism-1"] = model.ReadyParcel{
+	m.seedParcel(model.ReadyParcel{
 		ID:          "tourism-1",
 		Name:        "Туристический кластер «Бирюзовая Катунь»",
 		Category:    model.ParcelCategoryTourism,
@@ -76,13 +117,29 @@ func (m *MemoryStore) seedReadyParcels() {
 			Source:      model.ContourSourceImported,
 			CreatedAt:   time.Now(),
 			Description: "Контур сформирован органом власти и опубликован в справочнике",
-			Points:      []model.Point{{Latitude: 51.99, Longitude: 85.85}, {Latitude: 52.0, Longitude: 85.86}},
+			// Внутри границ находится анклав — ранее учтённый участок базы отдыха.
+			Parts: []model.Polygon{{
+				Exterior: []model.Point{
+					{Latitude: 51.99, Longitude: 85.85},
+					{Latitude: 51.99, Longitude: 85.86},
+					{Latitude: 52.0, Longitude: 85.86},
+					{Latitude: 52.0, Longitude: 85.85},
+				},
+				Holes: [][]model.Point{{
+					{Latitude: 51.994, Longitude: 85.854},
+					{Latitude: 51.996, Longitude: 85.854},
+					{Latitude: 51.996, Longitude: 85.856},
+					{Latitude: 51.994, Longitude: 85.856},
+				}},
+			}},
 		},
 		Available: true,
-	}
+	})
 }
 
-// SaveContour сохраняет контур участка и возвращает его копию с присвоенным идентификатором.
+// SaveContour сохраняет новый контур участка и возвращает его копию с
+// присвоенным идентификатором. Контур получает версию 1, с которой
+// начинается его история.
 func (m *MemoryStore) SaveContour(contour model.Contour) model.Contour {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -91,11 +148,82 @@ func (m *MemoryStore) SaveContour(contour model.Contour) model.Contour {
 		contour.ID = util.NewID()
 	}
 	contour.CreatedAt = time.Now()
-
-	m.contours[contour.ID] = contour
+	contour.Version = 1
+
+	snapshot := contour.Clone()
+	m.contours[contour.ID] = contour.Clone()
+	m.versions[contour.ID] = []model.ContourVersion{{
+		Version:   1,
+		Operation: "create",
+		CreatedAt: contour.CreatedAt,
+		Contour:   &snapshot,
+	}}
+	m.contourIndex.Insert(contour.ID, contour.Shape().Bounds())
 	return contour
 }
 
+// UpdateContour сохраняет изменённый контур новой версией и дополняет его
+// историю записью version (операция, автор, причина).
+//
+// Контур должен быть получен из хранилища: если с тех пор была сохранена
+// другая версия, возвращается ErrVersionConflict и изменение не применяется.
+func (m *MemoryStore) UpdateContour(contour model.Contour, version model.ContourVersion) (model.Contour, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.contours[contour.ID]
+	if !ok {
+		return model.Contour{}, ErrNotFound
+	}
+	if current.Version != contour.Version {
+		return model.Contour{}, ErrVersionConflict
+	}
+	contour.CreatedAt = current.CreatedAt
+	contour.Version++
+
+	snapshot := contour.Clone()
+	version.Version = contour.Version
+	version.CreatedAt = time.Now()
+	version.Contour = &snapshot
+	m.contours[contour.ID] = contour.Clone()
+	m.versions[contour.ID] = append(m.versions[contour.ID], version)
+	m.contourIndex.Insert(contour.ID, contour.Shape().Bounds())
+	return contour, nil
+}
+
+// ListContourVersions возвращает историю контура от первой версии к последней
+// без состояний контура.
+func (m *MemoryStore) ListContourVersions(id string) ([]model.ContourVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	history, ok := m.versions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	versions := make([]model.ContourVersion, len(history))
+	for i, v := range history {
+		v.Contour = nil
+		versions[i] = v
+	}
+	return versions, nil
+}
+
+// GetContourVersion возвращает версию контура вместе с его состоянием.
+func (m *MemoryStore) GetContourVersion(id string, version int) (model.ContourVersion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	history := m.versions[id]
+	if version < 1 || version > len(history) {
+		return model.ContourVersion{}, ErrNotFound
+	}
+	v := history[version-1]
+	snapshot := v.Contour.Clone()
+	v.Contour = &snapshot
+	return v, nil
+}
+
 // GetContourByID возвращает контур по идентификатору.
 func (m *MemoryStore) GetContourByID(id string) (model.Contour, error) {
 	m.mu.RLock()
@@ -105,7 +233,21 @@ func (m *MemoryStore) GetContourByID(id string) (model.Contour, error) {
 	if !ok {
 		return model.Contour{}, ErrNotFound
 	}
-	return contour, nil
+	return contour.Clone(), nil
+}
+
+// SetContourConflicts заменяет результаты проверки контура на наложения.
+func (m *MemoryStore) SetContourConflicts(id string, conflicts []model.ContourConflict) (model.Contour, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	contour, ok := m.contours[id]
+	if !ok {
+		return model.Contour{}, ErrNotFound
+	}
+	contour.Conflicts = conflicts
+	m.contours[id] = contour
+	return contour.Clone(), nil
 }
 
 // ListContours возвращает срез всех сохраненных контуров.
@@ -115,7 +257,26 @@ func (m *MemoryStore) ListContours() []model.Contour {
 
 	contours := make([]model.Contour, 0, len(m.contours))
 	for _, contour := range m.contours {
-		contours = append(contours, contour)
+		contours = append(contours, contour.Clone())
+	}
+	return contours
+}
+
+// SearchContours возвращает контуры, удовлетворяющие пространственному условию.
+// Кандидаты отбираются по индексу охватов и проверяются по точной геометрии.
+func (m *MemoryStore) SearchContours(q spatial.Query) []model.Contour {
+	if q.IsZero() {
+		return m.ListContours()
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	contours := make([]model.Contour, 0)
+	for _, id := range m.contourIndex.Search(q.Bounds()) {
+		contour := m.contours[id]
+		if q.Match(contour.Shape()) {
+			contours = append(contours, contour.Clone())
+		}
 	}
 	return contours
 }
@@ -161,6 +322,192 @@ func (m *MemoryStore) ListReadyParcels(category model.ParcelCategory) []model.Re
 	return parcels
 }
 
+// SearchReadyParcels возвращает готовые участки категории (или всех категорий),
+// удовлетворяющие пространственному условию.
+func (m *MemoryStore) SearchReadyParcels(category model.ParcelCategory, q spatial.Query) []model.ReadyParcel {
+	if q.IsZero() {
+		return m.ListReadyParcels(category)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	parcels := make([]model.ReadyParcel, 0)
+	for _, id := range m.parcelIndex.Search(q.Bounds()) {
+		parcel := m.readyParcels[id]
+		if (category == "" || parcel.Category == category) && q.Match(parcel.Contour.Shape()) {
+			parcels = append(parcels, parcel)
+		}
+	}
+	return parcels
+}
+
+// SaveCadastralParcel сохраняет участок справочника кадастровых данных,
+// заменяя прежние сведения с тем же кадастровым номером.
+func (m *MemoryStore) SaveCadastralParcel(parcel model.CadastralParcel) model.CadastralParcel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parcel.UpdatedAt = time.Now()
+	m.cadastre[parcel.CadastralNumber] = parcel
+	return parcel
+}
+
+// GetCadastralParcel возвращает участок справочника по кадастровому номеру.
+func (m *MemoryStore) GetCadastralParcel(number string) (model.CadastralParcel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	parcel, ok := m.cadastre[number]
+	if !ok {
+		return model.CadastralParcel{}, ErrNotFound
+	}
+	return parcel, nil
+}
+
+// ListCadastralParcels возвращает участки справочника, упорядоченные по кадастровому номеру.
+func (m *MemoryStore) ListCadastralParcels() []model.CadastralParcel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	parcels := make([]model.CadastralParcel, 0, len(m.cadastre))
+	for _, parcel := range m.cadastre {
+		parcels = append(parcels, parcel)
+	}
+	sort.Slice(parcels, func(i, j int) bool {
+		return parcels[i].CadastralNumber < parcels[j].CadastralNumber
+	})
+	return parcels
+}
+
+// SaveRestrictionZone сохраняет зону в реестре ЗОУИТ, заменяя прежние
+// сведения о зоне с тем же идентификатором.
+func (m *MemoryStore) SaveRestrictionZone(zone model.RestrictionZone) model.RestrictionZone {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if zone.ID == "" {
+		zone.ID = util.NewID()
+	}
+	zone.UpdatedAt = time.Now()
+
+	m.zones[zone.ID] = zone
+	m.zoneIndex.Insert(zone.ID, zone.Shape().Bounds())
+	return zone
+}
+
+// GetRestrictionZone возвращает зону реестра ЗОУИТ по идентификатору.
+func (m *MemoryStore) GetRestrictionZone(id string) (model.RestrictionZone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zone, ok := m.zones[id]
+	if !ok {
+		return model.RestrictionZone{}, ErrNotFound
+	}
+	return zone, nil
+}
+
+// SearchRestrictionZones возвращает зоны реестра ЗОУИТ, удовлетворяющие
+// пространственному условию (нулевое условие — все зоны), упорядоченные по
+// идентификатору.
+func (m *MemoryStore) SearchRestrictionZones(q spatial.Query) []model.RestrictionZone {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zones := make([]model.RestrictionZone, 0)
+	if q.IsZero() {
+		for _, zone := range m.zones {
+			zones = append(zones, zone)
+		}
+	} else {
+		for _, id := range m.zoneIndex.Search(q.Bounds()) {
+			if zone := m.zones[id]; q.Match(zone.Shape()) {
+				zones = append(zones, zone)
+			}
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].ID < zones[j].ID })
+	return zones
+}
+
+// SaveTerritorialZone сохраняет территориальную зону ПЗЗ, заменяя прежние
+// сведения о зоне с тем же идентификатором.
+func (m *MemoryStore) SaveTerritorialZone(zone model.TerritorialZone) model.TerritorialZone {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if zone.ID == "" {
+		zone.ID = util.NewID()
+	}
+	zone.UpdatedAt = time.Now()
+
+	m.territorial[zone.ID] = zone
+	m.pzzIndex.Insert(zone.ID, zone.Shape().Bounds())
+	return zone
+}
+
+// GetTerritorialZone возвращает территориальную зону по идентификатору.
+func (m *MemoryStore) GetTerritorialZone(id string) (model.TerritorialZone, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zone, ok := m.territorial[id]
+	if !ok {
+		return model.TerritorialZone{}, ErrNotFound
+	}
+	return zone, nil
+}
+
+// SearchTerritorialZones возвращает территориальные зоны, удовлетворяющие
+// пространственному условию (нулевое условие — все зоны), упорядоченные по
+// идентификатору.
+func (m *MemoryStore) SearchTerritorialZones(q spatial.Query) []model.TerritorialZone {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	zones := make([]model.TerritorialZone, 0)
+	if q.IsZero() {
+		for _, zone := range m.territorial {
+			zones = append(zones, zone)
+		}
+	} else {
+		for _, id := range m.pzzIndex.Search(q.Bounds()) {
+			if zone := m.territorial[id]; q.Match(zone.Shape()) {
+				zones = append(zones, zone)
+			}
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].ID < zones[j].ID })
+	return zones
+}
+
+// SaveFormation сохраняет операцию образования участков.
+func (m *MemoryStore) SaveFormation(formation model.Formation) model.Formation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if formation.ID == "" {
+		formation.ID = util.NewID()
+	}
+	if formation.CreatedAt.IsZero() {
+		formation.CreatedAt = time.Now()
+	}
+	m.formations[formation.ID] = formation
+	return formation
+}
+
+// GetFormation возвращает операцию образования участков по идентификатору.
+func (m *MemoryStore) GetFormation(id string) (model.Formation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	formation, ok := m.formations[id]
+	if !ok {
+		return model.Formation{}, ErrNotFound
+	}
+	return formation, nil
+}
+
 // SaveDocumentPackage сохраняет комплект документов.
 func (m *MemoryStore) SaveDocumentPackage(pkg model.DocumentPackage) model.DocumentPackage {
 	m.mu.Lock()
@@ -175,6 +522,50 @@ func (m *MemoryStore) SaveDocumentPackage(pkg model.DocumentPackage) model.Docum
 	return pkg
 }
 
+// GetDocumentPackage возвращает комплект документов по идентификатору.
+func (m *MemoryStore) GetDocumentPackage(id string) (model.DocumentPackage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pkg, ok := m.docPackages[id]
+	if !ok {
+		return model.DocumentPackage{}, ErrNotFound
+	}
+	return pkg, nil
+}
+
+// documentFile — файл документа вместе с содержимым.
+type documentFile struct {
+	file model.DocumentFile
+	data []byte
+}
+
+// SaveDocumentFile сохраняет содержимое файла документа и возвращает
+// описание файла с присвоенным идентификатором и размером.
+func (m *MemoryStore) SaveDocumentFile(file model.DocumentFile, data []byte) model.DocumentFile {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if file.ID == "" {
+		file.ID = util.NewID()
+	}
+	file.Size = len(data)
+	m.docFiles[file.ID] = documentFile{file: file, data: append([]byte(nil), data...)}
+	return file
+}
+
+// GetDocumentFile возвращает описание и содержимое файла документа.
+func (m *MemoryStore) GetDocumentFile(id string) (model.DocumentFile, []byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	f, ok := m.docFiles[id]
+	if !ok {
+		return model.DocumentFile{}, nil, ErrNotFound
+	}
+	return f.file, f.data, nil
+}
+
 // ListDocumentPackages возвращает все сформированные комплекты документов.
 func (m *MemoryStore) ListDocumentPackages() []model.DocumentPackage {
 	m.mu.RLock()
@@ -215,6 +606,21 @@ func (m *MemoryStore) GetBusinessProcessByID(id string) (model.BusinessProcess,
 	return process, nil
 }
 
+// ListBusinessProcesses возвращает бизнес-процессы в порядке создания.
+func (m *MemoryStore) ListBusinessProcesses() []model.BusinessProcess {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	processes := make([]model.BusinessProcess, 0, len(m.processes))
+	for _, process := range m.processes {
+		processes = append(processes, process)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].CreatedAt.Before(processes[j].CreatedAt)
+	})
+	return processes
+}
+
 // UpdateBusinessStage изменяет статус конкретного этапа бизнес-процесса.
 func (m *MemoryStore) UpdateBusinessStage(processID, stageID string, status model.BusinessStageStatus) (model.BusinessProcess, error) {
 	m.mu.Lock()
@@ -255,9 +661,23 @@ func (m *MemoryStore) AddLayerFeature(feature model.LayerFeature) model.LayerFea
 	feature.UpdatedAt = time.Now()
 
 	m.layer.Features = append(m.layer.Features, feature)
+	m.featureIndex.Insert(feature.ID, feature.Geometry.Shape().Bounds())
 	return feature
 }
 
+// GetLayerFeature возвращает объект слоя «Земля просто» по идентификатору.
+func (m *MemoryStore) GetLayerFeature(id string) (model.LayerFeature, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, feature := range m.layer.Features {
+		if feature.ID == id {
+			return feature, nil
+		}
+	}
+	return model.LayerFeature{}, ErrNotFound
+}
+
 // GetLayer возвращает копию слоя «Земля просто».
 func (m *MemoryStore) GetLayer() model.Layer {
 	m.mu.RLock()
@@ -266,9 +686,36 @@ func (m *MemoryStore) GetLayer() model.Layer {
 	return m.layer
 }
 
+// SearchLayer возвращает слой «Земля просто» только с объектами,
+// удовлетворяющими пространственному условию, в порядке их добавления.
+func (m *MemoryStore) SearchLayer(q spatial.Query) model.Layer {
+	if q.IsZero() {
+		return m.GetLayer()
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	found := make(map[string]struct{})
+	for _, id := range m.featureIndex.Search(q.Bounds()) {
+		found[id] = struct{}{}
+	}
+	layer := m.layer
+	layer.Features = make([]model.LayerFeature, 0, len(found))
+	for _, feature := range m.layer.Features {
+		if _, ok := found[feature.ID]; ok && q.Match(feature.Geometry.Shape()) {
+			layer.Features = append(layer.Features, feature)
+		}
+	}
+	return layer
+}
+
 // ErrNotFound используется в сервисах для единообразной обработки отсутствия данных.
 var ErrNotFound = errors.New("not found")
 
+// ErrVersionConflict сообщает, что объект изменён другим запросом после того,
+// как был прочитан.
+var ErrVersionConflict = errors.New("version conflict")
+
 // GetReadyParcelByID возвращает готовый участок по идентификатору.
 func (m *MemoryStore) GetReadyParcelByID(id string) (model.ReadyParcel, error) {
 	m.mu.RLock()
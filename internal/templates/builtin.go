@@ -0,0 +1,97 @@
+package templates
+
+// Встроенные шаблоны. Данные комплекта, к которым они обращаются:
+//   - date — дата формирования комплекта;
+//   - applicant — заявитель: full_name, identity_document, address, phone,
+//     email, snils, inn;
+//   - contour — контур: id, description, area_sq_m, perimeter_m, crs,
+//     crs_name и список points с полями number, x, y;
+//   - card — карточка участка: список attributes с полями name и value;
+//   - parcel — участок из перечня: id, name, purpose, address;
+//   - metadata — произвольные поля запроса, например authority (орган
+//     власти, в который подаётся заявление) и purpose (цель использования).
+var builtin = []Template{
+	{
+		ID:          "application",
+		Name:        "Заявление о предварительном согласовании предоставления земельного участка",
+		Description: "Заявление по статье 39.15 Земельного кодекса РФ",
+		Body: `> В {{metadata.authority}}
+> от {{applicant.full_name}}
+> {{applicant.identity_document}}
+> адрес: {{applicant.address}}
+{{#if applicant.snils}}
+> СНИЛС: {{applicant.snils}}
+{{/if}}
+{{#if applicant.inn}}
+> ИНН: {{applicant.inn}}
+{{/if}}
+{{#if applicant.phone}}
+> тел.: {{applicant.phone}}
+{{/if}}
+{{#if applicant.email}}
+> эл. почта: {{applicant.email}}
+{{/if}}
+
+# ЗАЯВЛЕНИЕ
+## о предварительном согласовании предоставления земельного участка
+
+Прошу предварительно согласовать предоставление земельного участка
+{{#if parcel}}
+«{{parcel.name}}» из перечня земельных участков, предназначенных для предоставления (назначение: {{parcel.purpose}}{{#if parcel.address}}, адрес: {{parcel.address}}{{/if}}).
+{{else}}
+{{#if contour.description}}«{{contour.description}}» {{/if}}(образуется в соответствии со схемой расположения земельного участка на кадастровом плане территории).
+{{/if}}
+
+Площадь земельного участка: {{contour.area_sq_m}} кв. м.
+{{#if contour.crs_name}}
+Система координат: {{contour.crs_name}}.
+{{/if}}
+
+Цель использования земельного участка: {{metadata.purpose}}.
+
+{{#if contour.points}}
+Координаты характерных точек границ земельного участка:
+
+| Обозначение | X, м | Y, м |
+|---|---|---|
+{{#each contour.points}}
+| {{number}} | {{x}} | {{y}} |
+{{/each}}
+{{/if}}
+
+{{#if card.attributes}}
+Сведения о земельном участке:
+
+| Характеристика | Значение |
+|---|---|
+{{#each card.attributes}}
+| {{name}} | {{value}} |
+{{/each}}
+{{/if}}
+
+Способ получения результата: в личном кабинете на Едином портале государственных и муниципальных услуг.
+
+Дата: {{date}}
+
+Подпись: ________________ / {{applicant.full_name}} /
+`,
+	},
+	{
+		ID:          "consent",
+		Name:        "Согласие на обработку персональных данных",
+		Description: "Согласие субъекта персональных данных по статье 9 Федерального закона № 152-ФЗ",
+		Body: `# СОГЛАСИЕ
+## на обработку персональных данных
+
+Я, {{applicant.full_name}}, {{applicant.identity_document}}, зарегистрированный(ая) по адресу: {{applicant.address}}, в соответствии со статьёй 9 Федерального закона от 27.07.2006 № 152-ФЗ «О персональных данных» даю согласие {{metadata.authority}} на обработку моих персональных данных: фамилии, имени, отчества, реквизитов документа, удостоверяющего личность, адреса{{#if applicant.snils}}, СНИЛС{{/if}}{{#if applicant.phone}}, номера телефона{{/if}}{{#if applicant.email}}, адреса электронной почты{{/if}}.
+
+Цель обработки: рассмотрение заявления о предоставлении земельного участка{{#if contour.description}} «{{contour.description}}»{{/if}}{{#if parcel.name}} «{{parcel.name}}»{{/if}} и предоставление государственной (муниципальной) услуги.
+
+Согласие даётся на совершение действий, предусмотренных пунктом 3 статьи 3 Федерального закона № 152-ФЗ, с использованием средств автоматизации и без них, действует до достижения цели обработки и может быть отозвано письменным заявлением.
+
+Дата: {{date}}
+
+Подпись: ________________ / {{applicant.full_name}} /
+`,
+	},
+}
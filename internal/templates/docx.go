@@ -0,0 +1,149 @@
+package templates
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Разметка Office Open XML, общая для всех документов.
+const (
+	docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+	docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+	docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+	// Шрифт Times New Roman 12 пт, интервал после абзаца 6 пт.
+	docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Times New Roman" w:hAnsi="Times New Roman" w:cs="Times New Roman" w:eastAsia="Times New Roman"/><w:sz w:val="24"/><w:szCs w:val="24"/><w:lang w:val="ru-RU"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="240" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+</w:styles>`
+	// Лист A4, поля по ГОСТ Р 7.0.97: левое 30 мм, правое 15 мм, верхнее и
+	// нижнее 20 мм.
+	docxSection = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1134" w:right="850" w:bottom="1134" w:left="1701" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>`
+)
+
+// DOCX выводит документ в формате Office Open XML (Microsoft Word).
+func (d Document) DOCX() ([]byte, error) {
+	var body strings.Builder
+	body.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	body.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	for _, b := range d.Blocks {
+		switch b.Kind {
+		case BlockHeading:
+			size := 28
+			if b.Level > 1 {
+				size = 24
+			}
+			body.WriteString(docxParagraph(b.Text, "center", fmt.Sprintf("<w:b/><w:sz w:val=\"%d\"/>", size)))
+		case BlockParagraph:
+			align := "both"
+			if b.AlignRight {
+				align = "right"
+			}
+			body.WriteString(docxParagraph(b.Text, align, ""))
+		case BlockTable:
+			body.WriteString(docxTable(b))
+		}
+	}
+	body.WriteString(docxSection + `</w:body></w:document>`)
+
+	var core strings.Builder
+	core.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	core.WriteString(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>`)
+	core.WriteString(escapeXML(d.Title))
+	core.WriteString(`</dc:title><dc:creator>zemlya-prosto</dc:creator></cp:coreProperties>`)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/document.xml", body.String()},
+		{"word/styles.xml", docxStyles},
+		{"docProps/core.xml", core.String()},
+	} {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func docxParagraph(text, align, runProps string) string {
+	var b strings.Builder
+	b.WriteString(`<w:p><w:pPr><w:jc w:val="` + align + `"/></w:pPr><w:r>`)
+	if runProps != "" {
+		b.WriteString("<w:rPr>" + runProps + "</w:rPr>")
+	}
+	b.WriteString(`<w:t xml:space="preserve">` + escapeXML(text) + `</w:t></w:r></w:p>`)
+	return b.String()
+}
+
+func docxTable(t Block) string {
+	var b strings.Builder
+	border := func(name string) string {
+		return `<w:` + name + ` w:val="single" w:sz="4" w:space="0" w:color="000000"/>`
+	}
+	b.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="5000" w:type="pct"/><w:tblBorders>`)
+	for _, name := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		b.WriteString(border(name))
+	}
+	b.WriteString(`</w:tblBorders></w:tblPr><w:tblGrid>`)
+	if len(t.Rows) > 0 {
+		for range t.Rows[0] {
+			b.WriteString(`<w:gridCol/>`)
+		}
+	}
+	b.WriteString(`</w:tblGrid>`)
+	for i, row := range t.Rows {
+		header := i < t.HeaderRows
+		b.WriteString(`<w:tr>`)
+		if header {
+			b.WriteString(`<w:trPr><w:tblHeader/></w:trPr>`)
+		}
+		for _, cell := range row {
+			props, align := "", "left"
+			if header {
+				props, align = "<w:b/>", "center"
+			}
+			b.WriteString(`<w:tc><w:tcPr><w:tcW w:w="0" w:type="auto"/></w:tcPr>`)
+			b.WriteString(docxParagraph(cell, align, props))
+			b.WriteString(`</w:tc>`)
+		}
+		b.WriteString(`</w:tr>`)
+	}
+	b.WriteString(`</w:tbl><w:p/>`)
+	return b.String()
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
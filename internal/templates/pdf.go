@@ -0,0 +1,189 @@
+package templates
+
+import (
+	"image/color"
+	"strings"
+
+	"zemlya-prosto/internal/drawing"
+)
+
+// Вёрстка PDF: лист A4, поля по ГОСТ Р 7.0.97, размеры в миллиметрах.
+const (
+	pageWidth    = 210.0
+	pageHeight   = 297.0
+	marginLeft   = 30.0
+	marginRight  = 15.0
+	marginTop    = 20.0
+	marginBottom = 20.0
+	// textSize — высота прописных букв основного текста (соответствует
+	// кеглю 12 пт).
+	textSize = 2.8
+	// lineSpacing — межстрочное расстояние относительно textSize.
+	lineSpacing = 1.9
+	// cellPadding — внутренний отступ ячейки таблицы.
+	cellPadding = 1.5
+	// minColumn — наименьшая ширина столбца таблицы.
+	minColumn = 15.0
+)
+
+var black = color.RGBA{0, 0, 0, 0xff}
+
+// pdfLayout размещает блоки документа по страницам сверху вниз.
+type pdfLayout struct {
+	pages []*drawing.Drawing
+	page  *drawing.Drawing
+	// y — верхняя граница свободного места на странице.
+	y float64
+}
+
+// PDF выводит документ в PDF встроенным штриховым шрифтом.
+func (d Document) PDF() []byte {
+	l := &pdfLayout{}
+	l.newPage()
+	for _, b := range d.Blocks {
+		switch b.Kind {
+		case BlockHeading:
+			size := textSize * 1.3
+			if b.Level > 1 {
+				size = textSize * 1.1
+			}
+			l.text(b.Text, size, drawing.AlignCenter)
+		case BlockParagraph:
+			align := drawing.AlignLeft
+			if b.AlignRight {
+				align = drawing.AlignRight
+			}
+			l.text(b.Text, textSize, align)
+		case BlockTable:
+			l.table(b)
+		}
+		l.y -= textSize
+	}
+	return drawing.PDF(d.Title, l.pages...)
+}
+
+func (l *pdfLayout) newPage() {
+	l.page = drawing.New(pageWidth, pageHeight)
+	l.pages = append(l.pages, l.page)
+	l.y = pageHeight - marginTop
+}
+
+// text выводит абзац с переносом строк по ширине поля набора.
+func (l *pdfLayout) text(s string, size float64, align drawing.Align) {
+	width := pageWidth - marginLeft - marginRight
+	x := marginLeft
+	switch align {
+	case drawing.AlignCenter:
+		x += width / 2
+	case drawing.AlignRight:
+		x += width
+	}
+	for _, line := range wrap(s, size, width) {
+		if l.y-size*lineSpacing < marginBottom {
+			l.newPage()
+		}
+		l.page.Text(line, drawing.Point{X: x, Y: l.y - size*1.4}, size, align, black)
+		l.y -= size * lineSpacing
+	}
+}
+
+// table выводит таблицу с рамками; строки, не помещающиеся на страницу,
+// переносятся на следующую вместе с шапкой.
+func (l *pdfLayout) table(t Block) {
+	if len(t.Rows) == 0 {
+		return
+	}
+	width := pageWidth - marginLeft - marginRight
+	columns := len(t.Rows[0])
+	natural := make([]float64, columns)
+	total := 0.0
+	for i := range natural {
+		for _, row := range t.Rows {
+			natural[i] = max(natural[i], drawing.TextWidth(row[i], textSize)+2*cellPadding)
+		}
+		natural[i] = max(natural[i], minColumn)
+		total += natural[i]
+	}
+	widths := make([]float64, columns)
+	for i := range widths {
+		widths[i] = width * natural[i] / total
+	}
+
+	lineHeight := textSize * lineSpacing
+	measure := func(row []string) ([][]string, float64) {
+		cells := make([][]string, columns)
+		height := 0.0
+		for i, cell := range row {
+			cells[i] = wrap(cell, textSize, widths[i]-2*cellPadding)
+			height = max(height, float64(len(cells[i]))*lineHeight+cellPadding)
+		}
+		return cells, height
+	}
+	for i, row := range t.Rows {
+		header := i < t.HeaderRows
+		cells, height := measure(row)
+		if l.y-height < marginBottom {
+			l.newPage()
+			if !header {
+				for _, h := range t.Rows[:t.HeaderRows] {
+					hc, hh := measure(h)
+					l.row(hc, widths, hh, true)
+				}
+			}
+		}
+		l.row(cells, widths, height, header)
+	}
+}
+
+// row выводит строку таблицы: ячейки, разбитые на строки, высотой height.
+func (l *pdfLayout) row(cells [][]string, widths []float64, height float64, header bool) {
+	lineHeight := textSize * lineSpacing
+	x := marginLeft
+	for i, lines := range cells {
+		l.page.Frame(drawing.Rect{MinX: x, MinY: l.y - height, MaxX: x + widths[i], MaxY: l.y}, drawing.LineStyle{Width: 0.2, Color: black})
+		for k, line := range lines {
+			at := drawing.Point{X: x + cellPadding, Y: l.y - float64(k)*lineHeight - textSize*1.4 - cellPadding/2}
+			align := drawing.AlignLeft
+			if header {
+				at.X, align = x+widths[i]/2, drawing.AlignCenter
+			}
+			l.page.Text(line, at, textSize, align, black)
+		}
+		x += widths[i]
+	}
+	l.y -= height
+}
+
+// wrap разбивает текст на строки не шире width; слова длиннее строки
+// разрываются.
+func wrap(s string, size, width float64) []string {
+	var lines []string
+	cur := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if cur != "" {
+			candidate = cur + " " + word
+		}
+		if drawing.TextWidth(candidate, size) <= width {
+			cur = candidate
+			continue
+		}
+		if cur != "" {
+			lines = append(lines, cur)
+		}
+		cur = word
+		for drawing.TextWidth(cur, size) > width {
+			runes := []rune(cur)
+			n := len(runes) - 1
+			for n > 1 && drawing.TextWidth(string(runes[:n]), size) > width {
+				n--
+			}
+			lines = append(lines, string(runes[:n]))
+			cur = string(runes[n:])
+		}
+	}
+	if cur != "" || len(lines) == 0 {
+		lines = append(lines, cur)
+	}
+	return lines
+}
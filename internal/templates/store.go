@@ -0,0 +1,131 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound возвращается, если шаблона или его версии нет в хранилище.
+var ErrNotFound = errors.New("шаблон не найден")
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Template — версия шаблона документа.
+type Template struct {
+	// ID — постоянный идентификатор шаблона, например application.
+	ID string `json:"id"`
+	// Version — номер версии; каждое сохранение шаблона создаёт новую версию.
+	Version int `json:"version"`
+	// Name — наименование документа, оно же заголовок файлов.
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Body        string `json:"body"`
+	// Fields — поля данных, которые использует шаблон.
+	Fields    []string  `json:"fields"`
+	CreatedAt time.Time `json:"created_at"`
+
+	nodes []node
+}
+
+// Render формирует документ по шаблону и данным комплекта.
+func (t Template) Render(data map[string]any) Document {
+	return Document{Title: t.Name, Blocks: layout(execute(t.nodes, data))}
+}
+
+// Store хранит все версии шаблонов. Сохранённые версии не изменяются,
+// поэтому комплект, сформированный по версии шаблона, можно воспроизвести.
+type Store struct {
+	mu       sync.RWMutex
+	versions map[string][]Template
+}
+
+// NewStore создаёт хранилище со встроенными шаблонами (builtin).
+func NewStore() *Store {
+	s := &Store{versions: make(map[string][]Template)}
+	for _, t := range builtin {
+		if _, err := s.Save(t); err != nil {
+			panic("templates: встроенный шаблон " + t.ID + ": " + err.Error())
+		}
+	}
+	return s
+}
+
+// Save проверяет шаблон и сохраняет его новой версией.
+func (s *Store) Save(t Template) (Template, error) {
+	t.ID = strings.TrimSpace(t.ID)
+	t.Name = strings.TrimSpace(t.Name)
+	if !idPattern.MatchString(t.ID) {
+		return Template{}, fmt.Errorf("идентификатор шаблона %q: допустимы строчные латинские буквы, цифры, «-» и «_»", t.ID)
+	}
+	if t.Name == "" {
+		return Template{}, errors.New("не указано наименование документа")
+	}
+	if strings.TrimSpace(t.Body) == "" {
+		return Template{}, errors.New("текст шаблона пуст")
+	}
+	nodes, err := parse(t.Body)
+	if err != nil {
+		return Template{}, fmt.Errorf("шаблон %s: %w", t.ID, err)
+	}
+	used := make(map[string]bool)
+	fields(nodes, "", used)
+	t.Fields = make([]string, 0, len(used))
+	for f := range used {
+		t.Fields = append(t.Fields, f)
+	}
+	sort.Strings(t.Fields)
+	t.nodes = nodes
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.Version = len(s.versions[t.ID]) + 1
+	t.CreatedAt = time.Now().UTC()
+	s.versions[t.ID] = append(s.versions[t.ID], t)
+	return t, nil
+}
+
+// Get возвращает версию шаблона; version 0 — последняя версия.
+func (s *Store) Get(id string, version int) (Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := s.versions[id]
+	if len(versions) == 0 {
+		return Template{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if version == 0 {
+		return versions[len(versions)-1], nil
+	}
+	if version < 0 || version > len(versions) {
+		return Template{}, fmt.Errorf("%w: %s, версия %d", ErrNotFound, id, version)
+	}
+	return versions[version-1], nil
+}
+
+// Versions возвращает все версии шаблона, начиная с первой.
+func (s *Store) Versions(id string) ([]Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	versions := s.versions[id]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return append([]Template(nil), versions...), nil
+}
+
+// List возвращает последние версии всех шаблонов, упорядоченные по
+// идентификатору.
+func (s *Store) List() []Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Template, 0, len(s.versions))
+	for _, versions := range s.versions {
+		out = append(out, versions[len(versions)-1])
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
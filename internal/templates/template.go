@@ -0,0 +1,506 @@
+// Package templates хранит версионируемые шаблоны документов (заявлений,
+// согласий, уведомлений) и формирует по ним документы DOCX и PDF.
+//
+// Шаблон — размеченный текст. Подстановки {{путь.к.полю}} заменяются
+// значениями из данных комплекта (заявитель, контур, карточка, участок);
+// пустое значение выводится чертой для заполнения от руки. Разделы
+// {{#if поле}} … {{else}} … {{/if}} выводятся в зависимости от наличия
+// значения, {{#each список}} … {{/each}} повторяются для каждого элемента
+// списка; внутри повторения поля элемента доступны по имени, {{@index}} —
+// номер элемента с единицы.
+//
+// Разметка строк:
+//   - «# » и «## » в начале строки — заголовок первого и второго уровня;
+//   - «> » — строка, выровненная по правому краю (шапка заявления);
+//   - строки, начинающиеся с «|», — строки таблицы; строка вида |---|---|
+//     отделяет шапку таблицы;
+//   - остальные строки подряд образуют абзац, абзацы разделяются пустой
+//     строкой.
+//
+// Теги разделов, занимающие всю строку, удаляются вместе со строкой, поэтому
+// строки таблицы можно повторять, поместив {{#each}} и {{/each}} на
+// отдельные строки.
+package templates
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// blank выводится вместо пустого значения подстановки.
+const blank = "________________"
+
+type node interface{}
+
+type textNode string
+
+type varNode string
+
+type ifNode struct {
+	path      string
+	then, els []node
+}
+
+type eachNode struct {
+	path string
+	body []node
+}
+
+var (
+	pathPattern       = regexp.MustCompile(`^(@index|\.|[\p{L}\d_]+(\.[\p{L}\d_]+)*)$`)
+	standalonePattern = regexp.MustCompile(`^\s*\{\{\s*(#if|#each|else|/if|/each)\b[^}]*\}\}\s*$`)
+)
+
+type tag struct {
+	kind string // "", "#if", "#each", "else", "/if", "/each"
+	path string
+	line int
+}
+
+type token struct {
+	text  string
+	tag   *tag
+	isTag bool
+}
+
+// tokenize разбивает шаблон на текст и теги. Теги разделов, занимающие всю
+// строку, поглощают её перевод строки.
+func tokenize(body string) ([]token, error) {
+	var out []token
+	lines := strings.SplitAfter(body, "\n")
+	for n, line := range lines {
+		if standalonePattern.MatchString(strings.TrimRight(line, "\r\n")) {
+			t, err := parseTag(strings.TrimSpace(line), n+1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, token{isTag: true, tag: t})
+			continue
+		}
+		rest := line
+		for {
+			i := strings.Index(rest, "{{")
+			if i < 0 {
+				break
+			}
+			j := strings.Index(rest[i:], "}}")
+			if j < 0 {
+				return nil, fmt.Errorf("строка %d: не закрыта подстановка {{", n+1)
+			}
+			if i > 0 {
+				out = append(out, token{text: rest[:i]})
+			}
+			t, err := parseTag(rest[i:i+j+2], n+1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, token{isTag: true, tag: t})
+			rest = rest[i+j+2:]
+		}
+		if rest != "" {
+			out = append(out, token{text: rest})
+		}
+	}
+	return out, nil
+}
+
+func parseTag(s string, line int) (*tag, error) {
+	inner := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "{{"), "}}"))
+	t := &tag{line: line}
+	switch {
+	case inner == "else" || inner == "/if" || inner == "/each":
+		t.kind = inner
+		return t, nil
+	case strings.HasPrefix(inner, "#if "):
+		t.kind, t.path = "#if", strings.TrimSpace(inner[len("#if "):])
+	case strings.HasPrefix(inner, "#each "):
+		t.kind, t.path = "#each", strings.TrimSpace(inner[len("#each "):])
+	case strings.HasPrefix(inner, "#") || strings.HasPrefix(inner, "/"):
+		return nil, fmt.Errorf("строка %d: неизвестный раздел %s", line, s)
+	default:
+		t.path = inner
+	}
+	if !pathPattern.MatchString(t.path) {
+		return nil, fmt.Errorf("строка %d: неверное имя поля в %s", line, s)
+	}
+	return t, nil
+}
+
+// parse строит дерево шаблона.
+func parse(body string) ([]node, error) {
+	tokens, err := tokenize(body)
+	if err != nil {
+		return nil, err
+	}
+	nodes, rest, end, err := parseNodes(tokens)
+	if err != nil {
+		return nil, err
+	}
+	if end != nil {
+		return nil, fmt.Errorf("строка %d: {{%s}} без открывающего раздела", end.line, end.kind)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("лишние теги в конце шаблона")
+	}
+	return nodes, nil
+}
+
+// parseNodes разбирает последовательность узлов до закрывающего тега или
+// {{else}}, который возвращается вызывающему.
+func parseNodes(tokens []token) ([]node, []token, *tag, error) {
+	var nodes []node
+	for len(tokens) > 0 {
+		tok := tokens[0]
+		tokens = tokens[1:]
+		if !tok.isTag {
+			nodes = append(nodes, textNode(tok.text))
+			continue
+		}
+		switch t := tok.tag; t.kind {
+		case "":
+			nodes = append(nodes, varNode(t.path))
+		case "else", "/if", "/each":
+			return nodes, tokens, t, nil
+		case "#if":
+			then, rest, end, err := parseNodes(tokens)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			n := ifNode{path: t.path, then: then}
+			if end != nil && end.kind == "else" {
+				n.els, rest, end, err = parseNodes(rest)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+			}
+			if end == nil || end.kind != "/if" {
+				return nil, nil, nil, fmt.Errorf("строка %d: раздел {{#if %s}} не закрыт {{/if}}", t.line, t.path)
+			}
+			nodes, tokens = append(nodes, n), rest
+		case "#each":
+			body, rest, end, err := parseNodes(tokens)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if end == nil || end.kind != "/each" {
+				return nil, nil, nil, fmt.Errorf("строка %d: раздел {{#each %s}} не закрыт {{/each}}", t.line, t.path)
+			}
+			nodes, tokens = append(nodes, eachNode{path: t.path, body: body}), rest
+		}
+	}
+	return nodes, nil, nil, nil
+}
+
+// fields перечисляет поля данных, которые использует шаблон. Поля элементов
+// списка записываются через «[].», например contour.points[].x.
+func fields(nodes []node, prefix string, out map[string]bool) {
+	add := func(path string) {
+		if path != "@index" && path != "." {
+			out[prefix+path] = true
+		}
+	}
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case varNode:
+			add(string(n))
+		case ifNode:
+			add(n.path)
+			fields(n.then, prefix, out)
+			fields(n.els, prefix, out)
+		case eachNode:
+			add(n.path)
+			fields(n.body, prefix+n.path+"[].", out)
+		}
+	}
+}
+
+// segment — часть строки результата: текст шаблона или подставленное
+// значение. Разметка распознаётся только в тексте шаблона.
+type segment struct {
+	text  string
+	value bool
+}
+
+// scope — область видимости подстановок: данные комплекта и элементы
+// вложенных повторений.
+type scope struct {
+	value  any
+	index  int
+	parent *scope
+}
+
+func (s *scope) lookup(path string) any {
+	if path == "@index" {
+		for ; s != nil; s = s.parent {
+			if s.index > 0 {
+				return s.index
+			}
+		}
+		return nil
+	}
+	if path == "." {
+		return s.value
+	}
+	names := strings.Split(path, ".")
+	for ; s != nil; s = s.parent {
+		m, ok := s.value.(map[string]any)
+		if !ok {
+			continue
+		}
+		v, ok := m[names[0]]
+		if !ok {
+			continue
+		}
+		for _, name := range names[1:] {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil
+			}
+			v = m[name]
+		}
+		return v
+	}
+	return nil
+}
+
+// execute выполняет шаблон и возвращает строки результата.
+func execute(nodes []node, data map[string]any) [][]segment {
+	lines := [][]segment{nil}
+	var run func(nodes []node, sc *scope)
+	emit := func(s segment) {
+		last := len(lines) - 1
+		lines[last] = append(lines[last], s)
+	}
+	run = func(nodes []node, sc *scope) {
+		for _, n := range nodes {
+			switch n := n.(type) {
+			case textNode:
+				parts := strings.Split(strings.ReplaceAll(string(n), "\r", ""), "\n")
+				for i, part := range parts {
+					if i > 0 {
+						lines = append(lines, nil)
+					}
+					if part != "" {
+						emit(segment{text: part})
+					}
+				}
+			case varNode:
+				text := format(sc.lookup(string(n)))
+				if text == "" {
+					text = blank
+				}
+				emit(segment{text: strings.Join(strings.Fields(text), " "), value: true})
+			case ifNode:
+				if truthy(sc.lookup(n.path)) {
+					run(n.then, sc)
+				} else {
+					run(n.els, sc)
+				}
+			case eachNode:
+				for i, item := range items(sc.lookup(n.path)) {
+					run(n.body, &scope{value: item, index: i + 1, parent: sc})
+				}
+			}
+		}
+	}
+	run(nodes, &scope{value: data})
+	return lines
+}
+
+// items возвращает элементы списка; значение, не являющееся списком,
+// считается пустым списком.
+func items(v any) []any {
+	switch v := v.(type) {
+	case []any:
+		return v
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = item
+		}
+		return out
+	case []string:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = item
+		}
+		return out
+	}
+	return nil
+}
+
+func truthy(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case bool:
+		return v
+	case int:
+		return v != 0
+	case float64:
+		return v != 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return len(items(v)) > 0
+}
+
+// format записывает значение для документа: дробные числа — с запятой.
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		if v {
+			return "да"
+		}
+		return "нет"
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		return strings.Replace(strconv.FormatFloat(v, 'f', -1, 64), ".", ",", 1)
+	}
+	return fmt.Sprint(v)
+}
+
+// BlockKind — вид блока документа.
+type BlockKind int
+
+const (
+	BlockParagraph BlockKind = iota
+	BlockHeading
+	BlockTable
+)
+
+// Block — абзац, заголовок или таблица документа.
+type Block struct {
+	Kind BlockKind
+	// Level — уровень заголовка: 1 или 2.
+	Level int
+	// AlignRight — абзац выравнивается по правому краю.
+	AlignRight bool
+	Text       string
+	// Rows — ячейки таблицы по строкам; первые HeaderRows строк — шапка.
+	Rows       [][]string
+	HeaderRows int
+}
+
+// Document — документ, сформированный по шаблону, до вывода в DOCX или PDF.
+type Document struct {
+	Title  string
+	Blocks []Block
+}
+
+var separatorCell = regexp.MustCompile(`^:?-{3,}:?$`)
+
+// layout распознаёт разметку строк результата и собирает блоки документа.
+func layout(lines [][]segment) []Block {
+	var blocks []Block
+	var paragraph []string
+	var table *Block
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, Block{Kind: BlockParagraph, Text: strings.Join(paragraph, " ")})
+			paragraph = nil
+		}
+	}
+	flushTable := func() {
+		if table != nil {
+			width := 0
+			for _, row := range table.Rows {
+				width = max(width, len(row))
+			}
+			for i := range table.Rows {
+				for len(table.Rows[i]) < width {
+					table.Rows[i] = append(table.Rows[i], "")
+				}
+			}
+			blocks = append(blocks, *table)
+			table = nil
+		}
+	}
+	for _, line := range lines {
+		text := ""
+		for _, s := range line {
+			text += s.text
+		}
+		lead := ""
+		if len(line) > 0 && !line[0].value {
+			lead = strings.TrimLeft(line[0].text, " \t")
+		}
+		switch {
+		case strings.TrimSpace(text) == "":
+			flushParagraph()
+			flushTable()
+		case strings.HasPrefix(lead, "|"):
+			flushParagraph()
+			if table == nil {
+				table = &Block{Kind: BlockTable}
+			}
+			cells := tableCells(line)
+			if len(table.Rows) > 0 && table.HeaderRows == 0 && !slices.ContainsFunc(cells, func(c string) bool { return !separatorCell.MatchString(c) }) {
+				table.HeaderRows = len(table.Rows)
+				continue
+			}
+			table.Rows = append(table.Rows, cells)
+		case strings.HasPrefix(lead, "## ") || strings.HasPrefix(lead, "# "):
+			flushParagraph()
+			flushTable()
+			level := 1
+			if strings.HasPrefix(lead, "## ") {
+				level = 2
+			}
+			blocks = append(blocks, Block{Kind: BlockHeading, Level: level, Text: strings.TrimSpace(text[strings.Index(text, "# ")+2:])})
+		case strings.HasPrefix(lead, "> "):
+			flushParagraph()
+			flushTable()
+			blocks = append(blocks, Block{Kind: BlockParagraph, AlignRight: true, Text: strings.TrimSpace(text[strings.Index(text, "> ")+2:])})
+		default:
+			flushTable()
+			paragraph = append(paragraph, strings.TrimSpace(text))
+		}
+	}
+	flushParagraph()
+	flushTable()
+	return blocks
+}
+
+// tableCells делит строку таблицы на ячейки по «|» в тексте шаблона;
+// подставленные значения не делятся.
+func tableCells(line []segment) []string {
+	var cells []string
+	cur := ""
+	for _, s := range line {
+		if s.value {
+			cur += s.text
+			continue
+		}
+		parts := strings.Split(s.text, "|")
+		for i, part := range parts {
+			if i > 0 {
+				cells = append(cells, strings.TrimSpace(cur))
+				cur = ""
+			}
+			cur += part
+		}
+	}
+	if strings.TrimSpace(cur) != "" {
+		cells = append(cells, strings.TrimSpace(cur))
+	}
+	// Первая ячейка перед открывающей «|» всегда пуста.
+	if len(cells) > 0 {
+		cells = cells[1:]
+	}
+	return cells
+}